@@ -13,12 +13,14 @@ import (
 	"github.com/drupdater/drupdater/internal"
 	"github.com/drupdater/drupdater/internal/codehosting"
 	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/notify"
 	"github.com/drupdater/drupdater/internal/report"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/drupdater/drupdater/pkg/drupal"
 	"github.com/drupdater/drupdater/pkg/drush"
 	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -27,6 +29,17 @@ import (
 // checkFull adds the expensive tier: clone, composer install and a real site install per site.
 var checkFull bool
 
+// checkFormat is what stdout gets: the ✓/✗ lines, or JUnit XML for a pipeline to parse.
+var checkFormat string
+
+// checkJUnitReport is where to write the JUnit XML besides whatever stdout gets; empty disables it.
+var checkJUnitReport string
+
+const (
+	checkFormatText  = "text"
+	checkFormatJUnit = "junit"
+)
+
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Validate prerequisites without running an update",
@@ -39,8 +52,18 @@ authenticates. Pass --full to additionally clone the repository and prove each s
 from its exported configuration (drush site-install --existing-config) -- most of a real run's
 cost, so it stays opt-in.
 
+--format junit prints the results as JUnit XML instead, one testcase per check, and
+--junit-report writes that document to a file whatever the format, so a pipeline's test tab
+lists the failing checks.
+
 Exits non-zero if any check fails, so it can gate a pipeline.`,
 	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if checkFormat != checkFormatText && checkFormat != checkFormatJUnit {
+			return fmt.Errorf("unknown format %q: use text or junit", checkFormat)
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 		cmd.SilenceErrors = true
@@ -58,6 +81,7 @@ Exits non-zero if any check fails, so it can gate a pipeline.`,
 		redactor.Register(token)
 
 		cfg := config
+		cfg.SSH.Passphrase = os.Getenv(sshPassphraseEnv)
 		gitSvc := repo.NewGitRepositoryService(logger)
 		var resolveErr error
 		if !cfg.Clone {
@@ -74,10 +98,19 @@ Exits non-zero if any check fails, so it can gate a pipeline.`,
 			results = append(results, runFullChecks(ctx, logger, cfg, token)...)
 		}
 
-		printCheckResults(cmd.OutOrStdout(), results, redactor)
+		tools := services.LookupToolVersions(ctx, logger, composerCLI)
+
+		if checkFormat == checkFormatJUnit {
+			if err := printJUnitResults(cmd.OutOrStdout(), tools, results, redactor); err != nil {
+				logger.Error("failed to print JUnit results", zap.Error(err))
+				return err
+			}
+		} else {
+			printCheckResults(cmd.OutOrStdout(), results, redactor)
+		}
 
-		writeCheckReport(logger, redactor, config.ReportPath,
-			services.LookupToolVersions(ctx, logger, composerCLI), results)
+		writeCheckReport(logger, redactor, config.ReportPath, tools, results)
+		writeJUnitReport(logger, redactor, checkJUnitReport, tools, results)
 
 		if anyCheckFailed(results) {
 			return errors.New("preflight check failed")
@@ -111,7 +144,9 @@ func runCheapChecks(
 	for _, site := range cfg.Sites {
 		results = append(results, services.CheckSiteSettings(ctx, composerSvc, fs, cfg.WorkingDir, site))
 	}
-	results = append(results, checkVCS(ctx, logger, cfg.RepositoryURL, token, resolveErr)...)
+	results = append(results, checkSigning(*cfg)...)
+	results = append(results, checkSSH(*cfg)...)
+	results = append(results, checkVCS(ctx, logger, cfg.RepositoryURL, cfg.HostOptions(), token, resolveErr)...)
 	return results
 }
 
@@ -129,6 +164,33 @@ func writeCheckReport(logger *zap.Logger, redactor *logging.Redactor, path strin
 	logger.Info("check report written", zap.String("path", path))
 }
 
+// writeJUnitReport writes the results as JUnit XML, or nothing without --junit-report. Failures
+// are logged, like writeCheckReport's.
+func writeJUnitReport(logger *zap.Logger, redactor *logging.Redactor, path string, tools report.ToolVersions, results []services.CheckResult) {
+	if path == "" {
+		return
+	}
+
+	check := report.NewCheck(internal.Version, tools, toReportCheckResults(results))
+	if err := report.WriteJUnit(afero.NewOsFs(), path, report.NewJUnit(check, redactor.Redact)); err != nil {
+		logger.Warn("failed to write JUnit report", zap.String("path", path), zap.Error(err))
+		return
+	}
+	logger.Info("JUnit report written", zap.String("path", path))
+}
+
+// printJUnitResults is printCheckResults for --format junit. The log goes to stderr, so stdout
+// stays a well-formed document a pipeline can redirect to a file.
+func printJUnitResults(w io.Writer, tools report.ToolVersions, results []services.CheckResult, redactor *logging.Redactor) error {
+	check := report.NewCheck(internal.Version, tools, toReportCheckResults(results))
+	encoded, err := report.EncodeJUnit(report.NewJUnit(check, redactor.Redact))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(encoded)
+	return err
+}
+
 // toReportCheckResults converts to the report's own schema type — see report.PackageChange.
 func toReportCheckResults(results []services.CheckResult) []report.CheckResult {
 	out := make([]report.CheckResult, 0, len(results))
@@ -159,20 +221,44 @@ func checkConfigAndAddons(path string, cfg *internal.Config) []services.CheckRes
 
 	const addonsName = "addon names resolve"
 	if err := validateAddons(*cfg); err != nil {
-		return append(results, services.CheckFailed(addonsName, err.Error()))
+		results = append(results, services.CheckFailed(addonsName, err.Error()))
+	} else {
+		results = append(results, services.CheckOK(addonsName))
+	}
+	if !cfg.Templates.IsZero() {
+		results = append(results, services.CheckMergeRequestTemplates(cfg.Templates))
+	}
+	if len(cfg.Notifications) > 0 {
+		results = append(results, checkNotifications(cfg.Notifications))
 	}
-	return append(results, services.CheckOK(addonsName))
+	return results
+}
+
+// checkNotifications reports whether every variable the notifications read is set here, where
+// the run will read them.
+func checkNotifications(notifications []internal.Notification) services.CheckResult {
+	const name = "notification variables set"
+	var problems []string
+	for i, n := range notifications {
+		if _, err := notify.Expand(n, os.LookupEnv); err != nil {
+			problems = append(problems, fmt.Sprintf("notification %d (%s): %v", i+1, n.Type, err))
+		}
+	}
+	if len(problems) > 0 {
+		return services.CheckFailed(name, strings.Join(problems, "; "))
+	}
+	return services.CheckOK(name)
 }
 
 // newVcsProvider is a variable so the token check can be tested without a real GetUser request.
-var newVcsProvider = func(repositoryURL string, token string, logger *zap.Logger) (codehosting.Platform, error) {
-	return codehosting.NewDefaultVcsProviderFactory().Create(repositoryURL, token, logger)
+var newVcsProvider = func(repositoryURL string, opts codehosting.Options, token string, logger *zap.Logger) (codehosting.Platform, error) {
+	return codehosting.NewDefaultVcsProviderFactory().Create(repositoryURL, opts, token, logger)
 }
 
 // checkVCS reports whether the URL routes to a known provider and, with a token, authenticates.
 // resolveErr is surfaced here because this is the only check it would otherwise silently fail.
-func checkVCS(ctx context.Context, logger *zap.Logger, repositoryURL string, token string, resolveErr error) []services.CheckResult {
-	const name = "repository host recognized (GitHub/GitLab)"
+func checkVCS(ctx context.Context, logger *zap.Logger, repositoryURL string, opts codehosting.Options, token string, resolveErr error) []services.CheckResult {
+	const name = "repository host recognized (GitHub/GitLab/Bitbucket/Gitea)"
 
 	if repositoryURL == "" {
 		detail := "could not determine repository URL (pass --repository-url or run inside a checkout with an origin remote)"
@@ -191,7 +277,7 @@ func checkVCS(ctx context.Context, logger *zap.Logger, repositoryURL string, tok
 	}
 
 	const tokenCheckName = "token authenticates"
-	platform, err := newVcsProvider(repositoryURL, token, logger)
+	platform, err := newVcsProvider(repositoryURL, opts, token, logger)
 	if err != nil {
 		return append(results, services.CheckFailed(tokenCheckName, err.Error()))
 	}
@@ -217,7 +303,7 @@ type siteInstaller interface {
 // fullCheckDeps holds constructors rather than values, so the tier keeps its build order:
 // composer only once the clone succeeded, the installer only once composer install did.
 type fullCheckDeps struct {
-	clone        func(repositoryURL string, branch string, token string) (string, error)
+	clone        func(repositoryURL string, branch string, auth transport.AuthMethod) (string, error)
 	newComposer  func() fullCheckComposer
 	newInstaller func(fullCheckComposer) (siteInstaller, error)
 }
@@ -225,9 +311,9 @@ type fullCheckDeps struct {
 // newFullCheckDeps builds the real services. A variable so tests can substitute doubles.
 var newFullCheckDeps = func(logger *zap.Logger) fullCheckDeps {
 	return fullCheckDeps{
-		clone: func(repositoryURL string, branch string, token string) (string, error) {
+		clone: func(repositoryURL string, branch string, auth transport.AuthMethod) (string, error) {
 			_, _, path, err := repo.NewGitRepositoryService(logger).
-				CloneRepository(repositoryURL, branch, token, "", "")
+				CloneRepository(repositoryURL, branch, auth, "", "")
 			return path, err
 		},
 		newComposer: func() fullCheckComposer { return composer.NewCLI(logger) },
@@ -255,7 +341,11 @@ func runFullChecks(ctx context.Context, logger *zap.Logger, cfg internal.Config,
 
 	deps := newFullCheckDeps(logger)
 
-	path, err := deps.clone(cfg.RepositoryURL, branch, token)
+	auth, err := repo.Auth(cfg.RepositoryURL, codehosting.GitUsername(cfg.RepositoryURL), token, cfg.SSH)
+	if err != nil {
+		return []services.CheckResult{services.CheckFailed("clone for full check", err.Error())}
+	}
+	path, err := deps.clone(cfg.RepositoryURL, branch, auth)
 	if err != nil {
 		return []services.CheckResult{services.CheckFailed("clone for full check", err.Error())}
 	}
@@ -315,5 +405,7 @@ func init() {
 	checkCmd.Flags().BoolVar(&checkFull, "full", false,
 		"Additionally clone the repository and verify each site installs from its exported "+
 			"configuration (drush site-install --existing-config). Expensive: most of a real run's cost.")
+	checkCmd.Flags().StringVar(&checkFormat, "format", checkFormatText, "Output format on stdout: text, or junit for JUnit XML.")
+	checkCmd.Flags().StringVar(&checkJUnitReport, "junit-report", "", "Also write the results as JUnit XML to this path, whatever --format is.")
 	rootCmd.AddCommand(checkCmd)
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"github.com/drupdater/drupdater/internal/codehosting"
 	"go.uber.org/zap"
 	"os"
@@ -11,6 +12,9 @@ import (
 	"testing"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/report"
+	"github.com/drupdater/drupdater/internal/services"
 	git "github.com/go-git/go-git/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -96,6 +100,75 @@ func TestCheckCommandWithoutReportPathWritesNoReport(t *testing.T) {
 	}
 }
 
+// withCheckOutput sets the JUnit flags for one test.
+func withCheckOutput(t *testing.T, format string, junitReport string) {
+	t.Helper()
+	oldFormat, oldJUnit := checkFormat, checkJUnitReport
+	checkFormat, checkJUnitReport = format, junitReport
+	t.Cleanup(func() { checkFormat, checkJUnitReport = oldFormat, oldJUnit })
+}
+
+func TestCheckCommandPrintsJUnit(t *testing.T) {
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	junitPath := filepath.Join(t.TempDir(), "junit", "check.xml")
+	withCheckCmdState(t, internal.Config{WorkingDir: dir, Sites: []string{"default"}}, "", false)
+	withCheckOutput(t, checkFormatJUnit, junitPath)
+
+	out, err := runCheckCmd(t)
+	require.Error(t, err, "the exit status does not depend on the format")
+
+	// stdout is the document and nothing else, so a pipeline can redirect it to a file.
+	var printed report.JUnitTestSuites
+	require.NoError(t, xml.Unmarshal([]byte(out), &printed))
+	assert.Positive(t, printed.Failures)
+	assert.NotContains(t, out, "✗")
+
+	written, readErr := os.ReadFile(junitPath) //nolint:gosec // test-controlled path
+	require.NoError(t, readErr)
+	var saved report.JUnitTestSuites
+	require.NoError(t, xml.Unmarshal(written, &saved))
+	assert.Equal(t, printed.Tests, saved.Tests)
+}
+
+func TestCheckCommandWritesJUnitBesideTextOutput(t *testing.T) {
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	junitPath := filepath.Join(t.TempDir(), "check.xml")
+	withCheckCmdState(t, internal.Config{WorkingDir: dir, Sites: []string{"default"}}, "", false)
+	withCheckOutput(t, checkFormatText, junitPath)
+
+	out, _ := runCheckCmd(t)
+
+	assert.Contains(t, out, "✗", "--junit-report leaves stdout to --format")
+	assert.FileExists(t, junitPath)
+}
+
+func TestCheckCommandRejectsAnUnknownFormat(t *testing.T) {
+	withCheckOutput(t, "sarif", "")
+
+	err := checkCmd.PreRunE(checkCmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown format "sarif"`)
+}
+
+func TestPrintJUnitResultsRedactsDetails(t *testing.T) {
+	redactor := logging.NewRedactor()
+	redactor.Register("s3cret")
+
+	var out bytes.Buffer
+	require.NoError(t, printJUnitResults(&out, report.ToolVersions{}, []services.CheckResult{
+		services.CheckFailed("token authenticates", "401 for token s3cret"),
+	}, redactor))
+
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.Contains(t, out.String(), "401 for token")
+}
+
 // stubPlatform is a codehosting.Platform whose GetUser answer the test controls, so the token
 // check can be exercised without a live GitHub or GitLab API call.
 type stubPlatform struct {
@@ -103,17 +176,35 @@ type stubPlatform struct {
 	email string
 }
 
-func (s stubPlatform) CreateMergeRequest(context.Context, string, string, string, string) (codehosting.MergeRequest, error) {
+func (s stubPlatform) CreateMergeRequest(context.Context, string, string, string, string, bool) (codehosting.MergeRequest, error) {
 	return codehosting.MergeRequest{}, nil
 }
 func (s stubPlatform) DeleteBranch(context.Context, string) error                      { return nil }
 func (s stubPlatform) GetUser(context.Context) (string, string)                        { return s.name, s.email }
 func (s stubPlatform) EnableAutoMerge(context.Context, codehosting.MergeRequest) error { return nil }
+func (s stubPlatform) ListMergeRequests(context.Context, string) ([]codehosting.MergeRequest, error) {
+	return nil, nil
+}
+func (s stubPlatform) UpdateMergeRequest(context.Context, codehosting.MergeRequest, string, string) error {
+	return nil
+}
+func (s stubPlatform) CloseMergeRequest(context.Context, codehosting.MergeRequest, string) error {
+	return nil
+}
+func (s stubPlatform) SetMergeRequestMetadata(context.Context, codehosting.MergeRequest, codehosting.MergeRequestMetadata) error {
+	return nil
+}
+func (s stubPlatform) PipelineStatus(context.Context, codehosting.MergeRequest) (codehosting.PipelineStatus, error) {
+	return codehosting.PipelineNone, nil
+}
+func (s stubPlatform) MarkMergeRequestReady(context.Context, codehosting.MergeRequest) error {
+	return nil
+}
 
 func withVcsProvider(t *testing.T, platform codehosting.Platform, err error) {
 	t.Helper()
 	old := newVcsProvider
-	newVcsProvider = func(string, string, *zap.Logger) (codehosting.Platform, error) {
+	newVcsProvider = func(string, codehosting.Options, string, *zap.Logger) (codehosting.Platform, error) {
 		return platform, err
 	}
 	t.Cleanup(func() { newVcsProvider = old })
@@ -126,7 +217,7 @@ func TestCheckVCSTokenBranch(t *testing.T) {
 	t.Run("a token that authenticates passes", func(t *testing.T) {
 		withVcsProvider(t, stubPlatform{name: "bot", email: "bot@example.com"}, nil)
 
-		results := checkVCS(t.Context(), logger, url, "tok", nil)
+		results := checkVCS(t.Context(), logger, url, codehosting.Options{}, "tok", nil)
 		require.Len(t, results, 2, "a token adds the authentication check")
 		assert.True(t, results[1].OK)
 		assert.Equal(t, "token authenticates", results[1].Name)
@@ -138,7 +229,7 @@ func TestCheckVCSTokenBranch(t *testing.T) {
 		// succeeds but returns nothing, so an OK here would pass a token that cannot be used.
 		withVcsProvider(t, stubPlatform{}, nil)
 
-		results := checkVCS(t.Context(), logger, url, "tok", nil)
+		results := checkVCS(t.Context(), logger, url, codehosting.Options{}, "tok", nil)
 		require.Len(t, results, 2)
 		assert.False(t, results[1].OK)
 		assert.Contains(t, results[1].Detail, "did not authenticate")
@@ -149,7 +240,7 @@ func TestCheckVCSTokenBranch(t *testing.T) {
 		// would reject a perfectly usable token.
 		withVcsProvider(t, stubPlatform{email: "bot@example.com"}, nil)
 
-		results := checkVCS(t.Context(), logger, url, "tok", nil)
+		results := checkVCS(t.Context(), logger, url, codehosting.Options{}, "tok", nil)
 		require.Len(t, results, 2)
 		assert.True(t, results[1].OK)
 	})
@@ -157,7 +248,7 @@ func TestCheckVCSTokenBranch(t *testing.T) {
 	t.Run("a provider that cannot be built fails the check", func(t *testing.T) {
 		withVcsProvider(t, nil, assert.AnError)
 
-		results := checkVCS(t.Context(), logger, url, "tok", nil)
+		results := checkVCS(t.Context(), logger, url, codehosting.Options{}, "tok", nil)
 		require.Len(t, results, 2)
 		assert.False(t, results[1].OK)
 		assert.Equal(t, "token authenticates", results[1].Name)
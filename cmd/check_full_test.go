@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -42,15 +44,15 @@ func (f *fakeInstaller) Install(_ context.Context, dir string, site string) erro
 
 // stubFullCheckDeps swaps in doubles for the real clone, composer install and site install,
 // without which the tier's control flow is untestable.
-func stubFullCheckDeps(t *testing.T, clonePath string, cloneErr error, composerDouble *fakeComposer, installer siteInstaller, installerErr error) *[]string {
+func stubFullCheckDeps(t *testing.T, clonePath string, cloneErr error, composerDouble *fakeComposer, installer siteInstaller, installerErr error) *[]any {
 	t.Helper()
 
-	cloneArgs := &[]string{}
+	cloneArgs := &[]any{}
 	original := newFullCheckDeps
 	newFullCheckDeps = func(*zap.Logger) fullCheckDeps {
 		return fullCheckDeps{
-			clone: func(repositoryURL string, branch string, token string) (string, error) {
-				*cloneArgs = []string{repositoryURL, branch, token}
+			clone: func(repositoryURL string, branch string, auth transport.AuthMethod) (string, error) {
+				*cloneArgs = []any{repositoryURL, branch, auth}
 				return clonePath, cloneErr
 			},
 			newComposer: func() fullCheckComposer { return composerDouble },
@@ -72,7 +74,7 @@ func TestRunFullChecksClonesTheConfiguredBranch(t *testing.T) {
 	cfg := internal.Config{RepositoryURL: "https://example.com/acme/site.git", Branch: "develop", Sites: []string{"default"}}
 	results := runFullChecks(t.Context(), zap.NewNop(), cfg, "tok")
 
-	assert.Equal(t, []string{"https://example.com/acme/site.git", "develop", "tok"}, *cloneArgs)
+	assert.Equal(t, []any{"https://example.com/acme/site.git", "develop", repo.BasicAuth("", "tok")}, *cloneArgs)
 	require.Len(t, results, 2)
 	assert.True(t, results[0].OK)
 	assert.Equal(t, "composer install", results[0].Name)
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/codehosting"
 	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/spf13/afero"
@@ -70,6 +71,36 @@ func TestCheckConfigAndAddons(t *testing.T) {
 		assert.False(t, results[1].OK)
 		assert.Contains(t, results[1].Detail, "no_such_addon")
 	})
+
+	t.Run("templates add a check that they render", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".drupdater.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("templates:\n  description: merge_request.md.tmpl\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "merge_request.md.tmpl"), []byte("{{ .Addon }}"), 0o600))
+
+		cfg := internal.Config{}
+		results := checkConfigAndAddons(path, &cfg)
+
+		require.Len(t, results, 3)
+		assert.Equal(t, "merge request templates valid", results[2].Name)
+		assert.False(t, results[2].OK)
+		assert.Contains(t, results[2].Detail, "templates.description")
+	})
+
+	t.Run("notifications add a check that their variables are set", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".drupdater.yaml")
+		body := "notifications:\n  - type: slack\n    url: ${SLACK_WEBHOOK_URL}\n  - type: webhook\n    url: ${OPS_WEBHOOK_URL}\n"
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+		t.Setenv("SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/T/B/x")
+
+		cfg := internal.Config{}
+		results := checkConfigAndAddons(path, &cfg)
+
+		require.Len(t, results, 3)
+		assert.Equal(t, "notification variables set", results[2].Name)
+		assert.False(t, results[2].OK)
+		assert.Equal(t, "notification 2 (webhook): environment variable OPS_WEBHOOK_URL is not set", results[2].Detail)
+	})
 }
 
 func TestCheckVCS(t *testing.T) {
@@ -78,10 +109,10 @@ func TestCheckVCS(t *testing.T) {
 
 	// Every branch returns the same check name. It is what the user reads in the output, so an
 	// unnamed result would be useless even when OK and Detail are right.
-	const hostCheck = "repository host recognized (GitHub/GitLab)"
+	const hostCheck = "repository host recognized (GitHub/GitLab/Bitbucket/Gitea)"
 
 	t.Run("no repository URL and no resolve error", func(t *testing.T) {
-		results := checkVCS(ctx, logger, "", "", nil)
+		results := checkVCS(ctx, logger, "", codehosting.Options{}, "", nil)
 		require.Len(t, results, 1)
 		assert.Equal(t, hostCheck, results[0].Name)
 		assert.False(t, results[0].OK)
@@ -89,7 +120,7 @@ func TestCheckVCS(t *testing.T) {
 	})
 
 	t.Run("no repository URL surfaces the resolve error", func(t *testing.T) {
-		results := checkVCS(ctx, logger, "", "", errors.New("no origin remote"))
+		results := checkVCS(ctx, logger, "", codehosting.Options{}, "", errors.New("no origin remote"))
 		require.Len(t, results, 1)
 		assert.Equal(t, hostCheck, results[0].Name)
 		assert.False(t, results[0].OK)
@@ -97,7 +128,7 @@ func TestCheckVCS(t *testing.T) {
 	})
 
 	t.Run("an unrecognized host fails", func(t *testing.T) {
-		results := checkVCS(ctx, logger, "not a url", "", nil)
+		results := checkVCS(ctx, logger, "not a url", codehosting.Options{}, "", nil)
 		require.Len(t, results, 1)
 		assert.Equal(t, hostCheck, results[0].Name)
 		assert.False(t, results[0].OK)
@@ -105,7 +136,7 @@ func TestCheckVCS(t *testing.T) {
 	})
 
 	t.Run("a recognized host with no token stops after the host check", func(t *testing.T) {
-		results := checkVCS(ctx, logger, "https://github.com/acme/site.git", "", nil)
+		results := checkVCS(ctx, logger, "https://github.com/acme/site.git", codehosting.Options{}, "", nil)
 		require.Len(t, results, 1)
 		assert.Equal(t, hostCheck, results[0].Name)
 		assert.True(t, results[0].OK)
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/report"
+	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/drupdater/drupdater/pkg/tracing"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// Fleet flags. Package-level like the root command's, so PreRunE and RunE read the same values.
+var (
+	fleetManifest         string
+	fleetParallel         int
+	fleetReportDir        string
+	fleetComposerCacheDir string
+)
+
+// fleetReportName is the aggregate report's file name inside --report-dir.
+const fleetReportName = "fleet.json"
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet [token]",
+	Short: "Update every repository a manifest lists",
+	Long: `Runs the update for each repository listed in a manifest, several at a time, in one
+process. Each repository is cloned to a temporary directory, as with --clone, and gets its own
+merge request exactly as a single run would open it.
+
+Every repository's run report is written to --report-dir, named after the repository, together
+with fleet.json, which summarises them all. Composer subprocesses share one download cache;
+pass --composer-cache-dir to put it somewhere a CI cache can keep between pipelines.
+
+The token is read as for the root command and used for every repository. Each repository's
+.drupdater.yaml is the one its manifest entry names, else the one --config names.
+
+Exits non-zero if any repository's run failed.`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, _ []string) error {
+		if fleetManifest == "" {
+			return errors.New("--manifest is required")
+		}
+		if fleetParallel < 1 {
+			return fmt.Errorf("invalid --parallel %d: at least one repository has to run", fleetParallel)
+		}
+		if config.Security && config.Major {
+			return errors.New("--security and --major cannot be combined")
+		}
+		// Each names one repository, where a fleet has many.
+		for _, flag := range []string{"repository-url", "report", "sarif-report"} {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s cannot be used with fleet: the manifest lists the repositories and --report-dir holds their reports", flag)
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		return runFleet(cmd, args)
+	},
+}
+
+// runFleet is the body of the fleet command's RunE, named for the same reason as runUpdate.
+func runFleet(cmd *cobra.Command, args []string) error {
+	start := time.Now()
+
+	redactor := logging.NewRedactor()
+	registerEnvSecrets(redactor)
+
+	logger, err := NewLogger(config, redactor)
+	if err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), "failed to initialize logger:", err)
+		return err
+	}
+
+	// Every repository is cloned, so the token is always required.
+	base := config
+	base.Clone = true
+	base.Token, err = resolveToken(args, base)
+	if err != nil {
+		logger.Error("missing token", zap.Error(err))
+		return err
+	}
+	redactor.Register(base.Token)
+	registerPushgatewaySecrets(redactor, base.PushgatewayURL)
+
+	base.Signer, err = resolveSigner(base)
+	if err != nil {
+		logger.Error("invalid signing key", zap.Error(err))
+		return err
+	}
+	base.SSH.Passphrase = os.Getenv(sshPassphraseEnv)
+
+	ctx, flushTrace, err := startTracing(cmd.Context(), logger, redactor, base)
+	if err != nil {
+		return err
+	}
+	defer flushTrace()
+
+	manifest, err := internal.LoadManifest(fleetManifest)
+	if err != nil {
+		logger.Error("invalid manifest", zap.String("path", fleetManifest), zap.Error(err))
+		return err
+	}
+
+	if err := shareComposerCache(fleetComposerCacheDir); err != nil {
+		logger.Error("failed to set up the shared composer cache", zap.String("path", fleetComposerCacheDir), zap.Error(err))
+		return err
+	}
+
+	logger.Info("updating fleet",
+		zap.String("manifest", fleetManifest),
+		zap.Int("repositories", len(manifest.Repositories)),
+		zap.Int("parallel", fleetParallel),
+	)
+
+	ctx, span := tracing.Start(ctx, "drupdater", tracing.String("drupdater.command", "fleet"), tracing.Int("drupdater.repositories", len(manifest.Repositories)))
+	entries := updateFleet(ctx, logger, redactor, base, manifest, fleetParallel, fleetReportDir)
+	span.End()
+
+	if base.MetricsFile != "" || base.PushgatewayURL != "" {
+		exportMetrics(ctx, logger, redactor, base, fleetMetricsReports(afero.NewOsFs(), logger, base, fleetReportDir, entries))
+	}
+
+	fleet := report.NewFleet(internal.Version, start, entries)
+	path := filepath.Join(fleetReportDir, fleetReportName)
+	if err := report.WriteFleet(afero.NewOsFs(), path, fleet, redactor.Redact); err != nil {
+		logger.Warn("failed to write fleet report", zap.String("path", path), zap.Error(err))
+	} else {
+		logger.Info("fleet report written", zap.String("path", path))
+	}
+
+	logger.Info("fleet finished",
+		zap.Int("success", fleet.Summary.Success),
+		zap.Int("no_changes", fleet.Summary.NoChanges),
+		zap.Int("failed", fleet.Summary.Failed),
+	)
+	if fleet.Summary.Failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed", fleet.Summary.Failed, len(entries))
+	}
+	return nil
+}
+
+// shareComposerCache points every composer subprocess at dir. Empty keeps the inherited cache,
+// which one process already shares across its repositories.
+func shareComposerCache(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return err
+	}
+	return os.Setenv("COMPOSER_CACHE_DIR", abs)
+}
+
+// updateFleet runs every repository of manifest, at most parallel at once, and returns their
+// report entries in manifest order. A failing repository does not stop the others.
+func updateFleet(
+	ctx context.Context,
+	logger *zap.Logger,
+	redactor *logging.Redactor,
+	base internal.Config,
+	manifest internal.Manifest,
+	parallel int,
+	reportDir string,
+) []report.FleetRepository {
+	entries := make([]report.FleetRepository, len(manifest.Repositories))
+
+	// Not errgroup.WithContext: one repository failing must not cancel the rest.
+	var g errgroup.Group
+	g.SetLimit(parallel)
+	for i, repository := range manifest.Repositories {
+		g.Go(func() error {
+			entries[i] = updateFleetRepository(ctx, logger.With(zap.String("repository", repository.Name)), redactor, base, repository, reportDir)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return entries
+}
+
+// updateFleetRepository runs one repository and summarises the outcome. Its report is written to
+// reportDir as the run finishes, so an interrupted fleet keeps the reports it got.
+func updateFleetRepository(
+	ctx context.Context,
+	logger *zap.Logger,
+	redactor *logging.Redactor,
+	base internal.Config,
+	repository internal.ManifestRepository,
+	reportDir string,
+) report.FleetRepository {
+	failed := func(phase string, err error) report.FleetRepository {
+		return report.FleetRepository{
+			Name:          repository.Name,
+			Repository:    report.SanitizeURL(repository.URL),
+			BaseBranch:    repository.Branch,
+			Status:        report.StatusFailed,
+			FailedPhase:   phase,
+			Error:         redactor.Redact(err.Error()),
+			MergeRequests: []report.MergeRequest{},
+		}
+	}
+
+	// Queued behind the parallel limit when the fleet was interrupted: nothing to start.
+	if err := ctx.Err(); err != nil {
+		return failed("start", err)
+	}
+
+	cfg := base
+	cfg.RepositoryURL = repository.URL
+	cfg.Branch = repository.Branch
+	if base.SBOMDir != "" {
+		cfg.SBOMDir = filepath.Join(base.SBOMDir, repository.Name)
+	}
+	if err := loadProjectConfig(logger, cmp.Or(repository.Config, configFilePath(configFile, base.WorkingDir)), &cfg); err != nil {
+		return failed("load configuration", err)
+	}
+	registerNotificationSecrets(redactor, cfg.Notifications)
+
+	reportFile := repository.Name + ".json"
+	var rep *report.Report
+	write := reportSink(logger, redactor, filepath.Join(reportDir, reportFile))
+	notifyRun := notifySink(ctx, logger, redactor, cfg.Notifications)
+	sink := func(r report.Report) {
+		rep = &r
+		write(r)
+		notifyRun(r)
+	}
+
+	logger.Info("updating repository", zap.String("url", repository.URL), zap.String("branch", repository.Branch))
+	ctx, span := tracing.Start(ctx, "repository "+repository.Name, tracing.String("drupdater.repository", report.SanitizeURL(repository.URL)))
+	err := fleetRunRepository(ctx, logger, cfg, repo.NewGitRepositoryService(logger), sink)
+	span.SetError(err)
+	span.End()
+	if rep == nil {
+		// Only an error before the workflow started leaves no report behind.
+		if err == nil {
+			err = errors.New("the run produced no report")
+		}
+		return failed("start", err)
+	}
+	return report.NewFleetRepository(repository.Name, reportFile, *rep)
+}
+
+// fleetRunRepository is a variable purely as a test seam: a fleet test replaces each whole
+// repository run, report included.
+var fleetRunRepository = updateRepository
+
+func init() {
+	fleetCmd.Flags().StringVar(&fleetManifest, "manifest", "", "Path to the manifest listing the repositories to update. Required.")
+	fleetCmd.Flags().IntVar(&fleetParallel, "parallel", 2, "Maximum number of repositories to update at once. Each also runs up to --concurrency sites at once.")
+	fleetCmd.Flags().StringVar(&fleetReportDir, "report-dir", "drupdater-reports", "Directory for fleet.json and one run report per repository.")
+	fleetCmd.Flags().StringVar(&fleetComposerCacheDir, "composer-cache-dir", "", "Composer download cache every repository shares. Empty keeps COMPOSER_CACHE_DIR, or Composer's own default.")
+
+	rootCmd.AddCommand(fleetCmd)
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/report"
+	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// withFleetRun swaps each repository's run for fn.
+func withFleetRun(t *testing.T, fn func(ctx context.Context, cfg internal.Config, sink func(report.Report)) error) {
+	t.Helper()
+	old := fleetRunRepository
+	fleetRunRepository = func(ctx context.Context, _ *zap.Logger, cfg internal.Config, _ *repo.GitRepositoryService, sink func(report.Report)) error {
+		return fn(ctx, cfg, sink)
+	}
+	t.Cleanup(func() { fleetRunRepository = old })
+}
+
+func fleetManifestOf(urls ...string) internal.Manifest {
+	var m internal.Manifest
+	for i, url := range urls {
+		m.Repositories = append(m.Repositories, internal.ManifestRepository{Name: filepath.Base(url), URL: url, Branch: []string{"main", "develop"}[i%2]})
+	}
+	return m
+}
+
+func TestUpdateFleetRunsEveryRepositoryInClonedMode(t *testing.T) {
+	withRootCmdState(t, internal.Config{WorkingDir: t.TempDir()}, "")
+
+	var mu sync.Mutex
+	seen := map[string]internal.Config{}
+	withFleetRun(t, func(_ context.Context, cfg internal.Config, sink func(report.Report)) error {
+		mu.Lock()
+		seen[cfg.RepositoryURL] = cfg
+		mu.Unlock()
+
+		rec := report.NewRecorder("dev", report.ModeNormal, false, cfg.RepositoryURL, cfg.Branch, cfg.Sites)
+		switch filepath.Base(cfg.RepositoryURL) {
+		case "broken":
+			_ = rec.Run("composer install", func() error { return errors.New("composer install failed") })
+			sink(rec.Finish())
+			return errors.New("composer install failed")
+		case "current":
+			rec.SetNoChanges()
+		default:
+			rec.SetMergeRequest(cfg.RepositoryURL + "/pull/1")
+		}
+		sink(rec.Finish())
+		return nil
+	})
+
+	reportDir := t.TempDir()
+	base := internal.Config{Clone: true, Token: "secret", Concurrency: 3}
+	entries := updateFleet(t.Context(), zap.NewNop(), logging.NewRedactor(), base,
+		fleetManifestOf("https://github.com/acme/updated", "https://github.com/acme/broken", "https://github.com/acme/current"), 2, reportDir)
+
+	require.Len(t, entries, 3)
+	assert.Equal(t, report.StatusSuccess, entries[0].Status)
+	assert.Equal(t, []report.MergeRequest{{URL: "https://github.com/acme/updated/pull/1"}}, entries[0].MergeRequests)
+	assert.Equal(t, report.StatusFailed, entries[1].Status)
+	assert.Equal(t, "composer install", entries[1].FailedPhase)
+	assert.Equal(t, report.StatusNoChanges, entries[2].Status)
+
+	for _, entry := range entries {
+		assert.FileExists(t, filepath.Join(reportDir, entry.Report))
+	}
+
+	// Each repository gets the shared flags plus its own URL, branch and .drupdater.yaml defaults.
+	cfg := seen["https://github.com/acme/broken"]
+	assert.True(t, cfg.Clone)
+	assert.Equal(t, "secret", cfg.Token)
+	assert.Equal(t, "develop", cfg.Branch)
+	assert.Equal(t, 3, cfg.Concurrency)
+	assert.Equal(t, []string{"default"}, cfg.Sites)
+}
+
+func TestUpdateFleetBoundsParallelism(t *testing.T) {
+	withRootCmdState(t, internal.Config{WorkingDir: t.TempDir()}, "")
+
+	var running, peak atomic.Int32
+	withFleetRun(t, func(_ context.Context, cfg internal.Config, sink func(report.Report)) error {
+		now := running.Add(1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+
+		sink(report.NewRecorder("dev", report.ModeNormal, false, cfg.RepositoryURL, cfg.Branch, cfg.Sites).Finish())
+		return nil
+	})
+
+	manifest := fleetManifestOf("https://github.com/acme/a", "https://github.com/acme/b", "https://github.com/acme/c", "https://github.com/acme/d", "https://github.com/acme/e")
+	entries := updateFleet(t.Context(), zap.NewNop(), logging.NewRedactor(), internal.Config{Clone: true}, manifest, 2, t.TempDir())
+
+	assert.Len(t, entries, 5)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestUpdateFleetRecordsARepositoryWhoseConfigIsInvalid(t *testing.T) {
+	withRootCmdState(t, internal.Config{WorkingDir: t.TempDir()}, "")
+	withFleetRun(t, func(context.Context, internal.Config, func(report.Report)) error {
+		t.Error("a repository with an invalid config must not run")
+		return nil
+	})
+
+	cfgPath := filepath.Join(t.TempDir(), "site.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("sites: []\n"), 0o600))
+	manifest := fleetManifestOf("https://github.com/acme/site")
+	manifest.Repositories[0].Config = cfgPath
+
+	entries := updateFleet(t.Context(), zap.NewNop(), logging.NewRedactor(), internal.Config{Clone: true}, manifest, 1, t.TempDir())
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, report.StatusFailed, entries[0].Status)
+	assert.Equal(t, "load configuration", entries[0].FailedPhase)
+	assert.Contains(t, entries[0].Error, "no sites configured")
+	assert.Empty(t, entries[0].Report)
+}
+
+func TestUpdateFleetRedactsAnErrorWithoutAReport(t *testing.T) {
+	withRootCmdState(t, internal.Config{WorkingDir: t.TempDir()}, "")
+	withFleetRun(t, func(context.Context, internal.Config, func(report.Report)) error {
+		return errors.New("provider rejected token s3cret")
+	})
+	redactor := logging.NewRedactor()
+	redactor.Register("s3cret")
+
+	entries := updateFleet(t.Context(), zap.NewNop(), redactor, internal.Config{Clone: true}, fleetManifestOf("https://github.com/acme/site"), 1, t.TempDir())
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, "start", entries[0].FailedPhase)
+	assert.NotContains(t, entries[0].Error, "s3cret")
+}
+
+func TestUpdateFleetStartsNothingOnceInterrupted(t *testing.T) {
+	withRootCmdState(t, internal.Config{WorkingDir: t.TempDir()}, "")
+	withFleetRun(t, func(context.Context, internal.Config, func(report.Report)) error {
+		t.Error("no repository may start after the fleet was interrupted")
+		return nil
+	})
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	entries := updateFleet(ctx, zap.NewNop(), logging.NewRedactor(), internal.Config{Clone: true}, fleetManifestOf("https://github.com/acme/a", "https://github.com/acme/b"), 1, t.TempDir())
+
+	for _, entry := range entries {
+		assert.Equal(t, report.StatusFailed, entry.Status)
+		assert.Equal(t, "start", entry.FailedPhase)
+	}
+}
+
+func TestFleetPreRunE(t *testing.T) {
+	defer func(manifest string, parallel int) { fleetManifest, fleetParallel = manifest, parallel }(fleetManifest, fleetParallel)
+
+	tests := []struct {
+		name     string
+		manifest string
+		parallel int
+		cfg      internal.Config
+		wantErr  string
+	}{
+		{name: "valid", manifest: "repos.yaml", parallel: 2},
+		{name: "no manifest", parallel: 2, wantErr: "--manifest is required"},
+		{name: "no parallelism", manifest: "repos.yaml", parallel: 0, wantErr: "invalid --parallel 0"},
+		{name: "security and major", manifest: "repos.yaml", parallel: 2, cfg: internal.Config{Security: true, Major: true}, wantErr: "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRootCmdState(t, tt.cfg, "")
+			fleetManifest, fleetParallel = tt.manifest, tt.parallel
+
+			err := fleetCmd.PreRunE(fleetCmd, nil)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestShareComposerCache(t *testing.T) {
+	t.Setenv("COMPOSER_CACHE_DIR", "/inherited")
+
+	require.NoError(t, shareComposerCache(""))
+	assert.Equal(t, "/inherited", os.Getenv("COMPOSER_CACHE_DIR"), "empty keeps the inherited cache")
+
+	dir := filepath.Join(t.TempDir(), "composer-cache")
+	require.NoError(t, shareComposerCache(dir))
+	assert.Equal(t, dir, os.Getenv("COMPOSER_CACHE_DIR"))
+	assert.DirExists(t, dir)
+}
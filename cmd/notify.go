@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/notify"
+	"github.com/drupdater/drupdater/internal/report"
+	"go.uber.org/zap"
+)
+
+// notifyTimeout bounds the notifications at the end of a run, which may follow an interrupt.
+const notifyTimeout = 10 * time.Second
+
+// registerNotificationSecrets registers each notification's webhook URL or token, read from the
+// environment as the run will read it, before either can reach a log line. Called once the
+// project configuration, which lists them, is loaded.
+func registerNotificationSecrets(redactor *logging.Redactor, notifications []internal.Notification) {
+	for _, n := range notifications {
+		// An unset variable leaves nothing to redact; the run reports it when it notifies.
+		if expanded, err := notify.Expand(n, os.LookupEnv); err == nil {
+			redactor.Register(notify.Secret(expanded))
+		}
+	}
+}
+
+// notifySink posts the run report's summary to every notification it triggers, failing the way
+// reportSink does. ctx bounds the posts; it is not cancelled with it, since an interrupted run is
+// one worth hearing about.
+func notifySink(ctx context.Context, logger *zap.Logger, redactor *logging.Redactor, notifications []internal.Notification) func(report.Report) {
+	return func(rep report.Report) {
+		summary := report.NewSummary(rep)
+		postCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+		defer cancel()
+
+		for i, n := range notifications {
+			fields := []zap.Field{zap.Int("notification", i+1), zap.String("type", string(n.Type))}
+			triggers := notify.Triggered(n, summary)
+			if len(triggers) == 0 {
+				logger.Debug("notification not triggered", fields...)
+				continue
+			}
+			expanded, err := notify.Expand(n, os.LookupEnv)
+			if err == nil {
+				err = notify.Send(postCtx, http.DefaultClient, expanded, summary, triggers, redactor.Redact)
+			}
+			if err != nil {
+				logger.Warn("failed to send notification", append(fields, zap.Error(err))...)
+				continue
+			}
+			logger.Info("notification sent", fields...)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRegisterNotificationSecrets(t *testing.T) {
+	t.Setenv("SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/T0/B0/s3cret")
+	redactor := logging.NewRedactor()
+
+	registerNotificationSecrets(redactor, []internal.Notification{
+		{Type: internal.NotificationSlack, URL: "${SLACK_WEBHOOK_URL}"},
+		{Type: internal.NotificationMatrix, URL: "https://matrix.example.org", Room: "!ops:example.org", Token: "syt_token"},
+	})
+
+	assert.NotContains(t, redactor.Redact("posting to https://hooks.slack.com/services/T0/B0/s3cret"), "s3cret")
+	assert.NotContains(t, redactor.Redact("Bearer syt_token"), "syt_token")
+	assert.Contains(t, redactor.Redact("https://matrix.example.org"), "matrix.example.org", "a homeserver is no secret")
+}
+
+func TestNotifySinkPostsWhereTriggered(t *testing.T) {
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		posts.Add(1)
+	}))
+	defer server.Close()
+	t.Setenv("OPS_WEBHOOK_URL", server.URL)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	sink := notifySink(t.Context(), zap.New(core), logging.NewRedactor(), []internal.Notification{
+		{Type: internal.NotificationWebhook, URL: "${OPS_WEBHOOK_URL}", On: []internal.NotifyTrigger{internal.NotifyOnFailed}},
+		{Type: internal.NotificationSlack, URL: server.URL, On: []internal.NotifyTrigger{internal.NotifyOnSuccess}},
+		{Type: internal.NotificationTeams, URL: "${UNSET_TEAMS_WEBHOOK_URL}", On: []internal.NotifyTrigger{internal.NotifyOnFailed}},
+	})
+
+	sink(report.Report{Repository: "https://github.com/acme/site", Status: report.StatusFailed, FailedPhase: "update code"})
+
+	assert.EqualValues(t, 1, posts.Load())
+	require.Equal(t, 1, logs.FilterMessage("notification sent").Len())
+	assert.Equal(t, 1, logs.FilterMessage("notification not triggered").Len())
+	warnings := logs.FilterMessage("failed to send notification").All()
+	require.Len(t, warnings, 1)
+	assert.EqualValues(t, 3, warnings[0].ContextMap()["notification"])
+	assert.Contains(t, warnings[0].ContextMap()["error"], "UNSET_TEAMS_WEBHOOK_URL")
+}
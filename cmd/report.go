@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/drupdater/drupdater/internal/report"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// reportFormat is the --format of "report" and "report diff".
+var reportFormat string
+
+var reportCmd = &cobra.Command{
+	Use:   "report <report.json>...",
+	Short: "Render run reports for reading",
+	Long: `Renders one or more run reports, as written by --report, for a person to read: a summary,
+the package changes, advisories, patch conflicts and phase timings of each run.
+
+--format picks the output: terminal (aligned text, the default), markdown (for an issue or a
+wiki page) or html (a standalone page). Output goes to stdout.
+
+Use "drupdater report diff" to compare two runs instead.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		format, err := report.ParseFormat(reportFormat)
+		if err != nil {
+			return err
+		}
+		return renderReports(afero.NewOsFs(), cmd.OutOrStdout(), format, args)
+	},
+}
+
+var reportDiffCmd = &cobra.Command{
+	Use:   "diff <before.json> <after.json>",
+	Short: "Compare two run reports",
+	Long: `Compares two run reports of one repository, typically last week's and this week's: the
+package changes, advisories, phase durations and patch conflicts that differ between them.
+
+Takes the same --format as "drupdater report".`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		format, err := report.ParseFormat(reportFormat)
+		if err != nil {
+			return err
+		}
+		return diffReports(afero.NewOsFs(), cmd.OutOrStdout(), format, args[0], args[1])
+	},
+}
+
+// renderReports reads every path before printing anything, so a bad one does not leave half a
+// document behind.
+func renderReports(fs afero.Fs, out io.Writer, format report.Format, paths []string) error {
+	var views []report.View
+	for _, path := range paths {
+		rep, err := report.Read(fs, path)
+		if err != nil {
+			return fmt.Errorf("failed to read report: %w", err)
+		}
+		views = append(views, report.NewView(rep)...)
+	}
+	return report.WriteViews(out, format, views)
+}
+
+func diffReports(fs afero.Fs, out io.Writer, format report.Format, beforePath, afterPath string) error {
+	before, err := report.Read(fs, beforePath)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+	after, err := report.Read(fs, afterPath)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+	return report.WriteViews(out, format, []report.View{report.Diff(before, after)})
+}
+
+func init() {
+	reportCmd.PersistentFlags().StringVar(&reportFormat, "format", string(report.FormatTerminal), "Output format: terminal, markdown or html.")
+
+	reportCmd.AddCommand(reportDiffCmd)
+	rootCmd.AddCommand(reportCmd)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drupdater/drupdater/internal/report"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRunReport(t *testing.T, fs afero.Fs, path string, status report.Status, packages ...report.PackageChange) {
+	t.Helper()
+	rec := report.NewRecorder("dev", report.ModeNormal, false, "https://github.com/org/site.git", "main", []string{"default"})
+	rec.SetPackages(packages)
+	if status == report.StatusNoChanges {
+		rec.SetNoChanges()
+	}
+	require.NoError(t, report.Write(fs, path, rec.Finish(), nil))
+}
+
+func TestRenderReportsPrintsEveryReport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeRunReport(t, fs, "/a.json", report.StatusSuccess, report.PackageChange{Action: "Upgrade", Package: "drupal/core", From: "10.3.8", To: "10.3.9"})
+	writeRunReport(t, fs, "/b.json", report.StatusNoChanges)
+
+	var out bytes.Buffer
+	require.NoError(t, renderReports(fs, &out, report.FormatMarkdown, []string{"/a.json", "/b.json"}))
+
+	assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("# https://github.com/org/site.git (main)\n")))
+	assert.Contains(t, out.String(), "| Upgrade | drupal/core | 10.3.8 | 10.3.9 |")
+	assert.Contains(t, out.String(), "| Status | no_changes |")
+}
+
+func TestRenderReportsPrintsNothingWhenAReportIsUnreadable(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeRunReport(t, fs, "/a.json", report.StatusSuccess)
+
+	var out bytes.Buffer
+	err := renderReports(fs, &out, report.FormatTerminal, []string{"/a.json", "/missing.json"})
+	require.Error(t, err)
+	assert.Empty(t, out.String(), "half a document reads as a complete one")
+}
+
+func TestDiffReportsShowsWhatChanged(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeRunReport(t, fs, "/before.json", report.StatusSuccess, report.PackageChange{Action: "Upgrade", Package: "drupal/token", From: "1.13.0", To: "1.14.0"})
+	writeRunReport(t, fs, "/after.json", report.StatusSuccess, report.PackageChange{Action: "Upgrade", Package: "drupal/token", From: "1.14.0", To: "1.15.0"})
+
+	var out bytes.Buffer
+	require.NoError(t, diffReports(fs, &out, report.FormatMarkdown, "/before.json", "/after.json"))
+	assert.Contains(t, out.String(), "| drupal/token | Upgrade 1.13.0 → 1.14.0 | Upgrade 1.14.0 → 1.15.0 |")
+
+	require.Error(t, diffReports(fs, &out, report.FormatMarkdown, "/before.json", "/missing.json"))
+}
+
+func TestReportCommandRejectsAnUnknownFormat(t *testing.T) {
+	defer func(old string) { reportFormat = old }(reportFormat)
+	reportFormat = "pdf"
+
+	err := reportCmd.RunE(reportCmd, []string{"report.json"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown format "pdf"`)
+}
@@ -65,6 +65,27 @@ func TestReportSinkWarnsInsteadOfFailingWhenTheWriteFails(t *testing.T) {
 	require.Equal(t, 1, logs.FilterMessage("failed to write run report").Len())
 }
 
+func TestSARIFSinkWritesTheAdvisories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.sarif")
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	rec := report.NewRecorder("dev", report.ModeSecurity, false, "https://example.com/repo.git", "main", []string{"default"})
+	rep := rec.Finish()
+	rep.Addons = map[string]any{"composer_audit": map[string]any{
+		"remaining": []map[string]any{{"cve": "CVE-2026-1", "packageName": "drupal/core", "severity": "high"}},
+	}}
+	sarifSink(zap.New(core), logging.NewRedactor(), path)(rep)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded report.SARIF
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.Len(t, decoded.Runs, 1)
+	require.Len(t, decoded.Runs[0].Results, 1)
+	assert.Equal(t, "CVE-2026-1", decoded.Runs[0].Results[0].RuleID)
+	assert.Equal(t, 1, logs.FilterMessage("SARIF report written").Len())
+}
+
 func TestWriteCheckReportWritesWhenPathGiven(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "check.json")
 	core, logs := observer.New(zapcore.InfoLevel)
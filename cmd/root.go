@@ -3,6 +3,8 @@ package cmd
 import (
 	"cmp"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +18,8 @@ import (
 	"slices"
 	"strings"
 	"syscall"
+	"text/template"
+	"time"
 
 	"github.com/drupdater/drupdater/internal"
 	"github.com/drupdater/drupdater/internal/addon"
@@ -30,6 +34,7 @@ import (
 	"github.com/drupdater/drupdater/pkg/phpcs"
 	"github.com/drupdater/drupdater/pkg/rector"
 	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/drupdater/drupdater/pkg/tracing"
 	"github.com/gookit/event"
 	"github.com/maypok86/otter"
 	"github.com/spf13/afero"
@@ -60,6 +65,10 @@ names you can set there. See the README for the full file format.`,
 		if config.Clone && config.RepositoryURL == "" {
 			return errors.New("--repository-url is required with --clone")
 		}
+		// Each selects its own run type; a security fix must not wait on a major upgrade.
+		if config.Security && config.Major {
+			return errors.New("--security and --major cannot be combined")
+		}
 		// Validated against what the provider factory accepts, so git@host:owner/repo.git passes.
 		if config.RepositoryURL != "" {
 			if err := codehosting.ValidateRepositoryURL(config.RepositoryURL); err != nil {
@@ -98,23 +107,26 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	redactor.Register(config.Token)
+	registerPushgatewaySecrets(redactor, config.PushgatewayURL)
 
-	if err := loadProjectConfig(logger, configFilePath(configFile, config.WorkingDir), &config); err != nil {
+	config.Signer, err = resolveSigner(config)
+	if err != nil {
+		logger.Error("invalid signing key", zap.Error(err))
 		return err
 	}
+	config.SSH.Passphrase = os.Getenv(sshPassphraseEnv)
 
-	cache, err := NewCache()
+	ctx, flushTrace, err := startTracing(cmd.Context(), logger, redactor, config)
 	if err != nil {
-		logger.Error("failed to create cache", zap.Error(err))
 		return err
 	}
+	defer flushTrace()
+
+	if err := loadProjectConfig(logger, configFilePath(configFile, config.WorkingDir), &config); err != nil {
+		return err
+	}
+	registerNotificationSecrets(redactor, config.Notifications)
 
-	drush := drush.NewCLI(logger, cache)
-	composer := composer.NewCLI(logger)
-	// Patch checks leave a scratch project with a full vendor tree behind otherwise.
-	defer composer.Cleanup()
-	drupalOrg := drupalorg.NewHTTPClient(logger)
-	installer := drupal.NewInstaller(logger, drush, composer)
 	git := repo.NewGitRepositoryService(logger)
 
 	// In checkout mode the URL and target branch come from the checkout; --branch is --clone only.
@@ -125,40 +137,130 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		logger.Info("using checkout", zap.String("url", config.RepositoryURL), zap.String("branch", config.Branch))
 
 		// CI mounts the checkout under another user, so git calls it "dubious ownership".
-		ensureGitSafeDirectory(cmd.Context(), logger, config.WorkingDir)
+		ensureGitSafeDirectory(ctx, logger, config.WorkingDir)
+	}
+
+	var sinks []func(report.Report)
+	if config.ReportPath != "" {
+		sinks = append(sinks, reportSink(logger, redactor, config.ReportPath))
+	}
+	if config.SARIFPath != "" {
+		sinks = append(sinks, sarifSink(logger, redactor, config.SARIFPath))
+	}
+	if config.MetricsFile != "" || config.PushgatewayURL != "" {
+		sinks = append(sinks, metricsSink(ctx, logger, redactor, config))
+	}
+	if len(config.Notifications) > 0 {
+		sinks = append(sinks, notifySink(ctx, logger, redactor, config.Notifications))
+	}
+	var sink func(report.Report)
+	if len(sinks) > 0 {
+		sink = func(rep report.Report) {
+			for _, s := range sinks {
+				s(rep)
+			}
+		}
 	}
 
+	ctx, span := tracing.Start(ctx, "drupdater", tracing.String("drupdater.command", "update"))
+	err = updateRepository(ctx, logger, config, git, sink)
+	span.SetError(err)
+	span.End()
+	return err
+}
+
+// updateRepository runs the update for the one repository cfg names, once per update group.
+// Shared by the root command and each repository of a fleet; sink may be nil.
+func updateRepository(ctx context.Context, logger *zap.Logger, cfg internal.Config, git *repo.GitRepositoryService, sink func(report.Report)) error {
+	cache, err := NewCache()
+	if err != nil {
+		logger.Error("failed to create cache", zap.Error(err))
+		return err
+	}
+
+	drush := drush.NewCLI(logger, cache)
+	composer := composer.NewCLI(logger)
+	// Patch checks leave a scratch project with a full vendor tree behind otherwise.
+	defer composer.Cleanup()
+	drupalOrg := drupalorg.NewHTTPClient(logger)
+	installer := drupal.NewInstaller(logger, drush, composer)
+
 	// Built only for a run that clones or publishes; see tokenRequired.
 	var platform codehosting.Platform
-	if tokenRequired(config) {
+	if tokenRequired(cfg) {
 		vcsProviderFactory := codehosting.NewDefaultVcsProviderFactory()
-		platform, err = vcsProviderFactory.Create(config.RepositoryURL, config.Token, logger)
+		platform, err = vcsProviderFactory.Create(cfg.RepositoryURL, cfg.HostOptions(), cfg.Token, logger)
 		if err != nil {
 			logger.Error("failed to create VCS provider", zap.Error(err))
 			return err
 		}
 	}
 
-	addons, err := createAddons(logger, config, drush, composer, drupalOrg, git)
+	templates, err := internal.ParseTemplates(cfg.Templates)
 	if err != nil {
+		logger.Error("invalid merge request template", zap.Error(err))
 		return err
 	}
-	dispatcher := createDispatcher(addons)
 
-	var opts []services.Option
-	if config.ReportPath != "" {
-		opts = append(opts, services.WithReportSink(reportSink(logger, redactor, config.ReportPath)))
+	// Fresh addons for every run: their sections would otherwise carry one group's findings into
+	// the next group's merge request.
+	run := func(cfg internal.Config, sink func(report.Report)) error {
+		logger := logger
+		if cfg.Group != "" {
+			logger = logger.With(zap.String("group", cfg.Group))
+		}
+		addons, err := createAddons(logger, cfg, templates, drush, composer, drupalOrg, git)
+		if err != nil {
+			return err
+		}
+		opts := []services.Option{services.WithTemplates(templates)}
+		if sink != nil {
+			opts = append(opts, services.WithReportSink(sink))
+		}
+		workflow := newWorkflowService(logger, cfg, drush, platform, git, installer, composer, createDispatcher(ctx, addons), opts...)
+		return workflow.StartUpdate(ctx, addons)
 	}
-	workflow := newWorkflowService(logger, config, drush, platform, git, installer, composer, dispatcher, opts...)
+	return runGroups(ctx, logger, cfg, sink, run)
+}
 
-	err = workflow.StartUpdate(cmd.Context(), addons)
-	if err != nil {
-		if err := handleWorkflowError(logger, err); err != nil {
-			return err
+// runGroups runs the update once per update group, or once in all for a project without groups.
+// A failing group does not stop the next, which touches other packages; the first failure is
+// returned once all have run. Their reports are combined into the one document --report names.
+func runGroups(ctx context.Context, logger *zap.Logger, cfg internal.Config, sink func(report.Report), run func(internal.Config, func(report.Report)) error) error {
+	groups := cfg.RunGroups()
+	if len(groups) == 1 && groups[0] == "" {
+		return finishRun(logger, run(cfg, sink))
+	}
+
+	var reports []report.Report
+	var collect func(report.Report)
+	if sink != nil {
+		collect = func(rep report.Report) { reports = append(reports, rep) }
+	}
+	var firstErr error
+	for _, group := range groups {
+		logger.Info("updating group", zap.String("group", group))
+		groupCfg := cfg
+		groupCfg.Group = group
+		if err := finishRun(logger, run(groupCfg, collect)); err != nil && firstErr == nil {
+			firstErr = err
 		}
-	} else {
-		logger.Info("update finished")
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(reports) > 0 {
+		sink(report.Combine(reports))
+	}
+	return firstErr
+}
+
+// finishRun logs a run's outcome and returns the error, if any, the command fails with.
+func finishRun(logger *zap.Logger, err error) error {
+	if err != nil {
+		return handleWorkflowError(logger, err)
 	}
+	logger.Info("update finished")
 	return nil
 }
 
@@ -194,6 +296,17 @@ func reportSink(logger *zap.Logger, redactor *logging.Redactor, path string) fun
 	}
 }
 
+// sarifSink writes the run report's advisories to path as SARIF, failing the same way reportSink does.
+func sarifSink(logger *zap.Logger, redactor *logging.Redactor, path string) func(report.Report) {
+	return func(rep report.Report) {
+		if err := report.WriteSARIF(afero.NewOsFs(), path, report.NewSARIF(rep), redactor.Redact); err != nil {
+			logger.Warn("failed to write SARIF report", zap.String("path", path), zap.Error(err))
+			return
+		}
+		logger.Info("SARIF report written", zap.String("path", path))
+	}
+}
+
 // resolveToken takes the argument, else DRUPDATER_TOKEN. Mandatory only when the run will use it.
 func resolveToken(args []string, cfg internal.Config) (string, error) {
 	var token string
@@ -216,6 +329,8 @@ func tokenRequired(cfg internal.Config) bool {
 // registerEnvSecrets registers every credential-bearing environment value a subprocess may echo.
 func registerEnvSecrets(redactor *logging.Redactor) {
 	redactor.Register(os.Getenv("DRUPALCODE_ACCESS_TOKEN"))
+	redactor.Register(os.Getenv(signingKeyEnv), os.Getenv(signingPassphraseEnv))
+	redactor.Register(os.Getenv(sshPassphraseEnv))
 	registerComposerAuth(redactor, os.Getenv("COMPOSER_AUTH"))
 }
 
@@ -285,6 +400,14 @@ func loadProjectConfig(logger *zap.Logger, path string, cfg *internal.Config) er
 		zap.Bool("run_types.normal.auto_merge", cfg.RunTypes.Normal.AutoMerge),
 		zap.Strings("run_types.security.addons", cfg.RunTypes.Security.Addons),
 		zap.Bool("run_types.security.auto_merge", cfg.RunTypes.Security.AutoMerge),
+		zap.Strings("run_types.major.addons", cfg.RunTypes.Major.Addons),
+		zap.Bool("run_types.major.auto_merge", cfg.RunTypes.Major.AutoMerge),
+		zap.Strings("groups", cfg.RunGroups()),
+		zap.Int("ignore", len(cfg.Ignore)),
+		zap.Int("hold", len(cfg.Hold)),
+		zap.Int("minimum_release_age", cfg.MinimumReleaseAge),
+		zap.Int("notifications", len(cfg.Notifications)),
+		zap.Bool("templates", !cfg.Templates.IsZero()),
 	)
 	return nil
 }
@@ -353,6 +476,13 @@ type addonDeps struct {
 	git       addon.Repository
 	// security marks a --security run. Only composer_audit reads it.
 	security bool
+	// ignore and hold are the project's package rules. Only package_rules reads them.
+	ignore []internal.PackageRule
+	hold   []internal.PackageRule
+	// updateLevel is the active run type's cap. Only update_policy reads it.
+	updateLevel internal.UpdateLevel
+	// releaseAge is the run's cooldown. Only release_age reads it.
+	releaseAge time.Duration
 }
 
 // addonRegistry maps the names used in .drupdater.yaml to their constructors.
@@ -375,10 +505,20 @@ var addonRegistry = map[string]func(addonDeps) internal.Addon{
 	"composer_diff":       func(d addonDeps) internal.Addon { return addon.NewComposerDiff(d.logger, d.composer) },
 	"update_hooks":        func(d addonDeps) internal.Addon { return addon.NewUpdateHooks(d.logger, d.drush) },
 	"unsupported_modules": func(d addonDeps) internal.Addon { return addon.NewUnsupportedModules(d.logger, d.drush) },
+	"package_rules": func(d addonDeps) internal.Addon {
+		return addon.NewPackageRules(d.logger, d.composer, d.ignore, d.hold)
+	},
+	"update_policy": func(d addonDeps) internal.Addon {
+		return addon.NewUpdatePolicy(d.logger, d.composer, d.updateLevel)
+	},
+	"release_age": func(d addonDeps) internal.Addon {
+		return addon.NewReleaseAge(d.logger, d.composer, d.releaseAge)
+	},
 }
 
 // mandatoryAddons always run, regardless of the .drupdater.yaml addon lists. composer_audit and
-// unsupported_modules render one shared end-of-life list, so both are needed on every update.
+// unsupported_modules render one shared end-of-life list, so both are needed on every update;
+// package_rules, update_policy and release_age enforce pins the project relies on.
 var mandatoryAddons = []string{
 	"composer_allow_plugins",
 	"composer_patches",
@@ -386,18 +526,42 @@ var mandatoryAddons = []string{
 	"update_hooks",
 	"composer_audit",
 	"unsupported_modules",
+	"package_rules",
+	"update_policy",
+	"release_age",
+}
+
+// sectionAddons render a section of the merge request from a template, which templates.addons can
+// replace. The others render nothing.
+var sectionAddons = []string{
+	"composer_allow_plugins",
+	"composer_patches",
+	"composer_diff",
+	"update_hooks",
+	"composer_audit",
+	"unsupported_modules",
+	"package_rules",
+	"update_policy",
+	"release_age",
 }
 
-// createAddons builds the mandatory addons plus the ones the active run type lists.
+// templatedAddon is an addon whose section a project template can replace.
+type templatedAddon interface {
+	UseTemplate(tmpl *template.Template)
+}
+
+// createAddons builds the mandatory addons plus the ones the active run type lists, each with the
+// project's template for its section, if any.
 func createAddons(
 	logger *zap.Logger,
 	config internal.Config,
+	templates internal.ProjectTemplates,
 	drush addon.Drush,
 	composer addon.Composer,
 	drupalOrg addon.DrupalOrg,
 	git addon.Repository,
 ) ([]internal.Addon, error) {
-	deps := addonDeps{logger: logger, drush: drush, composer: composer, drupalOrg: drupalOrg, git: git, security: config.Security}
+	deps := addonDeps{logger: logger, drush: drush, composer: composer, drupalOrg: drupalOrg, git: git, security: config.Security, ignore: config.Ignore, hold: config.Hold, updateLevel: config.ActiveRunType().UpdateLevel, releaseAge: config.ReleaseAge()}
 
 	names := config.ActiveRunType().Addons
 
@@ -411,7 +575,17 @@ func createAddons(
 		if !ok {
 			return fmt.Errorf("unknown addon %q", name)
 		}
-		addons = append(addons, factory(deps))
+		addonDeps := deps
+		addonDeps.logger = logger.With(zap.String("addon", name))
+		a := factory(addonDeps)
+		if tmpl := templates.Addons[name]; tmpl != nil {
+			templated, ok := a.(templatedAddon)
+			if !ok {
+				return fmt.Errorf("addon %q renders no merge request section to replace", name)
+			}
+			templated.UseTemplate(tmpl)
+		}
+		addons = append(addons, a)
 		added[name] = true
 		return nil
 	}
@@ -426,17 +600,30 @@ func createAddons(
 			return nil, err
 		}
 	}
+	// Not in the registry: --sbom-dir enables it, and a .drupdater.yaml entry would have nowhere to write.
+	if config.SBOMDir != "" {
+		addons = append(addons, addon.NewSBOM(logger.With(zap.String("addon", "sbom")), config.SBOMDir, config.Group, internal.Version))
+	}
 
 	return addons, nil
 }
 
-// validateAddons checks both run types, so a typo under run_types.security fails a normal run too.
+// validateAddons checks every run type, so a typo under run_types.security fails a normal run too,
+// and the addons templates.addons names.
 func validateAddons(config internal.Config) error {
-	for _, name := range append(append([]string{}, config.RunTypes.Normal.Addons...), config.RunTypes.Security.Addons...) {
+	for _, name := range slices.Concat(config.RunTypes.Normal.Addons, config.RunTypes.Security.Addons, config.RunTypes.Major.Addons) {
 		if _, ok := addonRegistry[name]; !ok {
 			return fmt.Errorf("unknown addon %q (run \"drupdater addons\" to list valid names)", name)
 		}
 	}
+	for _, name := range slices.Sorted(maps.Keys(config.Templates.Addons)) {
+		if _, ok := addonRegistry[name]; !ok {
+			return fmt.Errorf("unknown addon %q in templates.addons (run \"drupdater addons\" to list valid names)", name)
+		}
+		if !slices.Contains(sectionAddons, name) {
+			return fmt.Errorf("templates.addons.%s: the addon renders no merge request section to replace (use one of %s)", name, strings.Join(sectionAddons, ", "))
+		}
+	}
 	return nil
 }
 
@@ -452,18 +639,19 @@ var addonsCmd = &cobra.Command{
 	Short: "List the addon names that can be set in .drupdater.yaml",
 	Run: func(cmd *cobra.Command, _ []string) {
 		out := cmd.OutOrStdout()
-		fmt.Fprintln(out, "Addons you can set under run_types.normal.addons / run_types.security.addons in .drupdater.yaml:")
+		fmt.Fprintln(out, "Addons you can set under run_types.normal.addons / run_types.security.addons / run_types.major.addons in .drupdater.yaml:")
 		for _, n := range configurableAddons() {
 			fmt.Fprintf(out, "  %s\n", n)
 		}
 	},
 }
 
-// createDispatcher subscribes every addon to a new event manager.
-func createDispatcher(addons []internal.Addon) services.EventDispatcher {
+// createDispatcher subscribes every addon to a new event manager, each listener under a span of
+// its own. ctx parents the spans of events that carry no context of their own.
+func createDispatcher(ctx context.Context, addons []internal.Addon) services.EventDispatcher {
 	dispatcher := event.NewManager("")
 	for _, addon := range addons {
-		dispatcher.AddSubscriber(addon)
+		dispatcher.AddSubscriber(tracedSubscriber{addon: addon, ctx: ctx})
 	}
 	return dispatcher
 }
@@ -484,11 +672,23 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&config.Clone, "clone", false, "Clone the repository instead of using the existing checkout. Requires --repository-url. Intended for local testing.")
 	rootCmd.PersistentFlags().StringVar(&config.RepositoryURL, "repository-url", "", "Repository URL. Required with --clone; otherwise derived from the checkout's origin remote.")
 	rootCmd.PersistentFlags().BoolVar(&config.Security, "security", false, "Only security updates. If true, only security updates will be applied.")
+	rootCmd.PersistentFlags().BoolVar(&config.Major, "major", false, "Raise composer.json constraints to each direct dependency's next major release, one package at a time. Packages that cannot be resolved are reported and left as they are.")
 	rootCmd.PersistentFlags().BoolVar(&config.DryRun, "dry-run", false, "Do not push the update branch or create a merge request. The branch and commits are still created locally.")
 	rootCmd.PersistentFlags().BoolVar(&config.Verbose, "verbose", false, "Verbose")
+	rootCmd.PersistentFlags().StringVar(&config.LogFormat, "log-format", logFormatConsole, "Log line format on stderr: console, or json for a log shipper.")
+	rootCmd.PersistentFlags().StringVar(&config.LogFile, "log-file", "", "Also append every log line to this file, as JSON whatever --log-format says.")
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to the config file (default: <working-dir>/.drupdater.yaml).")
 	rootCmd.PersistentFlags().IntVar(&config.Concurrency, "concurrency", runtime.GOMAXPROCS(0), "Maximum number of sites to install/update concurrently. Defaults to GOMAXPROCS(0), which reflects the container's CPU quota, not just the host's core count.")
 	rootCmd.PersistentFlags().StringVar(&config.ReportPath, "report", "", "Write a machine-readable JSON report of the run to this path. Written on every outcome, including failures and --dry-run.")
+	rootCmd.PersistentFlags().StringVar(&config.SBOMDir, "sbom-dir", "", "Write CycloneDX SBOMs of the project before and after the update to this directory, and summarise their difference in the run report.")
+	rootCmd.PersistentFlags().StringVar(&config.MetricsFile, "metrics-file", "", "Write Prometheus metrics of the run to this path, for node_exporter's textfile collector: status, phase durations, package changes and findings. Written whenever --report would be.")
+	rootCmd.PersistentFlags().StringVar(&config.PushgatewayURL, "pushgateway-url", "", "Push Prometheus metrics of the run to this Pushgateway, grouped by repository. Pushed whenever --report would be.")
+	rootCmd.PersistentFlags().StringVar(&config.TraceFile, "trace-file", "", "Append an OpenTelemetry trace of the run to this path as OTLP/JSON: a span per phase, site, addon event handler and subprocess.")
+	rootCmd.PersistentFlags().StringVar(&config.OTLPEndpoint, "otlp-endpoint", "", "Send the OpenTelemetry trace of the run to this OTLP/HTTP collector base URL (/v1/traces is appended). Defaults to OTEL_EXPORTER_OTLP_ENDPOINT.")
+	rootCmd.PersistentFlags().StringVar(&config.SigningKey, "signing-key", "", "Sign every commit with the GPG or SSH private key in this file. Defaults to the key DRUPDATER_SIGNING_KEY holds; DRUPDATER_SIGNING_PASSPHRASE unlocks a protected one.")
+	rootCmd.PersistentFlags().StringVar(&config.SSH.KeyFile, "ssh-key", "", "Clone and push an SSH repository URL with the private key in this file. Defaults to ssh-agent's keys; DRUPDATER_SSH_KEY_PASSPHRASE unlocks a protected one.")
+	rootCmd.PersistentFlags().StringVar(&config.SSH.KnownHosts, "ssh-known-hosts", "", "Verify the SSH host's key against this known_hosts file. Defaults to SSH_KNOWN_HOSTS, else ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts.")
+	rootCmd.PersistentFlags().StringVar(&config.SARIFPath, "sarif-report", "", "Write the fixed and unresolved security advisories as SARIF 2.1.0 to this path, for code scanning. Written whenever --report would be.")
 
 	rootCmd.AddCommand(addonsCmd)
 }
@@ -510,14 +710,67 @@ func NewCache() (otter.Cache[string, string], error) {
 	return otter.MustBuilder[string, string](100).Build()
 }
 
+// Log formats --log-format accepts.
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+)
+
+// NewLogger builds the logger for config's --log-format and --log-file. Every line carries a
+// run_id, so the lines of one run can be told apart once shipped next to others; the workflow
+// adds the phase, site, addon and group fields as they apply.
 func NewLogger(config internal.Config, redactor *logging.Redactor) (*zap.Logger, error) {
-	loggerConfig := zap.NewDevelopmentConfig()
-	loggerConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	var loggerConfig zap.Config
+	switch config.LogFormat {
+	case "", logFormatConsole:
+		loggerConfig = zap.NewDevelopmentConfig()
+		loggerConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	case logFormatJSON:
+		loggerConfig = zap.NewProductionConfig()
+		// Every line, however many: a dropped line from a failing site is the one someone needs.
+		loggerConfig.Sampling = nil
+		loggerConfig.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		if config.Verbose {
+			loggerConfig.Level.SetLevel(zapcore.DebugLevel)
+		}
+	default:
+		return nil, fmt.Errorf("unknown log format %q: use %s or %s", config.LogFormat, logFormatConsole, logFormatJSON)
+	}
 
 	if !config.Verbose {
 		loggerConfig.Level.SetLevel(zapcore.InfoLevel)
 		loggerConfig.DisableCaller = true
 		loggerConfig.DisableStacktrace = true
 	}
-	return loggerConfig.Build(zap.AddStacktrace(zapcore.ErrorLevel), zap.WrapCore(logging.WrapCore(redactor)))
+
+	opts := []zap.Option{zap.AddStacktrace(zapcore.ErrorLevel)}
+	if config.LogFile != "" {
+		fileCore, err := logFileCore(config.LogFile, loggerConfig.Level)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core { return zapcore.NewTee(core, fileCore) }))
+	}
+	// After the tee, so the file is redacted too.
+	opts = append(opts, zap.WrapCore(logging.WrapCore(redactor)), zap.Fields(zap.String("run_id", newRunID())))
+	return loggerConfig.Build(opts...)
+}
+
+// logFileCore appends JSON lines to path whatever --log-format says: the file is for a log
+// shipper, and the console encoder's colours would only corrupt it.
+func logFileCore(path string, level zap.AtomicLevel) (zapcore.Core, error) {
+	sink, _, err := zap.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	return zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), sink, level), nil
+}
+
+// newRunID returns a random id for one invocation.
+func newRunID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
 }
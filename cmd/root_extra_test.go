@@ -100,13 +100,17 @@ func TestRegisterEnvSecrets(t *testing.T) {
 	// One wiring for both a real run and "drupdater check": same subprocesses, same secrets.
 	t.Setenv("DRUPALCODE_ACCESS_TOKEN", "drupalcode-secret")
 	t.Setenv("COMPOSER_AUTH", `{"bearer":{"example.com":"bearer-secret"}}`)
+	t.Setenv(signingPassphraseEnv, "signing-passphrase")
+	t.Setenv(sshPassphraseEnv, "ssh-passphrase")
 
 	redactor := logging.NewRedactor()
 	registerEnvSecrets(redactor)
 
-	got := redactor.Redact("leaked drupalcode-secret and bearer-secret")
+	got := redactor.Redact("leaked drupalcode-secret and bearer-secret and signing-passphrase and ssh-passphrase")
 	assert.NotContains(t, got, "drupalcode-secret")
 	assert.NotContains(t, got, "bearer-secret")
+	assert.NotContains(t, got, "signing-passphrase")
+	assert.NotContains(t, got, "ssh-passphrase")
 }
 
 func TestRegisterComposerAuth(t *testing.T) {
@@ -359,6 +363,15 @@ func TestRootCommandPreRunE(t *testing.T) {
 		assert.Contains(t, err.Error(), "--repository-url is required")
 	})
 
+	t.Run("--security with --major is rejected", func(t *testing.T) {
+		reset()
+		config.Security = true
+		config.Major = true
+
+		err := rootCmd.PreRunE(rootCmd, nil)
+		require.ErrorContains(t, err, "cannot be combined")
+	})
+
 	t.Run("an SCP-style repository URL is accepted", func(t *testing.T) {
 		reset()
 		config.RepositoryURL = "git@github.com:drupdater/drupdater.git"
@@ -11,9 +11,11 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/drupdater/drupdater/internal"
 	"github.com/drupdater/drupdater/internal/codehosting"
+	"github.com/drupdater/drupdater/internal/report"
 	"github.com/drupdater/drupdater/internal/services"
 	git "github.com/go-git/go-git/v5"
 	gitConfig "github.com/go-git/go-git/v5/config"
@@ -21,6 +23,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // withRootCmdState saves and restores the package-level state runUpdate reads.
@@ -268,7 +272,8 @@ func TestCreateAddonsPassesEveryDependency(t *testing.T) {
 	mandatoryAddons = []string{"probe", "probe_other"}
 	t.Cleanup(func() { addonRegistry, mandatoryAddons = oldRegistry, oldMandatory })
 
-	logger := zap.NewNop()
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
 	cache, err := NewCache()
 	require.NoError(t, err)
 	drushSvc := drush.NewCLI(logger, cache)
@@ -277,13 +282,66 @@ func TestCreateAddonsPassesEveryDependency(t *testing.T) {
 	drupalOrgSvc := drupalorg.NewHTTPClient(logger)
 	gitSvc := repo.NewGitRepositoryService(logger)
 
-	_, err = createAddons(logger, internal.Config{}, drushSvc, composerSvc, drupalOrgSvc, gitSvc)
+	ignore := []internal.PackageRule{{Package: "drupal/legacy_*"}}
+	hold := []internal.PackageRule{{Package: "drupal/search_api_solr", Constraint: "4.2.*"}}
+	runTypes := internal.RunTypesConfig{Normal: internal.RunTypeConfig{UpdateLevel: internal.UpdateLevelMinor}}
+	_, err = createAddons(logger, internal.Config{Ignore: ignore, Hold: hold, RunTypes: runTypes, MinimumReleaseAge: 3}, internal.ProjectTemplates{}, drushSvc, composerSvc, drupalOrgSvc, gitSvc)
 	require.NoError(t, err)
 
-	assert.Same(t, logger, got.logger)
+	// Each addon logs under its registry name, so a line can be traced to the addon that wrote it.
+	got.logger.Info("from probe")
+	second.logger.Info("from probe_other")
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, map[string]any{"addon": "probe"}, logs.All()[0].ContextMap())
+	assert.Equal(t, map[string]any{"addon": "probe_other"}, logs.All()[1].ContextMap())
+	got.logger, second.logger = nil, nil
+
 	assert.Equal(t, drushSvc, got.drush)
 	assert.Equal(t, composerSvc, got.composer)
 	assert.Equal(t, drupalOrgSvc, got.drupalOrg)
 	assert.Equal(t, gitSvc, got.git)
+	assert.Equal(t, ignore, got.ignore)
+	assert.Equal(t, hold, got.hold)
+	assert.Equal(t, internal.UpdateLevelMinor, got.updateLevel)
+	assert.Equal(t, 72*time.Hour, got.releaseAge)
 	assert.Equal(t, got, second, "every addon receives the same dependency set")
 }
+
+func TestRunGroups(t *testing.T) {
+	groups := internal.UpdateGroups{
+		{Name: "core", Patterns: []string{"drupal/core*"}},
+		{Name: "other", Patterns: []string{"*"}},
+	}
+
+	// run stands in for one workflow: it records the group and reports through the sink the way
+	// StartUpdate does.
+	run := func(ran *[]string, errs map[string]error) func(internal.Config, func(report.Report)) error {
+		return func(cfg internal.Config, sink func(report.Report)) error {
+			*ran = append(*ran, cfg.Group)
+			sink(report.Report{Group: cfg.Group, Status: report.StatusSuccess})
+			return errs[cfg.Group]
+		}
+	}
+
+	t.Run("an ungrouped run reports straight to the sink", func(t *testing.T) {
+		var ran []string
+		var reports []report.Report
+		err := runGroups(t.Context(), zap.NewNop(), internal.Config{}, func(r report.Report) { reports = append(reports, r) }, run(&ran, nil))
+		require.NoError(t, err)
+		assert.Equal(t, []string{""}, ran)
+		require.Len(t, reports, 1)
+		assert.Empty(t, reports[0].Groups)
+	})
+
+	t.Run("every group runs even after one fails", func(t *testing.T) {
+		var ran []string
+		var reports []report.Report
+		errs := map[string]error{"core": assert.AnError, "other": services.AbortError{Msg: "no changes detected"}}
+		err := runGroups(t.Context(), zap.NewNop(), internal.Config{Groups: groups}, func(r report.Report) { reports = append(reports, r) }, run(&ran, errs))
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, []string{"core", "other"}, ran)
+		require.Len(t, reports, 1, "the groups share one report")
+		require.Len(t, reports[0].Groups, 2)
+		assert.Equal(t, "other", reports[0].Groups[1].Group)
+	})
+}
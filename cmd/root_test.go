@@ -2,12 +2,17 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/addon"
 	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/drupdater/drupdater/pkg/repo"
@@ -54,6 +59,44 @@ func TestNewLogger(t *testing.T) {
 	})
 }
 
+func TestNewLoggerFormats(t *testing.T) {
+	t.Run("json to the log file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "drupdater.log")
+		redactor := logging.NewRedactor()
+		redactor.Register("s3cret")
+
+		logger, err := NewLogger(internal.Config{LogFormat: "json", LogFile: path}, redactor)
+		require.NoError(t, err)
+		logger.Info("cloning repository", zap.String("url", "https://s3cret@example.com/repo.git"))
+		logger.Debug("dropped below info")
+		// Sync of stderr fails under go test; the file is written unbuffered anyway.
+		_ = logger.Sync()
+
+		written, err := os.ReadFile(path) //nolint:gosec // test-controlled path
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(written)), "\n")
+		require.Len(t, lines, 1)
+
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+		assert.Equal(t, "info", line["level"])
+		assert.Equal(t, "cloning repository", line["msg"])
+		assert.Equal(t, "https://***@example.com/repo.git", line["url"], "the file is redacted like stderr")
+		assert.Len(t, line["run_id"], 16)
+		assert.Contains(t, line, "ts")
+	})
+
+	t.Run("console is the default", func(t *testing.T) {
+		_, err := NewLogger(internal.Config{LogFormat: "console"}, logging.NewRedactor())
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := NewLogger(internal.Config{LogFormat: "logfmt"}, logging.NewRedactor())
+		require.ErrorContains(t, err, `unknown log format "logfmt"`)
+	})
+}
+
 func TestPersistentFlagDefaults(t *testing.T) {
 	// Safety-critical: --security defaulting to true makes every run security-only, --clone
 	// makes drupdater clone instead of updating the checkout it was pointed at.
@@ -67,6 +110,7 @@ func TestPersistentFlagDefaults(t *testing.T) {
 		{flag: "repository-url", want: ""},
 		{flag: "security", want: "false"},
 		{flag: "dry-run", want: "false"},
+		{flag: "log-format", want: "console"},
 		{flag: "verbose", want: "false"},
 		{flag: "config", want: ""},
 		{flag: "report", want: ""},
@@ -143,14 +187,14 @@ func TestCreateDispatcher(t *testing.T) {
 
 	t.Run("returns a non-nil dispatcher with addons subscribed", func(t *testing.T) {
 		config := internal.Config{RunTypes: internal.RunTypesConfig{Normal: internal.RunTypeConfig{Addons: []string{"composer_normalizer"}}}}
-		addons, err := createAddons(logger, config, nil, nil, nil, nil)
+		addons, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
 		require.NoError(t, err)
-		dispatcher := createDispatcher(addons)
+		dispatcher := createDispatcher(t.Context(), addons)
 		assert.NotNil(t, dispatcher)
 	})
 
 	t.Run("works with an empty addon list", func(t *testing.T) {
-		dispatcher := createDispatcher(nil)
+		dispatcher := createDispatcher(t.Context(), nil)
 		assert.NotNil(t, dispatcher)
 	})
 }
@@ -162,7 +206,7 @@ func TestCreateAddons(t *testing.T) {
 		config := internal.Config{
 			RunTypes: internal.RunTypesConfig{Normal: internal.RunTypeConfig{Addons: []string{"code_beautifier"}}},
 		}
-		addons, err := createAddons(logger, config, nil, nil, nil, nil)
+		addons, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
 		require.NoError(t, err)
 		assert.Len(t, addons, len(mandatoryAddons)+1)
 	})
@@ -170,7 +214,7 @@ func TestCreateAddons(t *testing.T) {
 	t.Run("composer_audit and unsupported_modules run on a normal update", func(t *testing.T) {
 		// Both are mandatory: they render one list, so either alone publishes half of it.
 		config := internal.Config{RunTypes: internal.RunTypesConfig{Normal: internal.RunTypeConfig{}}}
-		addons, err := createAddons(logger, config, nil, nil, nil, nil)
+		addons, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
 		require.NoError(t, err)
 		assert.Len(t, addons, len(mandatoryAddons))
 		assert.Contains(t, mandatoryAddons, "composer_audit")
@@ -181,34 +225,42 @@ func TestCreateAddons(t *testing.T) {
 	// the one difference visible here: only a security run relabels the merge request.
 	t.Run("security mode lets composer_audit relabel the merge request", func(t *testing.T) {
 		config := internal.Config{Security: true, RunTypes: internal.RunTypesConfig{Security: internal.RunTypeConfig{}}}
-		addons, err := createAddons(logger, config, nil, nil, nil, nil)
+		addons, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
 		require.NoError(t, err)
 
 		evt := services.NewPreMergeRequestCreateEvent("July 2026: Drupal Maintenance Updates")
-		require.NoError(t, createDispatcher(addons).FireEvent(evt))
+		require.NoError(t, createDispatcher(t.Context(), addons).FireEvent(evt))
 		assert.Contains(t, evt.Title, "Drupal Security Updates")
 	})
 
 	t.Run("a normal run keeps the maintenance title", func(t *testing.T) {
 		config := internal.Config{RunTypes: internal.RunTypesConfig{Normal: internal.RunTypeConfig{}}}
-		addons, err := createAddons(logger, config, nil, nil, nil, nil)
+		addons, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
 		require.NoError(t, err)
 
 		evt := services.NewPreMergeRequestCreateEvent("July 2026: Drupal Maintenance Updates")
-		require.NoError(t, createDispatcher(addons).FireEvent(evt))
+		require.NoError(t, createDispatcher(t.Context(), addons).FireEvent(evt))
 		assert.Equal(t, "July 2026: Drupal Maintenance Updates", evt.Title)
 	})
 
 	t.Run("a mandatory addon listed in the YAML is not duplicated", func(t *testing.T) {
 		config := internal.Config{RunTypes: internal.RunTypesConfig{Normal: internal.RunTypeConfig{Addons: []string{"update_hooks"}}}}
-		addons, err := createAddons(logger, config, nil, nil, nil, nil)
+		addons, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
 		require.NoError(t, err)
 		assert.Len(t, addons, len(mandatoryAddons)) // update_hooks is already mandatory
 	})
 
+	t.Run("--sbom-dir adds the sbom addon", func(t *testing.T) {
+		config := internal.Config{SBOMDir: t.TempDir(), Group: "core"}
+		addons, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, addons, len(mandatoryAddons)+1)
+		assert.IsType(t, &addon.SBOM{}, addons[len(addons)-1])
+	})
+
 	t.Run("an unknown addon name is an error", func(t *testing.T) {
 		config := internal.Config{RunTypes: internal.RunTypesConfig{Normal: internal.RunTypeConfig{Addons: []string{"does_not_exist"}}}}
-		_, err := createAddons(logger, config, nil, nil, nil, nil)
+		_, err := createAddons(logger, config, internal.ProjectTemplates{}, nil, nil, nil, nil)
 		require.Error(t, err)
 	})
 }
@@ -234,6 +286,30 @@ func TestValidateAddons(t *testing.T) {
 		config := internal.Config{RunTypes: internal.RunTypesConfig{Security: internal.RunTypeConfig{Addons: []string{"typo"}}}}
 		require.Error(t, validateAddons(config))
 	})
+
+	t.Run("unknown name in the major list is caught regardless of mode", func(t *testing.T) {
+		config := internal.Config{RunTypes: internal.RunTypesConfig{Major: internal.RunTypeConfig{Addons: []string{"typo"}}}}
+		require.Error(t, validateAddons(config))
+	})
+
+	t.Run("a template for an unknown addon is an error", func(t *testing.T) {
+		config := internal.Config{Templates: internal.MergeRequestTemplates{Addons: map[string]string{"composer_dif": "diff.md.tmpl"}}}
+		require.ErrorContains(t, validateAddons(config), `unknown addon "composer_dif" in templates.addons`)
+	})
+
+	t.Run("a template for an addon without a section is an error", func(t *testing.T) {
+		config := internal.Config{Templates: internal.MergeRequestTemplates{Addons: map[string]string{"composer_normalizer": "normalizer.md.tmpl"}}}
+		require.ErrorContains(t, validateAddons(config), "templates.addons.composer_normalizer: the addon renders no merge request section")
+	})
+}
+
+// validateAddons accepts a template for every section addon, so each must be able to take it.
+func TestSectionAddonsTakeATemplate(t *testing.T) {
+	for _, name := range sectionAddons {
+		factory, ok := addonRegistry[name]
+		require.True(t, ok, name)
+		assert.Implements(t, (*templatedAddon)(nil), factory(addonDeps{logger: zap.NewNop()}), name)
+	}
 }
 
 func TestConfigurableAddons(t *testing.T) {
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/pkg/sbom"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// sbomOutput is the --output of "sbom"; empty prints to stdout.
+var sbomOutput string
+
+var sbomCmd = &cobra.Command{
+	Use:   "sbom",
+	Short: "Write a CycloneDX SBOM of the checkout",
+	Long: `Writes a CycloneDX 1.5 JSON software bill of materials of the checkout in --working-dir,
+built from its composer.json and composer.lock: one component per locked package, with its
+version, license, source and dependencies. Dev packages are included with scope optional.
+
+Output goes to stdout, or to --output. Nothing is installed and no token is needed.
+
+A run given --sbom-dir writes one of these before and one after the update.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+
+		return writeSBOM(afero.NewOsFs(), cmd.OutOrStdout(), config.WorkingDir, sbomOutput)
+	},
+}
+
+// writeSBOM generates the BOM of dir and writes it to output, or to out when output is empty.
+func writeSBOM(fs afero.Fs, out io.Writer, dir string, output string) error {
+	bom, err := sbom.Generate(fs, dir, internal.Version)
+	if err != nil {
+		return fmt.Errorf("failed to generate SBOM: %w", err)
+	}
+	if output != "" {
+		return sbom.Write(fs, output, bom)
+	}
+
+	encoded, err := sbom.Encode(bom)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(encoded)
+	return err
+}
+
+func init() {
+	sbomCmd.Flags().StringVarP(&sbomOutput, "output", "o", "", "Write the SBOM to this path instead of stdout.")
+
+	rootCmd.AddCommand(sbomCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/drupdater/drupdater/pkg/sbom"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeComposerProject(t *testing.T, fs afero.Fs, dir string) {
+	t.Helper()
+	require.NoError(t, afero.WriteFile(fs, dir+"/composer.json", []byte(`{"name":"acme/site"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, dir+"/composer.lock", []byte(`{"packages":[{"name":"drupal/core","version":"10.2.1"}]}`), 0o644))
+}
+
+func TestWriteSBOMPrintsToStdout(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeComposerProject(t, fs, "/site")
+
+	var out bytes.Buffer
+	require.NoError(t, writeSBOM(fs, &out, "/site", ""))
+
+	var bom sbom.BOM
+	require.NoError(t, json.Unmarshal(out.Bytes(), &bom))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	require.Len(t, bom.Components, 1)
+	assert.Equal(t, "pkg:composer/drupal/core@10.2.1", bom.Components[0].PURL)
+}
+
+func TestWriteSBOMWritesToOutput(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeComposerProject(t, fs, "/site")
+
+	var out bytes.Buffer
+	require.NoError(t, writeSBOM(fs, &out, "/site", "/out/sbom.cdx.json"))
+
+	assert.Empty(t, out.String())
+	exists, err := afero.Exists(fs, "/out/sbom.cdx.json")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestWriteSBOMFailsWithoutALockFile(t *testing.T) {
+	err := writeSBOM(afero.NewMemMapFs(), &bytes.Buffer{}, "/site", "")
+	require.ErrorContains(t, err, "failed to generate SBOM")
+}
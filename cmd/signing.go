@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/internal/signing"
+)
+
+// signingKeyEnv holds the signing key itself, for a CI secret variable; --signing-key names a
+// file instead. signingPassphraseEnv unlocks either, and has no flag: it would show in the
+// process list.
+const (
+	signingKeyEnv        = "DRUPDATER_SIGNING_KEY"
+	signingPassphraseEnv = "DRUPDATER_SIGNING_PASSPHRASE"
+)
+
+// signingSample is what check signs: shaped like the commit objects a run signs.
+const signingSample = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+	"author drupdater <drupdater@example.com> 0 +0000\n" +
+	"committer drupdater <drupdater@example.com> 0 +0000\n\n" +
+	"Update composer.json and composer.lock\n"
+
+// resolveSigner reads the key --signing-key names, else DRUPDATER_SIGNING_KEY. Neither means the
+// run's commits go unsigned, which is not an error.
+func resolveSigner(cfg internal.Config) (signing.Signer, error) {
+	var key []byte
+	source := signingKeyEnv
+	if cfg.SigningKey != "" {
+		var err error
+		key, err = os.ReadFile(cfg.SigningKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+		source = cfg.SigningKey
+	} else {
+		key = []byte(os.Getenv(signingKeyEnv))
+		if len(bytes.TrimSpace(key)) == 0 {
+			return nil, nil
+		}
+	}
+
+	signer, err := signing.Parse(key, []byte(os.Getenv(signingPassphraseEnv)))
+	if errors.Is(err, signing.ErrPassphraseRequired) {
+		return nil, fmt.Errorf("signing key from %s: %w: set %s", source, err, signingPassphraseEnv)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key from %s: %w", source, err)
+	}
+	return signer, nil
+}
+
+// checkSigning reports whether the configured key loads and verifies its own signature of a
+// commit, so a protected branch does not turn the run's push away. Nothing when no key is set.
+func checkSigning(cfg internal.Config) []services.CheckResult {
+	const name = "commit signing key valid"
+	signer, err := resolveSigner(cfg)
+	if err != nil {
+		return []services.CheckResult{services.CheckFailed(name, err.Error())}
+	}
+	if signer == nil {
+		return nil
+	}
+
+	signature, err := signer.Sign(bytes.NewReader([]byte(signingSample)))
+	if err == nil {
+		err = signer.Verify([]byte(signingSample), signature)
+	}
+	if err != nil {
+		return []services.CheckResult{services.CheckFailed(name, fmt.Sprintf("failed to sign a commit: %v", err))}
+	}
+	return []services.CheckResult{services.CheckOK(fmt.Sprintf("%s (%s %s)", name, signer.Format(), signer.Fingerprint()))}
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/repo"
+)
+
+// sshPassphraseEnv unlocks the key --ssh-key names. Like signingPassphraseEnv it has no flag: it
+// would show in the process list.
+const sshPassphraseEnv = "DRUPDATER_SSH_KEY_PASSPHRASE"
+
+// checkSSH reports whether an SSH repository URL can be authenticated: the key loads, or ssh-agent
+// answers, and known_hosts lists the host. Nothing for an HTTPS URL, which the token covers.
+func checkSSH(cfg internal.Config) []services.CheckResult {
+	if !repo.IsSSHURL(cfg.RepositoryURL) {
+		return nil
+	}
+	const name = "SSH key and known_hosts ready"
+	if _, err := repo.Auth(cfg.RepositoryURL, "", "", cfg.SSH); err != nil {
+		return []services.CheckResult{services.CheckFailed(name, err.Error())}
+	}
+	source := "ssh-agent"
+	if cfg.SSH.KeyFile != "" {
+		source = cfg.SSH.KeyFile
+	}
+	return []services.CheckResult{services.CheckOK(fmt.Sprintf("%s (%s)", name, source))}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSSH(t *testing.T) {
+	t.Run("nothing to check for an HTTPS remote", func(t *testing.T) {
+		assert.Empty(t, checkSSH(internal.Config{RepositoryURL: "https://github.com/acme/site.git"}))
+	})
+
+	t.Run("a key and a known host pass, naming the key", func(t *testing.T) {
+		dir := t.TempDir()
+		keyFile := filepath.Join(dir, "id_ed25519")
+		require.NoError(t, os.WriteFile(keyFile, []byte(testSigningKey(t, "")), 0o600))
+		knownHosts := filepath.Join(dir, "known_hosts")
+		hostKey := "github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl\n"
+		require.NoError(t, os.WriteFile(knownHosts, []byte(hostKey), 0o600))
+
+		results := checkSSH(internal.Config{
+			RepositoryURL: "git@github.com:acme/site.git",
+			SSH:           repo.SSHOptions{KeyFile: keyFile, KnownHosts: knownHosts},
+		})
+		require.Len(t, results, 1)
+		assert.True(t, results[0].OK, results[0].Detail)
+		assert.Equal(t, "SSH key and known_hosts ready ("+keyFile+")", results[0].Name)
+	})
+
+	t.Run("an unknown host fails", func(t *testing.T) {
+		knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+		require.NoError(t, os.WriteFile(knownHosts, nil, 0o600))
+
+		results := checkSSH(internal.Config{
+			RepositoryURL: "git@github.com:acme/site.git",
+			SSH:           repo.SSHOptions{KnownHosts: knownHosts},
+		})
+		require.Len(t, results, 1)
+		assert.False(t, results[0].OK)
+		assert.Contains(t, results[0].Detail, "github.com is not in known_hosts")
+	})
+}
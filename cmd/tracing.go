@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/pkg/tracing"
+	"github.com/gookit/event"
+	"go.uber.org/zap"
+)
+
+// traceFlushTimeout bounds the export at the end of a run, which may follow an interrupt.
+const traceFlushTimeout = 10 * time.Second
+
+// traceExporters returns where the run's trace goes: --trace-file, and the collector --otlp-endpoint
+// or the standard OTEL_EXPORTER_OTLP_* variables name. Header values are registered as secrets,
+// since they usually carry the collector's API key.
+func traceExporters(cfg internal.Config, redactor *logging.Redactor) ([]tracing.Exporter, error) {
+	var exporters []tracing.Exporter
+	if cfg.TraceFile != "" {
+		exporters = append(exporters, tracing.FileExporter{Path: cfg.TraceFile})
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if base := cfg.OTLPEndpoint; base != "" || endpoint == "" {
+		if base == "" {
+			base = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		}
+		endpoint = ""
+		if base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return exporters, nil
+	}
+	if _, err := url.ParseRequestURI(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %w", endpoint, err)
+	}
+
+	headers, err := otlpHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range headers {
+		redactor.Register(value)
+	}
+	return append(exporters, tracing.HTTPExporter{Endpoint: endpoint, Headers: headers}), nil
+}
+
+// otlpHeaders parses the comma-separated key=value lists of the OTEL_EXPORTER_OTLP_*HEADERS
+// variables, later lists overriding earlier ones. Values are URL-encoded, as the spec has them.
+func otlpHeaders(lists ...string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, list := range lists {
+		for pair := range strings.SplitSeq(list, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return nil, fmt.Errorf("invalid OTLP header %q: want key=value", strings.TrimSpace(key))
+			}
+			decoded, err := url.QueryUnescape(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid OTLP header %q: %w", strings.TrimSpace(key), err)
+			}
+			headers[strings.TrimSpace(key)] = decoded
+		}
+	}
+	return headers, nil
+}
+
+// startTracing puts a tracer on ctx when the run exports its trace anywhere. The returned flush
+// exports it, and is safe to call when tracing is off; an export failure is logged, never returned.
+func startTracing(ctx context.Context, logger *zap.Logger, redactor *logging.Redactor, cfg internal.Config) (context.Context, func(), error) {
+	exporters, err := traceExporters(cfg, redactor)
+	if err != nil {
+		logger.Error("invalid tracing configuration", zap.Error(err))
+		return ctx, func() {}, err
+	}
+	if len(exporters) == 0 {
+		return ctx, func() {}, nil
+	}
+
+	tracer := tracing.New(internal.Version, redactor.Redact)
+	logger.Info("tracing run", zap.String("trace_id", tracer.TraceID()))
+
+	flush := func() {
+		// Not ctx: an interrupted run is the one most worth a trace.
+		flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), traceFlushTimeout)
+		defer cancel()
+		if err := tracer.Flush(flushCtx, exporters...); err != nil {
+			logger.Warn("failed to export trace", zap.Error(err))
+			return
+		}
+		logger.Info("trace exported", zap.String("trace_id", tracer.TraceID()))
+	}
+	return tracing.WithTracer(ctx, tracer), flush, nil
+}
+
+// contextEvent is an addon event whose context a listener's span can replace.
+type contextEvent interface {
+	Context() context.Context
+	SetContext(ctx context.Context)
+}
+
+// tracedSubscriber subscribes an addon with every listener wrapped in a span named after the
+// addon and the event, so a trace shows which addon a slow or failing event spent its time in.
+type tracedSubscriber struct {
+	addon internal.Addon
+	// ctx parents the spans of events that carry no context of their own.
+	ctx context.Context
+}
+
+// SubscribedEvents implements event.Subscriber.
+func (s tracedSubscriber) SubscribedEvents() map[string]any {
+	addonType := reflect.TypeOf(s.addon)
+	if addonType.Kind() == reflect.Pointer {
+		addonType = addonType.Elem()
+	}
+	name := addonType.Name()
+	subscribed := s.addon.SubscribedEvents()
+
+	traced := make(map[string]any, len(subscribed))
+	for eventName, listener := range subscribed {
+		switch l := listener.(type) {
+		case event.ListenerItem:
+			l.Listener = s.trace(name, eventName, l.Listener)
+			traced[eventName] = l
+		case event.Listener:
+			traced[eventName] = s.trace(name, eventName, l)
+		default:
+			// Left for the manager to reject.
+			traced[eventName] = listener
+		}
+	}
+	return traced
+}
+
+func (s tracedSubscriber) trace(addonName string, eventName string, listener event.Listener) event.Listener {
+	return event.ListenerFunc(func(e event.Event) error {
+		ctx := s.ctx
+		evt, ok := e.(contextEvent)
+		if ok && evt.Context() != nil {
+			ctx = evt.Context()
+		}
+		if ctx == nil {
+			return listener.Handle(e)
+		}
+
+		spanCtx, span := tracing.Start(ctx, addonName+" "+eventName,
+			tracing.String("drupdater.addon", addonName),
+			tracing.String("drupdater.event", eventName),
+		)
+		if ok {
+			// The listener's subprocesses then nest under its span; the next listener gets the
+			// event's own context back.
+			original := evt.Context()
+			evt.SetContext(spanCtx)
+			defer evt.SetContext(original)
+		}
+		err := listener.Handle(e)
+		span.SetError(err)
+		span.End()
+		return err
+	})
+}
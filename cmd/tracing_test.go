@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/tracing"
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func clearOTLPEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestTraceExporters(t *testing.T) {
+	t.Run("nothing configured", func(t *testing.T) {
+		clearOTLPEnv(t)
+		exporters, err := traceExporters(internal.Config{}, logging.NewRedactor())
+		require.NoError(t, err)
+		assert.Empty(t, exporters)
+	})
+
+	t.Run("file and flag endpoint", func(t *testing.T) {
+		clearOTLPEnv(t)
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "https://ignored.example.com/v1/traces")
+		exporters, err := traceExporters(internal.Config{TraceFile: "trace.jsonl", OTLPEndpoint: "http://collector:4318/"}, logging.NewRedactor())
+		require.NoError(t, err)
+		assert.Equal(t, []tracing.Exporter{
+			tracing.FileExporter{Path: "trace.jsonl"},
+			tracing.HTTPExporter{Endpoint: "http://collector:4318/v1/traces", Headers: map[string]string{}},
+		}, exporters)
+	})
+
+	t.Run("traces endpoint is used as is", func(t *testing.T) {
+		clearOTLPEnv(t)
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://base:4318")
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "https://api.example.com/otlp/traces")
+		exporters, err := traceExporters(internal.Config{}, logging.NewRedactor())
+		require.NoError(t, err)
+		require.Len(t, exporters, 1)
+		assert.Equal(t, "https://api.example.com/otlp/traces", exporters[0].(tracing.HTTPExporter).Endpoint)
+	})
+
+	t.Run("header values become secrets", func(t *testing.T) {
+		clearOTLPEnv(t)
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=abc%3D%3D, x-team=web")
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS", "x-team=drupal")
+		redactor := logging.NewRedactor()
+
+		exporters, err := traceExporters(internal.Config{}, redactor)
+		require.NoError(t, err)
+		require.Len(t, exporters, 1)
+		assert.Equal(t, map[string]string{"x-api-key": "abc==", "x-team": "drupal"}, exporters[0].(tracing.HTTPExporter).Headers)
+		assert.NotContains(t, redactor.Redact("key abc=="), "abc==")
+	})
+
+	t.Run("malformed header", func(t *testing.T) {
+		clearOTLPEnv(t)
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "no-value")
+		_, err := traceExporters(internal.Config{}, logging.NewRedactor())
+		require.ErrorContains(t, err, "want key=value")
+	})
+}
+
+func TestStartTracingWithoutExportersLeavesContextAlone(t *testing.T) {
+	clearOTLPEnv(t)
+	ctx, flush, err := startTracing(t.Context(), zap.NewNop(), logging.NewRedactor(), internal.Config{})
+	require.NoError(t, err)
+
+	_, span := tracing.Start(ctx, "run")
+	assert.Nil(t, span)
+	assert.NotPanics(t, flush)
+}
+
+type tracedAddon struct {
+	internal.BasicAddon
+	handled context.Context
+}
+
+func (a *tracedAddon) SubscribedEvents() map[string]any {
+	return map[string]any{
+		"pre-composer-update": event.ListenerItem{
+			Priority: event.Normal,
+			Listener: event.ListenerFunc(func(e event.Event) error {
+				a.handled = e.(*services.PreComposerUpdateEvent).Context()
+				return errors.New("audit failed")
+			}),
+		},
+	}
+}
+
+func (a *tracedAddon) RenderTemplate() (string, error) { return "", nil }
+
+func TestDispatcherSpansEachAddonListener(t *testing.T) {
+	tracer := tracing.New("dev", nil)
+	ctx := tracing.WithTracer(t.Context(), tracer)
+	addon := &tracedAddon{}
+
+	evt := services.NewPreComposerUpdateEvent(ctx, "/tmp", nil, nil, nil, false)
+	err := createDispatcher(t.Context(), []internal.Addon{addon}).FireEvent(evt)
+	require.ErrorContains(t, err, "audit failed")
+
+	// The listener ran under its own span, and the event has its context back.
+	assert.NotNil(t, tracing.FromContext(addon.handled))
+	assert.Equal(t, ctx, evt.Context())
+
+	payload, err := tracer.Encode()
+	require.NoError(t, err)
+	assert.Contains(t, string(payload), `"name":"tracedAddon pre-composer-update"`)
+	assert.Contains(t, string(payload), "audit failed")
+}
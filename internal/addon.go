@@ -14,6 +14,11 @@ import (
 // cellReplacer stops a literal "|" or newline in a value from breaking a markdown table.
 var cellReplacer = strings.NewReplacer("|", "\\|", "\n", " ", "\r", "")
 
+// MarkdownCell escapes s for a markdown table cell, as the templates' cell helper does.
+func MarkdownCell(s string) string {
+	return cellReplacer.Replace(s)
+}
+
 //go:embed addon/templates
 var templates embed.FS
 
@@ -25,13 +30,19 @@ type Addon interface {
 }
 
 type BasicAddon struct {
+	// template is the project's replacement for the embedded template; nil renders the embedded one.
+	template *template.Template
+}
+
+// UseTemplate replaces the embedded template the addon renders with a project's own, which gets
+// the same data.
+func (ba *BasicAddon) UseTemplate(tmpl *template.Template) {
+	ba.template = tmpl
 }
 
 // addonTemplates parses the embedded templates once: the FS is compiled in, so the result is fixed.
 var addonTemplates = sync.OnceValues(func() (*template.Template, error) {
-	return template.New("").Funcs(template.FuncMap{
-		"cell": cellReplacer.Replace,
-	}).ParseFS(templates, "addon/templates/*.go.tmpl")
+	return template.New("").Funcs(TemplateFuncs()).ParseFS(templates, "addon/templates/*.go.tmpl")
 })
 
 func (ba *BasicAddon) Render(name string, data any) (string, error) {
@@ -42,7 +53,11 @@ func (ba *BasicAddon) Render(name string, data any) (string, error) {
 
 	var output bytes.Buffer
 
-	err = tmpl.ExecuteTemplate(&output, name, data)
+	if ba.template != nil {
+		err = ba.template.Execute(&output, data)
+	} else {
+		err = tmpl.ExecuteTemplate(&output, name, data)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
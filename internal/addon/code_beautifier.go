@@ -11,6 +11,8 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 
 	"github.com/go-git/go-git/v5"
@@ -94,7 +96,8 @@ var hasPHPCSPathDefinitions = func(path string) (bool, error) {
 
 func (cb *CodeBeautifier) postCodeUpdateHandler(e event.Event) (err error) { //nolint:cyclop
 	event := e.(*services.PostCodeUpdateEvent)
-	cb.logger.Info("updating coding styles")
+	logger := logging.For(event.Context(), cb.logger)
+	logger.Info("updating coding styles")
 
 	if !fileExists(event.Path()) {
 		created, err := cb.CreatePHPCSConfig(event.Context(), event.Path(), event.Worktree())
@@ -102,7 +105,7 @@ func (cb *CodeBeautifier) postCodeUpdateHandler(e event.Event) (err error) { //n
 			return err
 		}
 		if !created {
-			cb.logger.Debug("no phpcs.xml created, skipping coding style update")
+			logger.Debug("no phpcs.xml created, skipping coding style update")
 			return nil
 		}
 	} else {
@@ -111,7 +114,7 @@ func (cb *CodeBeautifier) postCodeUpdateHandler(e event.Event) (err error) { //n
 			return err
 		}
 		if !hasPaths {
-			cb.logger.Warn("phpcs.xml found but no file path definitions, skipping coding style update")
+			logger.Warn("phpcs.xml found but no file path definitions, skipping coding style update")
 			return nil
 		}
 	}
@@ -135,16 +138,16 @@ func (cb *CodeBeautifier) postCodeUpdateHandler(e event.Event) (err error) { //n
 	}
 
 	if codingStyleUpdateResult.Totals.Fixable == 0 {
-		cb.logger.Debug("no coding style issues found")
+		logger.Debug("no coding style issues found")
 		return nil
 	}
 
 	err = cb.phpcs.RunCBF(event.Context(), event.Path())
 	if err != nil {
-		cb.logger.Debug("remaining issues", zap.Error(err))
+		logger.Debug("remaining issues", zap.Error(err))
 	}
 
-	cb.logger.Debug("adding files to commit", zap.Any("files", codingStyleUpdateResult.Files))
+	logger.Debug("adding files to commit", zap.Any("files", codingStyleUpdateResult.Files))
 
 	var addedFiles []string
 	for file := range codingStyleUpdateResult.Files {
@@ -166,11 +169,11 @@ func (cb *CodeBeautifier) postCodeUpdateHandler(e event.Event) (err error) { //n
 		return fmt.Errorf("failed to check worktree status: %w", err)
 	}
 	if !staged {
-		cb.logger.Debug("no coding style changes to commit")
+		logger.Debug("no coding style changes to commit")
 		return nil
 	}
 
-	if _, err := event.Worktree().Commit("Update coding styles", &git.CommitOptions{}); err != nil {
+	if err := internal.CommitterFrom(event.Context()).Commit(event.Worktree(), internal.CommitData{Kind: internal.CommitCodingStyles}); err != nil {
 		return err
 	}
 
@@ -211,7 +214,8 @@ var phpcsTemplateStr = `<?xml version="1.0" encoding="UTF-8"?>
 `
 
 func (cb *CodeBeautifier) CreatePHPCSConfig(ctx context.Context, path string, worktree Worktree) (bool, error) {
-	cb.logger.Debug("no phpcs.xml or phpcs.xml.dist file found, creating phpcs.xml")
+	logger := logging.For(ctx, cb.logger)
+	logger.Debug("no phpcs.xml or phpcs.xml.dist file found, creating phpcs.xml")
 
 	tmpl, err := template.New("ruleset").Parse(phpcsTemplateStr)
 	if err != nil {
@@ -235,7 +239,7 @@ func (cb *CodeBeautifier) CreatePHPCSConfig(ctx context.Context, path string, wo
 	}
 
 	if len(data.Files) == 0 {
-		cb.logger.Debug("no custom code directories found, skipping coding style update")
+		logger.Debug("no custom code directories found, skipping coding style update")
 		return false, nil
 	}
 
@@ -262,7 +266,7 @@ func (cb *CodeBeautifier) CreatePHPCSConfig(ctx context.Context, path string, wo
 		return false, fmt.Errorf("failed to add file to commit: %w", err)
 	}
 
-	if _, err = worktree.Commit("Add PHPCS config", &git.CommitOptions{}); err != nil {
+	if err := internal.CommitterFrom(ctx).Commit(worktree, internal.CommitData{Kind: internal.CommitPHPCSConfig}); err != nil {
 		return false, err
 	}
 
@@ -270,7 +274,7 @@ func (cb *CodeBeautifier) CreatePHPCSConfig(ctx context.Context, path string, wo
 }
 
 func (cb *CodeBeautifier) InstallCoder(ctx context.Context, path string, worktree Worktree) error {
-	cb.logger.Debug("drupal/coder is not installed, installing")
+	logging.For(ctx, cb.logger).Debug("drupal/coder is not installed, installing")
 	if _, err := cb.composer.Require(ctx, path, "--dev", "drupal/coder"); err != nil {
 		return err
 	}
@@ -278,7 +282,7 @@ func (cb *CodeBeautifier) InstallCoder(ctx context.Context, path string, worktre
 	if err := worktree.AddGlob("composer.*"); err != nil {
 		return fmt.Errorf("failed to add file to commit: %w", err)
 	}
-	if _, err := worktree.Commit("Install drupal/coder", &git.CommitOptions{}); err != nil {
+	if err := internal.CommitterFrom(ctx).Commit(worktree, internal.CommitData{Kind: internal.CommitToolInstall, Package: "drupal/coder"}); err != nil {
 		return err
 	}
 
@@ -288,7 +292,7 @@ func (cb *CodeBeautifier) InstallCoder(ctx context.Context, path string, worktre
 // removeCoder undoes InstallCoder. Removing it rarely restores composer.lock byte-for-byte, so the
 // remainder is committed here rather than swept into another listener's AddGlob("composer.*").
 func (cb *CodeBeautifier) removeCoder(ctx context.Context, path string, worktree Worktree) error {
-	cb.logger.Debug("removing drupal/coder")
+	logging.For(ctx, cb.logger).Debug("removing drupal/coder")
 	// --dev because InstallCoder required it there; without the flag composer refuses.
 	if _, err := cb.composer.Remove(ctx, path, "--dev", "drupal/coder"); err != nil {
 		return err
@@ -304,6 +308,5 @@ func (cb *CodeBeautifier) removeCoder(ctx context.Context, path string, worktree
 	if !staged {
 		return nil
 	}
-	_, err = worktree.Commit("Remove temporary drupal/coder installation", &git.CommitOptions{})
-	return err
+	return internal.CommitterFrom(ctx).Commit(worktree, internal.CommitData{Kind: internal.CommitToolRemoval, Package: "drupal/coder"})
 }
@@ -1,13 +1,18 @@
 package addon
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/gookit/event"
@@ -32,6 +37,9 @@ type ComposerAudit struct {
 
 	beforeAudit composer.Audit
 	afterAudit  composer.Audit
+	// lockLines is each package's line in the updated composer.lock, where the SARIF export
+	// points its findings.
+	lockLines map[string]int
 }
 
 // NewComposerAudit creates a security auditor. security marks a `--security` run.
@@ -119,6 +127,7 @@ func (ca *ComposerAudit) preComposerUpdateHandler(e event.Event) error {
 
 func (ca *ComposerAudit) postCodeUpdateHandler(e event.Event) error {
 	evt := e.(*services.PostCodeUpdateEvent)
+	logger := logging.For(evt.Context(), ca.logger)
 
 	var err error
 	ca.afterAudit, err = ca.composer.Audit(evt.Context(), evt.Path())
@@ -126,7 +135,14 @@ func (ca *ComposerAudit) postCodeUpdateHandler(e event.Event) error {
 		return fmt.Errorf("failed to run composer audit after update: %w", err)
 	}
 
-	ca.logger.Info("security advisories",
+	lockPath := filepath.Join(evt.Path(), "composer.lock")
+	ca.lockLines, err = composerLockLines(lockPath)
+	if err != nil {
+		// Only costs the findings their line number.
+		logger.Warn("failed to locate packages in composer.lock", zap.String("path", lockPath), zap.Error(err))
+	}
+
+	logger.Info("security advisories",
 		zap.Int("fixed", len(ca.GetFixedAdvisories())),
 		zap.Int("unresolved", len(ca.afterAudit.Advisories)),
 		zap.Int("abandoned", len(ca.GetAbandonedPackages())),
@@ -135,6 +151,32 @@ func (ca *ComposerAudit) postCodeUpdateHandler(e event.Event) error {
 	return nil
 }
 
+// composerLockLines maps each locked package to the line of its "name" key, which is the first
+// key of every package composer writes. Scanned rather than decoded: encoding/json keeps no
+// positions.
+func composerLockLines(path string) (map[string]int, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // the worktree's own lock file
+	if err != nil {
+		return nil, err
+	}
+
+	lines := map[string]int{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		field, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), `"name": "`)
+		if !ok {
+			continue
+		}
+		name, _, ok := strings.Cut(field, `"`)
+		// Authors have a "name" too, but never a vendor/package one, and come after the package's.
+		if _, seen := lines[name]; ok && strings.Contains(name, "/") && !seen {
+			lines[name] = n
+		}
+	}
+	return lines, scanner.Err()
+}
+
 // GetAbandonedPackages returns the post-update audit's abandoned packages, minus drupal/*:
 // unsupported_modules reports those from drupal.org's release data, and twice reads as two findings.
 func (ca *ComposerAudit) GetAbandonedPackages() []composer.AbandonedPackage {
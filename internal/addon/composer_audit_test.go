@@ -2,6 +2,8 @@ package addon
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -144,6 +146,37 @@ func TestComposerAudit_PostCodeUpdateHandler(t *testing.T) {
 	assert.Equal(t, mockAudit, audit.afterAudit)
 }
 
+func TestComposerAudit_PostCodeUpdateHandler_LocatesPackagesInTheLockFile(t *testing.T) {
+	dir := t.TempDir()
+	lock := `{
+    "packages": [
+        {
+            "name": "drupal/core",
+            "version": "10.2.1",
+            "authors": [
+                {
+                    "name": "Dries Buytaert"
+                }
+            ]
+        },
+        {
+            "name": "symfony/http-kernel",
+            "version": "v6.4.1"
+        }
+    ]
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "composer.lock"), []byte(lock), 0o600))
+
+	mockComposer := NewMockComposer(t)
+	mockComposer.EXPECT().Audit(context.Background(), dir).Return(composer.Audit{}, nil)
+	audit := NewComposerAudit(zap.NewNop(), mockComposer, false)
+
+	require.NoError(t, audit.postCodeUpdateHandler(services.NewPostCodeUpdateEvent(context.Background(), dir, NewMockWorktree(t))))
+
+	assert.Equal(t, map[string]int{"drupal/core": 4, "symfony/http-kernel": 13}, audit.lockLines)
+}
+
 func TestAdvisoryKey(t *testing.T) {
 	// CVE takes precedence; without it the advisory ID is used; without either, package+title.
 	assert.Equal(t, "cve:CVE-1", advisoryKey(composer.Advisory{CVE: "CVE-1", AdvisoryID: "A1"}))
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/gookit/event"
 	"go.uber.org/zap"
@@ -43,6 +44,7 @@ func (cd *ComposerDiff) RenderTemplate() (string, error) {
 
 func (cd *ComposerDiff) postComposerUpdateHandler(e event.Event) error {
 	evt := e.(*services.PostComposerUpdateEvent)
+	logger := logging.For(evt.Context(), cd.logger)
 
 	table, err := cd.composer.Diff(evt.Context(), evt.Path(), true)
 	if err != nil {
@@ -53,10 +55,10 @@ func (cd *ComposerDiff) postComposerUpdateHandler(e event.Event) error {
 	// The log gets the link-free table: markdown URLs are unreadable in a terminal.
 	plain, err := cd.composer.Diff(evt.Context(), evt.Path(), false)
 	if err != nil {
-		cd.logger.Warn("failed to render the dependency diff for the log", zap.Error(err))
+		logger.Warn("failed to render the dependency diff for the log", zap.Error(err))
 		return nil
 	}
-	cd.logger.Info("dependency diff\n" + plain)
+	logger.Info("dependency diff\n" + plain)
 
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/gookit/event"
 	"go.uber.org/zap"
@@ -44,7 +45,7 @@ func (cn *ComposerNormalizer) postComposerUpdateHandler(e event.Event) error {
 		return fmt.Errorf("failed to check if composer-normalize is installed: %w", err)
 	}
 	if !installed {
-		cn.logger.Warn("composer-normalize not installed, skipping")
+		logging.For(evt.Context(), cn.logger).Warn("composer-normalize not installed, skipping")
 		return nil
 	}
 
@@ -12,10 +12,10 @@ import (
 	"strings"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/drupdater/drupdater/pkg/drupalorg"
-	git "github.com/go-git/go-git/v5"
 	"github.com/gookit/event"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 	"go.uber.org/zap"
@@ -104,6 +104,7 @@ func (h *ComposerPatches1) RenderTemplate() (string, error) {
 func (h *ComposerPatches1) preComposerUpdateHandler(e event.Event) error {
 	event := e.(*services.PreComposerUpdateEvent)
 	ctx := event.Context()
+	logger := logging.For(ctx, h.logger)
 	path := event.Path()
 	worktree := event.Worktree()
 	packagesToUpdate := event.PackagesToUpdate
@@ -113,7 +114,7 @@ func (h *ComposerPatches1) preComposerUpdateHandler(e event.Event) error {
 	patches := make(map[string]map[string]string)
 	patchesString, err := h.composer.GetConfig(ctx, path, "extra.patches")
 	if err != nil {
-		h.logger.Debug("extra.patches not defined")
+		logger.Debug("extra.patches not defined")
 		patchesString = "{}"
 	}
 
@@ -130,7 +131,7 @@ func (h *ComposerPatches1) preComposerUpdateHandler(e event.Event) error {
 	h.patchUpdates = patchUpdates
 
 	if h.patchUpdates.Changes() {
-		h.logger.Info("patches changed",
+		logger.Info("patches changed",
 			zap.Int("removed", len(h.patchUpdates.Removed)),
 			zap.Int("updated", len(h.patchUpdates.Updated)),
 			zap.Int("conflicts", len(h.patchUpdates.Conflicts)),
@@ -153,7 +154,7 @@ func (h *ComposerPatches1) preComposerUpdateHandler(e event.Event) error {
 			return fmt.Errorf("failed to add composer.* files: %w", err)
 		}
 
-		if _, err := worktree.Commit("Update patches", &git.CommitOptions{}); err != nil {
+		if err := internal.CommitterFrom(ctx).Commit(worktree, internal.CommitData{Kind: internal.CommitPatches, Packages: operations}); err != nil {
 			return fmt.Errorf("failed to commit patches: %w", err)
 		}
 	}
@@ -167,7 +168,7 @@ func (h *ComposerPatches1) preComposerUpdateHandler(e event.Event) error {
 
 func (h *ComposerPatches1) updatePatches(ctx context.Context, path string, worktree Worktree, operations []composer.PackageChange, patches map[string]map[string]string) (PatchUpdates, map[string]map[string]string) {
 	updates := PatchUpdates{}
-	h.logger.Debug("processing composer patches", zap.Any("patches", patches))
+	logging.For(ctx, h.logger).Debug("processing composer patches", zap.Any("patches", patches))
 
 	updates.Removed = append(updates.Removed, h.removeUninstalledPackagePatches(ctx, path, worktree, patches)...)
 	updates.Removed = append(updates.Removed, h.removeDependencyProvidedPatches(ctx, path, patches)...)
@@ -245,9 +246,10 @@ func (h *ComposerPatches1) dropPatchFile(worktree Worktree, patchPath string) er
 // removeDependencyProvidedPatches drops root patches a dependency already applies, which
 // composer-patches would apply twice. Remote only: a local path is package-relative.
 func (h *ComposerPatches1) removeDependencyProvidedPatches(ctx context.Context, path string, patches map[string]map[string]string) []RemovedPatch {
+	logger := logging.For(ctx, h.logger)
 	depPatches, err := h.composer.GetDependencyPatches(ctx, path)
 	if err != nil {
-		h.logger.Error("failed to read dependency patches", zap.Error(err))
+		logger.Error("failed to read dependency patches", zap.Error(err))
 		return nil
 	}
 
@@ -264,7 +266,7 @@ func (h *ComposerPatches1) removeDependencyProvidedPatches(ctx context.Context,
 			if !depFiles[patchPath] {
 				continue
 			}
-			h.logger.Info("removing patch: already applied by a dependency", zap.String("package", packageName), zap.String("patch", patchPath))
+			logger.Info("removing patch: already applied by a dependency", zap.String("package", packageName), zap.String("patch", patchPath))
 			removed = append(removed, RemovedPatch{Package: packageName, PatchPath: patchPath, PatchDescription: description, Reason: fmt.Sprintf("Patch is already applied by a dependency of %s", packageName)})
 			delete(patches[packageName], description)
 		}
@@ -276,6 +278,7 @@ func (h *ComposerPatches1) removeDependencyProvidedPatches(ctx context.Context,
 }
 
 func (h *ComposerPatches1) removeUninstalledPackagePatches(ctx context.Context, path string, worktree Worktree, patches map[string]map[string]string) []RemovedPatch {
+	logger := logging.For(ctx, h.logger)
 	var removed []RemovedPatch
 	for packageName := range patches {
 		if installed, _ := h.composer.IsPackageInstalled(ctx, path, packageName); installed {
@@ -283,9 +286,9 @@ func (h *ComposerPatches1) removeUninstalledPackagePatches(ctx context.Context,
 		}
 		for description, patchPath := range patches[packageName] {
 			if err := h.dropPatchFile(worktree, patchPath); err != nil {
-				h.logger.Error("failed to remove patch", zap.String("patch", patchPath), zap.Error(err))
+				logger.Error("failed to remove patch", zap.String("patch", patchPath), zap.Error(err))
 			}
-			h.logger.Info("removing patch: package no longer installed", zap.String("package", packageName), zap.String("patch", patchPath))
+			logger.Info("removing patch: package no longer installed", zap.String("package", packageName), zap.String("patch", patchPath))
 			removed = append(removed, RemovedPatch{Package: packageName, PatchPath: patchPath, PatchDescription: description, Reason: fmt.Sprintf("%s is not installed in the project", packageName)})
 		}
 		delete(patches, packageName)
@@ -307,6 +310,7 @@ func (h *ComposerPatches1) removePackagePatches(worktree Worktree, op composer.P
 }
 
 func (h *ComposerPatches1) processSinglePatch(ctx context.Context, path string, worktree Worktree, op composer.PackageChange, description, patchPath string, patches map[string]map[string]string, updates *PatchUpdates) { //nolint:cyclop
+	logger := logging.For(ctx, h.logger)
 	issueNumber, issueNumberExists := h.drupalOrg.FindIssueNumber(description)
 	if !issueNumberExists {
 		issueNumber, issueNumberExists = h.drupalOrg.FindIssueNumber(patchPath)
@@ -317,10 +321,10 @@ func (h *ComposerPatches1) processSinglePatch(ctx context.Context, path string,
 		var err error
 		issue, err = h.drupalOrg.GetIssue(ctx, issueNumber)
 		if err != nil {
-			h.logger.Error("failed to get issue", zap.String("issue", issueNumber), zap.Error(err))
+			logger.Error("failed to get issue", zap.String("issue", issueNumber), zap.Error(err))
 			return
 		}
-		h.logger.Debug("fetched issue details", zap.Any("issue", issue))
+		logger.Debug("fetched issue details", zap.Any("issue", issue))
 
 		delete(patches[op.Package], description)
 
@@ -329,20 +333,20 @@ func (h *ComposerPatches1) processSinglePatch(ctx context.Context, path string,
 			commits, _, err := h.gitlab.Search.CommitsByProject("project/"+issue.Project.MaschineName, issue.ID,
 				&gitlab.SearchOptions{Ref: &op.To})
 			if err != nil {
-				h.logger.Error("failed to search commit history", zap.Error(err))
+				logger.Error("failed to search commit history", zap.Error(err))
 			} else if len(commits) != 0 {
-				h.logger.Debug("issue is fixed", zap.String("issue", issue.ID))
+				logger.Debug("issue is fixed", zap.String("issue", issue.ID))
 				if err := h.dropPatchFile(worktree, patchPath); err != nil {
 					// Restore the entry deleted above: a patch whose file survived must stay
 					// declared rather than vanish from composer.json unreported.
-					h.logger.Error("failed to remove patch", zap.String("patch", patchPath), zap.Error(err))
+					logger.Error("failed to remove patch", zap.String("patch", patchPath), zap.Error(err))
 					patches[op.Package][description] = patchPath
 					return
 				}
 				if len(patches[op.Package]) == 0 {
 					delete(patches, op.Package)
 				}
-				h.logger.Info("removing patch: issue fixed in new version", zap.String("package", op.Package), zap.String("patch", patchPath))
+				logger.Info("removing patch: issue fixed in new version", zap.String("package", op.Package), zap.String("patch", patchPath))
 				updates.Removed = append(updates.Removed, RemovedPatch{Package: op.Package, PatchPath: patchPath, Reason: fmt.Sprintf("Issue [#%s](%s) is fixed in %s %s", issue.ID, issue.URL, op.Package, op.To), PatchDescription: description})
 				return
 			}
@@ -356,36 +360,36 @@ func (h *ComposerPatches1) processSinglePatch(ctx context.Context, path string,
 	if err != nil {
 		// An unverifiable patch is not a stale one: pinning here would hold the package back
 		// on every run and blame a conflict that never happened.
-		h.logger.Warn("could not check whether the patch still applies, leaving the package unpinned",
+		logger.Warn("could not check whether the patch still applies, leaving the package unpinned",
 			zap.String("package", op.Package), zap.String("patch", patchPath), zap.Error(err))
 		return
 	}
 	if ok {
-		h.logger.Debug("patch applies", zap.String("package", op.Package), zap.String("version", op.To), zap.String("patch", patchPath))
+		logger.Debug("patch applies", zap.String("package", op.Package), zap.String("version", op.To), zap.String("patch", patchPath))
 		return
 	}
 
-	h.logger.Debug("patch does not apply", zap.String("package", op.Package), zap.String("version", op.To), zap.String("patch", patchPath))
+	logger.Debug("patch does not apply", zap.String("package", op.Package), zap.String("version", op.To), zap.String("patch", patchPath))
 
 	if !issueNumberExists {
-		h.logger.Info("patch does not apply, keeping current package version", zap.String("package", op.Package), zap.String("version", op.From), zap.String("patch", patchPath))
+		logger.Info("patch does not apply, keeping current package version", zap.String("package", op.Package), zap.String("version", op.From), zap.String("patch", patchPath))
 		updates.Conflicts = append(updates.Conflicts, conflict(op, patchPath, description))
 		return
 	}
 
 	// Finding a newer patch needs the drupalcode client, configured only with DRUPALCODE_ACCESS_TOKEN.
 	if h.gitlab == nil {
-		h.logger.Info("patch does not apply and no drupalcode client is configured, keeping current package version", zap.String("package", op.Package), zap.String("version", op.From), zap.String("patch", patchPath))
+		logger.Info("patch does not apply and no drupalcode client is configured, keeping current package version", zap.String("package", op.Package), zap.String("version", op.From), zap.String("patch", patchPath))
 		updates.Conflicts = append(updates.Conflicts, conflict(op, patchPath, description))
 		return
 	}
 
 	forkProject, _, err := h.gitlab.Projects.GetProject("issue/"+issue.Project.MaschineName+"-"+issue.ID, &gitlab.GetProjectOptions{})
 	if err != nil {
-		h.logger.Error("failed to get fork project", zap.Error(err))
+		logger.Error("failed to get fork project", zap.Error(err))
 		return
 	}
-	h.logger.Debug("fetched fork project", zap.Any("project", forkProject))
+	logger.Debug("fetched fork project", zap.Any("project", forkProject))
 
 	mergeRequests, err := h.fetchForkMergeRequests(issue.Project.MaschineName, forkProject.ID)
 	if err != nil {
@@ -393,44 +397,45 @@ func (h *ComposerPatches1) processSinglePatch(ctx context.Context, path string,
 	}
 
 	if len(mergeRequests) == 0 {
-		h.logger.Debug("no merge requests found")
+		logger.Debug("no merge requests found")
 		return
 	}
 
 	mr := mergeRequests[0]
 	newPatchDir := fmt.Sprintf("patches/%s", issue.Project.MaschineName)
 	newPatchFile := fmt.Sprintf("%s-%s-%s.diff", issue.ID, mr.SHA, h.cleanURLString(issue.Title))
-	h.logger.Debug("downloading patch", zap.String("url", mr.WebURL+".diff"), zap.String("path", newPatchDir))
+	logger.Debug("downloading patch", zap.String("url", mr.WebURL+".diff"), zap.String("path", newPatchDir))
 
 	if err := h.downloadFile(ctx, mr.WebURL+".diff", path+"/"+newPatchDir, newPatchFile); err != nil {
-		h.logger.Debug("failed to download patch", zap.Error(err))
+		logger.Debug("failed to download patch", zap.Error(err))
 		return
 	}
 
 	fullNewPath := newPatchDir + "/" + newPatchFile
 	if ok, err := h.composer.CheckIfPatchApplies(ctx, path, op.Package, op.To, path+"/"+fullNewPath); err != nil {
-		h.logger.Warn("could not check whether the merge request patch applies, leaving the package unpinned",
+		logger.Warn("could not check whether the merge request patch applies, leaving the package unpinned",
 			zap.String("package", op.Package), zap.String("patch", fullNewPath), zap.Error(err))
 		return
 	} else if ok {
 		if err := h.dropPatchFile(worktree, patchPath); err != nil {
-			h.logger.Debug("failed to remove old patch file", zap.String("patch", patchPath), zap.Error(err))
+			logger.Debug("failed to remove old patch file", zap.String("patch", patchPath), zap.Error(err))
 			return
 		}
 		patches[op.Package][description] = fullNewPath
 		if _, err := worktree.Add(fullNewPath); err != nil {
-			h.logger.Debug("failed to add patch", zap.Error(err))
+			logger.Debug("failed to add patch", zap.Error(err))
 			return
 		}
-		h.logger.Info("replacing patch", zap.String("package", op.Package), zap.String("previous_patch", patchPath), zap.String("new_patch", fullNewPath))
+		logger.Info("replacing patch", zap.String("package", op.Package), zap.String("previous_patch", patchPath), zap.String("new_patch", fullNewPath))
 		updates.Updated = append(updates.Updated, UpdatedPatch{Package: op.Package, PreviousPatchPath: patchPath, NewPatchPath: fullNewPath, PatchDescription: description})
 	} else {
-		h.logger.Info("merge request does not apply, keeping current package version", zap.String("package", op.Package), zap.String("version", op.To), zap.String("patch", path+"/"+newPatchDir))
+		logger.Info("merge request does not apply, keeping current package version", zap.String("package", op.Package), zap.String("version", op.To), zap.String("patch", path+"/"+newPatchDir))
 		updates.Conflicts = append(updates.Conflicts, conflict(op, patchPath, description))
 	}
 }
 
 func (h *ComposerPatches1) validateCombinedPatches(ctx context.Context, path string, op composer.PackageChange, patches map[string]map[string]string, updates *PatchUpdates) {
+	logger := logging.For(ctx, h.logger)
 	patchPaths := make([]string, 0, len(patches[op.Package]))
 	for _, patchPath := range patches[op.Package] {
 		patchPaths = append(patchPaths, resolvePatchPath(path, patchPath))
@@ -438,17 +443,17 @@ func (h *ComposerPatches1) validateCombinedPatches(ctx context.Context, path str
 
 	ok, err := h.composer.CheckIfPatchesApply(ctx, path, op.Package, op.To, patchPaths)
 	if err != nil {
-		h.logger.Warn("could not check whether the patches apply together, leaving the package unpinned",
+		logger.Warn("could not check whether the patches apply together, leaving the package unpinned",
 			zap.String("package", op.Package), zap.Error(err))
 		return
 	}
 	if !ok {
-		h.logger.Info("patches do not apply together, keeping current package version",
+		logger.Info("patches do not apply together, keeping current package version",
 			zap.String("package", op.Package), zap.String("version", op.To))
 		// No single patch to name: the package is held back because the set as a whole failed.
 		updates.Conflicts = append(updates.Conflicts, conflict(op, "", "Multiple patches do not apply together"))
 	} else {
-		h.logger.Debug("patches apply together", zap.String("package", op.Package), zap.String("version", op.To), zap.Any("patch", patchPaths))
+		logger.Debug("patches apply together", zap.String("package", op.Package), zap.String("version", op.To), zap.Any("patch", patchPaths))
 
 	}
 }
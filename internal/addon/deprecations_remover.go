@@ -2,13 +2,15 @@ package addon
 
 import (
 	"cmp"
+	"context"
 	"slices"
 
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/drupdater/drupdater/pkg/rector"
 	"github.com/gookit/event"
 
-	"github.com/go-git/go-git/v5"
 	"go.uber.org/zap"
 )
 
@@ -46,12 +48,13 @@ func (dr *DeprecationsRemover) RenderTemplate() (string, error) {
 
 func (dr *DeprecationsRemover) postCodeUpdateHandler(e event.Event) error {
 	evt := e.(*services.PostCodeUpdateEvent)
+	logger := logging.For(evt.Context(), dr.logger)
 
-	dr.logger.Info("removing deprecations")
+	logger.Info("removing deprecations")
 
 	installed, _ := dr.composer.IsPackageInstalled(evt.Context(), evt.Path(), "palantirnet/drupal-rector")
 	if !installed {
-		dr.logger.Debug("rector is not installed, installing")
+		logger.Debug("rector is not installed, installing")
 		if _, err := dr.composer.Require(evt.Context(), evt.Path(), "palantirnet/drupal-rector"); err != nil {
 			return err
 		}
@@ -68,19 +71,19 @@ func (dr *DeprecationsRemover) postCodeUpdateHandler(e event.Event) error {
 	}
 
 	if !installed {
-		dr.logger.Debug("removing rector")
+		logger.Debug("removing rector")
 		if _, err := dr.composer.Remove(evt.Context(), evt.Path(), "palantirnet/drupal-rector"); err != nil {
 			return err
 		}
 		// Removing rector rarely restores composer.lock byte-for-byte; commit the remainder
 		// here so no other listener's AddGlob("composer.*") sweeps it up.
-		if err := dr.commitTemporaryRectorCleanup(evt.Worktree()); err != nil {
+		if err := dr.commitTemporaryRectorCleanup(evt.Context(), evt.Worktree()); err != nil {
 			return err
 		}
 	}
 
 	if deprecationRemovalResult.Totals.ChangedFiles == 0 {
-		dr.logger.Debug("no deprecations to remove")
+		logger.Debug("no deprecations to remove")
 		return nil
 	}
 
@@ -92,14 +95,12 @@ func (dr *DeprecationsRemover) postCodeUpdateHandler(e event.Event) error {
 		}
 	}
 
-	dr.logger.Debug("committing deprecation removals")
-	_, err = evt.Worktree().Commit("Remove deprecations", &git.CommitOptions{})
-
-	return err
+	logger.Debug("committing deprecation removals")
+	return internal.CommitterFrom(evt.Context()).Commit(evt.Worktree(), internal.CommitData{Kind: internal.CommitDeprecations})
 }
 
 // commitTemporaryRectorCleanup commits whatever composer.* diff removing drupal-rector left behind.
-func (dr *DeprecationsRemover) commitTemporaryRectorCleanup(worktree Worktree) error {
+func (dr *DeprecationsRemover) commitTemporaryRectorCleanup(ctx context.Context, worktree Worktree) error {
 	if err := worktree.AddGlob("composer.*"); err != nil {
 		return err
 	}
@@ -110,8 +111,7 @@ func (dr *DeprecationsRemover) commitTemporaryRectorCleanup(worktree Worktree) e
 	if !staged {
 		return nil
 	}
-	_, err = worktree.Commit("Remove temporary drupal-rector installation", &git.CommitOptions{})
-	return err
+	return internal.CommitterFrom(ctx).Commit(worktree, internal.CommitData{Kind: internal.CommitToolRemoval, Package: "palantirnet/drupal-rector"})
 }
 
 // recordFixes captures which rules fired on which files, for the report.
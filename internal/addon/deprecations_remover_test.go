@@ -104,7 +104,7 @@ func TestRemoveDeprecations(t *testing.T) {
 		wt := NewMockWorktree(t)
 		wt.EXPECT().AddGlob("composer.*").Return(nil).Once()
 		wt.EXPECT().Status().Return(git.Status{"composer.lock": &git.FileStatus{Staging: git.Modified}}, nil).Once()
-		wt.EXPECT().Commit("Remove temporary drupal-rector installation", mock.Anything).Return(plumbing.NewHash(""), nil).Once()
+		wt.EXPECT().Commit("Remove temporary palantirnet/drupal-rector installation", mock.Anything).Return(plumbing.NewHash(""), nil).Once()
 
 		updateRemoveDeprecations := NewDeprecationsRemover(logger, runner, composer)
 		postCodeUpdate := services.NewPostCodeUpdateEvent(context.Background(), "/path/to/repo", wt)
@@ -3,6 +3,7 @@ package addon
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/drupdater/drupdater/pkg/drupalorg"
@@ -21,6 +22,9 @@ type Composer interface {
 	Diff(ctx context.Context, path string, withLinks bool) (string, error)
 
 	GetInstalledPackageVersion(ctx context.Context, dir string, packageName string) (string, error)
+	GetLockedPackages(dir string) ([]composer.LockedPackage, error)
+	Outdated(ctx context.Context, dir string) ([]composer.OutdatedPackage, error)
+	ReleaseDate(ctx context.Context, dir string, pkg string, version string) (time.Time, error)
 	GetAllowPlugins(ctx context.Context, dir string) (map[string]bool, error)
 	SetAllowPlugins(ctx context.Context, dir string, plugins map[string]bool) error
 	GetConfig(ctx context.Context, dir string, key string) (string, error)
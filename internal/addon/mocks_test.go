@@ -7,6 +7,7 @@ package addon
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/drupdater/drupdater/pkg/drupalorg"
@@ -768,6 +769,68 @@ func (_c *MockComposer_GetInstalledPlugins_Call) RunAndReturn(run func(ctx conte
 	return _c
 }
 
+// GetLockedPackages provides a mock function for the type MockComposer
+func (_mock *MockComposer) GetLockedPackages(dir string) ([]composer.LockedPackage, error) {
+	ret := _mock.Called(dir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLockedPackages")
+	}
+
+	var r0 []composer.LockedPackage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]composer.LockedPackage, error)); ok {
+		return returnFunc(dir)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []composer.LockedPackage); ok {
+		r0 = returnFunc(dir)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]composer.LockedPackage)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(dir)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockComposer_GetLockedPackages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLockedPackages'
+type MockComposer_GetLockedPackages_Call struct {
+	*mock.Call
+}
+
+// GetLockedPackages is a helper method to define mock.On call
+//   - dir string
+func (_e *MockComposer_Expecter) GetLockedPackages(dir any) *MockComposer_GetLockedPackages_Call {
+	return &MockComposer_GetLockedPackages_Call{Call: _e.mock.On("GetLockedPackages", dir)}
+}
+
+func (_c *MockComposer_GetLockedPackages_Call) Run(run func(dir string)) *MockComposer_GetLockedPackages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockComposer_GetLockedPackages_Call) Return(r0 []composer.LockedPackage, err error) *MockComposer_GetLockedPackages_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *MockComposer_GetLockedPackages_Call) RunAndReturn(run func(dir string) ([]composer.LockedPackage, error)) *MockComposer_GetLockedPackages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IsPackageInstalled provides a mock function for the type MockComposer
 func (_mock *MockComposer) IsPackageInstalled(ctx context.Context, dir string, packageToCheck string) (bool, error) {
 	ret := _mock.Called(ctx, dir, packageToCheck)
@@ -906,6 +969,152 @@ func (_c *MockComposer_Normalize_Call) RunAndReturn(run func(ctx context.Context
 	return _c
 }
 
+// Outdated provides a mock function for the type MockComposer
+func (_mock *MockComposer) Outdated(ctx context.Context, dir string) ([]composer.OutdatedPackage, error) {
+	ret := _mock.Called(ctx, dir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Outdated")
+	}
+
+	var r0 []composer.OutdatedPackage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]composer.OutdatedPackage, error)); ok {
+		return returnFunc(ctx, dir)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []composer.OutdatedPackage); ok {
+		r0 = returnFunc(ctx, dir)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]composer.OutdatedPackage)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, dir)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockComposer_Outdated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Outdated'
+type MockComposer_Outdated_Call struct {
+	*mock.Call
+}
+
+// Outdated is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dir string
+func (_e *MockComposer_Expecter) Outdated(ctx any, dir any) *MockComposer_Outdated_Call {
+	return &MockComposer_Outdated_Call{Call: _e.mock.On("Outdated", ctx, dir)}
+}
+
+func (_c *MockComposer_Outdated_Call) Run(run func(ctx context.Context, dir string)) *MockComposer_Outdated_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockComposer_Outdated_Call) Return(r0 []composer.OutdatedPackage, err error) *MockComposer_Outdated_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *MockComposer_Outdated_Call) RunAndReturn(run func(ctx context.Context, dir string) ([]composer.OutdatedPackage, error)) *MockComposer_Outdated_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseDate provides a mock function for the type MockComposer
+func (_mock *MockComposer) ReleaseDate(ctx context.Context, dir string, pkg string, version string) (time.Time, error) {
+	ret := _mock.Called(ctx, dir, pkg, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseDate")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (time.Time, error)); ok {
+		return returnFunc(ctx, dir, pkg, version)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) time.Time); ok {
+		r0 = returnFunc(ctx, dir, pkg, version)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, dir, pkg, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockComposer_ReleaseDate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseDate'
+type MockComposer_ReleaseDate_Call struct {
+	*mock.Call
+}
+
+// ReleaseDate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dir string
+//   - pkg string
+//   - version string
+func (_e *MockComposer_Expecter) ReleaseDate(ctx any, dir any, pkg any, version any) *MockComposer_ReleaseDate_Call {
+	return &MockComposer_ReleaseDate_Call{Call: _e.mock.On("ReleaseDate", ctx, dir, pkg, version)}
+}
+
+func (_c *MockComposer_ReleaseDate_Call) Run(run func(ctx context.Context, dir string, pkg string, version string)) *MockComposer_ReleaseDate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockComposer_ReleaseDate_Call) Return(r0 time.Time, err error) *MockComposer_ReleaseDate_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *MockComposer_ReleaseDate_Call) RunAndReturn(run func(ctx context.Context, dir string, pkg string, version string) (time.Time, error)) *MockComposer_ReleaseDate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Remove provides a mock function for the type MockComposer
 func (_mock *MockComposer) Remove(ctx context.Context, dir string, packages ...string) (string, error) {
 	var tmpRet mock.Arguments
@@ -0,0 +1,170 @@
+package addon
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/gookit/event"
+	"go.uber.org/zap"
+)
+
+// PackageRules applies .drupdater.yaml's ignore and hold rules through composer's --with: an
+// ignored package is pinned to its installed version, a held one to the rule's constraint.
+// Mandatory, so a pin cannot be lost by leaving an addon out of a run type.
+type PackageRules struct {
+	internal.BasicAddon
+	logger   *zap.Logger
+	composer Composer
+	ignore   []internal.PackageRule
+	hold     []internal.PackageRule
+	current  time.Time
+
+	// Written once from pre-composer-update, before anything reads them — no lock needed.
+	held    []HeldPackage
+	expired []ExpiredRule
+}
+
+// HeldPackage is one installed package a rule kept out of the update.
+type HeldPackage struct {
+	Package string `json:"package"`
+	// Rule is "ignore" or "hold".
+	Rule       string `json:"rule"`
+	Installed  string `json:"installed"`
+	Constraint string `json:"constraint,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Until      string `json:"until,omitempty"`
+}
+
+// ExpiredRule is a rule past its until date. It no longer applies, but stays in the file until
+// someone removes it, so each run says so.
+type ExpiredRule struct {
+	Rule string `json:"rule"`
+	// Package is the rule's pattern, not a package name.
+	Package string `json:"package"`
+	Until   string `json:"until"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// PackageRulesSummary is what the template renders.
+type PackageRulesSummary struct {
+	Held    []HeldPackage
+	Expired []ExpiredRule
+}
+
+// NewPackageRules creates the rule enforcer for the given ignore and hold rules.
+func NewPackageRules(logger *zap.Logger, composer Composer, ignore []internal.PackageRule, hold []internal.PackageRule) *PackageRules {
+	return &PackageRules{
+		logger:   logger,
+		composer: composer,
+		ignore:   ignore,
+		hold:     hold,
+		current:  time.Now(),
+	}
+}
+
+func (pr *PackageRules) SubscribedEvents() map[string]any {
+	return map[string]any{
+		// Min: the rules have the last word on what the update may touch, after composer_audit has
+		// chosen the packages a security run updates.
+		"pre-composer-update": event.ListenerItem{
+			Priority: event.Min,
+			Listener: event.ListenerFunc(pr.preComposerUpdateHandler),
+		},
+	}
+}
+
+// RenderTemplate lists the held packages and expired rules, or nothing when there are none.
+func (pr *PackageRules) RenderTemplate() (string, error) {
+	if len(pr.held) == 0 && len(pr.expired) == 0 {
+		return "", nil
+	}
+
+	return pr.Render("package_rules.go.tmpl", PackageRulesSummary{Held: pr.held, Expired: pr.expired})
+}
+
+// preComposerUpdateHandler pins every locked package a rule covers. An ignored package also
+// leaves the update's package list; emptying a list that was not empty aborts the run, since
+// composer reads an empty list as "update everything".
+func (pr *PackageRules) preComposerUpdateHandler(e event.Event) error {
+	evt := e.(*services.PreComposerUpdateEvent)
+
+	ignore := pr.activeRules("ignore", pr.ignore)
+	hold := pr.activeRules("hold", pr.hold)
+	if len(ignore) == 0 && len(hold) == 0 {
+		return nil
+	}
+
+	locked, err := pr.composer.GetLockedPackages(evt.Path())
+	if err != nil {
+		return fmt.Errorf("failed to read locked packages: %w", err)
+	}
+
+	for _, pkg := range locked {
+		// Through KeepPackage: composer_patches or the update policy may have kept the package
+		// already, and a second --with would replace theirs rather than add to it.
+		if rule, ok := firstMatchingRule(ignore, pkg.Name); ok {
+			evt.KeepPackage(pkg.Name, pkg.Version)
+			pr.held = append(pr.held, heldPackage("ignore", rule, pkg))
+			continue
+		}
+		if rule, ok := firstMatchingRule(hold, pkg.Name); ok {
+			evt.KeepPackage(pkg.Name, rule.Constraint)
+			pr.held = append(pr.held, heldPackage("hold", rule, pkg))
+		}
+	}
+
+	selected := len(evt.PackagesToUpdate) > 0
+	evt.PackagesToUpdate = slices.DeleteFunc(evt.PackagesToUpdate, func(name string) bool {
+		_, ignored := firstMatchingRule(ignore, name)
+		return ignored
+	})
+	if selected && len(evt.PackagesToUpdate) == 0 {
+		return services.AbortError{Msg: "every package selected for update is ignored"}
+	}
+
+	if len(pr.held) > 0 {
+		logging.For(evt.Context(), pr.logger).Info("packages held back", zap.Int("count", len(pr.held)))
+	}
+	return nil
+}
+
+// activeRules returns the rules still in force, recording the ones that have lapsed.
+func (pr *PackageRules) activeRules(kind string, rules []internal.PackageRule) []internal.PackageRule {
+	var active []internal.PackageRule
+	for _, rule := range rules {
+		if rule.Expired(pr.current) {
+			pr.logger.Warn("package rule expired, remove it from .drupdater.yaml",
+				zap.String("rule", kind), zap.String("package", rule.Package), zap.String("until", rule.Until))
+			pr.expired = append(pr.expired, ExpiredRule{Rule: kind, Package: rule.Package, Until: rule.Until, Reason: rule.Reason})
+			continue
+		}
+		active = append(active, rule)
+	}
+	return active
+}
+
+// firstMatchingRule returns the first rule covering name, in file order.
+func firstMatchingRule(rules []internal.PackageRule, name string) (internal.PackageRule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(name) {
+			return rule, true
+		}
+	}
+	return internal.PackageRule{}, false
+}
+
+func heldPackage(kind string, rule internal.PackageRule, pkg composer.LockedPackage) HeldPackage {
+	return HeldPackage{
+		Package:    pkg.Name,
+		Rule:       kind,
+		Installed:  pkg.Version,
+		Constraint: rule.Constraint,
+		Reason:     rule.Reason,
+		Until:      rule.Until,
+	}
+}
@@ -0,0 +1,153 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/golden"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var lockedForRules = []composer.LockedPackage{
+	{Name: "drupal/core", Version: "10.3.8"},
+	{Name: "drupal/search_api_solr", Version: "4.2.12"},
+	{Name: "drupal/legacy_theme", Version: "2.1.0"},
+}
+
+func newTestPackageRules(t *testing.T, ignore []internal.PackageRule, hold []internal.PackageRule) (*PackageRules, *MockComposer) {
+	t.Helper()
+	mockComposer := NewMockComposer(t)
+	pr := NewPackageRules(zap.NewNop(), mockComposer, ignore, hold)
+	pr.current = time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	return pr, mockComposer
+}
+
+func TestPackageRules_SubscribedEvents(t *testing.T) {
+	pr := &PackageRules{}
+
+	events := pr.SubscribedEvents()
+
+	// Strictly last: composer_audit picks a security run's packages at Max, and the rules have
+	// to see that choice to take ignored packages back out of it.
+	item, ok := events["pre-composer-update"].(event.ListenerItem)
+	require.True(t, ok)
+	assert.Equal(t, event.Min, item.Priority)
+}
+
+func TestPackageRules_PreComposerUpdateHandler(t *testing.T) {
+	ignore := []internal.PackageRule{{Package: "drupal/legacy_*", Reason: "Replaced in Q3"}}
+	hold := []internal.PackageRule{
+		{Package: "drupal/search_api_solr", Constraint: "4.2.*", Reason: "Solr 8 server", Until: "2026-12-31"},
+		// Also matched by the ignore rule, which wins.
+		{Package: "drupal/legacy_theme", Constraint: "^2"},
+	}
+
+	t.Run("pins ignored and held packages", func(t *testing.T) {
+		pr, mockComposer := newTestPackageRules(t, ignore, hold)
+		mockComposer.EXPECT().GetLockedPackages("/tmp").Return(lockedForRules, nil)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{"drupal/webform:6.2.7"}, false)
+
+		require.NoError(t, pr.preComposerUpdateHandler(evt))
+
+		assert.Equal(t, []string{"drupal/webform:6.2.7", "drupal/search_api_solr:4.2.*", "drupal/legacy_theme:2.1.0"}, evt.PackagesToKeep)
+		assert.Empty(t, evt.PackagesToUpdate)
+		assert.Equal(t, []HeldPackage{
+			{Package: "drupal/search_api_solr", Rule: "hold", Installed: "4.2.12", Constraint: "4.2.*", Reason: "Solr 8 server", Until: "2026-12-31"},
+			{Package: "drupal/legacy_theme", Rule: "ignore", Installed: "2.1.0", Reason: "Replaced in Q3"},
+		}, pr.held)
+	})
+
+	t.Run("a hold never loosens a patch pin, and narrows the update policy's cap", func(t *testing.T) {
+		// composer_patches pinned search_api_solr exactly; the update policy capped core. Composer
+		// keeps only the last --with per package, so a second one would replace theirs.
+		pr, mockComposer := newTestPackageRules(t, nil, []internal.PackageRule{
+			{Package: "drupal/search_api_solr", Constraint: "4.2.*"},
+			{Package: "drupal/core", Constraint: "<10.3.10"},
+		})
+		mockComposer.EXPECT().GetLockedPackages("/tmp").Return(lockedForRules, nil)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{"drupal/search_api_solr:4.2.12", "drupal/core:~10.3.8"}, false)
+
+		require.NoError(t, pr.preComposerUpdateHandler(evt))
+
+		assert.Equal(t, []string{"drupal/search_api_solr:4.2.12", "drupal/core:~10.3.8, <10.3.10"}, evt.PackagesToKeep)
+		assert.Len(t, pr.held, 2)
+	})
+
+	t.Run("takes ignored packages out of a selected update", func(t *testing.T) {
+		pr, mockComposer := newTestPackageRules(t, ignore, nil)
+		mockComposer.EXPECT().GetLockedPackages("/tmp").Return(lockedForRules, nil)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{"drupal/core", "drupal/legacy_theme"}, []string{}, true)
+
+		require.NoError(t, pr.preComposerUpdateHandler(evt))
+		assert.Equal(t, []string{"drupal/core"}, evt.PackagesToUpdate)
+	})
+
+	t.Run("aborts when every selected package is ignored", func(t *testing.T) {
+		// An empty list would reach composer as "update everything".
+		pr, mockComposer := newTestPackageRules(t, ignore, nil)
+		mockComposer.EXPECT().GetLockedPackages("/tmp").Return(lockedForRules, nil)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{"drupal/legacy_theme"}, []string{}, true)
+
+		err := pr.preComposerUpdateHandler(evt)
+		var abort services.AbortError
+		require.ErrorAs(t, err, &abort)
+	})
+
+	t.Run("an expired rule no longer applies", func(t *testing.T) {
+		expired := []internal.PackageRule{{Package: "drupal/legacy_*", Until: "2026-05-31", Reason: "Migration window"}}
+		pr, _ := newTestPackageRules(t, expired, nil)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+
+		require.NoError(t, pr.preComposerUpdateHandler(evt))
+		assert.Empty(t, evt.PackagesToKeep)
+		assert.Equal(t, []ExpiredRule{{Rule: "ignore", Package: "drupal/legacy_*", Until: "2026-05-31", Reason: "Migration window"}}, pr.expired)
+	})
+
+	t.Run("a lock that cannot be read is an error", func(t *testing.T) {
+		pr, mockComposer := newTestPackageRules(t, ignore, nil)
+		mockComposer.EXPECT().GetLockedPackages("/tmp").Return(nil, assert.AnError)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+
+		require.ErrorIs(t, pr.preComposerUpdateHandler(evt), assert.AnError)
+	})
+}
+
+func TestPackageRules_RenderTemplate(t *testing.T) {
+	pr := &PackageRules{
+		held: []HeldPackage{
+			{Package: "drupal/search_api_solr", Rule: "hold", Installed: "4.2.12", Constraint: "4.2.*", Reason: "Solr 8 server", Until: "2026-12-31"},
+			{Package: "drupal/legacy_theme", Rule: "ignore", Installed: "2.1.0"},
+		},
+		expired: []ExpiredRule{{Rule: "ignore", Package: "drupal/old_*", Until: "2026-01-31", Reason: "Migration window"}},
+	}
+
+	result, err := pr.RenderTemplate()
+
+	require.NoError(t, err)
+	golden.Assert(t, "testdata/package_rules.md", result)
+}
+
+func TestPackageRules_RenderTemplate_Empty(t *testing.T) {
+	pr := &PackageRules{}
+
+	result, err := pr.RenderTemplate()
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestPackageRules_RenderTemplate_ExpiredOnly(t *testing.T) {
+	pr := &PackageRules{expired: []ExpiredRule{{Rule: "hold", Package: "drupal/core*", Until: "2026-01-31"}}}
+
+	result, err := pr.RenderTemplate()
+
+	require.NoError(t, err)
+	assert.Equal(t, "## 📌 Held back packages\n\nThese rules have expired and no longer apply. Remove them from `.drupdater.yaml`:\n\n- hold `drupal/core*`, until 2026-01-31\n", result)
+}
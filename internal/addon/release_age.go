@@ -0,0 +1,135 @@
+package addon
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/gookit/event"
+	"go.uber.org/zap"
+)
+
+// ReleaseAge holds back releases younger than the project's minimum_release_age: a package the
+// update would move to one is pinned at its installed version until the release has aged.
+// Packages with a known advisory are exempt, since their update may be the fix. Mandatory, so
+// the cooldown holds whichever addons a run type lists.
+type ReleaseAge struct {
+	internal.BasicAddon
+	logger     *zap.Logger
+	composer   Composer
+	minimumAge time.Duration
+	current    time.Time
+
+	// Written once from pre-composer-update, before anything reads it — no lock needed.
+	deferred []DeferredUpdate
+}
+
+// DeferredUpdate is one update the cooldown held back.
+type DeferredUpdate struct {
+	Package   string `json:"package"`
+	Installed string `json:"installed"`
+	// Version is the release the update would have taken, Released when it came out.
+	Version  string    `json:"version"`
+	Released time.Time `json:"released"`
+}
+
+// ReleaseAgeSummary is what the template renders.
+type ReleaseAgeSummary struct {
+	Days     int
+	Deferred []DeferredUpdate
+}
+
+// NewReleaseAge creates the cooldown. A zero minimumAge adopts every release immediately.
+func NewReleaseAge(logger *zap.Logger, composer Composer, minimumAge time.Duration) *ReleaseAge {
+	return &ReleaseAge{
+		logger:     logger,
+		composer:   composer,
+		minimumAge: minimumAge,
+		current:    time.Now(),
+	}
+}
+
+func (ra *ReleaseAge) SubscribedEvents() map[string]any {
+	return map[string]any{
+		// Below Min, after package_rules: the dry run has to see every other pin to know what the
+		// update would really take. Its own pins are installed versions, never looser than a rule.
+		"pre-composer-update": event.ListenerItem{
+			Priority: event.Min - 1,
+			Listener: event.ListenerFunc(ra.preComposerUpdateHandler),
+		},
+	}
+}
+
+// RenderTemplate lists the deferred updates, or nothing when there are none.
+func (ra *ReleaseAge) RenderTemplate() (string, error) {
+	if len(ra.deferred) == 0 {
+		return "", nil
+	}
+
+	return ra.Render("release_age.go.tmpl", ReleaseAgeSummary{Days: int(ra.minimumAge.Hours() / 24), Deferred: ra.deferred})
+}
+
+// preComposerUpdateHandler dry-runs the update the event describes and pins every package it
+// would move to a release younger than the cooldown. The pins can still conflict with the rest of
+// the update — an exempt package whose fix needs the newer release, say — so a second dry run
+// checks them, and when it fails they are dropped and the young releases adopted, with a warning:
+// the cooldown is not worth a run that cannot update at all.
+func (ra *ReleaseAge) preComposerUpdateHandler(e event.Event) error {
+	if ra.minimumAge <= 0 {
+		return nil
+	}
+	evt := e.(*services.PreComposerUpdateEvent)
+
+	planned, err := ra.composer.Update(evt.Context(), evt.Path(), evt.PackagesToUpdate, evt.PackagesToKeep, evt.MinimalChanges, true)
+	if err != nil {
+		return fmt.Errorf("failed to plan the update: %w", err)
+	}
+
+	audit, err := ra.composer.Audit(evt.Context(), evt.Path())
+	if err != nil {
+		return fmt.Errorf("failed to audit installed packages: %w", err)
+	}
+
+	cutoff := ra.current.Add(-ra.minimumAge)
+	var deferred []DeferredUpdate
+	for _, change := range planned {
+		// A new dependency has no installed version to stay at.
+		if change.Action != "Upgrade" || hasAdvisory(audit, change.Package) {
+			continue
+		}
+		released, err := ra.composer.ReleaseDate(evt.Context(), evt.Path(), change.Package, change.To)
+		if err != nil {
+			return err
+		}
+		// A repository that publishes no date cannot be held to one.
+		if released.IsZero() || released.Before(cutoff) {
+			continue
+		}
+		deferred = append(deferred, DeferredUpdate{Package: change.Package, Installed: change.From, Version: change.To, Released: released})
+	}
+	if len(deferred) == 0 {
+		return nil
+	}
+
+	unpinned := slices.Clone(evt.PackagesToKeep)
+	for _, d := range deferred {
+		evt.KeepPackage(d.Package, d.Installed)
+	}
+	if _, err := ra.composer.Update(evt.Context(), evt.Path(), evt.PackagesToUpdate, evt.PackagesToKeep, evt.MinimalChanges, true); err != nil {
+		evt.PackagesToKeep = unpinned
+		logging.For(evt.Context(), ra.logger).Warn("minimum release age would leave the update unresolvable, adopting the young releases", zap.Duration("minimum_age", ra.minimumAge), zap.Error(err))
+		return nil
+	}
+
+	ra.deferred = deferred
+	logging.For(evt.Context(), ra.logger).Info("updates deferred by minimum release age", zap.Duration("minimum_age", ra.minimumAge), zap.Int("count", len(ra.deferred)))
+	return nil
+}
+
+func hasAdvisory(audit composer.Audit, pkg string) bool {
+	return slices.ContainsFunc(audit.Advisories, func(a composer.Advisory) bool { return a.PackageName == pkg })
+}
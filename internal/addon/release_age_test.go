@@ -0,0 +1,125 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/drupdater/drupdater/internal/golden"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var releaseAgeNow = time.Date(2026, time.June, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestReleaseAge(t *testing.T, days int) (*ReleaseAge, *MockComposer) {
+	t.Helper()
+	mockComposer := NewMockComposer(t)
+	ra := NewReleaseAge(zap.NewNop(), mockComposer, time.Duration(days)*24*time.Hour)
+	ra.current = releaseAgeNow
+	return ra, mockComposer
+}
+
+func TestReleaseAge_SubscribedEvents(t *testing.T) {
+	ra := &ReleaseAge{}
+
+	events := ra.SubscribedEvents()
+
+	// After package_rules, so the dry run sees its pins too.
+	item, ok := events["pre-composer-update"].(event.ListenerItem)
+	require.True(t, ok)
+	assert.Less(t, item.Priority, event.Min)
+}
+
+func TestReleaseAge_PreComposerUpdateHandler(t *testing.T) {
+	planned := []composer.PackageChange{
+		{Action: "Upgrade", Package: "drupal/pathauto", From: "1.13.0", To: "1.14.0"},
+		{Action: "Upgrade", Package: "drupal/token", From: "1.14.0", To: "1.15.0"},
+		{Action: "Upgrade", Package: "drupal/webform", From: "6.2.7", To: "6.2.9"},
+		{Action: "Install", Package: "drupal/new_dependency", To: "1.0.0"},
+	}
+
+	t.Run("pins packages whose planned release is too young", func(t *testing.T) {
+		ra, mockComposer := newTestReleaseAge(t, 7)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{"drupal/core:10.3.8"}, false)
+		mockComposer.EXPECT().Update(mock.Anything, "/tmp", []string{}, []string{"drupal/core:10.3.8"}, false, true).Return(planned, nil)
+		// drupal/webform's update may be the fix, so it is not held however young.
+		mockComposer.EXPECT().Audit(mock.Anything, "/tmp").Return(composer.Audit{Advisories: []composer.Advisory{{PackageName: "drupal/webform"}}}, nil)
+		mockComposer.EXPECT().ReleaseDate(mock.Anything, "/tmp", "drupal/pathauto", "1.14.0").Return(releaseAgeNow.Add(-2*24*time.Hour), nil)
+		mockComposer.EXPECT().ReleaseDate(mock.Anything, "/tmp", "drupal/token", "1.15.0").Return(releaseAgeNow.Add(-30*24*time.Hour), nil)
+		mockComposer.EXPECT().Update(mock.Anything, "/tmp", []string{}, []string{"drupal/core:10.3.8", "drupal/pathauto:1.13.0"}, false, true).Return(nil, nil)
+
+		require.NoError(t, ra.preComposerUpdateHandler(evt))
+
+		assert.Equal(t, []string{"drupal/core:10.3.8", "drupal/pathauto:1.13.0"}, evt.PackagesToKeep)
+		assert.Equal(t, []DeferredUpdate{
+			{Package: "drupal/pathauto", Installed: "1.13.0", Version: "1.14.0", Released: releaseAgeNow.Add(-2 * 24 * time.Hour)},
+		}, ra.deferred)
+	})
+
+	t.Run("pins that leave the update unresolvable are dropped", func(t *testing.T) {
+		ra, mockComposer := newTestReleaseAge(t, 7)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{"drupal/core:10.3.8"}, false)
+		mockComposer.EXPECT().Update(mock.Anything, "/tmp", []string{}, []string{"drupal/core:10.3.8"}, false, true).Return(planned[:1], nil)
+		mockComposer.EXPECT().Audit(mock.Anything, "/tmp").Return(composer.Audit{}, nil)
+		mockComposer.EXPECT().ReleaseDate(mock.Anything, "/tmp", "drupal/pathauto", "1.14.0").Return(releaseAgeNow.Add(-2*24*time.Hour), nil)
+		// Another package in the update requires pathauto 1.14.0.
+		mockComposer.EXPECT().Update(mock.Anything, "/tmp", []string{}, []string{"drupal/core:10.3.8", "drupal/pathauto:1.13.0"}, false, true).Return(nil, assert.AnError)
+
+		require.NoError(t, ra.preComposerUpdateHandler(evt))
+
+		assert.Equal(t, []string{"drupal/core:10.3.8"}, evt.PackagesToKeep)
+		assert.Empty(t, ra.deferred)
+	})
+
+	t.Run("a release without a date is adopted", func(t *testing.T) {
+		ra, mockComposer := newTestReleaseAge(t, 7)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+		mockComposer.EXPECT().Update(mock.Anything, "/tmp", []string{}, []string{}, false, true).Return(planned[:1], nil)
+		mockComposer.EXPECT().Audit(mock.Anything, "/tmp").Return(composer.Audit{}, nil)
+		mockComposer.EXPECT().ReleaseDate(mock.Anything, "/tmp", "drupal/pathauto", "1.14.0").Return(time.Time{}, nil)
+
+		require.NoError(t, ra.preComposerUpdateHandler(evt))
+		assert.Empty(t, evt.PackagesToKeep)
+	})
+
+	t.Run("no cooldown runs nothing", func(t *testing.T) {
+		ra, _ := newTestReleaseAge(t, 0)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+
+		require.NoError(t, ra.preComposerUpdateHandler(evt))
+	})
+
+	t.Run("a failed plan is an error", func(t *testing.T) {
+		ra, mockComposer := newTestReleaseAge(t, 7)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+		mockComposer.EXPECT().Update(mock.Anything, "/tmp", []string{}, []string{}, false, true).Return(nil, assert.AnError)
+
+		require.ErrorIs(t, ra.preComposerUpdateHandler(evt), assert.AnError)
+	})
+}
+
+func TestReleaseAge_RenderTemplate(t *testing.T) {
+	ra := &ReleaseAge{minimumAge: 7 * 24 * time.Hour, deferred: []DeferredUpdate{
+		{Package: "drupal/pathauto", Installed: "1.13.0", Version: "1.14.0", Released: time.Date(2026, time.June, 8, 9, 15, 0, 0, time.UTC)},
+	}}
+
+	result, err := ra.RenderTemplate()
+
+	require.NoError(t, err)
+	golden.Assert(t, "testdata/release_age.md", result)
+}
+
+func TestReleaseAge_RenderTemplate_Empty(t *testing.T) {
+	ra := &ReleaseAge{minimumAge: 7 * 24 * time.Hour}
+
+	result, err := ra.RenderTemplate()
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
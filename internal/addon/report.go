@@ -7,10 +7,11 @@ import (
 
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/drupdater/drupdater/pkg/drush"
+	"github.com/drupdater/drupdater/pkg/sbom"
 )
 
 // Every addon's contribution to the --report document, together in one file because these
-// methods are a published contract and a rename spread over eight files is easy to miss.
+// methods are a published contract and a rename spread over eleven files is easy to miss.
 //
 // Addons satisfy report.Reporter structurally, so none of them imports the report package. Keys
 // match .drupdater.yaml's addon names.
@@ -22,10 +23,14 @@ import (
 
 // SecurityAdvisories is the composer_audit section. Abandoned is not an advisory but the same
 // kind of finding, on the non-Drupal packages unsupported_modules cannot see.
+//
+// LockLines is the composer.lock line of each advisory's package, for the SARIF export; a package
+// the update removed has none.
 type SecurityAdvisories struct {
 	Fixed     []composer.Advisory         `json:"fixed"`
 	Remaining []composer.Advisory         `json:"remaining"`
 	Abandoned []composer.AbandonedPackage `json:"abandoned"`
+	LockLines map[string]int              `json:"lock_lines,omitempty"`
 }
 
 // ReportKey implements report.Reporter.
@@ -40,7 +45,19 @@ func (ca *ComposerAudit) ReportData() any {
 		return nil
 	}
 
-	return SecurityAdvisories{Fixed: fixed, Remaining: remaining, Abandoned: abandoned}
+	var lockLines map[string]int
+	for _, a := range slices.Concat(fixed, remaining) {
+		line, ok := ca.lockLines[a.PackageName]
+		if !ok {
+			continue
+		}
+		if lockLines == nil {
+			lockLines = map[string]int{}
+		}
+		lockLines[a.PackageName] = line
+	}
+
+	return SecurityAdvisories{Fixed: fixed, Remaining: remaining, Abandoned: abandoned, LockLines: lockLines}
 }
 
 // --- update_hooks ---
@@ -152,6 +169,52 @@ func (dr *DeprecationsRemover) ReportData() any {
 	return dr.fixes
 }
 
+// --- package_rules ---
+
+// PackageRulesReport is the package_rules section: what the rules kept out of the update, and
+// the rules that have lapsed and should be removed.
+type PackageRulesReport struct {
+	Held    []HeldPackage `json:"held"`
+	Expired []ExpiredRule `json:"expired"`
+}
+
+// ReportKey implements report.Reporter.
+func (pr *PackageRules) ReportKey() string { return "package_rules" }
+
+// ReportData implements report.Reporter. Held is in lock order, Expired in file order.
+func (pr *PackageRules) ReportData() any {
+	if len(pr.held) == 0 && len(pr.expired) == 0 {
+		return nil
+	}
+	return PackageRulesReport{Held: pr.held, Expired: pr.expired}
+}
+
+// --- update_policy ---
+
+// ReportKey implements report.Reporter.
+func (up *UpdatePolicy) ReportKey() string { return "update_policy" }
+
+// ReportData implements report.Reporter. In composer outdated's order, which is by name.
+func (up *UpdatePolicy) ReportData() any {
+	if len(up.blocked) == 0 {
+		return nil
+	}
+	return up.blocked
+}
+
+// --- release_age ---
+
+// ReportKey implements report.Reporter.
+func (ra *ReleaseAge) ReportKey() string { return "release_age" }
+
+// ReportData implements report.Reporter. In the order composer planned the update.
+func (ra *ReleaseAge) ReportData() any {
+	if len(ra.deferred) == 0 {
+		return nil
+	}
+	return ra.deferred
+}
+
 // --- translations_updater ---
 
 // TranslationResult is one site's outcome. Skipped records a deliberate bail-out, which omitting
@@ -177,3 +240,32 @@ func (tu *TranslationsUpdater) ReportData() any {
 	// Copy: the caller has no way to know this map is mutex-guarded state.
 	return maps.Clone(tu.results)
 }
+
+// --- sbom ---
+
+// SBOMSummary is the sbom section: the component counts of both BOMs, what changed between them,
+// and where they were written. A path is empty when its BOM could not be written.
+type SBOMSummary struct {
+	Before int `json:"before_components"`
+	After  int `json:"after_components"`
+	sbom.Changes
+	Files SBOMFiles `json:"files"`
+}
+
+// ReportKey implements report.Reporter.
+func (s *SBOM) ReportKey() string { return "sbom" }
+
+// ReportData implements report.Reporter. Nil unless both BOMs were generated: a diff against a
+// missing side would report every component as added or removed.
+func (s *SBOM) ReportData() any {
+	if s.before == nil || s.after == nil {
+		return nil
+	}
+
+	return SBOMSummary{
+		Before:  len(s.before.Components),
+		After:   len(s.after.Components),
+		Changes: sbom.Diff(*s.before, *s.after),
+		Files:   s.files,
+	}
+}
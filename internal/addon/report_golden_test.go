@@ -83,6 +83,19 @@ func reportingAddons() []internal.Addon {
 			File:           "web/modules/custom/acme/src/Plugin/Block/AcmeBlock.php",
 			AppliedRectors: []string{"Drupal\\Rector\\Rector\\Deprecation\\DrupalSetMessageRector"},
 		}}},
+		&PackageRules{
+			held: []HeldPackage{
+				{Package: "drupal/search_api_solr", Rule: "hold", Installed: "4.2.12", Constraint: "4.2.*", Reason: "Solr 8 server", Until: "2026-12-31"},
+				{Package: "drupal/legacy_theme", Rule: "ignore", Installed: "2.1.0", Reason: "Replaced in Q3"},
+			},
+			expired: []ExpiredRule{{Rule: "ignore", Package: "drupal/old_*", Until: "2026-01-31", Reason: "Migration window"}},
+		},
+		&UpdatePolicy{level: internal.UpdateLevelMinor, blocked: []BlockedUpdate{
+			{Package: "drupal/paragraphs", Installed: "1.17.0", Latest: "2.0.1", Allowed: ">=1.17.0 <2.0.0"},
+		}},
+		&ReleaseAge{minimumAge: 7 * 24 * time.Hour, deferred: []DeferredUpdate{
+			{Package: "drupal/pathauto", Installed: "1.13.0", Version: "1.14.0", Released: fixedTime.Add(-2 * 24 * time.Hour)},
+		}},
 		&TranslationsUpdater{results: map[string]TranslationResult{
 			"default": {Path: "translations", Updated: true},
 			"second":  {Skipped: "locale_deploy not enabled"},
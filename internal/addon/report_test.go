@@ -78,6 +78,20 @@ func TestComposerAuditReportDataNilWhenOnlyDrupalPackagesAreAbandoned(t *testing
 	assert.Nil(t, ca.ReportData())
 }
 
+func TestComposerAuditReportDataLocatesOnlyAdvisoryPackages(t *testing.T) {
+	ca := &ComposerAudit{
+		beforeAudit: composer.Audit{Advisories: []composer.Advisory{
+			{CVE: "CVE-1", PackageName: "drupal/core"},
+			{CVE: "CVE-2", PackageName: "drupal/removed"},
+		}},
+		lockLines: map[string]int{"drupal/core": 12, "drupal/token": 40},
+	}
+
+	data, ok := ca.ReportData().(SecurityAdvisories)
+	require.True(t, ok)
+	assert.Equal(t, map[string]int{"drupal/core": 12}, data.LockLines)
+}
+
 func TestComposerAuditReportDataNilWhenNothingToReport(t *testing.T) {
 	ca := &ComposerAudit{}
 	assert.Nil(t, ca.ReportData(), "an addon with nothing to say must not add an empty section")
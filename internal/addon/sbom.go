@@ -0,0 +1,101 @@
+package addon
+
+import (
+	"path/filepath"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/sbom"
+	"github.com/gookit/event"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// SBOM writes a CycloneDX bill of materials of the project before and after the update, and
+// reports what changed between the two. Enabled by --sbom-dir rather than .drupdater.yaml: where
+// the files go is a property of the pipeline, not the project.
+type SBOM struct {
+	internal.BasicAddon
+	logger  *zap.Logger
+	fs      afero.Fs
+	dir     string
+	version string
+
+	// Written from the two handlers, which the workflow fires in order — no lock needed.
+	before *sbom.BOM
+	after  *sbom.BOM
+	files  SBOMFiles
+}
+
+// SBOMFiles are the paths of the two BOMs a run wrote.
+type SBOMFiles struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// NewSBOM creates the generator. The BOMs go to dir, or to a directory per group in a split run,
+// whose groups would otherwise overwrite each other's. version is drupdater's, recorded as the
+// producing tool.
+func NewSBOM(logger *zap.Logger, dir string, group string, version string) *SBOM {
+	if group != "" {
+		dir = filepath.Join(dir, group)
+	}
+	return &SBOM{
+		logger:  logger,
+		fs:      afero.NewOsFs(),
+		dir:     dir,
+		version: version,
+	}
+}
+
+func (s *SBOM) SubscribedEvents() map[string]any {
+	return map[string]any{
+		// Max: nothing has touched composer.lock yet.
+		"pre-composer-update": event.ListenerItem{
+			Priority: event.Max,
+			Listener: event.ListenerFunc(s.preComposerUpdateHandler),
+		},
+		// Min: after every addon that rewrites composer.lock, so the BOM describes what is committed.
+		"post-code-update": event.ListenerItem{
+			Priority: event.Min,
+			Listener: event.ListenerFunc(s.postCodeUpdateHandler),
+		},
+	}
+}
+
+// RenderTemplate renders nothing: the merge request already lists the package changes, and the
+// BOMs are for the compliance archive.
+func (s *SBOM) RenderTemplate() (string, error) {
+	return "", nil
+}
+
+func (s *SBOM) preComposerUpdateHandler(e event.Event) error {
+	evt := e.(*services.PreComposerUpdateEvent)
+	s.before = s.generate(evt.Path(), "before.cdx.json", &s.files.Before)
+	return nil
+}
+
+func (s *SBOM) postCodeUpdateHandler(e event.Event) error {
+	evt := e.(*services.PostCodeUpdateEvent)
+	s.after = s.generate(evt.Path(), "after.cdx.json", &s.files.After)
+	return nil
+}
+
+// generate builds and writes one BOM, recording where in path. A failure is logged, never
+// returned: the BOM describes the update, it is not the update.
+func (s *SBOM) generate(dir string, name string, path *string) *sbom.BOM {
+	bom, err := sbom.Generate(s.fs, dir, s.version)
+	if err != nil {
+		s.logger.Warn("failed to generate SBOM", zap.String("file", name), zap.Error(err))
+		return nil
+	}
+
+	target := filepath.Join(s.dir, name)
+	if err := sbom.Write(s.fs, target, bom); err != nil {
+		s.logger.Warn("failed to write SBOM", zap.String("path", target), zap.Error(err))
+		return &bom
+	}
+	*path = target
+	s.logger.Info("SBOM written", zap.String("path", target), zap.Int("components", len(bom.Components)))
+	return &bom
+}
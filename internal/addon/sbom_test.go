@@ -0,0 +1,60 @@
+package addon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/sbom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeProject(t *testing.T, dir string, lock string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "composer.json"), []byte(`{"name":"acme/site","require":{"drupal/core":"^10"}}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "composer.lock"), []byte(lock), 0o600))
+}
+
+func TestSBOMWritesBothStatesAndReportsTheDiff(t *testing.T) {
+	project, out := t.TempDir(), t.TempDir()
+	ctx := context.Background()
+	s := NewSBOM(zap.NewNop(), out, "core", "v1.4.0")
+
+	writeProject(t, project, `{"packages":[{"name":"drupal/core","version":"10.2.1"},{"name":"drupal/token","version":"1.13.0"}]}`)
+	require.NoError(t, s.preComposerUpdateHandler(services.NewPreComposerUpdateEvent(ctx, project, NewMockWorktree(t), nil, nil, false)))
+
+	writeProject(t, project, `{"packages":[{"name":"drupal/core","version":"10.2.9"},{"name":"psr/log","version":"3.0.0"}]}`)
+	require.NoError(t, s.postCodeUpdateHandler(services.NewPostCodeUpdateEvent(ctx, project, NewMockWorktree(t))))
+
+	assert.Equal(t, "sbom", s.ReportKey())
+	data, ok := s.ReportData().(SBOMSummary)
+	require.True(t, ok)
+	assert.Equal(t, 2, data.Before)
+	assert.Equal(t, 2, data.After)
+	assert.Equal(t, []sbom.VersionChange{{Name: "drupal/core", From: "10.2.1", To: "10.2.9"}}, data.Updated)
+	assert.Equal(t, []sbom.Package{{Name: "psr/log", Version: "3.0.0"}}, data.Added)
+	assert.Equal(t, []sbom.Package{{Name: "drupal/token", Version: "1.13.0"}}, data.Removed)
+
+	// A split run writes each group's BOMs to its own directory.
+	assert.Equal(t, SBOMFiles{Before: filepath.Join(out, "core", "before.cdx.json"), After: filepath.Join(out, "core", "after.cdx.json")}, data.Files)
+	assert.FileExists(t, data.Files.Before)
+	assert.FileExists(t, data.Files.After)
+}
+
+// A project the BOM cannot be built for still updates; it just has no sbom section.
+func TestSBOMWithoutALockFileReportsNothing(t *testing.T) {
+	s := NewSBOM(zap.NewNop(), t.TempDir(), "", "dev")
+	project := t.TempDir()
+
+	require.NoError(t, s.preComposerUpdateHandler(services.NewPreComposerUpdateEvent(context.Background(), project, NewMockWorktree(t), nil, nil, false)))
+	require.NoError(t, s.postCodeUpdateHandler(services.NewPostCodeUpdateEvent(context.Background(), project, NewMockWorktree(t))))
+
+	assert.Nil(t, s.ReportData())
+	rendered, err := s.RenderTemplate()
+	require.NoError(t, err)
+	assert.Empty(t, rendered)
+}
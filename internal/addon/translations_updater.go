@@ -4,8 +4,9 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
-	"github.com/go-git/go-git/v5"
 	"github.com/gookit/event"
 
 	"go.uber.org/zap"
@@ -44,18 +45,19 @@ func (tu *TranslationsUpdater) RenderTemplate() (string, error) {
 
 func (tu *TranslationsUpdater) postSiteUpdateHandler(e event.Event) error {
 	evt := e.(*services.PostSiteUpdateEvent)
+	logger := logging.For(evt.Context(), tu.logger)
 
 	enabled, err := tu.drush.IsModuleEnabled(evt.Context(), evt.Path(), evt.Site(), "locale_deploy")
 	if err != nil {
 		return err
 	}
 	if !enabled {
-		tu.logger.Info("locale_deploy not enabled, skipping translations update", zap.String("site", evt.Site()))
+		logger.Info("locale_deploy not enabled, skipping translations update")
 		tu.record(evt.Site(), TranslationResult{Skipped: "locale_deploy not enabled"})
 		return nil
 	}
 
-	tu.logger.Info("updating translations")
+	logger.Info("updating translations")
 
 	if err := tu.drush.LocalizeTranslations(evt.Context(), evt.Path(), evt.Site()); err != nil {
 		return err
@@ -63,7 +65,7 @@ func (tu *TranslationsUpdater) postSiteUpdateHandler(e event.Event) error {
 
 	translationPath, err := tu.drush.GetTranslationPath(evt.Context(), evt.Path(), evt.Site(), true)
 	if err != nil {
-		tu.logger.Info("translation path not available, skipping translations update", zap.String("site", evt.Site()), zap.Error(err))
+		logger.Info("translation path not available, skipping translations update", zap.Error(err))
 		tu.record(evt.Site(), TranslationResult{Skipped: "translation path not available: " + err.Error()})
 		return nil
 	}
@@ -74,13 +76,13 @@ func (tu *TranslationsUpdater) postSiteUpdateHandler(e event.Event) error {
 	}
 
 	status, _ := evt.Worktree().Status()
-	tu.logger.Debug("git status", zap.Any("status", status))
+	logger.Debug("git status", zap.Any("status", status))
 	if !tu.repository.IsSomethingStagedInPath(evt.Worktree(), translationPath) {
-		tu.logger.Debug("nothing to commit")
+		logger.Debug("nothing to commit")
 		tu.record(evt.Site(), TranslationResult{Path: translationPath})
 		return nil
 	}
-	_, err = evt.Worktree().Commit("Update translations", &git.CommitOptions{})
+	err = internal.CommitterFrom(evt.Context()).Commit(evt.Worktree(), internal.CommitData{Kind: internal.CommitTranslations, Site: evt.Site()})
 	if err != nil {
 		return fmt.Errorf("failed to commit translation path: %w", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/drupdater/drupdater/internal"
 	"github.com/drupdater/drupdater/internal/services"
 
 	git "github.com/go-git/go-git/v5"
@@ -89,6 +90,32 @@ func TestUpdateTranslationsEventHandlerWithLocaleDeploy(t *testing.T) {
 	worktree.AssertExpectations(t)
 }
 
+func TestUpdateTranslationsEventHandlerCommitsWithTheProjectsMessage(t *testing.T) {
+	mockDrush := NewMockDrush(t)
+	mockRepository := NewMockRepository(t)
+	handler := NewTranslationsUpdater(zap.NewNop(), mockDrush, mockRepository)
+
+	committer, err := internal.NewCommitter(internal.CommitsConfig{
+		Messages: map[internal.CommitKind]string{internal.CommitTranslations: "chore(i18n): update {{ .Site }} translations"},
+	}, "normal", "")
+	require.NoError(t, err)
+	ctx := internal.WithCommitter(context.Background(), committer)
+
+	worktree := NewMockWorktree(t)
+	mockDrush.EXPECT().IsModuleEnabled(anyCtx, "/tmp", "example.com", "locale_deploy").Return(true, nil)
+	mockDrush.EXPECT().LocalizeTranslations(anyCtx, "/tmp", "example.com").Return(nil)
+	mockDrush.EXPECT().GetTranslationPath(anyCtx, "/tmp", "example.com", true).Return("translations", nil)
+	mockRepository.EXPECT().IsSomethingStagedInPath(worktree, "translations").Return(true)
+	worktree.EXPECT().Add("translations").Return(plumbing.NewHash(""), nil)
+	worktree.EXPECT().Commit("chore(i18n): update example.com translations", &git.CommitOptions{}).Return(plumbing.NewHash(""), nil)
+	worktree.EXPECT().Status().Return(git.Status{}, nil)
+
+	err = handler.postSiteUpdateHandler(services.NewPostSiteUpdateEvent(ctx, "/tmp", worktree, "example.com"))
+
+	require.NoError(t, err)
+	worktree.AssertExpectations(t)
+}
+
 func TestUpdateTranslationsEventHandlerSkipsWhenTranslationPathUnavailable(t *testing.T) {
 	// A soft skip, not a fatal error — and nothing staged: an empty path handed to
 	// Worktree.Add stages the entire working tree.
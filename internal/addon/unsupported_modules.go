@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/drupdater/drupdater/pkg/drush"
 	"github.com/gookit/event"
@@ -133,10 +134,11 @@ func (um *UnsupportedModules) preMergeRequestCreateHandler(e event.Event) error
 // preSiteUpdateHandler checks a site. Best-effort: an unreachable status service is not an error.
 func (um *UnsupportedModules) preSiteUpdateHandler(e event.Event) error {
 	evt := e.(*services.PreSiteUpdateEvent)
+	logger := logging.For(evt.Context(), um.logger)
 
 	modules, err := um.drush.GetUnsupportedModules(evt.Context(), evt.Path(), evt.Site())
 	if err != nil {
-		um.logger.Warn("failed to check for unsupported modules", zap.String("site", evt.Site()), zap.Error(err))
+		logger.Warn("failed to check for unsupported modules", zap.Error(err))
 		return nil
 	}
 	if len(modules) == 0 {
@@ -149,7 +151,7 @@ func (um *UnsupportedModules) preSiteUpdateHandler(e event.Event) error {
 	}
 	um.mu.Unlock()
 
-	um.logger.Info("unsupported modules found", zap.String("site", evt.Site()), zap.Int("count", len(modules)))
+	logger.Info("unsupported modules found", zap.Int("count", len(modules)))
 
 	return nil
 }
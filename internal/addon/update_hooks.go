@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/services"
 	"github.com/drupdater/drupdater/pkg/drush"
 	"github.com/gookit/event"
@@ -53,20 +54,21 @@ func (uh *UpdateHooks) RenderTemplate() (string, error) {
 
 func (uh *UpdateHooks) preSiteUpdateHandler(e event.Event) error {
 	evt := e.(*services.PreSiteUpdateEvent)
+	logger := logging.For(evt.Context(), uh.logger)
 
 	hooks, err := uh.drush.GetUpdateHooks(evt.Context(), evt.Path(), evt.Site())
-	uh.logger.Debug("update hooks", zap.Any("hooks", hooks))
+	logger.Debug("update hooks", zap.Any("hooks", hooks))
 	if err != nil {
 		return fmt.Errorf("failed to get update hooks: %w", err)
 	}
 	if len(hooks) == 0 {
-		uh.logger.Debug("no update hooks found")
+		logger.Debug("no update hooks found")
 		return nil
 	}
 	uh.mu.Lock()
 	uh.hooks[evt.Site()] = hooks
 	uh.mu.Unlock()
-	uh.logger.Info("update hooks found", zap.String("site", evt.Site()), zap.Int("count", len(hooks)))
+	logger.Info("update hooks found", zap.Int("count", len(hooks)))
 
 	return nil
 }
@@ -0,0 +1,149 @@
+package addon
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/gookit/event"
+	"go.uber.org/zap"
+)
+
+// UpdatePolicy caps every locked package at the run type's update_level, through composer's
+// --with: a patch level pins a package to ~X.Y.Z, a minor level to ~X.Y. Mandatory, so the cap
+// holds whichever addons a run type lists.
+type UpdatePolicy struct {
+	internal.BasicAddon
+	logger   *zap.Logger
+	composer Composer
+	level    internal.UpdateLevel
+
+	// Written once from pre-composer-update, before anything reads it — no lock needed.
+	blocked []BlockedUpdate
+}
+
+// BlockedUpdate is one package composer.json would have let move further than the policy did.
+type BlockedUpdate struct {
+	Package   string `json:"package"`
+	Installed string `json:"installed"`
+	Latest    string `json:"latest"`
+	// Allowed is the constraint the policy passed to composer.
+	Allowed string `json:"allowed"`
+}
+
+// UpdatePolicySummary is what the template renders.
+type UpdatePolicySummary struct {
+	Level   internal.UpdateLevel
+	Blocked []BlockedUpdate
+}
+
+// NewUpdatePolicy creates the policy for level. Major, the default, pins nothing.
+func NewUpdatePolicy(logger *zap.Logger, composer Composer, level internal.UpdateLevel) *UpdatePolicy {
+	return &UpdatePolicy{
+		logger:   logger,
+		composer: composer,
+		level:    level,
+	}
+}
+
+func (up *UpdatePolicy) SubscribedEvents() map[string]any {
+	return map[string]any{
+		// Low: after composer_audit has chosen a security run's packages, and before package_rules,
+		// whose holds narrow these pins.
+		"pre-composer-update": event.ListenerItem{
+			Priority: event.Low,
+			Listener: event.ListenerFunc(up.preComposerUpdateHandler),
+		},
+	}
+}
+
+// RenderTemplate lists the updates the policy held back, or nothing when there are none.
+func (up *UpdatePolicy) RenderTemplate() (string, error) {
+	if len(up.blocked) == 0 {
+		return "", nil
+	}
+
+	return up.Render("update_policy.go.tmpl", UpdatePolicySummary{Level: up.level, Blocked: up.blocked})
+}
+
+// preComposerUpdateHandler pins every locked package whose newest release is beyond the level.
+// Packages already within it need no pin: composer cannot reach past their newest release.
+func (up *UpdatePolicy) preComposerUpdateHandler(e event.Event) error {
+	if up.level == "" || up.level == internal.UpdateLevelMajor {
+		return nil
+	}
+	evt := e.(*services.PreComposerUpdateEvent)
+
+	outdated, err := up.composer.Outdated(evt.Context(), evt.Path())
+	if err != nil {
+		return fmt.Errorf("failed to list outdated packages: %w", err)
+	}
+
+	for _, pkg := range outdated {
+		installed, ok := parseVersion(pkg.Version)
+		if !ok {
+			// A branch alias like dev-main has no level to stay within.
+			continue
+		}
+		latest, ok := parseVersion(pkg.Latest)
+		if !ok || installed.within(latest, up.level) {
+			continue
+		}
+
+		allowed := installed.constraint(up.level)
+		// False for a package composer_patches pinned exactly: the pin holds it, not the policy.
+		if !evt.KeepPackage(pkg.Name, allowed) {
+			continue
+		}
+		// Only semver-safe-update says composer.json admits the newer release; anything else was
+		// out of reach before the policy had a say.
+		if pkg.LatestStatus == "semver-safe-update" {
+			up.blocked = append(up.blocked, BlockedUpdate{Package: pkg.Name, Installed: pkg.Version, Latest: pkg.Latest, Allowed: allowed})
+		}
+	}
+
+	if len(up.blocked) > 0 {
+		logging.For(evt.Context(), up.logger).Info("updates blocked by update level", zap.String("level", string(up.level)), zap.Int("count", len(up.blocked)))
+	}
+	return nil
+}
+
+// versionPattern reads the numeric head of a composer version. A pre-release suffix is ignored:
+// the level compares releases, not stabilities.
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+type semver struct {
+	major, minor, patch int
+}
+
+func parseVersion(v string) (semver, bool) {
+	m := versionPattern.FindStringSubmatch(v)
+	if m == nil {
+		return semver{}, false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return semver{major: major, minor: minor, patch: patch}, true
+}
+
+// within reports whether moving from v to other stays inside level.
+func (v semver) within(other semver, level internal.UpdateLevel) bool {
+	if level == internal.UpdateLevelPatch {
+		return v.major == other.major && v.minor == other.minor
+	}
+	return v.major == other.major
+}
+
+// constraint is the composer range level leaves v, starting at v so nothing downgrades.
+func (v semver) constraint(level internal.UpdateLevel) string {
+	if level == internal.UpdateLevelPatch {
+		return fmt.Sprintf("~%d.%d.%d", v.major, v.minor, v.patch)
+	}
+	// A range rather than ^X.Y.Z: a caret treats a 0.x minor as breaking, which is the patch
+	// level. And not ~X.Y, which starts at X.Y.0.
+	return fmt.Sprintf(">=%d.%d.%d <%d.0.0", v.major, v.minor, v.patch, v.major+1)
+}
@@ -0,0 +1,113 @@
+package addon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/golden"
+	"github.com/drupdater/drupdater/internal/services"
+	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var outdatedForPolicy = []composer.OutdatedPackage{
+	{Name: "drupal/core", Version: "10.3.8", Latest: "11.1.2", LatestStatus: "update-possible"},
+	{Name: "drupal/paragraphs", Version: "1.17.0", Latest: "1.18.2", LatestStatus: "semver-safe-update"},
+	{Name: "drupal/token", Version: "1.14.0", Latest: "1.14.1", LatestStatus: "semver-safe-update"},
+	{Name: "drupal/webform", Version: "6.2.7", Latest: "6.3.0-beta2", LatestStatus: "semver-safe-update"},
+	{Name: "drupal/acme", Version: "dev-main", Latest: "1.0.0", LatestStatus: "update-possible"},
+}
+
+func TestUpdatePolicy_SubscribedEvents(t *testing.T) {
+	up := &UpdatePolicy{}
+
+	events := up.SubscribedEvents()
+
+	// Before package_rules at Min, whose holds narrow these pins.
+	item, ok := events["pre-composer-update"].(event.ListenerItem)
+	require.True(t, ok)
+	assert.Equal(t, event.Low, item.Priority)
+}
+
+func TestUpdatePolicy_PreComposerUpdateHandler(t *testing.T) {
+	t.Run("patch pins every package past its minor", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().Outdated(mock.Anything, "/tmp").Return(outdatedForPolicy, nil)
+		up := NewUpdatePolicy(zap.NewNop(), mockComposer, internal.UpdateLevelPatch)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{"drupal/webform:6.2.7"}, false)
+
+		require.NoError(t, up.preComposerUpdateHandler(evt))
+
+		// One --with per package: composer keeps only the last, and the patch pin on webform is
+		// the stricter.
+		assert.Equal(t, []string{"drupal/webform:6.2.7", "drupal/core:~10.3.8", "drupal/paragraphs:~1.17.0"}, evt.PackagesToKeep)
+		// drupal/core's 11.x was never allowed by composer.json, so the policy blocked nothing
+		// there; webform is held by its patch pin, not the policy.
+		assert.Equal(t, []BlockedUpdate{
+			{Package: "drupal/paragraphs", Installed: "1.17.0", Latest: "1.18.2", Allowed: "~1.17.0"},
+		}, up.blocked)
+	})
+
+	t.Run("minor pins only packages past their major", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().Outdated(mock.Anything, "/tmp").Return(outdatedForPolicy, nil)
+		up := NewUpdatePolicy(zap.NewNop(), mockComposer, internal.UpdateLevelMinor)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+
+		require.NoError(t, up.preComposerUpdateHandler(evt))
+
+		assert.Equal(t, []string{"drupal/core:>=10.3.8 <11.0.0"}, evt.PackagesToKeep)
+		assert.Empty(t, up.blocked)
+	})
+
+	t.Run("major leaves composer.json in charge", func(t *testing.T) {
+		up := NewUpdatePolicy(zap.NewNop(), NewMockComposer(t), internal.UpdateLevelMajor)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+
+		require.NoError(t, up.preComposerUpdateHandler(evt))
+		assert.Empty(t, evt.PackagesToKeep)
+	})
+
+	t.Run("a failed listing is an error", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().Outdated(mock.Anything, "/tmp").Return(nil, assert.AnError)
+		up := NewUpdatePolicy(zap.NewNop(), mockComposer, internal.UpdateLevelPatch)
+		evt := services.NewPreComposerUpdateEvent(context.Background(), "/tmp", nil, []string{}, []string{}, false)
+
+		require.ErrorIs(t, up.preComposerUpdateHandler(evt), assert.AnError)
+	})
+}
+
+func TestUpdatePolicyConstraint(t *testing.T) {
+	v, ok := parseVersion("v0.4.2")
+	require.True(t, ok)
+
+	assert.Equal(t, "~0.4.2", v.constraint(internal.UpdateLevelPatch))
+	// Not ^0.4.2, which would stop at 0.5.0.
+	assert.Equal(t, ">=0.4.2 <1.0.0", v.constraint(internal.UpdateLevelMinor))
+}
+
+func TestUpdatePolicy_RenderTemplate(t *testing.T) {
+	up := &UpdatePolicy{level: internal.UpdateLevelPatch, blocked: []BlockedUpdate{
+		{Package: "drupal/paragraphs", Installed: "1.17.0", Latest: "1.18.2", Allowed: "~1.17.0"},
+	}}
+
+	result, err := up.RenderTemplate()
+
+	require.NoError(t, err)
+	golden.Assert(t, "testdata/update_policy.md", result)
+}
+
+func TestUpdatePolicy_RenderTemplate_Empty(t *testing.T) {
+	up := &UpdatePolicy{level: internal.UpdateLevelPatch}
+
+	result, err := up.RenderTemplate()
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"testing"
+	"text/template"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,4 +22,12 @@ func TestBasicAddon_Render(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to execute template")
 	})
+	t.Run("a project template replaces the embedded one", func(t *testing.T) {
+		custom := &BasicAddon{}
+		custom.UseTemplate(template.Must(template.New("update_hooks").Funcs(TemplateFuncs()).Parse("Hooks: {{ len . }}")))
+
+		out, err := custom.Render("update_hooks.go.tmpl", []string{"a", "b"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hooks: 2", out)
+	})
 }
@@ -0,0 +1,613 @@
+package codehosting
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// bitbucketCloudHost is the only Bitbucket Cloud host. Every other Bitbucket is Data Center,
+// which serves a different API under the instance's own host.
+const bitbucketCloudHost = "bitbucket.org"
+
+// bitbucketCloudGitUsername is who a Bitbucket Cloud access token pushes as over HTTPS.
+const bitbucketCloudGitUsername = "x-token-auth"
+
+// bitbucketCloudAPI is Cloud's API root. It lives on its own host, not under bitbucket.org.
+const bitbucketCloudAPI = "https://api.bitbucket.org/2.0"
+
+// Bitbucket talks to Bitbucket Cloud or Bitbucket Data Center. One type rather than two: both
+// implement every Platform operation, and only the URLs and payload shapes differ.
+type Bitbucket struct {
+	// api is rooted at Cloud's API, or at the Data Center instance's /rest, context path included.
+	api   restClient
+	cloud bool
+	// owner is the Cloud workspace or the Data Center project key.
+	owner  string
+	repo   string
+	logger *zap.Logger
+}
+
+// newBitbucket builds a Bitbucket platform from a repository host and path. Data Center clone
+// URLs carry an "scm" segment ("/scm/PROJ/repo", behind any context path) that the API does not.
+func newBitbucket(host string, path string, token string, logger *zap.Logger) (*Bitbucket, error) {
+	if host == "" {
+		return nil, fmt.Errorf("could not determine Bitbucket host from repository URL")
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	if strings.EqualFold(host, bitbucketCloudHost) {
+		if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+			return nil, fmt.Errorf("could not determine workspace and repository from %q", path)
+		}
+		return &Bitbucket{
+			api:    newBitbucketClient(bitbucketCloudAPI, token),
+			cloud:  true,
+			owner:  segments[0],
+			repo:   segments[1],
+			logger: logger,
+		}, nil
+	}
+
+	owner, repo, contextPath, err := bitbucketServerPath(segments)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine project and repository from %q: %w", path, err)
+	}
+	// Without "scm" the URL was the SSH form, whose port (7999 by default) is not the one the
+	// REST API listens on.
+	if !slices.Contains(segments, "scm") {
+		if hostname, _, splitErr := net.SplitHostPort(host); splitErr == nil {
+			host = hostname
+		}
+	}
+
+	return &Bitbucket{
+		api:    newBitbucketClient("https://"+host+contextPath+"/rest", token),
+		owner:  owner,
+		repo:   repo,
+		logger: logger,
+	}, nil
+}
+
+func newBitbucketClient(baseURL string, token string) restClient {
+	return restClient{
+		client:        http.DefaultClient,
+		baseURL:       baseURL,
+		authorization: "Bearer " + token,
+		errorMessage:  bitbucketErrorMessage,
+	}
+}
+
+// bitbucketServerPath splits a Data Center repository path into project, repository and the
+// instance's context path ("" when served from the root).
+func bitbucketServerPath(segments []string) (owner string, repo string, contextPath string, err error) {
+	if i := slices.Index(segments, "scm"); i >= 0 {
+		contextPath = strings.Join(segments[:i], "/")
+		if contextPath != "" {
+			contextPath = "/" + contextPath
+		}
+		segments = segments[i+1:]
+	}
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return "", "", "", errors.New("expected PROJECT/repository")
+	}
+	return segments[0], segments[1], contextPath, nil
+}
+
+// repoPath is the repository's path below the API root, for the core and branch-utils APIs alike.
+func (b *Bitbucket) repoPath() string {
+	if b.cloud {
+		return "/repositories/" + url.PathEscape(b.owner) + "/" + url.PathEscape(b.repo)
+	}
+	return "/projects/" + url.PathEscape(b.owner) + "/repos/" + url.PathEscape(b.repo)
+}
+
+// CreateMergeRequest opens a draft only on Cloud. Data Center refuses one up front rather than
+// open a pull request that asks for review before its pipeline has run.
+func (b *Bitbucket) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error) {
+	if b.cloud {
+		return b.createCloudPullRequest(ctx, title, description, sourceBranch, targetBranch, draft)
+	}
+	if draft {
+		return MergeRequest{}, fmt.Errorf("could not open a draft pull request: not supported on Bitbucket Data Center: %w", errors.ErrUnsupported)
+	}
+	return b.createServerPullRequest(ctx, title, description, sourceBranch, targetBranch)
+}
+
+func (b *Bitbucket) createCloudPullRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error) {
+	type branch struct {
+		Name string `json:"name"`
+	}
+	type endpoint struct {
+		Branch branch `json:"branch"`
+	}
+	body := struct {
+		Title             string   `json:"title"`
+		Description       string   `json:"description"`
+		Source            endpoint `json:"source"`
+		Destination       endpoint `json:"destination"`
+		CloseSourceBranch bool     `json:"close_source_branch"`
+		Draft             bool     `json:"draft"`
+	}{
+		Title:       title,
+		Description: description,
+		Source:      endpoint{Branch: branch{Name: sourceBranch}},
+		Destination: endpoint{Branch: branch{Name: targetBranch}},
+		// Matches GitLab, where the source branch is always removed on merge.
+		CloseSourceBranch: true,
+		Draft:             draft,
+	}
+
+	var pr struct {
+		ID    int64 `json:"id"`
+		Draft bool  `json:"draft"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	if _, err := b.api.do(ctx, http.MethodPost, b.repoPath()+"/pullrequests", body, &pr); err != nil {
+		return MergeRequest{}, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return MergeRequest{ID: pr.ID, URL: pr.Links.HTML.Href, Draft: pr.Draft}, nil
+}
+
+func (b *Bitbucket) createServerPullRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (MergeRequest, error) {
+	type project struct {
+		Key string `json:"key"`
+	}
+	type repository struct {
+		Slug    string  `json:"slug"`
+		Project project `json:"project"`
+	}
+	type ref struct {
+		ID         string     `json:"id"`
+		Repository repository `json:"repository"`
+	}
+	repo := repository{Slug: b.repo, Project: project{Key: b.owner}}
+	body := struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		FromRef     ref    `json:"fromRef"`
+		ToRef       ref    `json:"toRef"`
+	}{
+		Title:       title,
+		Description: description,
+		FromRef:     ref{ID: "refs/heads/" + sourceBranch, Repository: repo},
+		ToRef:       ref{ID: "refs/heads/" + targetBranch, Repository: repo},
+	}
+
+	var pr struct {
+		ID    int64 `json:"id"`
+		Links struct {
+			Self []struct {
+				Href string `json:"href"`
+			} `json:"self"`
+		} `json:"links"`
+	}
+	if _, err := b.api.do(ctx, http.MethodPost, "/api/latest"+b.repoPath()+"/pull-requests", body, &pr); err != nil {
+		return MergeRequest{}, fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	mr := MergeRequest{ID: pr.ID}
+	if len(pr.Links.Self) > 0 {
+		mr.URL = pr.Links.Self[0].Href
+	}
+	return mr, nil
+}
+
+// DeleteBranch removes a remote branch. Data Center has no branch deletion in its core API, only
+// in the bundled branch-utils plugin.
+func (b *Bitbucket) DeleteBranch(ctx context.Context, branch string) error {
+	var err error
+	if b.cloud {
+		_, err = b.api.do(ctx, http.MethodDelete, b.repoPath()+"/refs/branches/"+url.PathEscape(branch), nil, nil)
+	} else {
+		body := struct {
+			Name string `json:"name"`
+		}{Name: "refs/heads/" + branch}
+		_, err = b.api.do(ctx, http.MethodDelete, "/branch-utils/latest"+b.repoPath()+"/branches", body, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	return nil
+}
+
+func (b *Bitbucket) ListMergeRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error) {
+	var (
+		out []MergeRequest
+		err error
+	)
+	if b.cloud {
+		out, err = b.listCloudPullRequests(ctx, targetBranch)
+	} else {
+		out, err = b.listServerPullRequests(ctx, targetBranch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	return out, nil
+}
+
+func (b *Bitbucket) listCloudPullRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error) {
+	type endpoint struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	query := url.Values{
+		"state":   {"OPEN"},
+		"q":       {fmt.Sprintf("destination.branch.name = %q", targetBranch)},
+		"sort":    {"-created_on"},
+		"pagelen": {"50"},
+	}
+	path := b.repoPath() + "/pullrequests?" + query.Encode()
+
+	var out []MergeRequest
+	for path != "" {
+		var page struct {
+			Values []struct {
+				ID          int64    `json:"id"`
+				Description string   `json:"description"`
+				Draft       bool     `json:"draft"`
+				Source      endpoint `json:"source"`
+				Destination endpoint `json:"destination"`
+				Links       struct {
+					HTML struct {
+						Href string `json:"href"`
+					} `json:"html"`
+				} `json:"links"`
+			} `json:"values"`
+			// Next is an absolute URL, and absent on the last page.
+			Next string `json:"next"`
+		}
+		if _, err := b.api.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, pr := range page.Values {
+			if pr.Source.Repository.FullName != pr.Destination.Repository.FullName {
+				continue
+			}
+			out = append(out, MergeRequest{ID: pr.ID, URL: pr.Links.HTML.Href, SourceBranch: pr.Source.Branch.Name, Description: pr.Description, Draft: pr.Draft})
+		}
+		path = ""
+		if page.Next != "" {
+			rest, found := strings.CutPrefix(page.Next, b.api.baseURL)
+			if !found {
+				return nil, fmt.Errorf("unexpected next page %q", page.Next)
+			}
+			path = rest
+		}
+	}
+	return out, nil
+}
+
+func (b *Bitbucket) listServerPullRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error) {
+	var out []MergeRequest
+	for start := 0; ; {
+		query := url.Values{
+			"state":     {"OPEN"},
+			"direction": {"INCOMING"},
+			"at":        {"refs/heads/" + targetBranch},
+			"order":     {"NEWEST"},
+			"start":     {fmt.Sprint(start)},
+			"limit":     {"100"},
+		}
+		var page struct {
+			Values []struct {
+				ID          int64  `json:"id"`
+				Description string `json:"description"`
+				FromRef     struct {
+					DisplayID  string `json:"displayId"`
+					Repository struct {
+						Slug    string `json:"slug"`
+						Project struct {
+							Key string `json:"key"`
+						} `json:"project"`
+					} `json:"repository"`
+				} `json:"fromRef"`
+				Links struct {
+					Self []struct {
+						Href string `json:"href"`
+					} `json:"self"`
+				} `json:"links"`
+			} `json:"values"`
+			IsLastPage    bool `json:"isLastPage"`
+			NextPageStart int  `json:"nextPageStart"`
+		}
+		if _, err := b.api.do(ctx, http.MethodGet, "/api/latest"+b.repoPath()+"/pull-requests?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, pr := range page.Values {
+			from := pr.FromRef.Repository
+			if !strings.EqualFold(from.Project.Key, b.owner) || !strings.EqualFold(from.Slug, b.repo) {
+				continue
+			}
+			mr := MergeRequest{ID: pr.ID, SourceBranch: pr.FromRef.DisplayID, Description: pr.Description}
+			if len(pr.Links.Self) > 0 {
+				mr.URL = pr.Links.Self[0].Href
+			}
+			out = append(out, mr)
+		}
+		if page.IsLastPage || len(page.Values) == 0 {
+			return out, nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+// UpdateMergeRequest rewrites title and description. Data Center rejects an update that does not
+// name the pull request's current version, so that is read first.
+func (b *Bitbucket) UpdateMergeRequest(ctx context.Context, mr MergeRequest, title string, description string) error {
+	var err error
+	if b.cloud {
+		body := struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+		}{Title: title, Description: description}
+		_, err = b.api.do(ctx, http.MethodPut, fmt.Sprintf("%s/pullrequests/%d", b.repoPath(), mr.ID), body, nil)
+	} else {
+		var version int
+		if version, err = b.serverPullRequestVersion(ctx, mr.ID); err == nil {
+			body := struct {
+				Version     int    `json:"version"`
+				Title       string `json:"title"`
+				Description string `json:"description"`
+			}{Version: version, Title: title, Description: description}
+			_, err = b.api.do(ctx, http.MethodPut, b.serverPullRequestPath(mr.ID), body, nil)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update pull request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// CloseMergeRequest declines the pull request, Bitbucket's word for closing one unmerged. The
+// decline carries an empty JSON body: Data Center's XSRF guard turns away a bodiless POST.
+func (b *Bitbucket) CloseMergeRequest(ctx context.Context, mr MergeRequest, comment string) error {
+	var err error
+	if b.cloud {
+		body := struct {
+			Content struct {
+				Raw string `json:"raw"`
+			} `json:"content"`
+		}{}
+		body.Content.Raw = comment
+		if _, err = b.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/pullrequests/%d/comments", b.repoPath(), mr.ID), body, nil); err != nil {
+			return fmt.Errorf("failed to comment on pull request %d: %w", mr.ID, err)
+		}
+		_, err = b.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/pullrequests/%d/decline", b.repoPath(), mr.ID), struct{}{}, nil)
+	} else {
+		body := struct {
+			Text string `json:"text"`
+		}{Text: comment}
+		if _, err = b.api.do(ctx, http.MethodPost, b.serverPullRequestPath(mr.ID)+"/comments", body, nil); err != nil {
+			return fmt.Errorf("failed to comment on pull request %d: %w", mr.ID, err)
+		}
+		var version int
+		if version, err = b.serverPullRequestVersion(ctx, mr.ID); err == nil {
+			_, err = b.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/decline?version=%d", b.serverPullRequestPath(mr.ID), version), struct{}{}, nil)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close pull request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+func (b *Bitbucket) serverPullRequestPath(id int64) string {
+	return fmt.Sprintf("/api/latest%s/pull-requests/%d", b.repoPath(), id)
+}
+
+// serverPullRequestVersion reads the optimistic-locking version Data Center requires on a change.
+func (b *Bitbucket) serverPullRequestVersion(ctx context.Context, id int64) (int, error) {
+	var current struct {
+		Version int `json:"version"`
+	}
+	if _, err := b.api.do(ctx, http.MethodGet, b.serverPullRequestPath(id), nil, &current); err != nil {
+		return 0, err
+	}
+	return current.Version, nil
+}
+
+// GetUser returns the token owner's name and email, empty on failure. An access token's bot user
+// has no email, and an empty one falls back to the checkout's identity like any other.
+func (b *Bitbucket) GetUser(ctx context.Context) (name string, email string) {
+	var err error
+	if b.cloud {
+		name, email, err = b.cloudUser(ctx)
+	} else {
+		name, email, err = b.serverUser(ctx)
+	}
+	if err != nil {
+		if b.logger != nil {
+			b.logger.Error("failed to get Bitbucket user", zap.Error(err))
+		}
+		return "", ""
+	}
+	return name, email
+}
+
+func (b *Bitbucket) cloudUser(ctx context.Context) (string, string, error) {
+	var user struct {
+		DisplayName string `json:"display_name"`
+		Nickname    string `json:"nickname"`
+	}
+	if _, err := b.api.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", "", err
+	}
+	name := user.DisplayName
+	if name == "" {
+		name = user.Nickname
+	}
+
+	// A separate scope from the profile, so a token without it still has a usable name.
+	var emails struct {
+		Values []struct {
+			Email     string `json:"email"`
+			IsPrimary bool   `json:"is_primary"`
+		} `json:"values"`
+	}
+	if _, err := b.api.do(ctx, http.MethodGet, "/user/emails", nil, &emails); err != nil {
+		if b.logger != nil {
+			b.logger.Debug("could not read the Bitbucket user's email", zap.Error(err))
+		}
+		return name, "", nil
+	}
+	for _, e := range emails.Values {
+		if e.IsPrimary {
+			return name, e.Email, nil
+		}
+	}
+	return name, "", nil
+}
+
+// serverUser resolves the token owner through the X-AUSERNAME header: Data Center has no
+// "current user" endpoint, but names the authenticated user on every response.
+func (b *Bitbucket) serverUser(ctx context.Context) (string, string, error) {
+	resp, err := b.api.do(ctx, http.MethodGet, "/api/latest"+b.repoPath(), nil, nil)
+	if err != nil {
+		return "", "", err
+	}
+	username := resp.Header.Get("X-AUSERNAME")
+	if username == "" {
+		return "", "", errors.New("the response did not name the authenticated user")
+	}
+
+	var user struct {
+		DisplayName  string `json:"displayName"`
+		EmailAddress string `json:"emailAddress"`
+	}
+	if _, err := b.api.do(ctx, http.MethodGet, "/api/latest/users/"+url.PathEscape(username), nil, &user); err != nil {
+		return "", "", err
+	}
+	return cmp.Or(user.DisplayName, username), user.EmailAddress, nil
+}
+
+// EnableAutoMerge asks Data Center (8.15+) to merge once the merge checks pass. Bitbucket Cloud
+// has no API for that, and merging outright would skip the pipeline, so it reports an error.
+func (b *Bitbucket) EnableAutoMerge(ctx context.Context, mr MergeRequest) error {
+	if b.cloud {
+		return fmt.Errorf("could not enable auto merge for PR %d: Bitbucket Cloud has no API to merge once checks pass", mr.ID)
+	}
+	path := fmt.Sprintf("/api/latest%s/pull-requests/%d/auto-merge", b.repoPath(), mr.ID)
+	if _, err := b.api.do(ctx, http.MethodPost, path, nil, nil); err != nil {
+		return fmt.Errorf("could not enable auto merge for PR %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// bitbucketErrorMessage extracts the message from either API's error body: Cloud nests one
+// under "error", Data Center lists several under "errors". Falls back to the raw body.
+func bitbucketErrorMessage(payload []byte) string {
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(payload, &parsed); err == nil {
+		if parsed.Error.Message != "" {
+			return parsed.Error.Message
+		}
+		messages := make([]string, 0, len(parsed.Errors))
+		for _, e := range parsed.Errors {
+			messages = append(messages, e.Message)
+		}
+		if len(messages) > 0 {
+			return strings.Join(messages, "; ")
+		}
+	}
+	return strings.TrimSpace(string(payload))
+}
+
+// SetMergeRequestMetadata is not implemented for Bitbucket: the run warns and leaves the request as opened.
+func (b *Bitbucket) SetMergeRequestMetadata(_ context.Context, mr MergeRequest, _ MergeRequestMetadata) error {
+	return fmt.Errorf("could not set labels, assignees, reviewers or milestone of PR %d: not supported on Bitbucket: %w", mr.ID, errors.ErrUnsupported)
+}
+
+// PipelineStatus reads the build statuses on the pull request's latest commit, which is where
+// Bitbucket Pipelines and external CI both report. Data Center is not implemented: only promoting
+// a draft reads it, and CreateMergeRequest opens none there.
+func (b *Bitbucket) PipelineStatus(ctx context.Context, mr MergeRequest) (PipelineStatus, error) {
+	if !b.cloud {
+		return "", fmt.Errorf("could not read the checks of PR %d: not supported on Bitbucket Data Center: %w", mr.ID, errors.ErrUnsupported)
+	}
+
+	var statuses []PipelineStatus
+	path := fmt.Sprintf("%s/pullrequests/%d/statuses?pagelen=100", b.repoPath(), mr.ID)
+	for path != "" {
+		var page struct {
+			Values []struct {
+				State string `json:"state"`
+			} `json:"values"`
+			// Next is an absolute URL, and absent on the last page.
+			Next string `json:"next"`
+		}
+		if _, err := b.api.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return "", fmt.Errorf("could not read the checks of PR %d: %w", mr.ID, err)
+		}
+		for _, status := range page.Values {
+			switch status.State {
+			case "SUCCESSFUL":
+				statuses = append(statuses, PipelineSuccess)
+			case "INPROGRESS":
+				statuses = append(statuses, PipelinePending)
+			default:
+				statuses = append(statuses, PipelineFailed)
+			}
+		}
+		path = ""
+		if page.Next != "" {
+			rest, found := strings.CutPrefix(page.Next, b.api.baseURL)
+			if !found {
+				return "", fmt.Errorf("could not read the checks of PR %d: unexpected next page %q", mr.ID, page.Next)
+			}
+			path = rest
+		}
+	}
+	return combinePipelineStatuses(statuses), nil
+}
+
+// MarkMergeRequestReady clears the draft flag of a Cloud pull request. The update names the
+// current title, which Cloud requires on every change, so that is read first. Data Center is not
+// implemented, since no draft is opened there.
+func (b *Bitbucket) MarkMergeRequestReady(ctx context.Context, mr MergeRequest) error {
+	if !b.cloud {
+		return fmt.Errorf("could not mark PR %d ready for review: not supported on Bitbucket Data Center: %w", mr.ID, errors.ErrUnsupported)
+	}
+
+	path := fmt.Sprintf("%s/pullrequests/%d", b.repoPath(), mr.ID)
+	var current struct {
+		Title string `json:"title"`
+	}
+	if _, err := b.api.do(ctx, http.MethodGet, path, nil, &current); err != nil {
+		return fmt.Errorf("failed to read pull request %d: %w", mr.ID, err)
+	}
+	body := struct {
+		Title string `json:"title"`
+		Draft bool   `json:"draft"`
+	}{Title: current.Title}
+	if _, err := b.api.do(ctx, http.MethodPut, path, body, nil); err != nil {
+		return fmt.Errorf("failed to mark pull request %d ready for review: %w", mr.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,575 @@
+package codehosting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewBitbucket(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		path      string
+		wantCloud bool
+		wantBase  string
+		wantOwner string
+		wantRepo  string
+	}{
+		{
+			name: "cloud", host: "bitbucket.org", path: "workspace/repo",
+			wantCloud: true, wantBase: "https://api.bitbucket.org/2.0", wantOwner: "workspace", wantRepo: "repo",
+		},
+		{
+			name: "cloud host in another case", host: "BitBucket.org", path: "workspace/repo",
+			wantCloud: true, wantBase: "https://api.bitbucket.org/2.0", wantOwner: "workspace", wantRepo: "repo",
+		},
+		{
+			name: "data center HTTP clone URL", host: "bitbucket.example.com", path: "scm/PROJ/repo",
+			wantBase: "https://bitbucket.example.com/rest", wantOwner: "PROJ", wantRepo: "repo",
+		},
+		{
+			// The API is served below the same context path as the clone URL.
+			name: "data center behind a context path", host: "git.example.com:8443", path: "bitbucket/scm/PROJ/repo",
+			wantBase: "https://git.example.com:8443/bitbucket/rest", wantOwner: "PROJ", wantRepo: "repo",
+		},
+		{
+			// 7999 is the SSH port; sending REST calls there gets no answer at all.
+			name: "data center SSH URL drops the SSH port", host: "bitbucket.example.com:7999", path: "proj/repo",
+			wantBase: "https://bitbucket.example.com/rest", wantOwner: "proj", wantRepo: "repo",
+		},
+		{
+			name: "data center personal repository", host: "bitbucket.example.com", path: "scm/~jdoe/repo",
+			wantBase: "https://bitbucket.example.com/rest", wantOwner: "~jdoe", wantRepo: "repo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zap.NewNop()
+			b, err := newBitbucket(tt.host, tt.path, "dummy-token", logger)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantCloud, b.cloud)
+			assert.Equal(t, tt.wantBase, b.api.baseURL)
+			assert.Equal(t, tt.wantOwner, b.owner)
+			assert.Equal(t, tt.wantRepo, b.repo)
+			assert.Equal(t, "Bearer dummy-token", b.api.authorization)
+			assert.NotNil(t, b.api.client)
+			assert.Same(t, logger, b.logger)
+		})
+	}
+}
+
+func TestNewBitbucket_InvalidPath(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		path string
+	}{
+		{name: "no host", host: "", path: "workspace/repo"},
+		{name: "cloud without repo", host: "bitbucket.org", path: "workspace"},
+		{name: "cloud with a nested path", host: "bitbucket.org", path: "a/b/c"},
+		{name: "data center scm without repo", host: "bitbucket.example.com", path: "scm/PROJ"},
+		{name: "data center with extra segments", host: "bitbucket.example.com", path: "scm/PROJ/repo/extra"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newBitbucket(tt.host, tt.path, "dummy-token", zap.NewNop())
+			require.Error(t, err)
+		})
+	}
+}
+
+func newTestBitbucket(serverURL string, cloud bool) *Bitbucket {
+	return &Bitbucket{
+		api:    newBitbucketClient(serverURL, "s3cr3t"),
+		cloud:  cloud,
+		owner:  "acme",
+		repo:   "site",
+		logger: zap.NewNop(),
+	}
+}
+
+func TestBitbucket_CreateMergeRequest_Cloud(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repositories/acme/site/pullrequests" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 12, "links": {"html": {"href": "https://bitbucket.org/acme/site/pull-requests/12"}}}`))
+	}))
+	defer server.Close()
+
+	mr, err := newTestBitbucket(server.URL, true).CreateMergeRequest(context.Background(), "Title", "Body", "update-abc", "main", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, MergeRequest{ID: 12, URL: "https://bitbucket.org/acme/site/pull-requests/12"}, mr)
+	assert.Equal(t, "Title", sent["title"])
+	assert.Equal(t, "Body", sent["description"])
+	assert.Equal(t, map[string]any{"branch": map[string]any{"name": "update-abc"}}, sent["source"])
+	assert.Equal(t, map[string]any{"branch": map[string]any{"name": "main"}}, sent["destination"])
+	assert.Equal(t, true, sent["close_source_branch"])
+	assert.Equal(t, false, sent["draft"])
+}
+
+func TestBitbucket_CreateMergeRequest_Draft(t *testing.T) {
+	t.Run("cloud opens one", func(t *testing.T) {
+		var sent map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id": 12, "draft": true, "links": {"html": {"href": "https://bitbucket.org/acme/site/pull-requests/12"}}}`))
+		}))
+		defer server.Close()
+
+		mr, err := newTestBitbucket(server.URL, true).CreateMergeRequest(context.Background(), "Title", "Body", "update-abc", "main", true)
+		require.NoError(t, err)
+
+		assert.Equal(t, true, sent["draft"])
+		assert.True(t, mr.Draft)
+	})
+
+	t.Run("data center refuses one before asking", func(t *testing.T) {
+		_, err := newTestBitbucket("http://example.invalid", false).CreateMergeRequest(context.Background(), "Title", "Body", "update-abc", "main", true)
+		require.ErrorIs(t, err, errors.ErrUnsupported)
+	})
+}
+
+func TestBitbucket_CreateMergeRequest_DataCenter(t *testing.T) {
+	var sent struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		FromRef     struct {
+			ID         string `json:"id"`
+			Repository struct {
+				Slug    string `json:"slug"`
+				Project struct {
+					Key string `json:"key"`
+				} `json:"project"`
+			} `json:"repository"`
+		} `json:"fromRef"`
+		ToRef struct {
+			ID string `json:"id"`
+		} `json:"toRef"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/latest/projects/acme/repos/site/pull-requests" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 3, "links": {"self": [{"href": "https://bitbucket.example.com/projects/ACME/repos/site/pull-requests/3"}]}}`))
+	}))
+	defer server.Close()
+
+	mr, err := newTestBitbucket(server.URL, false).CreateMergeRequest(context.Background(), "Title", "Body", "update-abc", "main", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), mr.ID)
+	assert.Equal(t, "https://bitbucket.example.com/projects/ACME/repos/site/pull-requests/3", mr.URL)
+	assert.Equal(t, "Title", sent.Title)
+	assert.Equal(t, "Body", sent.Description)
+	assert.Equal(t, "refs/heads/update-abc", sent.FromRef.ID)
+	assert.Equal(t, "site", sent.FromRef.Repository.Slug)
+	assert.Equal(t, "acme", sent.FromRef.Repository.Project.Key)
+	assert.Equal(t, "refs/heads/main", sent.ToRef.ID)
+}
+
+func TestBitbucket_CreateMergeRequest_ReportsTheAPIError(t *testing.T) {
+	tests := []struct {
+		name  string
+		cloud bool
+		body  string
+		want  string
+	}{
+		{name: "cloud", cloud: true, body: `{"type":"error","error":{"message":"branch not found"}}`, want: "branch not found"},
+		{name: "data center", body: `{"errors":[{"message":"no changes"},{"message":"already open"}]}`, want: "no changes; already open"},
+		{name: "unparseable body", body: `Service Unavailable`, want: "Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := newTestBitbucket(server.URL, tt.cloud).CreateMergeRequest(context.Background(), "t", "d", "s", "main", false)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to create pull request")
+			assert.Contains(t, err.Error(), "400")
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestBitbucket_CreateMergeRequest_HonorsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := newTestBitbucket("http://example.invalid", true).CreateMergeRequest(ctx, "t", "d", "s", "main", false)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBitbucket_DeleteBranch(t *testing.T) {
+	t.Run("cloud", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete && r.URL.Path == "/repositories/acme/site/refs/branches/update-abc" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, true).DeleteBranch(context.Background(), "update-abc"))
+	})
+
+	t.Run("data center", func(t *testing.T) {
+		var sent struct {
+			Name string `json:"name"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete && r.URL.Path == "/branch-utils/latest/projects/acme/repos/site/branches" {
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, false).DeleteBranch(context.Background(), "update-abc"))
+		assert.Equal(t, "refs/heads/update-abc", sent.Name)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := newTestBitbucket(server.URL, true).DeleteBranch(context.Background(), "update-abc")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to delete branch")
+	})
+}
+
+func TestBitbucket_GetUser_Cloud(t *testing.T) {
+	t.Run("name and primary email", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/user":
+				_, _ = w.Write([]byte(`{"display_name":"Jane Doe","nickname":"jdoe"}`))
+			case "/user/emails":
+				_, _ = w.Write([]byte(`{"values":[{"email":"old@example.com","is_primary":false},{"email":"jane@example.com","is_primary":true}]}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		name, email := newTestBitbucket(server.URL, true).GetUser(context.Background())
+		assert.Equal(t, "Jane Doe", name)
+		assert.Equal(t, "jane@example.com", email)
+	})
+
+	t.Run("an unreadable email keeps the name", func(t *testing.T) {
+		// Reading emails is a scope of its own: an access token can lack it and still push.
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/user" {
+				_, _ = w.Write([]byte(`{"nickname":"site-bot"}`))
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		name, email := newTestBitbucket(server.URL, true).GetUser(context.Background())
+		assert.Equal(t, "site-bot", name)
+		assert.Empty(t, email)
+	})
+
+	t.Run("an unauthenticated token yields nothing", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		name, email := newTestBitbucket(server.URL, true).GetUser(context.Background())
+		assert.Empty(t, name)
+		assert.Empty(t, email)
+	})
+}
+
+func TestBitbucket_GetUser_DataCenter(t *testing.T) {
+	t.Run("resolves the user the response names", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/latest/projects/acme/repos/site":
+				w.Header().Set("X-AUSERNAME", "jdoe")
+				_, _ = w.Write([]byte(`{"slug":"site"}`))
+			case "/api/latest/users/jdoe":
+				_, _ = w.Write([]byte(`{"displayName":"Jane Doe","emailAddress":"jane@example.com"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		name, email := newTestBitbucket(server.URL, false).GetUser(context.Background())
+		assert.Equal(t, "Jane Doe", name)
+		assert.Equal(t, "jane@example.com", email)
+	})
+
+	t.Run("an anonymous response yields nothing", func(t *testing.T) {
+		// A public repository answers without credentials, so a 200 alone proves nothing.
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"slug":"site"}`))
+		}))
+		defer server.Close()
+
+		name, email := newTestBitbucket(server.URL, false).GetUser(context.Background())
+		assert.Empty(t, name)
+		assert.Empty(t, email)
+	})
+}
+
+func TestBitbucket_EnableAutoMerge(t *testing.T) {
+	t.Run("data center requests auto-merge", func(t *testing.T) {
+		var called bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost && r.URL.Path == "/api/latest/projects/acme/repos/site/pull-requests/3/auto-merge" {
+				called = true
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, false).EnableAutoMerge(context.Background(), MergeRequest{ID: 3}))
+		assert.True(t, called)
+	})
+
+	t.Run("data center failure names the PR", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"auto-merge is disabled"}]}`))
+		}))
+		defer server.Close()
+
+		err := newTestBitbucket(server.URL, false).EnableAutoMerge(context.Background(), MergeRequest{ID: 3})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "PR 3")
+		assert.Contains(t, err.Error(), "auto-merge is disabled")
+	})
+
+	t.Run("cloud refuses rather than merging unchecked", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}))
+		defer server.Close()
+
+		err := newTestBitbucket(server.URL, true).EnableAutoMerge(context.Background(), MergeRequest{ID: 12})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Bitbucket Cloud")
+	})
+}
+
+func TestBitbucket_ListMergeRequests_Cloud(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/repositories/acme/site/pullrequests" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(`{"values": [
+				{"id": 1, "description": "fork", "source": {"branch": {"name": "update-a"}, "repository": {"full_name": "someone/site"}}, "destination": {"branch": {"name": "main"}, "repository": {"full_name": "acme/site"}}}
+			]}`))
+			return
+		}
+		assert.Equal(t, `destination.branch.name = "main"`, r.URL.Query().Get("q"))
+		_, _ = w.Write([]byte(`{"values": [
+			{"id": 2, "description": "mine", "draft": true, "source": {"branch": {"name": "update-b"}, "repository": {"full_name": "acme/site"}}, "destination": {"branch": {"name": "main"}, "repository": {"full_name": "acme/site"}}, "links": {"html": {"href": "https://bitbucket.org/acme/site/pull-requests/2"}}}
+		], "next": "` + server.URL + `/repositories/acme/site/pullrequests?page=2"}`))
+	}))
+	defer server.Close()
+
+	mrs, err := newTestBitbucket(server.URL, true).ListMergeRequests(context.Background(), "main")
+	require.NoError(t, err)
+	assert.Equal(t, []MergeRequest{{ID: 2, URL: "https://bitbucket.org/acme/site/pull-requests/2", SourceBranch: "update-b", Description: "mine", Draft: true}}, mrs)
+}
+
+func TestBitbucket_PipelineStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		values string
+		want   PipelineStatus
+	}{
+		{name: "nothing reported", values: `[]`, want: PipelineNone},
+		{name: "all passed", values: `[{"state": "SUCCESSFUL"}, {"state": "SUCCESSFUL"}]`, want: PipelineSuccess},
+		{name: "one still running", values: `[{"state": "SUCCESSFUL"}, {"state": "INPROGRESS"}]`, want: PipelinePending},
+		{name: "a stopped build counts as failed", values: `[{"state": "INPROGRESS"}, {"state": "STOPPED"}]`, want: PipelineFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/repositories/acme/site/pullrequests/12/statuses" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(`{"values": ` + tt.values + `}`))
+			}))
+			defer server.Close()
+
+			status, err := newTestBitbucket(server.URL, true).PipelineStatus(context.Background(), MergeRequest{ID: 12})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, status)
+		})
+	}
+}
+
+func TestBitbucket_MarkMergeRequestReady(t *testing.T) {
+	t.Run("cloud clears the draft flag", func(t *testing.T) {
+		var sent map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/repositories/acme/site/pullrequests/12" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"id": 12, "title": "Current title", "draft": true}`))
+				return
+			}
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, true).MarkMergeRequestReady(context.Background(), MergeRequest{ID: 12}))
+		assert.Equal(t, map[string]any{"title": "Current title", "draft": false}, sent)
+	})
+
+	t.Run("data center is not supported", func(t *testing.T) {
+		err := newTestBitbucket("http://example.invalid", false).MarkMergeRequestReady(context.Background(), MergeRequest{ID: 4})
+		require.ErrorIs(t, err, errors.ErrUnsupported)
+	})
+}
+
+func TestBitbucket_ListMergeRequests_DataCenter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/latest/projects/acme/repos/site/pull-requests" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "refs/heads/main", r.URL.Query().Get("at"))
+		_, _ = w.Write([]byte(`{"isLastPage": true, "values": [
+			{"id": 4, "description": "mine", "fromRef": {"displayId": "update-b", "repository": {"slug": "site", "project": {"key": "ACME"}}}, "links": {"self": [{"href": "https://git.example.com/projects/ACME/repos/site/pull-requests/4"}]}},
+			{"id": 3, "description": "fork", "fromRef": {"displayId": "update-a", "repository": {"slug": "site", "project": {"key": "~SOMEONE"}}}}
+		]}`))
+	}))
+	defer server.Close()
+
+	mrs, err := newTestBitbucket(server.URL, false).ListMergeRequests(context.Background(), "main")
+	require.NoError(t, err)
+	assert.Equal(t, []MergeRequest{{ID: 4, URL: "https://git.example.com/projects/ACME/repos/site/pull-requests/4", SourceBranch: "update-b", Description: "mine"}}, mrs)
+}
+
+func TestBitbucket_UpdateMergeRequest(t *testing.T) {
+	t.Run("cloud", func(t *testing.T) {
+		var sent map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut || r.URL.Path != "/repositories/acme/site/pullrequests/2" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, true).UpdateMergeRequest(context.Background(), MergeRequest{ID: 2}, "New title", "New body"))
+		assert.Equal(t, map[string]any{"title": "New title", "description": "New body"}, sent)
+	})
+
+	t.Run("data center names the current version", func(t *testing.T) {
+		var sent map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/latest/projects/acme/repos/site/pull-requests/4" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"id": 4, "version": 6}`))
+				return
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, false).UpdateMergeRequest(context.Background(), MergeRequest{ID: 4}, "New title", "New body"))
+		assert.Equal(t, map[string]any{"version": float64(6), "title": "New title", "description": "New body"}, sent)
+	})
+}
+
+func TestBitbucket_CloseMergeRequest(t *testing.T) {
+	t.Run("cloud", func(t *testing.T) {
+		var comment map[string]any
+		var declined bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/repositories/acme/site/pullrequests/2/comments":
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+			case r.Method == http.MethodPost && r.URL.Path == "/repositories/acme/site/pullrequests/2/decline":
+				declined = true
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, true).CloseMergeRequest(context.Background(), MergeRequest{ID: 2}, "Superseded by #3."))
+		assert.Equal(t, map[string]any{"content": map[string]any{"raw": "Superseded by #3."}}, comment)
+		assert.True(t, declined)
+	})
+
+	t.Run("data center declines the current version", func(t *testing.T) {
+		var comment map[string]any
+		var declineVersion string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const pr = "/api/latest/projects/acme/repos/site/pull-requests/4"
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == pr+"/comments":
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+			case r.Method == http.MethodGet && r.URL.Path == pr:
+				_, _ = w.Write([]byte(`{"id": 4, "version": 6}`))
+			case r.Method == http.MethodPost && r.URL.Path == pr+"/decline":
+				declineVersion = r.URL.Query().Get("version")
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		require.NoError(t, newTestBitbucket(server.URL, false).CloseMergeRequest(context.Background(), MergeRequest{ID: 4}, "Superseded by #5."))
+		assert.Equal(t, map[string]any{"text": "Superseded by #5."}, comment)
+		assert.Equal(t, "6", declineVersion)
+	})
+}
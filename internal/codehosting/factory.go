@@ -3,8 +3,10 @@ package codehosting
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 
 	"go.uber.org/zap"
@@ -12,7 +14,9 @@ import (
 
 // Platform is a version control hosting provider.
 type Platform interface {
-	CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (MergeRequest, error)
+	// CreateMergeRequest opens a request from sourceBranch into targetBranch. A draft one is
+	// visible but not up for review until MarkMergeRequestReady.
+	CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error)
 
 	DeleteBranch(ctx context.Context, branch string) error
 
@@ -20,11 +24,83 @@ type Platform interface {
 
 	// EnableAutoMerge merges once every condition the platform enforces is met.
 	EnableAutoMerge(ctx context.Context, mr MergeRequest) error
+
+	// ListMergeRequests returns the open requests into targetBranch, newest first. Requests from
+	// forks are left out: their branches are not this repository's to push to.
+	ListMergeRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error)
+
+	// UpdateMergeRequest replaces an open request's title and description.
+	UpdateMergeRequest(ctx context.Context, mr MergeRequest, title string, description string) error
+
+	// CloseMergeRequest leaves comment on an open request, then closes it without merging. The
+	// branch is left alone; DeleteBranch removes it.
+	CloseMergeRequest(ctx context.Context, mr MergeRequest, comment string) error
+
+	// SetMergeRequestMetadata adds labels, assignees and reviewers to a request, and sets its
+	// milestone. Labels missing from the repository are created. Every part is attempted; the
+	// errors of those that failed are joined.
+	SetMergeRequestMetadata(ctx context.Context, mr MergeRequest, metadata MergeRequestMetadata) error
+
+	// PipelineStatus sums up the pipelines and status checks on the request's latest commit.
+	PipelineStatus(ctx context.Context, mr MergeRequest) (PipelineStatus, error)
+
+	// MarkMergeRequestReady takes a draft request out of draft, which puts it up for review.
+	MarkMergeRequestReady(ctx context.Context, mr MergeRequest) error
+}
+
+// PipelineStatus is what the checks on a request's latest commit add up to.
+type PipelineStatus string
+
+const (
+	// PipelineNone means no pipeline or status check has reported on the commit yet.
+	PipelineNone PipelineStatus = "none"
+	// PipelinePending means at least one is still queued or running, and none has failed.
+	PipelinePending PipelineStatus = "pending"
+	// PipelineSuccess means every one has finished, and none failed.
+	PipelineSuccess PipelineStatus = "success"
+	// PipelineFailed means at least one failed or was cancelled.
+	PipelineFailed PipelineStatus = "failed"
+)
+
+// combinePipelineStatuses adds up the statuses of everything that checked one commit: a failure
+// outweighs anything still running, which outweighs the successes.
+func combinePipelineStatuses(statuses []PipelineStatus) PipelineStatus {
+	switch {
+	case len(statuses) == 0:
+		return PipelineNone
+	case slices.Contains(statuses, PipelineFailed):
+		return PipelineFailed
+	case slices.Contains(statuses, PipelinePending):
+		return PipelinePending
+	default:
+		return PipelineSuccess
+	}
+}
+
+// MergeRequestMetadata is the triage a project wants on the requests a run opens.
+type MergeRequestMetadata struct {
+	Labels []string
+	// Assignees and Reviewers are usernames. A GitHub reviewer may also be a team, as
+	// "org/team-slug".
+	Assignees []string
+	Reviewers []string
+	// Milestone is an open milestone's title.
+	Milestone string
+}
+
+// IsZero reports whether there is nothing to set.
+func (m MergeRequestMetadata) IsZero() bool {
+	return len(m.Labels) == 0 && len(m.Assignees) == 0 && len(m.Reviewers) == 0 && m.Milestone == ""
 }
 
 type MergeRequest struct {
 	ID  int64  `json:"id"`
 	URL string `json:"url"`
+	// SourceBranch and Description are filled in by ListMergeRequests only.
+	SourceBranch string `json:"source_branch,omitempty"`
+	Description  string `json:"description,omitempty"`
+	// Draft is true while the request is a draft.
+	Draft bool `json:"draft,omitempty"`
 }
 
 type DefaultVcsProviderFactory struct{}
@@ -33,35 +109,120 @@ func NewDefaultVcsProviderFactory() *DefaultVcsProviderFactory {
 	return &DefaultVcsProviderFactory{}
 }
 
+// Providers are the names an explicit provider override accepts. "gitea" covers Forgejo too.
+var Providers = []string{"github", "gitlab", "bitbucket", "gitea"}
+
+// ValidateProvider accepts a name from Providers, or "" for auto-detection.
+func ValidateProvider(provider string) error {
+	if provider == "" || slices.Contains(Providers, provider) {
+		return nil
+	}
+	return fmt.Errorf("unknown provider %q (use one of: %s)", provider, strings.Join(Providers, ", "))
+}
+
+// Options is what the project states about its host, beyond what the repository URL implies.
+type Options struct {
+	// Provider skips detection entirely: the answer for self-hosted hosts whose name gives
+	// nothing away. Empty auto-detects.
+	Provider string
+	// GithubAPIURL is the GitHub REST API root. Empty derives it from the repository host.
+	GithubAPIURL string
+}
+
 // Create returns the Platform implementation for a repository URL.
-func (vpf *DefaultVcsProviderFactory) Create(repositoryURL string, token string, logger *zap.Logger) (Platform, error) {
+func (vpf *DefaultVcsProviderFactory) Create(repositoryURL string, opts Options, token string, logger *zap.Logger) (Platform, error) {
+	if err := ValidateProvider(opts.Provider); err != nil {
+		return nil, err
+	}
 	host, path, err := parseGitURL(repositoryURL)
 	if err != nil {
 		return nil, err
 	}
 
-	provider := providerFromEnv()
+	provider := opts.Provider
+	if provider == "" {
+		provider = providerFromEnv()
+	}
 	if provider == "" {
 		provider = providerFromHost(host)
 	}
 
 	switch provider {
 	case "github":
-		return newGithub(path, token, logger)
+		return newGithub(path, githubAPIURL(host, opts.GithubAPIURL), token, logger)
+	case "bitbucket":
+		return newBitbucket(host, path, token, logger)
+	case "gitea":
+		return newGitea(host, path, token, logger)
 	default:
 		return newGitlab(host, path, token, logger)
 	}
 }
 
+// GitUsername is the username to send with the token over HTTPS. Most hosts ignore it; Bitbucket
+// Cloud, which bitbucket.org always is whatever the provider setting, refuses an access token
+// under any name but "x-token-auth". "" leaves the choice to the caller.
+func GitUsername(repositoryURL string) string {
+	host, _, err := parseGitURL(repositoryURL)
+	if err == nil && strings.EqualFold(host, bitbucketCloudHost) {
+		return bitbucketCloudGitUsername
+	}
+	return ""
+}
+
+// githubAPIURL resolves the REST root for a GitHub host, "" meaning github.com's. Actions'
+// GITHUB_API_URL is used only for the server the workflow runs on: a job on github.com may well
+// update a repository on an Enterprise instance, or the other way around.
+func githubAPIURL(host string, explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if api := os.Getenv("GITHUB_API_URL"); api != "" && sameHost(os.Getenv("GITHUB_SERVER_URL"), host) {
+		if sameHost(api, "api.github.com") {
+			return ""
+		}
+		return api
+	}
+
+	hostname := strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(hostname); err == nil {
+		hostname = h
+	}
+	switch {
+	case hostname == "github.com", hostname == "www.github.com":
+		return ""
+	// GitHub Enterprise Cloud with data residency serves its API from an "api." subdomain.
+	case strings.HasSuffix(hostname, ".ghe.com"):
+		return "https://api." + hostname + "/"
+	default:
+		return "https://" + host + "/api/v3/"
+	}
+}
+
+// sameHost compares a URL's host with a bare host, ignoring case. False for an unparseable URL.
+func sameHost(rawURL string, host string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, host)
+}
+
 // providerFromEnv reads the provider from CI, which also covers self-hosted instances whose
 // hostname does not name it. "" when not in CI.
 func providerFromEnv() string {
+	// Gitea and Forgejo Actions also set GITHUB_ACTIONS for workflow compatibility, so their own
+	// flags are checked first.
+	if os.Getenv("GITEA_ACTIONS") == "true" || os.Getenv("FORGEJO_ACTIONS") == "true" {
+		return "gitea"
+	}
 	if os.Getenv("GITHUB_ACTIONS") == "true" {
 		return "github"
 	}
 	if os.Getenv("GITLAB_CI") == "true" {
 		return "gitlab"
 	}
+	// Pipelines sets no "true" flag of its own; a build number is the one variable always present.
+	if os.Getenv("BITBUCKET_BUILD_NUMBER") != "" {
+		return "bitbucket"
+	}
 	return ""
 }
 
@@ -73,6 +234,11 @@ func providerFromHost(host string) string {
 		return "gitlab"
 	case strings.Contains(host, "github"):
 		return "github"
+	case strings.Contains(host, "bitbucket"):
+		return "bitbucket"
+	// Codeberg is the one large public Forgejo instance whose name says neither.
+	case strings.Contains(host, "gitea"), strings.Contains(host, "forgejo"), strings.Contains(host, "codeberg"):
+		return "gitea"
 	default:
 		return ""
 	}
@@ -41,16 +41,44 @@ func TestDefaultVcsProviderFactory_Create(t *testing.T) {
 			repositoryURL: "git@github.com:owner/repo.git",
 			expectedType:  &Github{},
 		},
+		{
+			name:          "returns bitbucket platform for bitbucket.org URLs",
+			repositoryURL: "https://bitbucket.org/workspace/repo.git",
+			expectedType:  &Bitbucket{},
+		},
+		{
+			name:          "returns bitbucket platform for a Data Center host",
+			repositoryURL: "https://bitbucket.example.com/scm/PROJ/repo.git",
+			expectedType:  &Bitbucket{},
+		},
+		{
+			name:          "returns gitea platform for a gitea host",
+			repositoryURL: "https://gitea.example.com/owner/repo.git",
+			expectedType:  &Gitea{},
+		},
+		{
+			name:          "returns gitea platform for a forgejo host",
+			repositoryURL: "git@forgejo.example.com:owner/repo.git",
+			expectedType:  &Gitea{},
+		},
+		{
+			name:          "returns gitea platform for codeberg.org",
+			repositoryURL: "https://codeberg.org/owner/repo.git",
+			expectedType:  &Gitea{},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Setenv("GITHUB_ACTIONS", "")
 			t.Setenv("GITLAB_CI", "")
+			t.Setenv("BITBUCKET_BUILD_NUMBER", "")
+			t.Setenv("GITEA_ACTIONS", "")
+			t.Setenv("FORGEJO_ACTIONS", "")
 
 			factory := NewDefaultVcsProviderFactory()
 
-			provider, err := factory.Create(tt.repositoryURL, "dummy-token", zap.NewNop())
+			provider, err := factory.Create(tt.repositoryURL, Options{}, "dummy-token", zap.NewNop())
 
 			require.NoError(t, err)
 			assert.IsType(t, tt.expectedType, provider)
@@ -94,16 +122,40 @@ func TestDefaultVcsProviderFactory_Create_CIEnvVars(t *testing.T) {
 			envValue:      "true",
 			expectedType:  &Gitlab{},
 		},
+		{
+			name:          "GITEA_ACTIONS overrides hostname detection",
+			repositoryURL: "https://git.company.com/owner/repo",
+			envKey:        "GITEA_ACTIONS",
+			envValue:      "true",
+			expectedType:  &Gitea{},
+		},
+		{
+			name:          "FORGEJO_ACTIONS overrides hostname detection",
+			repositoryURL: "https://git.company.com/owner/repo",
+			envKey:        "FORGEJO_ACTIONS",
+			envValue:      "true",
+			expectedType:  &Gitea{},
+		},
+		{
+			name:          "BITBUCKET_BUILD_NUMBER overrides hostname detection",
+			repositoryURL: "https://git.company.com/owner/repo",
+			envKey:        "BITBUCKET_BUILD_NUMBER",
+			envValue:      "42",
+			expectedType:  &Bitbucket{},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Setenv("GITHUB_ACTIONS", "")
 			t.Setenv("GITLAB_CI", "")
+			t.Setenv("BITBUCKET_BUILD_NUMBER", "")
+			t.Setenv("GITEA_ACTIONS", "")
+			t.Setenv("FORGEJO_ACTIONS", "")
 			t.Setenv(tt.envKey, tt.envValue)
 
 			factory := NewDefaultVcsProviderFactory()
-			provider, err := factory.Create(tt.repositoryURL, "dummy-token", zap.NewNop())
+			provider, err := factory.Create(tt.repositoryURL, Options{}, "dummy-token", zap.NewNop())
 
 			require.NoError(t, err)
 			assert.IsType(t, tt.expectedType, provider)
@@ -147,12 +199,37 @@ func TestProviderFromEnv(t *testing.T) {
 			env:      map[string]string{"GITHUB_ACTIONS": "true", "GITLAB_CI": "true"},
 			expected: "github",
 		},
+		{
+			name:     "returns bitbucket when BITBUCKET_BUILD_NUMBER is set",
+			env:      map[string]string{"BITBUCKET_BUILD_NUMBER": "7"},
+			expected: "bitbucket",
+		},
+		{
+			// Gitea and Forgejo Actions set GITHUB_ACTIONS as well, for workflow compatibility.
+			name:     "GITEA_ACTIONS takes priority over GITHUB_ACTIONS",
+			env:      map[string]string{"GITEA_ACTIONS": "true", "GITHUB_ACTIONS": "true"},
+			expected: "gitea",
+		},
+		{
+			name:     "FORGEJO_ACTIONS takes priority over GITHUB_ACTIONS",
+			env:      map[string]string{"FORGEJO_ACTIONS": "true", "GITHUB_ACTIONS": "true"},
+			expected: "gitea",
+		},
+		{
+			// A GitLab job mirroring to Bitbucket may carry the variable; its own CI still wins.
+			name:     "GITLAB_CI takes priority over BITBUCKET_BUILD_NUMBER",
+			env:      map[string]string{"GITLAB_CI": "true", "BITBUCKET_BUILD_NUMBER": "7"},
+			expected: "gitlab",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Setenv("GITHUB_ACTIONS", "")
 			t.Setenv("GITLAB_CI", "")
+			t.Setenv("BITBUCKET_BUILD_NUMBER", "")
+			t.Setenv("GITEA_ACTIONS", "")
+			t.Setenv("FORGEJO_ACTIONS", "")
 			for k, v := range tt.env {
 				t.Setenv(k, v)
 			}
@@ -165,13 +242,92 @@ func TestProviderFromEnv(t *testing.T) {
 func TestDefaultVcsProviderFactory_Create_InvalidURL(t *testing.T) {
 	t.Setenv("GITHUB_ACTIONS", "")
 	t.Setenv("GITLAB_CI", "")
+	t.Setenv("BITBUCKET_BUILD_NUMBER", "")
+	t.Setenv("GITEA_ACTIONS", "")
+	t.Setenv("FORGEJO_ACTIONS", "")
 
 	factory := NewDefaultVcsProviderFactory()
 
-	_, err := factory.Create("", "dummy-token", zap.NewNop())
+	_, err := factory.Create("", Options{}, "dummy-token", zap.NewNop())
 	require.Error(t, err)
 }
 
+func TestDefaultVcsProviderFactory_Create_ExplicitProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		repositoryURL string
+		provider      string
+		expectedType  any
+	}{
+		{
+			name:          "routes a host that names nothing to gitea",
+			repositoryURL: "https://git.company.com/owner/repo.git",
+			provider:      "gitea",
+			expectedType:  &Gitea{},
+		},
+		{
+			name:          "routes a host that names nothing to github",
+			repositoryURL: "https://code.company.com/owner/repo.git",
+			provider:      "github",
+			expectedType:  &Github{},
+		},
+		{
+			// A forge migrated off GitLab can keep its old hostname.
+			name:          "wins over a host naming another provider",
+			repositoryURL: "https://gitlab.company.com/owner/repo.git",
+			provider:      "gitea",
+			expectedType:  &Gitea{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Gitea Actions sets this too; the explicit setting must still win over it.
+			t.Setenv("GITHUB_ACTIONS", "true")
+			t.Setenv("GITLAB_CI", "")
+			t.Setenv("BITBUCKET_BUILD_NUMBER", "")
+			t.Setenv("GITEA_ACTIONS", "")
+			t.Setenv("FORGEJO_ACTIONS", "")
+
+			provider, err := NewDefaultVcsProviderFactory().Create(tt.repositoryURL, Options{Provider: tt.provider}, "dummy-token", zap.NewNop())
+
+			require.NoError(t, err)
+			assert.IsType(t, tt.expectedType, provider)
+		})
+	}
+
+	t.Run("rejects an unknown provider", func(t *testing.T) {
+		_, err := NewDefaultVcsProviderFactory().Create("https://git.company.com/owner/repo.git", Options{Provider: "gogs"}, "dummy-token", zap.NewNop())
+		require.ErrorContains(t, err, `unknown provider "gogs"`)
+	})
+}
+
+func TestValidateProvider(t *testing.T) {
+	for _, provider := range append([]string{""}, Providers...) {
+		require.NoError(t, ValidateProvider(provider), provider)
+	}
+	require.Error(t, ValidateProvider("GitHub"))
+}
+
+func TestGitUsername(t *testing.T) {
+	t.Run("Bitbucket Cloud pushes as x-token-auth", func(t *testing.T) {
+		assert.Equal(t, "x-token-auth", GitUsername("https://bitbucket.org/acme/site.git"))
+		assert.Equal(t, "x-token-auth", GitUsername("https://user@BitBucket.org/acme/site.git"))
+	})
+
+	t.Run("every other host leaves it to the caller", func(t *testing.T) {
+		for _, url := range []string{
+			"https://github.com/acme/site.git",
+			"https://gitlab.com/acme/site.git",
+			"https://bitbucket.example.com/scm/ACME/site.git",
+			"https://codeberg.org/acme/site.git",
+			"not a url",
+		} {
+			assert.Empty(t, GitUsername(url), url)
+		}
+	})
+}
+
 func TestParseGitURL(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -240,3 +396,80 @@ func TestValidateRepositoryURL(t *testing.T) {
 		require.Error(t, ValidateRepositoryURL("not-a-url"))
 	})
 }
+
+func TestGithubAPIURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		explicit  string
+		apiURL    string
+		serverURL string
+		want      string
+	}{
+		{name: "github.com", host: "github.com", want: ""},
+		{name: "github.com in mixed case", host: "GitHub.com", want: ""},
+		{name: "Enterprise Server", host: "github.example.com", want: "https://github.example.com/api/v3/"},
+		{name: "Enterprise Server on a custom port", host: "github.example.com:8443", want: "https://github.example.com:8443/api/v3/"},
+		{name: "data residency", host: "acme.ghe.com", want: "https://api.acme.ghe.com/"},
+		{
+			name:     "an explicit setting wins",
+			host:     "code.example.com",
+			explicit: "https://api.code.example.com/",
+			want:     "https://api.code.example.com/",
+		},
+		{
+			name:      "Actions on the same server",
+			host:      "code.example.com",
+			apiURL:    "https://code.example.com/custom/api/v3",
+			serverURL: "https://code.example.com",
+			want:      "https://code.example.com/custom/api/v3",
+		},
+		{
+			name:      "Actions on github.com",
+			host:      "github.com",
+			apiURL:    "https://api.github.com",
+			serverURL: "https://github.com",
+			want:      "",
+		},
+		{
+			// A github.com workflow updating a repository on an Enterprise instance.
+			name:      "Actions on another server is ignored",
+			host:      "github.example.com",
+			apiURL:    "https://api.github.com",
+			serverURL: "https://github.com",
+			want:      "https://github.example.com/api/v3/",
+		},
+		{
+			name:      "an explicit setting wins over Actions",
+			host:      "github.example.com",
+			explicit:  "https://github.example.com/api/v3/",
+			apiURL:    "https://elsewhere.example.com/api/v3",
+			serverURL: "https://github.example.com",
+			want:      "https://github.example.com/api/v3/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_API_URL", tt.apiURL)
+			t.Setenv("GITHUB_SERVER_URL", tt.serverURL)
+
+			assert.Equal(t, tt.want, githubAPIURL(tt.host, tt.explicit))
+		})
+	}
+}
+
+func TestDefaultVcsProviderFactory_Create_GithubEnterprise(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("BITBUCKET_BUILD_NUMBER", "")
+	t.Setenv("GITEA_ACTIONS", "")
+	t.Setenv("FORGEJO_ACTIONS", "")
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_SERVER_URL", "")
+
+	platform, err := NewDefaultVcsProviderFactory().Create("git@github.example.com:owner/repo.git", Options{}, "dummy-token", zap.NewNop())
+	require.NoError(t, err)
+	require.IsType(t, &Github{}, platform)
+	assert.Equal(t, "https://github.example.com/api/v3/", platform.(*Github).client.BaseURL.String())
+}
@@ -0,0 +1,247 @@
+package codehosting
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Gitea talks to Gitea and to Forgejo, which kept Gitea's API when it forked. Codeberg runs Forgejo.
+type Gitea struct {
+	// api is rooted at the instance's /api/v1, sub-path included.
+	api    restClient
+	owner  string
+	repo   string
+	logger *zap.Logger
+}
+
+// newGitea builds a Gitea platform from a repository host and path. Gitea has no nested groups, so
+// anything before the last two segments is the sub-path the instance is served under.
+func newGitea(host string, path string, token string, logger *zap.Logger) (*Gitea, error) {
+	if host == "" {
+		return nil, fmt.Errorf("could not determine Gitea host from repository URL")
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 || segments[len(segments)-2] == "" || segments[len(segments)-1] == "" {
+		return nil, fmt.Errorf("could not determine owner and repository from %q", path)
+	}
+	subPath := strings.Join(segments[:len(segments)-2], "/")
+	if subPath != "" {
+		subPath = "/" + subPath
+	}
+
+	return &Gitea{
+		api:    newGiteaClient("https://"+host+subPath+"/api/v1", token),
+		owner:  segments[len(segments)-2],
+		repo:   segments[len(segments)-1],
+		logger: logger,
+	}, nil
+}
+
+func newGiteaClient(baseURL string, token string) restClient {
+	return restClient{
+		client:        http.DefaultClient,
+		baseURL:       baseURL,
+		authorization: "token " + token,
+		errorMessage:  giteaErrorMessage,
+	}
+}
+
+func (g *Gitea) repoPath() string {
+	return "/repos/" + url.PathEscape(g.owner) + "/" + url.PathEscape(g.repo)
+}
+
+// CreateMergeRequest refuses a draft up front rather than open a pull request that asks for
+// review before its pipeline has run.
+func (g *Gitea) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error) {
+	if draft {
+		return MergeRequest{}, fmt.Errorf("could not open a draft pull request: not supported on Gitea: %w", errors.ErrUnsupported)
+	}
+	body := struct {
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}{
+		Head:  sourceBranch,
+		Base:  targetBranch,
+		Title: title,
+		Body:  description,
+	}
+
+	var pr struct {
+		Number  int64  `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if _, err := g.api.do(ctx, http.MethodPost, g.repoPath()+"/pulls", body, &pr); err != nil {
+		return MergeRequest{}, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return MergeRequest{ID: pr.Number, URL: pr.HTMLURL}, nil
+}
+
+func (g *Gitea) DeleteBranch(ctx context.Context, branch string) error {
+	if _, err := g.api.do(ctx, http.MethodDelete, g.repoPath()+"/branches/"+url.PathEscape(branch), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	return nil
+}
+
+// giteaPageSize is the page size asked for. An instance may cap it lower (MAX_RESPONSE_ITEMS), so
+// only an empty page ends a listing.
+const giteaPageSize = 50
+
+// ListMergeRequests filters on the target branch itself: older Gitea releases have no such query.
+func (g *Gitea) ListMergeRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error) {
+	type ref struct {
+		Ref    string `json:"ref"`
+		RepoID int64  `json:"repo_id"`
+	}
+	var out []MergeRequest
+	for page := 1; ; page++ {
+		var prs []struct {
+			Number  int64  `json:"number"`
+			HTMLURL string `json:"html_url"`
+			Body    string `json:"body"`
+			Head    ref    `json:"head"`
+			Base    ref    `json:"base"`
+		}
+		path := fmt.Sprintf("%s/pulls?state=open&sort=newest&limit=%d&page=%d", g.repoPath(), giteaPageSize, page)
+		if _, err := g.api.do(ctx, http.MethodGet, path, nil, &prs); err != nil {
+			return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		}
+		for _, pr := range prs {
+			if pr.Base.Ref != targetBranch || pr.Head.RepoID != pr.Base.RepoID {
+				continue
+			}
+			out = append(out, MergeRequest{ID: pr.Number, URL: pr.HTMLURL, SourceBranch: pr.Head.Ref, Description: pr.Body})
+		}
+		if len(prs) == 0 {
+			return out, nil
+		}
+	}
+}
+
+func (g *Gitea) UpdateMergeRequest(ctx context.Context, mr MergeRequest, title string, description string) error {
+	body := struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}{Title: title, Body: description}
+	if _, err := g.api.do(ctx, http.MethodPatch, fmt.Sprintf("%s/pulls/%d", g.repoPath(), mr.ID), body, nil); err != nil {
+		return fmt.Errorf("failed to update pull request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// CloseMergeRequest comments through the issues API, which pull requests share their numbers with.
+func (g *Gitea) CloseMergeRequest(ctx context.Context, mr MergeRequest, comment string) error {
+	body := struct {
+		Body string `json:"body"`
+	}{Body: comment}
+	if _, err := g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/issues/%d/comments", g.repoPath(), mr.ID), body, nil); err != nil {
+		return fmt.Errorf("failed to comment on pull request %d: %w", mr.ID, err)
+	}
+	state := struct {
+		State string `json:"state"`
+	}{State: "closed"}
+	if _, err := g.api.do(ctx, http.MethodPatch, fmt.Sprintf("%s/pulls/%d", g.repoPath(), mr.ID), state, nil); err != nil {
+		return fmt.Errorf("failed to close pull request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// GetUser returns the token owner's name and email, empty on failure. With email privacy enabled
+// the API already substitutes the instance's no-reply address.
+func (g *Gitea) GetUser(ctx context.Context) (name string, email string) {
+	var user struct {
+		Login    string `json:"login"`
+		FullName string `json:"full_name"`
+		Email    string `json:"email"`
+	}
+	if _, err := g.api.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		if g.logger != nil {
+			g.logger.Error("failed to get Gitea user", zap.Error(err))
+		}
+		return "", ""
+	}
+	return cmp.Or(user.FullName, user.Login), user.Email
+}
+
+// EnableAutoMerge schedules the merge for when the required checks succeed, using a merge style
+// the repository permits, and removes the branch afterwards as the other platforms do.
+func (g *Gitea) EnableAutoMerge(ctx context.Context, mr MergeRequest) error {
+	var repo giteaRepository
+	if _, err := g.api.do(ctx, http.MethodGet, g.repoPath(), nil, &repo); err != nil {
+		return fmt.Errorf("could not enable auto merge for PR %d: %w", mr.ID, err)
+	}
+
+	body := struct {
+		Do                     string `json:"Do"`
+		MergeWhenChecksSucceed bool   `json:"merge_when_checks_succeed"`
+		DeleteBranchAfterMerge bool   `json:"delete_branch_after_merge"`
+	}{
+		Do:                     giteaMergeStyle(repo),
+		MergeWhenChecksSucceed: true,
+		DeleteBranchAfterMerge: true,
+	}
+	path := fmt.Sprintf("%s/pulls/%d/merge", g.repoPath(), mr.ID)
+	if _, err := g.api.do(ctx, http.MethodPost, path, body, nil); err != nil {
+		return fmt.Errorf("could not enable auto merge for PR %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// giteaRepository is the part of the repository settings that decides the merge style.
+type giteaRepository struct {
+	AllowMergeCommits bool `json:"allow_merge_commits"`
+	AllowSquashMerge  bool `json:"allow_squash_merge"`
+	AllowRebase       bool `json:"allow_rebase"`
+}
+
+// giteaMergeStyle mirrors mergeMethodFor: requesting a disabled style is rejected outright.
+func giteaMergeStyle(repo giteaRepository) string {
+	switch {
+	case repo.AllowMergeCommits:
+		return "merge"
+	case repo.AllowSquashMerge:
+		return "squash"
+	case repo.AllowRebase:
+		return "rebase"
+	default:
+		return "merge"
+	}
+}
+
+// giteaErrorMessage extracts "message" from an error body, falling back to the raw body.
+func giteaErrorMessage(payload []byte) string {
+	var parsed struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(payload, &parsed); err == nil && parsed.Message != "" {
+		return parsed.Message
+	}
+	return strings.TrimSpace(string(payload))
+}
+
+// SetMergeRequestMetadata is not implemented for Gitea: the run warns and leaves the request as opened.
+func (g *Gitea) SetMergeRequestMetadata(_ context.Context, mr MergeRequest, _ MergeRequestMetadata) error {
+	return fmt.Errorf("could not set labels, assignees, reviewers or milestone of PR %d: not supported on Gitea: %w", mr.ID, errors.ErrUnsupported)
+}
+
+// PipelineStatus is not implemented for Gitea: only promoting a draft reads it, and
+// CreateMergeRequest opens none here.
+func (g *Gitea) PipelineStatus(_ context.Context, mr MergeRequest) (PipelineStatus, error) {
+	return "", fmt.Errorf("could not read the checks of PR %d: not supported on Gitea: %w", mr.ID, errors.ErrUnsupported)
+}
+
+// MarkMergeRequestReady is not implemented for Gitea, where no draft is opened.
+func (g *Gitea) MarkMergeRequestReady(_ context.Context, mr MergeRequest) error {
+	return fmt.Errorf("could not mark PR %d ready for review: not supported on Gitea: %w", mr.ID, errors.ErrUnsupported)
+}
@@ -0,0 +1,297 @@
+package codehosting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewGitea(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		path      string
+		wantBase  string
+		wantOwner string
+		wantRepo  string
+	}{
+		{
+			name:      "served from the root",
+			host:      "git.example.com",
+			path:      "acme/site",
+			wantBase:  "https://git.example.com/api/v1",
+			wantOwner: "acme",
+			wantRepo:  "site",
+		},
+		{
+			name:      "served under a sub-path",
+			host:      "example.com",
+			path:      "gitea/acme/site",
+			wantBase:  "https://example.com/gitea/api/v1",
+			wantOwner: "acme",
+			wantRepo:  "site",
+		},
+		{
+			name:      "keeps a port",
+			host:      "git.example.com:3000",
+			path:      "acme/site",
+			wantBase:  "https://git.example.com:3000/api/v1",
+			wantOwner: "acme",
+			wantRepo:  "site",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zap.NewNop()
+			g, err := newGitea(tt.host, tt.path, "dummy-token", logger)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantBase, g.api.baseURL)
+			assert.Equal(t, tt.wantOwner, g.owner)
+			assert.Equal(t, tt.wantRepo, g.repo)
+			assert.Equal(t, "token dummy-token", g.api.authorization)
+			assert.Same(t, logger, g.logger)
+		})
+	}
+}
+
+func TestNewGitea_InvalidPath(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		path string
+	}{
+		{name: "no host", host: "", path: "acme/site"},
+		{name: "no repo", host: "git.example.com", path: "acme"},
+		{name: "empty owner", host: "git.example.com", path: "/site"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newGitea(tt.host, tt.path, "dummy-token", zap.NewNop())
+			require.Error(t, err)
+		})
+	}
+}
+
+func newTestGitea(serverURL string) *Gitea {
+	return &Gitea{
+		api:    newGiteaClient(serverURL, "s3cr3t"),
+		owner:  "acme",
+		repo:   "site",
+		logger: zap.NewNop(),
+	}
+}
+
+func TestGitea_CreateMergeRequest(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/acme/site/pulls" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "token s3cr3t", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		_, _ = w.Write([]byte(`{"number": 7, "html_url": "https://git.example.com/acme/site/pulls/7"}`))
+	}))
+	defer server.Close()
+
+	mr, err := newTestGitea(server.URL).CreateMergeRequest(context.Background(), "Title", "Body", "update-abc", "main", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, MergeRequest{ID: 7, URL: "https://git.example.com/acme/site/pulls/7"}, mr)
+	assert.Equal(t, map[string]any{"head": "update-abc", "base": "main", "title": "Title", "body": "Body"}, sent)
+}
+
+func TestGitea_CreateMergeRequest_ReportsTheAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"message": "pull request already exists for these targets", "url": "https://git.example.com/api/swagger"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestGitea(server.URL).CreateMergeRequest(context.Background(), "Title", "Body", "update-abc", "main", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "409 pull request already exists for these targets")
+}
+
+// Opening a regular request instead would ask for review before the pipeline ran, the one thing
+// a draft was asked for to prevent.
+func TestGitea_CreateMergeRequest_RefusesADraft(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("nothing may be opened")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	_, err := newTestGitea(server.URL).CreateMergeRequest(context.Background(), "Title", "Body", "update-abc", "main", true)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestGitea_DeleteBranch(t *testing.T) {
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	require.NoError(t, newTestGitea(server.URL).DeleteBranch(context.Background(), "update-abc"))
+	assert.Equal(t, http.MethodDelete, method)
+	assert.Equal(t, "/repos/acme/site/branches/update-abc", path)
+}
+
+func TestGitea_GetUser(t *testing.T) {
+	t.Run("prefers the full name", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"login": "bot", "full_name": "Update Bot", "email": "bot@example.com"}`))
+		}))
+		defer server.Close()
+
+		name, email := newTestGitea(server.URL).GetUser(context.Background())
+		assert.Equal(t, "Update Bot", name)
+		assert.Equal(t, "bot@example.com", email)
+	})
+
+	t.Run("falls back to the login", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"login": "bot", "full_name": "", "email": "bot@noreply.example.com"}`))
+		}))
+		defer server.Close()
+
+		name, _ := newTestGitea(server.URL).GetUser(context.Background())
+		assert.Equal(t, "bot", name)
+	})
+
+	t.Run("an unauthenticated token yields nothing", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		name, email := newTestGitea(server.URL).GetUser(context.Background())
+		assert.Empty(t, name)
+		assert.Empty(t, email)
+	})
+}
+
+func TestGitea_EnableAutoMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      string
+		wantStyle string
+	}{
+		{name: "merge commits allowed", repo: `{"allow_merge_commits": true, "allow_squash_merge": true}`, wantStyle: "merge"},
+		{name: "squash only", repo: `{"allow_merge_commits": false, "allow_squash_merge": true}`, wantStyle: "squash"},
+		{name: "rebase only", repo: `{"allow_rebase": true}`, wantStyle: "rebase"},
+		{name: "nothing reported", repo: `{}`, wantStyle: "merge"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/site":
+					_, _ = w.Write([]byte(tt.repo))
+				case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/site/pulls/7/merge":
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			require.NoError(t, newTestGitea(server.URL).EnableAutoMerge(context.Background(), MergeRequest{ID: 7}))
+			assert.Equal(t, tt.wantStyle, sent["Do"])
+			assert.Equal(t, true, sent["merge_when_checks_succeed"])
+			assert.Equal(t, true, sent["delete_branch_after_merge"])
+		})
+	}
+
+	t.Run("reports a rejected merge", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"allow_merge_commits": true}`))
+				return
+			}
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_, _ = w.Write([]byte(`{"message": "Please try again later"}`))
+		}))
+		defer server.Close()
+
+		err := newTestGitea(server.URL).EnableAutoMerge(context.Background(), MergeRequest{ID: 7})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "could not enable auto merge for PR 7")
+		assert.Contains(t, err.Error(), "Please try again later")
+	})
+}
+
+func TestGitea_ListMergeRequests(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/repos/acme/site/pulls" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		pages = append(pages, r.URL.Query().Get("page"))
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"number": 3, "html_url": "https://git.example.com/acme/site/pulls/3", "body": "mine", "head": {"ref": "update-b", "repo_id": 1}, "base": {"ref": "main", "repo_id": 1}},
+			{"number": 2, "html_url": "https://git.example.com/acme/site/pulls/2", "body": "other base", "head": {"ref": "update-c", "repo_id": 1}, "base": {"ref": "develop", "repo_id": 1}},
+			{"number": 1, "html_url": "https://git.example.com/acme/site/pulls/1", "body": "fork", "head": {"ref": "update-a", "repo_id": 9}, "base": {"ref": "main", "repo_id": 1}}
+		]`))
+	}))
+	defer server.Close()
+
+	mrs, err := newTestGitea(server.URL).ListMergeRequests(context.Background(), "main")
+	require.NoError(t, err)
+	assert.Equal(t, []MergeRequest{{ID: 3, URL: "https://git.example.com/acme/site/pulls/3", SourceBranch: "update-b", Description: "mine"}}, mrs)
+	assert.Equal(t, []string{"1", "2"}, pages)
+}
+
+func TestGitea_UpdateMergeRequest(t *testing.T) {
+	var method, path string
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		_, _ = w.Write([]byte(`{"number": 3}`))
+	}))
+	defer server.Close()
+
+	require.NoError(t, newTestGitea(server.URL).UpdateMergeRequest(context.Background(), MergeRequest{ID: 3}, "New title", "New body"))
+	assert.Equal(t, http.MethodPatch, method)
+	assert.Equal(t, "/repos/acme/site/pulls/3", path)
+	assert.Equal(t, map[string]any{"title": "New title", "body": "New body"}, sent)
+}
+
+func TestGitea_CloseMergeRequest(t *testing.T) {
+	var comment, state map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/site/issues/3/comments":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/acme/site/pulls/3":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&state))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	require.NoError(t, newTestGitea(server.URL).CloseMergeRequest(context.Background(), MergeRequest{ID: 3}, "Superseded by #4."))
+	assert.Equal(t, map[string]any{"body": "Superseded by #4."}, comment)
+	assert.Equal(t, map[string]any{"state": "closed"}, state)
+}
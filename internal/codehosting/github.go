@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/google/go-github/v68/github"
@@ -17,35 +19,49 @@ type Github struct {
 	logger *zap.Logger
 }
 
-// newGithub builds a GitHub platform from an "owner/repo" path.
-func newGithub(path string, token string, logger *zap.Logger) (*Github, error) {
+// newGithub builds a GitHub platform from an "owner/repo" path. An empty apiURL means github.com;
+// anything else is an Enterprise REST root, and uploads go to the matching Enterprise endpoint.
+func newGithub(path string, apiURL string, token string, logger *zap.Logger) (*Github, error) {
 	owner, repo, found := strings.Cut(strings.Trim(path, "/"), "/")
 	if !found || owner == "" || repo == "" {
 		return nil, fmt.Errorf("could not determine owner and repository from %q", path)
 	}
 
+	client := github.NewClient(nil).WithAuthToken(token)
+	if apiURL != "" {
+		var err error
+		// The upload root is derived the same way from the same base: go-github appends
+		// "api/uploads/" where it appends "api/v3/".
+		client, err = client.WithEnterpriseURLs(apiURL, strings.TrimSuffix(strings.TrimSuffix(apiURL, "/"), "/api/v3"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %q: %w", apiURL, err)
+		}
+	}
+
 	return &Github{
-		client: github.NewClient(nil).WithAuthToken(token),
+		client: client,
 		owner:  owner,
 		repo:   repo,
 		logger: logger,
 	}, nil
 }
 
-func (g *Github) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (MergeRequest, error) {
+func (g *Github) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error) {
 	mr, _, err := g.client.PullRequests.Create(ctx, g.owner, g.repo, &github.NewPullRequest{
 		Head:  &sourceBranch,
 		Base:  &targetBranch,
 		Title: &title,
 		Body:  &description,
+		Draft: &draft,
 	})
 
 	if err != nil {
 		return MergeRequest{}, fmt.Errorf("failed to create pull request: %w", err)
 	}
 	return MergeRequest{
-		ID:  int64(mr.GetNumber()),
-		URL: mr.GetHTMLURL(),
+		ID:    int64(mr.GetNumber()),
+		URL:   mr.GetHTMLURL(),
+		Draft: mr.GetDraft(),
 	}, nil
 }
 
@@ -58,6 +74,151 @@ func (g *Github) DeleteBranch(ctx context.Context, branch string) error {
 	return nil
 }
 
+func (g *Github) ListMergeRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		Base:        targetBranch,
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var out []MergeRequest
+	for {
+		prs, resp, err := g.client.PullRequests.List(ctx, g.owner, g.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		}
+		for _, pr := range prs {
+			if pr.GetHead().GetRepo().GetID() != pr.GetBase().GetRepo().GetID() {
+				continue
+			}
+			out = append(out, MergeRequest{
+				ID:           int64(pr.GetNumber()),
+				URL:          pr.GetHTMLURL(),
+				SourceBranch: pr.GetHead().GetRef(),
+				Description:  pr.GetBody(),
+				Draft:        pr.GetDraft(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (g *Github) UpdateMergeRequest(ctx context.Context, mr MergeRequest, title string, description string) error {
+	_, _, err := g.client.PullRequests.Edit(ctx, g.owner, g.repo, int(mr.ID), &github.PullRequest{
+		Title: &title,
+		Body:  &description,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update pull request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// CloseMergeRequest comments through the Issues API: a pull request is an issue to GitHub, and
+// its own review comments must be anchored to a line.
+func (g *Github) CloseMergeRequest(ctx context.Context, mr MergeRequest, comment string) error {
+	if _, _, err := g.client.Issues.CreateComment(ctx, g.owner, g.repo, int(mr.ID), &github.IssueComment{Body: &comment}); err != nil {
+		return fmt.Errorf("failed to comment on pull request %d: %w", mr.ID, err)
+	}
+	if _, _, err := g.client.PullRequests.Edit(ctx, g.owner, g.repo, int(mr.ID), &github.PullRequest{State: github.Ptr("closed")}); err != nil {
+		return fmt.Errorf("failed to close pull request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// newLabelColor is the colour GitHub gets for a label SetMergeRequestMetadata creates: its own
+// default grey, so the label reads as unstyled until a person picks one.
+const newLabelColor = "ededed"
+
+// SetMergeRequestMetadata goes through the Issues API for all but reviewers: to GitHub a pull
+// request's labels, assignees and milestone are its issue's.
+func (g *Github) SetMergeRequestMetadata(ctx context.Context, mr MergeRequest, metadata MergeRequestMetadata) error {
+	number := int(mr.ID)
+	var errs []error
+
+	if len(metadata.Labels) > 0 {
+		if err := g.ensureLabels(ctx, metadata.Labels); err != nil {
+			errs = append(errs, err)
+		} else if _, _, err := g.client.Issues.AddLabelsToIssue(ctx, g.owner, g.repo, number, metadata.Labels); err != nil {
+			errs = append(errs, fmt.Errorf("failed to label pull request %d: %w", mr.ID, err))
+		}
+	}
+	if len(metadata.Assignees) > 0 {
+		if _, _, err := g.client.Issues.AddAssignees(ctx, g.owner, g.repo, number, metadata.Assignees); err != nil {
+			errs = append(errs, fmt.Errorf("failed to assign pull request %d: %w", mr.ID, err))
+		}
+	}
+	if len(metadata.Reviewers) > 0 {
+		var request github.ReviewersRequest
+		for _, reviewer := range metadata.Reviewers {
+			if _, team, ok := strings.Cut(reviewer, "/"); ok {
+				request.TeamReviewers = append(request.TeamReviewers, team)
+			} else {
+				request.Reviewers = append(request.Reviewers, reviewer)
+			}
+		}
+		if _, _, err := g.client.PullRequests.RequestReviewers(ctx, g.owner, g.repo, number, request); err != nil {
+			errs = append(errs, fmt.Errorf("failed to request reviewers for pull request %d: %w", mr.ID, err))
+		}
+	}
+	if metadata.Milestone != "" {
+		if err := g.setMilestone(ctx, number, metadata.Milestone); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ensureLabels creates the labels the repository does not have yet, rather than rely on what
+// adding an unknown label to an issue does.
+func (g *Github) ensureLabels(ctx context.Context, labels []string) error {
+	for _, name := range labels {
+		_, resp, err := g.client.Issues.GetLabel(ctx, g.owner, g.repo, name)
+		if err == nil {
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to look up label %q: %w", name, err)
+		}
+		if _, _, err := g.client.Issues.CreateLabel(ctx, g.owner, g.repo, &github.Label{Name: github.Ptr(name), Color: github.Ptr(newLabelColor)}); err != nil {
+			return fmt.Errorf("failed to create label %q: %w", name, err)
+		}
+		if g.logger != nil {
+			g.logger.Info("label created", zap.String("label", name))
+		}
+	}
+	return nil
+}
+
+// setMilestone finds the open milestone titled title and sets it on the pull request.
+func (g *Github) setMilestone(ctx context.Context, number int, title string) error {
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		milestones, resp, err := g.client.Issues.ListMilestones(ctx, g.owner, g.repo, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list milestones: %w", err)
+		}
+		for _, m := range milestones {
+			if m.GetTitle() != title {
+				continue
+			}
+			if _, _, err := g.client.Issues.Edit(ctx, g.owner, g.repo, number, &github.IssueRequest{Milestone: github.Ptr(m.GetNumber())}); err != nil {
+				return fmt.Errorf("failed to set milestone of pull request %d: %w", number, err)
+			}
+			return nil
+		}
+		if resp.NextPage == 0 {
+			return fmt.Errorf("no open milestone titled %q", title)
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
 // GetUser returns the authenticated user's name and email, empty on failure. An Actions token
 // cannot read /user, so it falls back to the github-actions[bot] identity rather than need a PAT.
 func (g *Github) GetUser(ctx context.Context) (name string, email string) {
@@ -79,32 +240,56 @@ func (g *Github) GetUser(ctx context.Context) (name string, email string) {
 	return user.GetName(), email
 }
 
-// EnableAutoMerge merges the PR once every required status check passes. The "graphql" path
-// resolves only for github.com; Enterprise serves it outside the /api/v3/ prefix.
+// EnableAutoMerge merges the PR once every required status check passes.
 func (g *Github) EnableAutoMerge(ctx context.Context, mr MergeRequest) error {
 	pr, _, err := g.client.PullRequests.Get(ctx, g.owner, g.repo, int(mr.ID))
 	if err != nil {
 		return fmt.Errorf("could not enable auto merge for PR %d: %w", mr.ID, err)
 	}
 
-	body := struct {
-		Query     string         `json:"query"`
-		Variables map[string]any `json:"variables"`
-	}{
-		Query: `mutation($prId: ID!, $mergeMethod: PullRequestMergeMethod!) {
+	err = g.mutate(ctx, `mutation($prId: ID!, $mergeMethod: PullRequestMergeMethod!) {
 			enablePullRequestAutoMerge(input: {pullRequestId: $prId, mergeMethod: $mergeMethod}) {
 				pullRequest { autoMergeRequest { mergeMethod } }
 			}
-		}`,
-		Variables: map[string]any{
-			"prId":        pr.GetNodeID(),
-			"mergeMethod": mergeMethodFor(pr.GetBase().GetRepo()),
-		},
+		}`, map[string]any{
+		"prId":        pr.GetNodeID(),
+		"mergeMethod": mergeMethodFor(pr.GetBase().GetRepo()),
+	})
+	if err != nil {
+		return fmt.Errorf("could not enable auto merge for PR %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// MarkMergeRequestReady goes through GraphQL: the REST API can open a draft, but not promote one.
+func (g *Github) MarkMergeRequestReady(ctx context.Context, mr MergeRequest) error {
+	pr, _, err := g.client.PullRequests.Get(ctx, g.owner, g.repo, int(mr.ID))
+	if err != nil {
+		return fmt.Errorf("could not mark PR %d ready for review: %w", mr.ID, err)
 	}
 
-	req, err := g.client.NewRequest("POST", "graphql", body)
+	err = g.mutate(ctx, `mutation($prId: ID!) {
+			markPullRequestReadyForReview(input: {pullRequestId: $prId}) {
+				pullRequest { isDraft }
+			}
+		}`, map[string]any{"prId": pr.GetNodeID()})
 	if err != nil {
-		return fmt.Errorf("could not enable auto merge for PR %d: %w", mr.ID, err)
+		return fmt.Errorf("could not mark PR %d ready for review: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// mutate runs a GraphQL mutation. GraphQL reports a failed one with status 200 and an errors
+// list, every entry of which ends up in the error.
+func (g *Github) mutate(ctx context.Context, query string, variables map[string]any) error {
+	body := struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}{Query: query, Variables: variables}
+
+	req, err := g.client.NewRequest("POST", graphqlURL(g.client.BaseURL), body)
+	if err != nil {
+		return err
 	}
 
 	var result struct {
@@ -113,18 +298,86 @@ func (g *Github) EnableAutoMerge(ctx context.Context, mr MergeRequest) error {
 		} `json:"errors"`
 	}
 	if _, err = g.client.Do(ctx, req, &result); err != nil {
-		return fmt.Errorf("could not enable auto merge for PR %d: %w", mr.ID, err)
+		return err
 	}
 	if len(result.Errors) > 0 {
 		messages := make([]string, 0, len(result.Errors))
 		for _, e := range result.Errors {
 			messages = append(messages, e.Message)
 		}
-		return fmt.Errorf("could not enable auto merge for PR %d: %s", mr.ID, strings.Join(messages, "; "))
+		return errors.New(strings.Join(messages, "; "))
 	}
 	return nil
 }
 
+// PipelineStatus combines both ways a GitHub commit is checked: check runs, which Actions and
+// most CI apps report, and the older commit statuses.
+func (g *Github) PipelineStatus(ctx context.Context, mr MergeRequest) (PipelineStatus, error) {
+	pr, _, err := g.client.PullRequests.Get(ctx, g.owner, g.repo, int(mr.ID))
+	if err != nil {
+		return "", fmt.Errorf("could not read the checks of PR %d: %w", mr.ID, err)
+	}
+	sha := pr.GetHead().GetSHA()
+
+	var statuses []PipelineStatus
+	combined, _, err := g.client.Repositories.GetCombinedStatus(ctx, g.owner, g.repo, sha, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", fmt.Errorf("could not read the checks of PR %d: %w", mr.ID, err)
+	}
+	// A commit nothing has reported on is "pending" too; TotalCount tells the two apart.
+	if combined.GetTotalCount() > 0 {
+		switch combined.GetState() {
+		case "success":
+			statuses = append(statuses, PipelineSuccess)
+		case "pending":
+			statuses = append(statuses, PipelinePending)
+		default:
+			statuses = append(statuses, PipelineFailed)
+		}
+	}
+
+	opts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := g.client.Checks.ListCheckRunsForRef(ctx, g.owner, g.repo, sha, opts)
+		if err != nil {
+			return "", fmt.Errorf("could not read the checks of PR %d: %w", mr.ID, err)
+		}
+		for _, run := range runs.CheckRuns {
+			statuses = append(statuses, checkRunStatus(run))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return combinePipelineStatuses(statuses), nil
+}
+
+// checkRunStatus reads one check run. neutral and skipped are how GitHub reports a check that
+// chose not to judge the commit, which blocks nothing.
+func checkRunStatus(run *github.CheckRun) PipelineStatus {
+	if run.GetStatus() != "completed" {
+		return PipelinePending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return PipelineSuccess
+	default:
+		return PipelineFailed
+	}
+}
+
+// graphqlURL is the GraphQL endpoint next to a REST root. github.com and data-residency hosts
+// serve it beside the REST API; Enterprise Server serves it at /api/graphql, outside /api/v3/.
+func graphqlURL(base *url.URL) string {
+	if prefix, found := strings.CutSuffix(base.Path, "/api/v3/"); found {
+		endpoint := *base
+		endpoint.Path = prefix + "/api/graphql"
+		return endpoint.String()
+	}
+	return base.JoinPath("graphql").String()
+}
+
 // mergeMethodFor picks a method the repository permits: requesting a disallowed one fails the
 // mutation. With no flags set it falls back to MERGE, whose rejection at least names the problem.
 func mergeMethodFor(repo *github.Repository) string {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/google/go-github/v68/github"
@@ -15,14 +16,14 @@ import (
 )
 
 func TestGithub_GetOwner(t *testing.T) {
-	gh, err := newGithub("owner/repo", "dummy-token", zap.NewNop())
+	gh, err := newGithub("owner/repo", "", "dummy-token", zap.NewNop())
 	require.NoError(t, err)
 
 	assert.Equal(t, "owner", gh.owner)
 }
 
 func TestGithub_GetRepo(t *testing.T) {
-	gh, err := newGithub("owner/repo", "dummy-token", zap.NewNop())
+	gh, err := newGithub("owner/repo", "", "dummy-token", zap.NewNop())
 	require.NoError(t, err)
 
 	assert.Equal(t, "repo", gh.repo)
@@ -42,7 +43,7 @@ func TestNewGithub_InvalidPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := newGithub(tt.path, "dummy-token", zap.NewNop())
+			_, err := newGithub(tt.path, "", "dummy-token", zap.NewNop())
 			require.Error(t, err)
 		})
 	}
@@ -51,17 +52,80 @@ func TestNewGithub_InvalidPath(t *testing.T) {
 func TestNewGithub_WiresUpTheClient(t *testing.T) {
 	logger := zap.NewNop()
 
-	gh, err := newGithub("owner/repo", "dummy-token", logger)
+	gh, err := newGithub("owner/repo", "", "dummy-token", logger)
 	require.NoError(t, err)
 
 	// The surrounding API tests build a Github literal directly, so nothing else asserts what
 	// the constructor actually populates.
 	assert.NotNil(t, gh.client)
+	assert.Equal(t, "https://api.github.com/", gh.client.BaseURL.String())
 	assert.Equal(t, "owner", gh.owner)
 	assert.Equal(t, "repo", gh.repo)
 	assert.Same(t, logger, gh.logger)
 }
 
+func TestNewGithub_EnterpriseURLs(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiURL     string
+		wantBase   string
+		wantUpload string
+	}{
+		{
+			name:       "Enterprise Server REST root",
+			apiURL:     "https://github.example.com/api/v3/",
+			wantBase:   "https://github.example.com/api/v3/",
+			wantUpload: "https://github.example.com/api/uploads/",
+		},
+		{
+			name:       "Enterprise Server REST root without the trailing slash",
+			apiURL:     "https://github.example.com/api/v3",
+			wantBase:   "https://github.example.com/api/v3/",
+			wantUpload: "https://github.example.com/api/uploads/",
+		},
+		{
+			name:       "Enterprise Cloud with data residency",
+			apiURL:     "https://api.acme.ghe.com/",
+			wantBase:   "https://api.acme.ghe.com/",
+			wantUpload: "https://api.acme.ghe.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh, err := newGithub("owner/repo", tt.apiURL, "dummy-token", zap.NewNop())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBase, gh.client.BaseURL.String())
+			assert.Equal(t, tt.wantUpload, gh.client.UploadURL.String())
+		})
+	}
+
+	t.Run("rejects an unparseable URL", func(t *testing.T) {
+		_, err := newGithub("owner/repo", "https://github.example.com/%zz", "dummy-token", zap.NewNop())
+		require.Error(t, err)
+	})
+}
+
+func TestGraphqlURL(t *testing.T) {
+	tests := map[string]struct {
+		base string
+		want string
+	}{
+		"github.com":             {"https://api.github.com/", "https://api.github.com/graphql"},
+		"data residency":         {"https://api.acme.ghe.com/", "https://api.acme.ghe.com/graphql"},
+		"Enterprise Server":      {"https://github.example.com/api/v3/", "https://github.example.com/api/graphql"},
+		"Enterprise Server port": {"https://github.example.com:8443/api/v3/", "https://github.example.com:8443/api/graphql"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			base, err := url.Parse(tt.base)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, graphqlURL(base))
+		})
+	}
+}
+
 func TestGithub_CreateMergeRequest(t *testing.T) {
 	var sent struct {
 		Head  string `json:"head"`
@@ -97,7 +161,7 @@ func TestGithub_CreateMergeRequest(t *testing.T) {
 		repo:   "test_project",
 	}
 
-	mr, err := gh.CreateMergeRequest(context.Background(), "Test MR", "This is a test MR", "source-branch", "target-branch")
+	mr, err := gh.CreateMergeRequest(context.Background(), "Test MR", "This is a test MR", "source-branch", "target-branch", false)
 
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), mr.ID)
@@ -186,7 +250,7 @@ func TestGithub_CreateMergeRequest_HonorsContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	_, err := gh.CreateMergeRequest(ctx, "Test MR", "body", "source", "target")
+	_, err := gh.CreateMergeRequest(ctx, "Test MR", "body", "source", "target", false)
 	require.ErrorIs(t, err, context.Canceled)
 }
 
@@ -247,7 +311,7 @@ func TestGithub_DeleteBranch_HonorsContext(t *testing.T) {
 }
 
 // newAutoMergePRServer serves the PR endpoint and a successful GraphQL mutation, capturing the
-// mergeMethod that was sent. WithEnterpriseURLs puts "graphql" under /api/v3/, unlike real GHES.
+// mergeMethod that was sent. The paths are Enterprise Server's, GraphQL outside /api/v3/.
 func newAutoMergePRServer(t *testing.T, baseRepoJSON string, gotMethod *string) *httptest.Server {
 	t.Helper()
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -256,7 +320,7 @@ func newAutoMergePRServer(t *testing.T, baseRepoJSON string, gotMethod *string)
 		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/owner/repo/pulls/1":
 			w.WriteHeader(http.StatusOK)
 			_, _ = w.Write([]byte(`{"number":1,"node_id":"PR_kwDOABCDEF123","base":{"repo":` + baseRepoJSON + `}}`))
-		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/graphql":
+		case r.Method == http.MethodPost && r.URL.Path == "/api/graphql":
 			var body struct {
 				Variables struct {
 					MergeMethod string `json:"mergeMethod"`
@@ -322,7 +386,7 @@ func TestGithub_EnableAutoMerge_GraphQLError(t *testing.T) {
 		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/owner/repo/pulls/1":
 			w.WriteHeader(http.StatusOK)
 			_, _ = w.Write([]byte(`{"number":1,"node_id":"PR_kwDOABCDEF123"}`))
-		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/graphql":
+		case r.Method == http.MethodPost && r.URL.Path == "/api/graphql":
 			w.WriteHeader(http.StatusOK)
 			_, _ = w.Write([]byte(`{"errors":[{"message":"Auto-merge is not allowed for this repository"},{"message":"second problem"}]}`))
 		default:
@@ -380,3 +444,246 @@ func TestIsGitHubActionsToken403_ReturnsTrueWhenRespFallback(t *testing.T) {
 	resp := &github.Response{Response: &http.Response{StatusCode: http.StatusForbidden}}
 	assert.True(t, isGitHubActionsToken403(resp, ghErr))
 }
+
+func TestGithub_ListMergeRequests(t *testing.T) {
+	var query url.Values
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v3/repos/test_owner/test_project/pulls" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"number": 2, "html_url": "https://github.com/pr/2", "body": "mine", "head": {"ref": "update-b", "repo": {"id": 1}}, "base": {"repo": {"id": 1}}},
+			{"number": 1, "html_url": "https://github.com/pr/1", "body": "fork", "head": {"ref": "update-a", "repo": {"id": 9}}, "base": {"repo": {"id": 1}}}
+		]`))
+	}))
+	defer mockServer.Close()
+
+	client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+	gh := &Github{client: client, owner: "test_owner", repo: "test_project"}
+
+	mrs, err := gh.ListMergeRequests(context.Background(), "main")
+	require.NoError(t, err)
+	assert.Equal(t, []MergeRequest{{ID: 2, URL: "https://github.com/pr/2", SourceBranch: "update-b", Description: "mine"}}, mrs)
+	assert.Equal(t, "open", query.Get("state"))
+	assert.Equal(t, "main", query.Get("base"))
+}
+
+func TestGithub_UpdateMergeRequest(t *testing.T) {
+	var sent map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/api/v3/repos/test_owner/test_project/pulls/2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"number": 2}`))
+	}))
+	defer mockServer.Close()
+
+	client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+	gh := &Github{client: client, owner: "test_owner", repo: "test_project"}
+
+	require.NoError(t, gh.UpdateMergeRequest(context.Background(), MergeRequest{ID: 2}, "New title", "New body"))
+	assert.Equal(t, map[string]any{"title": "New title", "body": "New body"}, sent)
+}
+
+func TestGithub_CloseMergeRequest(t *testing.T) {
+	var comment, state map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/test_owner/test_project/issues/2/comments":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v3/repos/test_owner/test_project/pulls/2":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&state))
+			_, _ = w.Write([]byte(`{"number": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+	gh := &Github{client: client, owner: "test_owner", repo: "test_project"}
+
+	require.NoError(t, gh.CloseMergeRequest(context.Background(), MergeRequest{ID: 2}, "Superseded by #3."))
+	assert.Equal(t, map[string]any{"body": "Superseded by #3."}, comment)
+	assert.Equal(t, map[string]any{"state": "closed"}, state)
+}
+
+func TestGithub_SetMergeRequestMetadata(t *testing.T) {
+	sent := map[string]any{}
+	var created []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		const repo = "/api/v3/repos/test_owner/test_project"
+		record := func(response string) {
+			var body any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			sent[r.Method+" "+r.URL.Path] = body
+			_, _ = w.Write([]byte(response))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == repo+"/labels/dependencies":
+			_, _ = w.Write([]byte(`{"name": "dependencies"}`))
+		case r.Method == http.MethodGet && r.URL.Path == repo+"/labels/security":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == repo+"/labels":
+			var label map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&label))
+			created = append(created, label["name"].(string))
+			assert.Equal(t, newLabelColor, label["color"])
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && r.URL.Path == repo+"/milestones":
+			assert.Equal(t, "open", r.URL.Query().Get("state"))
+			_, _ = w.Write([]byte(`[{"number": 3, "title": "Sprint 41"}, {"number": 4, "title": "Sprint 42"}]`))
+		case r.Method == http.MethodPost && r.URL.Path == repo+"/issues/2/labels":
+			record(`[]`)
+		case r.Method == http.MethodPost && r.URL.Path == repo+"/issues/2/assignees",
+			r.Method == http.MethodPost && r.URL.Path == repo+"/pulls/2/requested_reviewers",
+			r.Method == http.MethodPatch && r.URL.Path == repo+"/issues/2":
+			record(`{"number": 2}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+	gh := &Github{client: client, owner: "test_owner", repo: "test_project", logger: zap.NewNop()}
+
+	require.NoError(t, gh.SetMergeRequestMetadata(context.Background(), MergeRequest{ID: 2}, MergeRequestMetadata{
+		Labels:    []string{"dependencies", "security"},
+		Assignees: []string{"alice"},
+		Reviewers: []string{"bob", "acme/maintainers"},
+		Milestone: "Sprint 42",
+	}))
+
+	assert.Equal(t, []string{"security"}, created, "only the missing label is created")
+	assert.Equal(t, []any{"dependencies", "security"}, sent["POST /api/v3/repos/test_owner/test_project/issues/2/labels"])
+	assert.Equal(t, map[string]any{"assignees": []any{"alice"}}, sent["POST /api/v3/repos/test_owner/test_project/issues/2/assignees"])
+	assert.Equal(t, map[string]any{"reviewers": []any{"bob"}, "team_reviewers": []any{"maintainers"}},
+		sent["POST /api/v3/repos/test_owner/test_project/pulls/2/requested_reviewers"])
+	assert.Equal(t, map[string]any{"milestone": float64(4)}, sent["PATCH /api/v3/repos/test_owner/test_project/issues/2"])
+}
+
+// Every part is attempted, so one that fails does not cost the others.
+func TestGithub_SetMergeRequestMetadata_JoinsErrors(t *testing.T) {
+	var assigned bool
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/test_owner/test_project/milestones":
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/test_owner/test_project/issues/2/assignees":
+			assigned = true
+			_, _ = w.Write([]byte(`{"number": 2}`))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+	gh := &Github{client: client, owner: "test_owner", repo: "test_project"}
+
+	err := gh.SetMergeRequestMetadata(context.Background(), MergeRequest{ID: 2}, MergeRequestMetadata{
+		Reviewers: []string{"bob"},
+		Assignees: []string{"alice"},
+		Milestone: "Sprint 42",
+	})
+
+	require.Error(t, err)
+	assert.True(t, assigned)
+	assert.Contains(t, err.Error(), "failed to request reviewers for pull request 2")
+	assert.Contains(t, err.Error(), `no open milestone titled "Sprint 42"`)
+}
+
+func TestGithub_CreateMergeRequest_Draft(t *testing.T) {
+	var sent map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"number": 1, "html_url": "http://example.com", "draft": true}`))
+	}))
+	defer mockServer.Close()
+
+	client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+	gh := &Github{client: client, owner: "test_owner", repo: "test_project"}
+
+	mr, err := gh.CreateMergeRequest(context.Background(), "Test MR", "body", "source", "target", true)
+	require.NoError(t, err)
+	assert.True(t, mr.Draft)
+	assert.Equal(t, true, sent["draft"])
+}
+
+func TestGithub_PipelineStatus(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status    string
+		checkRuns string
+		want      PipelineStatus
+	}{
+		"nothing reported yet":          {`{"state": "pending", "total_count": 0}`, `[]`, PipelineNone},
+		"every check passed":            {`{"state": "success", "total_count": 1}`, `[{"status": "completed", "conclusion": "success"}, {"status": "completed", "conclusion": "skipped"}]`, PipelineSuccess},
+		"only check runs, one running":  {`{"state": "pending", "total_count": 0}`, `[{"status": "completed", "conclusion": "success"}, {"status": "in_progress"}]`, PipelinePending},
+		"a status still pending":        {`{"state": "pending", "total_count": 2}`, `[{"status": "completed", "conclusion": "success"}]`, PipelinePending},
+		"a failure outweighs a pending": {`{"state": "pending", "total_count": 1}`, `[{"status": "completed", "conclusion": "timed_out"}]`, PipelineFailed},
+		"a failed status":               {`{"state": "failure", "total_count": 1}`, `[]`, PipelineFailed},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/api/v3/repos/owner/repo/pulls/1":
+					_, _ = w.Write([]byte(`{"number": 1, "head": {"sha": "abc123"}}`))
+				case "/api/v3/repos/owner/repo/commits/abc123/status":
+					_, _ = w.Write([]byte(tc.status))
+				case "/api/v3/repos/owner/repo/commits/abc123/check-runs":
+					_, _ = w.Write([]byte(`{"check_runs": ` + tc.checkRuns + `}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer mockServer.Close()
+
+			client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+			gh := &Github{client: client, owner: "owner", repo: "repo"}
+
+			status, err := gh.PipelineStatus(context.Background(), MergeRequest{ID: 1})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, status)
+		})
+	}
+}
+
+func TestGithub_MarkMergeRequestReady(t *testing.T) {
+	var sent struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/owner/repo/pulls/1":
+			_, _ = w.Write([]byte(`{"number":1,"node_id":"PR_kwDOABCDEF123"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/graphql":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			_, _ = w.Write([]byte(`{"data":{"markPullRequestReadyForReview":{"pullRequest":{"isDraft":false}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := github.NewClient(nil).WithEnterpriseURLs(mockServer.URL, "")
+	gh := &Github{client: client, owner: "owner", repo: "repo"}
+
+	require.NoError(t, gh.MarkMergeRequestReady(context.Background(), MergeRequest{ID: 1}))
+	assert.Contains(t, sent.Query, "markPullRequestReadyForReview")
+	assert.Equal(t, map[string]any{"prId": "PR_kwDOABCDEF123"}, sent.Variables)
+}
@@ -2,8 +2,10 @@ package codehosting
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
 	"time"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
@@ -35,7 +37,16 @@ func newGitlab(host string, path string, token string, logger *zap.Logger) (*Git
 	}, nil
 }
 
-func (g *Gitlab) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (MergeRequest, error) {
+// draftTitlePrefix is how a GitLab merge request becomes a draft: the API has no field for it.
+const draftTitlePrefix = "Draft: "
+
+// draftTitlePattern matches every prefix GitLab reads as a draft marker, repeated or not.
+var draftTitlePattern = regexp.MustCompile(`(?i)^\s*((\[draft\]|\(draft\)|draft:|draft -)\s*)+`)
+
+func (g *Gitlab) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error) {
+	if draft {
+		title = draftTitlePrefix + title
+	}
 	mr, _, err := g.client.MergeRequests.CreateMergeRequest(g.projectPath, &gitlab.CreateMergeRequestOptions{
 		SourceBranch: &sourceBranch,
 		TargetBranch: &targetBranch,
@@ -48,8 +59,9 @@ func (g *Gitlab) CreateMergeRequest(ctx context.Context, title string, descripti
 	}
 
 	return MergeRequest{
-		ID:  mr.IID,
-		URL: mr.WebURL,
+		ID:    mr.IID,
+		URL:   mr.WebURL,
+		Draft: mr.Draft,
 	}, nil
 }
 
@@ -62,6 +74,174 @@ func (g *Gitlab) DeleteBranch(ctx context.Context, branch string) error {
 	return nil
 }
 
+func (g *Gitlab) ListMergeRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error) {
+	opts := &gitlab.ListProjectMergeRequestsOptions{
+		ListOptions:  gitlab.ListOptions{PerPage: 100},
+		State:        gitlab.Ptr("opened"),
+		TargetBranch: &targetBranch,
+		OrderBy:      gitlab.Ptr("created_at"),
+		Sort:         gitlab.Ptr("desc"),
+	}
+
+	var out []MergeRequest
+	for {
+		mrs, resp, err := g.client.MergeRequests.ListProjectMergeRequests(g.projectPath, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge requests: %w", err)
+		}
+		for _, mr := range mrs {
+			if mr.SourceProjectID != mr.TargetProjectID {
+				continue
+			}
+			out = append(out, MergeRequest{
+				ID:           mr.IID,
+				URL:          mr.WebURL,
+				SourceBranch: mr.SourceBranch,
+				Description:  mr.Description,
+				Draft:        mr.Draft,
+			})
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// UpdateMergeRequest keeps a draft a draft: GitLab would read the new title as marking it ready.
+func (g *Gitlab) UpdateMergeRequest(ctx context.Context, mr MergeRequest, title string, description string) error {
+	if mr.Draft {
+		title = draftTitlePrefix + title
+	}
+	_, _, err := g.client.MergeRequests.UpdateMergeRequest(g.projectPath, mr.ID, &gitlab.UpdateMergeRequestOptions{
+		Title:       &title,
+		Description: &description,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to update merge request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+func (g *Gitlab) CloseMergeRequest(ctx context.Context, mr MergeRequest, comment string) error {
+	if _, _, err := g.client.Notes.CreateMergeRequestNote(g.projectPath, mr.ID, &gitlab.CreateMergeRequestNoteOptions{
+		Body: &comment,
+	}, gitlab.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to comment on merge request %d: %w", mr.ID, err)
+	}
+	if _, _, err := g.client.MergeRequests.UpdateMergeRequest(g.projectPath, mr.ID, &gitlab.UpdateMergeRequestOptions{
+		StateEvent: gitlab.Ptr("close"),
+	}, gitlab.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to close merge request %d: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// SetMergeRequestMetadata resolves usernames and the milestone to the ids GitLab wants, then sets
+// whatever resolved in one update. add_labels creates a label the project lacks, scoped labels
+// like "priority::high" included.
+func (g *Gitlab) SetMergeRequestMetadata(ctx context.Context, mr MergeRequest, metadata MergeRequestMetadata) error {
+	var errs []error
+	opts := &gitlab.UpdateMergeRequestOptions{}
+	if len(metadata.Labels) > 0 {
+		opts.AddLabels = gitlab.Ptr(gitlab.LabelOptions(metadata.Labels))
+	}
+	if ids, err := g.userIDs(ctx, metadata.Assignees); err != nil {
+		errs = append(errs, fmt.Errorf("failed to resolve assignees: %w", err))
+	} else if len(ids) > 0 {
+		opts.AssigneeIDs = &ids
+	}
+	if ids, err := g.userIDs(ctx, metadata.Reviewers); err != nil {
+		errs = append(errs, fmt.Errorf("failed to resolve reviewers: %w", err))
+	} else if len(ids) > 0 {
+		opts.ReviewerIDs = &ids
+	}
+	if metadata.Milestone != "" {
+		if id, err := g.milestoneID(ctx, metadata.Milestone); err != nil {
+			errs = append(errs, err)
+		} else {
+			opts.MilestoneID = &id
+		}
+	}
+
+	if *opts != (gitlab.UpdateMergeRequestOptions{}) {
+		if _, _, err := g.client.MergeRequests.UpdateMergeRequest(g.projectPath, mr.ID, opts, gitlab.WithContext(ctx)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set metadata of merge request %d: %w", mr.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// userIDs resolves usernames to user ids. An unknown username fails the lot: assigning the rest
+// would look like the whole list applied.
+func (g *Gitlab) userIDs(ctx context.Context, usernames []string) ([]int64, error) {
+	ids := make([]int64, 0, len(usernames))
+	for _, username := range usernames {
+		users, _, err := g.client.Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.Ptr(username)}, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("no user %q", username)
+		}
+		ids = append(ids, users[0].ID)
+	}
+	return ids, nil
+}
+
+// milestoneID finds the active milestone titled title, the project's or one of its groups'.
+func (g *Gitlab) milestoneID(ctx context.Context, title string) (int64, error) {
+	milestones, _, err := g.client.Milestones.ListMilestones(g.projectPath, &gitlab.ListMilestonesOptions{
+		Title:            gitlab.Ptr(title),
+		State:            gitlab.Ptr("active"),
+		IncludeAncestors: gitlab.Ptr(true),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	if len(milestones) == 0 {
+		return 0, fmt.Errorf("no active milestone titled %q", title)
+	}
+	return milestones[0].ID, nil
+}
+
+// MarkMergeRequestReady strips the draft prefix from the current title, which may have been
+// edited since the run opened the request.
+func (g *Gitlab) MarkMergeRequestReady(ctx context.Context, mr MergeRequest) error {
+	details, _, err := g.client.MergeRequests.GetMergeRequest(g.projectPath, mr.ID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not mark MR %d ready for review: %w", mr.ID, err)
+	}
+	title := draftTitlePattern.ReplaceAllString(details.Title, "")
+	if _, _, err := g.client.MergeRequests.UpdateMergeRequest(g.projectPath, mr.ID, &gitlab.UpdateMergeRequestOptions{
+		Title: &title,
+	}, gitlab.WithContext(ctx)); err != nil {
+		return fmt.Errorf("could not mark MR %d ready for review: %w", mr.ID, err)
+	}
+	return nil
+}
+
+// PipelineStatus reads the merge request's head pipeline, the one that ran on its latest commit.
+func (g *Gitlab) PipelineStatus(ctx context.Context, mr MergeRequest) (PipelineStatus, error) {
+	details, _, err := g.client.MergeRequests.GetMergeRequest(g.projectPath, mr.ID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("could not read the pipeline of MR %d: %w", mr.ID, err)
+	}
+	if details.HeadPipeline == nil {
+		return PipelineNone, nil
+	}
+	switch details.HeadPipeline.Status {
+	// A skipped pipeline ran nothing that could fail.
+	case "success", "skipped":
+		return PipelineSuccess, nil
+	case "failed", "canceled":
+		return PipelineFailed, nil
+	default:
+		// Including "manual": the pipeline waits on a person before it can pass.
+		return PipelinePending, nil
+	}
+}
+
 // Attempt budgets for EnableAutoMerge. GitLab computes mergeability asynchronously, so the status
 // right after MR creation is usually pending. Bounded so a run can't hang on it.
 const (
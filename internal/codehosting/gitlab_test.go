@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -32,7 +33,7 @@ func TestGitlab_CreateMergeRequest(t *testing.T) {
 
 	t.Run("failed to get create mr", func(t *testing.T) {
 
-		_, err := gitlab.CreateMergeRequest(context.Background(), title, description, sourceBranch, targetBranch)
+		_, err := gitlab.CreateMergeRequest(context.Background(), title, description, sourceBranch, targetBranch, false)
 		require.Error(t, err)
 	})
 
@@ -98,7 +99,7 @@ func TestCreateMergeRequest(t *testing.T) {
 		projectPath: "test_project",
 	}
 
-	mr, err := gitlab.CreateMergeRequest(context.Background(), "Test MR", "This is a test MR", "source-branch", "target-branch")
+	mr, err := gitlab.CreateMergeRequest(context.Background(), "Test MR", "This is a test MR", "source-branch", "target-branch", false)
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), mr.ID)
 	assert.Equal(t, "http://example.com", mr.URL)
@@ -116,7 +117,7 @@ func TestGitlab_CreateMergeRequest_HonorsContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	_, err := g.CreateMergeRequest(ctx, "Test MR", "body", "source", "target")
+	_, err := g.CreateMergeRequest(ctx, "Test MR", "body", "source", "target", false)
 	require.ErrorIs(t, err, context.Canceled)
 }
 
@@ -344,3 +345,208 @@ func TestGetUser_ReturnsEmptyStringsOnError(t *testing.T) {
 	assert.Empty(t, name)
 	assert.Empty(t, email)
 }
+
+func TestGitlab_ListMergeRequests(t *testing.T) {
+	var query url.Values
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v4/projects/test_project/merge_requests" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"iid": 5, "web_url": "https://gitlab.com/mr/5", "description": "mine", "source_branch": "update-b", "source_project_id": 1, "target_project_id": 1},
+			{"iid": 4, "web_url": "https://gitlab.com/mr/4", "description": "fork", "source_branch": "update-a", "source_project_id": 7, "target_project_id": 1}
+		]`))
+	}))
+	defer mockServer.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(mockServer.URL))
+	g := &Gitlab{client: client, projectPath: "test_project"}
+
+	mrs, err := g.ListMergeRequests(context.Background(), "main")
+	require.NoError(t, err)
+	assert.Equal(t, []MergeRequest{{ID: 5, URL: "https://gitlab.com/mr/5", SourceBranch: "update-b", Description: "mine"}}, mrs)
+	assert.Equal(t, "opened", query.Get("state"))
+	assert.Equal(t, "main", query.Get("target_branch"))
+}
+
+func TestGitlab_UpdateMergeRequest(t *testing.T) {
+	var sent map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v4/projects/test_project/merge_requests/5" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"iid": 5}`))
+	}))
+	defer mockServer.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(mockServer.URL))
+	g := &Gitlab{client: client, projectPath: "test_project"}
+
+	require.NoError(t, g.UpdateMergeRequest(context.Background(), MergeRequest{ID: 5}, "New title", "New body"))
+	assert.Equal(t, map[string]any{"title": "New title", "description": "New body"}, sent)
+}
+
+func TestGitlab_CloseMergeRequest(t *testing.T) {
+	var note, update map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v4/projects/test_project/merge_requests/5/notes":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&note))
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v4/projects/test_project/merge_requests/5":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+			_, _ = w.Write([]byte(`{"iid": 5, "state": "closed"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(mockServer.URL))
+	g := &Gitlab{client: client, projectPath: "test_project"}
+
+	require.NoError(t, g.CloseMergeRequest(context.Background(), MergeRequest{ID: 5}, "Superseded by !6."))
+	assert.Equal(t, map[string]any{"body": "Superseded by !6."}, note)
+	assert.Equal(t, map[string]any{"state_event": "close"}, update)
+}
+
+func TestGitlab_SetMergeRequestMetadata(t *testing.T) {
+	users := map[string]string{"alice": `[{"id": 11}]`, "bob": `[{"id": 12}]`, "carol": `[{"id": 13}]`}
+	var milestoneQuery url.Values
+	var sent map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/users":
+			_, _ = w.Write([]byte(users[r.URL.Query().Get("username")]))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/test_project/milestones":
+			milestoneQuery = r.URL.Query()
+			_, _ = w.Write([]byte(`[{"id": 40, "title": "Sprint 42"}]`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v4/projects/test_project/merge_requests/5":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			_, _ = w.Write([]byte(`{"iid": 5}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(mockServer.URL))
+	g := &Gitlab{client: client, projectPath: "test_project"}
+
+	require.NoError(t, g.SetMergeRequestMetadata(context.Background(), MergeRequest{ID: 5}, MergeRequestMetadata{
+		Labels:    []string{"dependencies", "priority::high"},
+		Assignees: []string{"alice"},
+		Reviewers: []string{"bob", "carol"},
+		Milestone: "Sprint 42",
+	}))
+
+	assert.Equal(t, "Sprint 42", milestoneQuery.Get("title"))
+	assert.Equal(t, "active", milestoneQuery.Get("state"))
+	assert.Equal(t, "true", milestoneQuery.Get("include_ancestors"))
+	assert.Equal(t, map[string]any{
+		"add_labels":   "dependencies,priority::high",
+		"assignee_ids": []any{float64(11)},
+		"reviewer_ids": []any{float64(12), float64(13)},
+		"milestone_id": float64(40),
+	}, sent)
+}
+
+// What resolved is still set; what did not is reported.
+func TestGitlab_SetMergeRequestMetadata_UnknownUser(t *testing.T) {
+	var sent map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/users":
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v4/projects/test_project/merge_requests/5":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			_, _ = w.Write([]byte(`{"iid": 5}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(mockServer.URL))
+	g := &Gitlab{client: client, projectPath: "test_project"}
+
+	err := g.SetMergeRequestMetadata(context.Background(), MergeRequest{ID: 5}, MergeRequestMetadata{
+		Labels:    []string{"dependencies"},
+		Reviewers: []string{"nobody"},
+	})
+
+	require.EqualError(t, err, `failed to resolve reviewers: no user "nobody"`)
+	assert.Equal(t, map[string]any{"add_labels": "dependencies"}, sent)
+}
+
+// GitLab has no draft field: the title prefix is the draft, so every title the run writes keeps it
+// until the request is marked ready.
+func TestGitlab_Draft(t *testing.T) {
+	var sent []map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v4/projects/test_project/merge_requests":
+			var body map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			sent = append(sent, body)
+			_, _ = w.Write([]byte(`{"iid": 5, "web_url": "http://example.com", "draft": true}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/test_project/merge_requests/5":
+			_, _ = w.Write([]byte(`{"iid": 5, "title": "[Draft] Draft: Drupal updates"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v4/projects/test_project/merge_requests/5":
+			var body map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			sent = append(sent, body)
+			_, _ = w.Write([]byte(`{"iid": 5}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(mockServer.URL))
+	g := &Gitlab{client: client, projectPath: "test_project"}
+
+	mr, err := g.CreateMergeRequest(context.Background(), "Drupal updates", "body", "source", "main", true)
+	require.NoError(t, err)
+	assert.True(t, mr.Draft)
+	require.NoError(t, g.UpdateMergeRequest(context.Background(), mr, "Newer Drupal updates", "body"))
+	require.NoError(t, g.MarkMergeRequestReady(context.Background(), mr))
+
+	require.Len(t, sent, 3)
+	assert.Equal(t, "Draft: Drupal updates", sent[0]["title"])
+	assert.Equal(t, "Draft: Newer Drupal updates", sent[1]["title"])
+	assert.Equal(t, map[string]any{"title": "Drupal updates"}, sent[2])
+}
+
+func TestGitlab_PipelineStatus(t *testing.T) {
+	for response, want := range map[string]PipelineStatus{
+		`{"iid": 5}`: PipelineNone,
+		`{"iid": 5, "head_pipeline": {"status": "running"}}`:  PipelinePending,
+		`{"iid": 5, "head_pipeline": {"status": "manual"}}`:   PipelinePending,
+		`{"iid": 5, "head_pipeline": {"status": "success"}}`:  PipelineSuccess,
+		`{"iid": 5, "head_pipeline": {"status": "canceled"}}`: PipelineFailed,
+	} {
+		mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(response))
+		}))
+
+		client, _ := gitlab.NewClient("", gitlab.WithBaseURL(mockServer.URL))
+		g := &Gitlab{client: client, projectPath: "test_project"}
+
+		status, err := g.PipelineStatus(context.Background(), MergeRequest{ID: 5})
+		require.NoError(t, err)
+		assert.Equal(t, want, status, response)
+		mockServer.Close()
+	}
+}
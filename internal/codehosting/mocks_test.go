@@ -37,9 +37,72 @@ func (_m *MockPlatform) EXPECT() *MockPlatform_Expecter {
 	return &MockPlatform_Expecter{mock: &_m.Mock}
 }
 
+// CloseMergeRequest provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) CloseMergeRequest(ctx context.Context, mr MergeRequest, comment string) error {
+	ret := _mock.Called(ctx, mr, comment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CloseMergeRequest")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, MergeRequest, string) error); ok {
+		r0 = returnFunc(ctx, mr, comment)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_CloseMergeRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CloseMergeRequest'
+type MockPlatform_CloseMergeRequest_Call struct {
+	*mock.Call
+}
+
+// CloseMergeRequest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr MergeRequest
+//   - comment string
+func (_e *MockPlatform_Expecter) CloseMergeRequest(ctx any, mr any, comment any) *MockPlatform_CloseMergeRequest_Call {
+	return &MockPlatform_CloseMergeRequest_Call{Call: _e.mock.On("CloseMergeRequest", ctx, mr, comment)}
+}
+
+func (_c *MockPlatform_CloseMergeRequest_Call) Run(run func(ctx context.Context, mr MergeRequest, comment string)) *MockPlatform_CloseMergeRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(MergeRequest)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_CloseMergeRequest_Call) Return(err error) *MockPlatform_CloseMergeRequest_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_CloseMergeRequest_Call) RunAndReturn(run func(ctx context.Context, mr MergeRequest, comment string) error) *MockPlatform_CloseMergeRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateMergeRequest provides a mock function for the type MockPlatform
-func (_mock *MockPlatform) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (MergeRequest, error) {
-	ret := _mock.Called(ctx, title, description, sourceBranch, targetBranch)
+func (_mock *MockPlatform) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error) {
+	ret := _mock.Called(ctx, title, description, sourceBranch, targetBranch, draft)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateMergeRequest")
@@ -47,16 +110,16 @@ func (_mock *MockPlatform) CreateMergeRequest(ctx context.Context, title string,
 
 	var r0 MergeRequest
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) (MergeRequest, error)); ok {
-		return returnFunc(ctx, title, description, sourceBranch, targetBranch)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string, bool) (MergeRequest, error)); ok {
+		return returnFunc(ctx, title, description, sourceBranch, targetBranch, draft)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) MergeRequest); ok {
-		r0 = returnFunc(ctx, title, description, sourceBranch, targetBranch)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string, bool) MergeRequest); ok {
+		r0 = returnFunc(ctx, title, description, sourceBranch, targetBranch, draft)
 	} else {
 		r0 = ret.Get(0).(MergeRequest)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
-		r1 = returnFunc(ctx, title, description, sourceBranch, targetBranch)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, string, bool) error); ok {
+		r1 = returnFunc(ctx, title, description, sourceBranch, targetBranch, draft)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -74,11 +137,12 @@ type MockPlatform_CreateMergeRequest_Call struct {
 //   - description string
 //   - sourceBranch string
 //   - targetBranch string
-func (_e *MockPlatform_Expecter) CreateMergeRequest(ctx any, title any, description any, sourceBranch any, targetBranch any) *MockPlatform_CreateMergeRequest_Call {
-	return &MockPlatform_CreateMergeRequest_Call{Call: _e.mock.On("CreateMergeRequest", ctx, title, description, sourceBranch, targetBranch)}
+//   - draft bool
+func (_e *MockPlatform_Expecter) CreateMergeRequest(ctx any, title any, description any, sourceBranch any, targetBranch any, draft any) *MockPlatform_CreateMergeRequest_Call {
+	return &MockPlatform_CreateMergeRequest_Call{Call: _e.mock.On("CreateMergeRequest", ctx, title, description, sourceBranch, targetBranch, draft)}
 }
 
-func (_c *MockPlatform_CreateMergeRequest_Call) Run(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string)) *MockPlatform_CreateMergeRequest_Call {
+func (_c *MockPlatform_CreateMergeRequest_Call) Run(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool)) *MockPlatform_CreateMergeRequest_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -100,12 +164,17 @@ func (_c *MockPlatform_CreateMergeRequest_Call) Run(run func(ctx context.Context
 		if args[4] != nil {
 			arg4 = args[4].(string)
 		}
+		var arg5 bool
+		if args[5] != nil {
+			arg5 = args[5].(bool)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
 			arg3,
 			arg4,
+			arg5,
 		)
 	})
 	return _c
@@ -116,7 +185,7 @@ func (_c *MockPlatform_CreateMergeRequest_Call) Return(mergeRequest MergeRequest
 	return _c
 }
 
-func (_c *MockPlatform_CreateMergeRequest_Call) RunAndReturn(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (MergeRequest, error)) *MockPlatform_CreateMergeRequest_Call {
+func (_c *MockPlatform_CreateMergeRequest_Call) RunAndReturn(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (MergeRequest, error)) *MockPlatform_CreateMergeRequest_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -294,3 +363,326 @@ func (_c *MockPlatform_GetUser_Call) RunAndReturn(run func(ctx context.Context)
 	_c.Call.Return(run)
 	return _c
 }
+
+// ListMergeRequests provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) ListMergeRequests(ctx context.Context, targetBranch string) ([]MergeRequest, error) {
+	ret := _mock.Called(ctx, targetBranch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMergeRequests")
+	}
+
+	var r0 []MergeRequest
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]MergeRequest, error)); ok {
+		return returnFunc(ctx, targetBranch)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []MergeRequest); ok {
+		r0 = returnFunc(ctx, targetBranch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]MergeRequest)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, targetBranch)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPlatform_ListMergeRequests_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMergeRequests'
+type MockPlatform_ListMergeRequests_Call struct {
+	*mock.Call
+}
+
+// ListMergeRequests is a helper method to define mock.On call
+//   - ctx context.Context
+//   - targetBranch string
+func (_e *MockPlatform_Expecter) ListMergeRequests(ctx any, targetBranch any) *MockPlatform_ListMergeRequests_Call {
+	return &MockPlatform_ListMergeRequests_Call{Call: _e.mock.On("ListMergeRequests", ctx, targetBranch)}
+}
+
+func (_c *MockPlatform_ListMergeRequests_Call) Run(run func(ctx context.Context, targetBranch string)) *MockPlatform_ListMergeRequests_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_ListMergeRequests_Call) Return(mergeRequests []MergeRequest, err error) *MockPlatform_ListMergeRequests_Call {
+	_c.Call.Return(mergeRequests, err)
+	return _c
+}
+
+func (_c *MockPlatform_ListMergeRequests_Call) RunAndReturn(run func(ctx context.Context, targetBranch string) ([]MergeRequest, error)) *MockPlatform_ListMergeRequests_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkMergeRequestReady provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) MarkMergeRequestReady(ctx context.Context, mr MergeRequest) error {
+	ret := _mock.Called(ctx, mr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkMergeRequestReady")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, MergeRequest) error); ok {
+		r0 = returnFunc(ctx, mr)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_MarkMergeRequestReady_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkMergeRequestReady'
+type MockPlatform_MarkMergeRequestReady_Call struct {
+	*mock.Call
+}
+
+// MarkMergeRequestReady is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr MergeRequest
+func (_e *MockPlatform_Expecter) MarkMergeRequestReady(ctx any, mr any) *MockPlatform_MarkMergeRequestReady_Call {
+	return &MockPlatform_MarkMergeRequestReady_Call{Call: _e.mock.On("MarkMergeRequestReady", ctx, mr)}
+}
+
+func (_c *MockPlatform_MarkMergeRequestReady_Call) Run(run func(ctx context.Context, mr MergeRequest)) *MockPlatform_MarkMergeRequestReady_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(MergeRequest)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_MarkMergeRequestReady_Call) Return(err error) *MockPlatform_MarkMergeRequestReady_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_MarkMergeRequestReady_Call) RunAndReturn(run func(ctx context.Context, mr MergeRequest) error) *MockPlatform_MarkMergeRequestReady_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PipelineStatus provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) PipelineStatus(ctx context.Context, mr MergeRequest) (PipelineStatus, error) {
+	ret := _mock.Called(ctx, mr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PipelineStatus")
+	}
+
+	var r0 PipelineStatus
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, MergeRequest) (PipelineStatus, error)); ok {
+		return returnFunc(ctx, mr)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, MergeRequest) PipelineStatus); ok {
+		r0 = returnFunc(ctx, mr)
+	} else {
+		r0 = ret.Get(0).(PipelineStatus)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, MergeRequest) error); ok {
+		r1 = returnFunc(ctx, mr)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPlatform_PipelineStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PipelineStatus'
+type MockPlatform_PipelineStatus_Call struct {
+	*mock.Call
+}
+
+// PipelineStatus is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr MergeRequest
+func (_e *MockPlatform_Expecter) PipelineStatus(ctx any, mr any) *MockPlatform_PipelineStatus_Call {
+	return &MockPlatform_PipelineStatus_Call{Call: _e.mock.On("PipelineStatus", ctx, mr)}
+}
+
+func (_c *MockPlatform_PipelineStatus_Call) Run(run func(ctx context.Context, mr MergeRequest)) *MockPlatform_PipelineStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(MergeRequest)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_PipelineStatus_Call) Return(pipelineStatus PipelineStatus, err error) *MockPlatform_PipelineStatus_Call {
+	_c.Call.Return(pipelineStatus, err)
+	return _c
+}
+
+func (_c *MockPlatform_PipelineStatus_Call) RunAndReturn(run func(ctx context.Context, mr MergeRequest) (PipelineStatus, error)) *MockPlatform_PipelineStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMergeRequestMetadata provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) SetMergeRequestMetadata(ctx context.Context, mr MergeRequest, metadata MergeRequestMetadata) error {
+	ret := _mock.Called(ctx, mr, metadata)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMergeRequestMetadata")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, MergeRequest, MergeRequestMetadata) error); ok {
+		r0 = returnFunc(ctx, mr, metadata)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_SetMergeRequestMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMergeRequestMetadata'
+type MockPlatform_SetMergeRequestMetadata_Call struct {
+	*mock.Call
+}
+
+// SetMergeRequestMetadata is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr MergeRequest
+//   - metadata MergeRequestMetadata
+func (_e *MockPlatform_Expecter) SetMergeRequestMetadata(ctx any, mr any, metadata any) *MockPlatform_SetMergeRequestMetadata_Call {
+	return &MockPlatform_SetMergeRequestMetadata_Call{Call: _e.mock.On("SetMergeRequestMetadata", ctx, mr, metadata)}
+}
+
+func (_c *MockPlatform_SetMergeRequestMetadata_Call) Run(run func(ctx context.Context, mr MergeRequest, metadata MergeRequestMetadata)) *MockPlatform_SetMergeRequestMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(MergeRequest)
+		}
+		var arg2 MergeRequestMetadata
+		if args[2] != nil {
+			arg2 = args[2].(MergeRequestMetadata)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_SetMergeRequestMetadata_Call) Return(err error) *MockPlatform_SetMergeRequestMetadata_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_SetMergeRequestMetadata_Call) RunAndReturn(run func(ctx context.Context, mr MergeRequest, metadata MergeRequestMetadata) error) *MockPlatform_SetMergeRequestMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateMergeRequest provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) UpdateMergeRequest(ctx context.Context, mr MergeRequest, title string, description string) error {
+	ret := _mock.Called(ctx, mr, title, description)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMergeRequest")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, MergeRequest, string, string) error); ok {
+		r0 = returnFunc(ctx, mr, title, description)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_UpdateMergeRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateMergeRequest'
+type MockPlatform_UpdateMergeRequest_Call struct {
+	*mock.Call
+}
+
+// UpdateMergeRequest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr MergeRequest
+//   - title string
+//   - description string
+func (_e *MockPlatform_Expecter) UpdateMergeRequest(ctx any, mr any, title any, description any) *MockPlatform_UpdateMergeRequest_Call {
+	return &MockPlatform_UpdateMergeRequest_Call{Call: _e.mock.On("UpdateMergeRequest", ctx, mr, title, description)}
+}
+
+func (_c *MockPlatform_UpdateMergeRequest_Call) Run(run func(ctx context.Context, mr MergeRequest, title string, description string)) *MockPlatform_UpdateMergeRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(MergeRequest)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_UpdateMergeRequest_Call) Return(err error) *MockPlatform_UpdateMergeRequest_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_UpdateMergeRequest_Call) RunAndReturn(run func(ctx context.Context, mr MergeRequest, title string, description string) error) *MockPlatform_UpdateMergeRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
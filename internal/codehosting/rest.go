@@ -0,0 +1,69 @@
+package codehosting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// restClient is the JSON-over-HTTP plumbing for the platforms that have no Go client library
+// worth depending on. Each platform supplies its own auth header and error-body shape.
+type restClient struct {
+	client  *http.Client
+	baseURL string
+	// authorization is the complete Authorization header value, scheme included.
+	authorization string
+	// errorMessage extracts the API's own explanation from an error response body.
+	errorMessage func(payload []byte) string
+}
+
+// do sends one API request and decodes the response into out when it is non-nil. The body is
+// consumed and closed here; the returned response is for its status and headers only.
+func (c *restClient) do(ctx context.Context, method string, path string, body any, out any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", c.authorization)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message := strings.TrimSpace(string(payload))
+		if c.errorMessage != nil {
+			message = c.errorMessage(payload)
+		}
+		return resp, fmt.Errorf("%s %s: %d %s", method, req.URL.Path, resp.StatusCode, message)
+	}
+	if out != nil && len(payload) > 0 {
+		if err := json.Unmarshal(payload, out); err != nil {
+			return resp, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,263 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// CommitKind names each commit a run makes. It is the key of the commit's message under
+// commits.messages.
+type CommitKind string
+
+const (
+	// CommitDependencies records composer update's changes to composer.json and composer.lock.
+	CommitDependencies CommitKind = "dependencies"
+	// CommitPatches records composer_patches' changes to the patch list.
+	CommitPatches CommitKind = "patches"
+	// CommitTranslations records one site's updated interface translations.
+	CommitTranslations CommitKind = "translations"
+	// CommitCodingStyles records code_beautifier's fixes.
+	CommitCodingStyles CommitKind = "coding_styles"
+	// CommitPHPCSConfig records the phpcs.xml code_beautifier writes when a project has none.
+	CommitPHPCSConfig CommitKind = "phpcs_config"
+	// CommitDeprecations records deprecations_remover's fixes.
+	CommitDeprecations CommitKind = "deprecations"
+	// CommitToolInstall and CommitToolRemoval record a tool an addon installs for its own use,
+	// and whatever removing it again leaves in composer.lock.
+	CommitToolInstall CommitKind = "tool_install"
+	CommitToolRemoval CommitKind = "tool_removal"
+)
+
+// defaultCommitMessages are the messages of a project that configures none.
+var defaultCommitMessages = map[CommitKind]string{
+	CommitDependencies: "Update composer.json and composer.lock",
+	CommitPatches:      "Update patches",
+	CommitTranslations: "Update translations",
+	CommitCodingStyles: "Update coding styles",
+	CommitPHPCSConfig:  "Add PHPCS config",
+	CommitDeprecations: "Remove deprecations",
+	CommitToolInstall:  "Install {{ .Package }}",
+	CommitToolRemoval:  "Remove temporary {{ .Package }} installation",
+}
+
+// CommitKinds lists every kind, sorted, for the errors that name them.
+var CommitKinds = slices.Sorted(maps.Keys(defaultCommitMessages))
+
+// CommitsConfig is how .drupdater.yaml words the run's commits.
+type CommitsConfig struct {
+	// Messages maps a commit kind to its message template. A kind left out keeps its default.
+	Messages map[CommitKind]string `yaml:"messages,omitempty"`
+	// Trailers are appended to every message, each a "Key: value" line whose value may be a
+	// template.
+	Trailers []string `yaml:"trailers,omitempty"`
+}
+
+// CommitData is what a commit message renders from.
+type CommitData struct {
+	Kind CommitKind
+	// RunType is normal, security or major; Group is empty for an ungrouped run.
+	RunType string
+	Group   string
+	// Site is the site a translations commit is for; empty on the others.
+	Site string
+	// Package is the tool a tool_install or tool_removal commit is about.
+	Package string
+	// Packages are the update's package changes. The patches commit, made before the update,
+	// gets the changes composer plans.
+	Packages []composer.PackageChange
+}
+
+// trailerPattern is a git trailer: a token, a colon, and a value.
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: *\S`)
+
+// sampleCommitData is what the configured templates are tried against when the file loads, so a
+// misspelt field fails the load rather than a commit halfway through the run.
+var sampleCommitData = CommitData{
+	Kind:     CommitDependencies,
+	RunType:  "normal",
+	Group:    "core",
+	Site:     "default",
+	Package:  "drupal/coder",
+	Packages: []composer.PackageChange{{Action: "Upgrade", Package: "drupal/core", From: "10.4.0", To: "10.4.1"}},
+}
+
+// CommitWorktree is all a Committer needs of a worktree.
+type CommitWorktree interface {
+	Commit(msg string, opts *git.CommitOptions) (plumbing.Hash, error)
+}
+
+// Committer makes every commit of a run, with the project's message for its kind and the
+// project's trailers. Shared by the workflow and the addons through the context.
+type Committer struct {
+	messages map[CommitKind]*template.Template
+	trailers []*template.Template
+	runType  string
+	group    string
+	signer   git.Signer
+
+	// mu guards packages: sites commit concurrently, after the update has set them.
+	mu       sync.Mutex
+	packages []composer.PackageChange
+}
+
+// NewCommitter parses cfg's templates over the defaults, for a run of runType and group.
+func NewCommitter(cfg CommitsConfig, runType string, group string) (*Committer, error) {
+	for _, kind := range slices.Sorted(maps.Keys(cfg.Messages)) {
+		if _, ok := defaultCommitMessages[kind]; !ok {
+			return nil, fmt.Errorf("unknown commit kind %q in commits.messages: use %s", kind, joinKinds())
+		}
+	}
+
+	c := &Committer{messages: map[CommitKind]*template.Template{}, runType: runType, group: group}
+	for _, kind := range CommitKinds {
+		text := defaultCommitMessages[kind]
+		if custom, ok := cfg.Messages[kind]; ok {
+			text = custom
+		}
+		tmpl, err := template.New(string(kind)).Funcs(TemplateFuncs()).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("commits.messages.%s: %w", kind, err)
+		}
+		c.messages[kind] = tmpl
+	}
+	for i, text := range cfg.Trailers {
+		if !trailerPattern.MatchString(text) {
+			return nil, fmt.Errorf("commits.trailers[%d] %q: a trailer is a line like \"Signed-off-by: Name <email>\"", i, text)
+		}
+		tmpl, err := template.New("trailer").Funcs(TemplateFuncs()).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("commits.trailers[%d]: %w", i, err)
+		}
+		c.trailers = append(c.trailers, tmpl)
+	}
+	return c, nil
+}
+
+func joinKinds() string {
+	kinds := make([]string, len(CommitKinds))
+	for i, kind := range CommitKinds {
+		kinds[i] = string(kind)
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// validateCommits renders every template cfg sets against sample data.
+func validateCommits(cfg CommitsConfig) error {
+	c, err := NewCommitter(cfg, sampleCommitData.RunType, sampleCommitData.Group)
+	if err != nil {
+		return err
+	}
+	for _, kind := range CommitKinds {
+		data := sampleCommitData
+		data.Kind = kind
+		if _, err := c.Message(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetPackages records the update's package changes, for the commits made after it.
+func (c *Committer) SetPackages(changes []composer.PackageChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.packages = changes
+}
+
+// SetSigner signs every commit made from now on with signer; nil leaves them unsigned.
+func (c *Committer) SetSigner(signer git.Signer) {
+	c.signer = signer
+}
+
+// Message renders the message for data.Kind, trailers included. The run type, group and, unless
+// data carries its own, the package changes are the committer's.
+func (c *Committer) Message(data CommitData) (string, error) {
+	tmpl, ok := c.messages[data.Kind]
+	if !ok {
+		return "", fmt.Errorf("unknown commit kind %q", data.Kind)
+	}
+	data.RunType, data.Group = c.runType, c.group
+	if data.Packages == nil {
+		c.mu.Lock()
+		data.Packages = c.packages
+		c.mu.Unlock()
+	}
+
+	message, err := renderCommitTemplate(tmpl, data)
+	if err != nil {
+		return "", fmt.Errorf("commits.messages.%s: %w", data.Kind, err)
+	}
+	if message == "" {
+		return "", fmt.Errorf("commits.messages.%s rendered an empty message", data.Kind)
+	}
+
+	var trailers []string
+	for i, tmpl := range c.trailers {
+		trailer, err := renderCommitTemplate(tmpl, data)
+		if err != nil {
+			return "", fmt.Errorf("commits.trailers[%d]: %w", i, err)
+		}
+		trailers = append(trailers, trailer)
+	}
+	if len(trailers) > 0 {
+		// A blank line, then one trailer per line: the block git interpret-trailers reads.
+		message += "\n\n" + strings.Join(trailers, "\n")
+	}
+	return message, nil
+}
+
+// Commit commits what worktree has staged, with data's message, signed if the committer has a
+// signer.
+func (c *Committer) Commit(worktree CommitWorktree, data CommitData) error {
+	message, err := c.Message(data)
+	if err != nil {
+		return fmt.Errorf("failed to render commit message: %w", err)
+	}
+	_, err = worktree.Commit(message, &git.CommitOptions{Signer: c.signer})
+	return err
+}
+
+func renderCommitTemplate(tmpl *template.Template, data CommitData) (string, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+type committerKey struct{}
+
+// WithCommitter returns ctx carrying c, for every commit made under it.
+func WithCommitter(ctx context.Context, c *Committer) context.Context {
+	return context.WithValue(ctx, committerKey{}, c)
+}
+
+// defaultCommitter commits with the default messages, for a context that carries no committer.
+var defaultCommitter = sync.OnceValue(func() *Committer {
+	c, err := NewCommitter(CommitsConfig{}, "", "")
+	if err != nil {
+		panic(err) // the defaults are compiled in
+	}
+	return c
+})
+
+// CommitterFrom returns the committer ctx carries, or one with the default messages.
+func CommitterFrom(ctx context.Context) *Committer {
+	if ctx != nil {
+		if c, ok := ctx.Value(committerKey{}).(*Committer); ok {
+			return c
+		}
+	}
+	return defaultCommitter()
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"testing"
+
+	"github.com/drupdater/drupdater/internal/signing"
+	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommitter(t *testing.T) {
+	changes := []composer.PackageChange{
+		{Action: "Upgrade", Package: "drupal/core", From: "10.4.0", To: "10.4.1"},
+		{Action: "Upgrade", Package: "drupal/token", From: "1.14.0", To: "1.15.0"},
+	}
+
+	t.Run("the defaults keep the historical messages", func(t *testing.T) {
+		c, err := NewCommitter(CommitsConfig{}, "normal", "")
+		require.NoError(t, err)
+
+		for _, tc := range []struct {
+			data CommitData
+			want string
+		}{
+			{CommitData{Kind: CommitDependencies}, "Update composer.json and composer.lock"},
+			{CommitData{Kind: CommitTranslations, Site: "default"}, "Update translations"},
+			{CommitData{Kind: CommitToolInstall, Package: "drupal/coder"}, "Install drupal/coder"},
+			{CommitData{Kind: CommitToolRemoval, Package: "drupal/coder"}, "Remove temporary drupal/coder installation"},
+			{CommitData{Kind: CommitPHPCSConfig}, "Add PHPCS config"},
+		} {
+			got, err := c.Message(tc.data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		}
+	})
+
+	t.Run("renders the project's message and trailers", func(t *testing.T) {
+		c, err := NewCommitter(CommitsConfig{
+			Messages: map[CommitKind]string{
+				CommitDependencies: "chore(deps): {{ .RunType }} update of {{ len .Packages }} packages\n\n{{ range .Packages }}- {{ .Package }} {{ .From }} -> {{ .To }}\n{{ end }}",
+				CommitTranslations: "chore(i18n): update {{ .Site }} translations",
+			},
+			Trailers: []string{"Signed-off-by: ACME Bot <bot@acme.example>", "Refs: OPS-{{ .Group }}"},
+		}, "security", "core")
+		require.NoError(t, err)
+		c.SetPackages(changes)
+
+		got, err := c.Message(CommitData{Kind: CommitDependencies})
+		require.NoError(t, err)
+		assert.Equal(t, "chore(deps): security update of 2 packages\n\n"+
+			"- drupal/core 10.4.0 -> 10.4.1\n- drupal/token 1.14.0 -> 1.15.0\n\n"+
+			"Signed-off-by: ACME Bot <bot@acme.example>\nRefs: OPS-core", got)
+
+		got, err = c.Message(CommitData{Kind: CommitTranslations, Site: "intranet"})
+		require.NoError(t, err)
+		assert.Equal(t, "chore(i18n): update intranet translations\n\nSigned-off-by: ACME Bot <bot@acme.example>\nRefs: OPS-core", got)
+	})
+
+	t.Run("a commit's own packages win over the run's", func(t *testing.T) {
+		c, err := NewCommitter(CommitsConfig{Messages: map[CommitKind]string{CommitPatches: "Update patches for {{ range .Packages }}{{ .Package }}{{ end }}"}}, "normal", "")
+		require.NoError(t, err)
+		c.SetPackages(changes)
+
+		got, err := c.Message(CommitData{Kind: CommitPatches, Packages: changes[1:]})
+		require.NoError(t, err)
+		assert.Equal(t, "Update patches for drupal/token", got)
+	})
+
+	t.Run("an empty message is an error", func(t *testing.T) {
+		c, err := NewCommitter(CommitsConfig{Messages: map[CommitKind]string{CommitTranslations: "{{ .Site }}"}}, "normal", "")
+		require.NoError(t, err)
+
+		_, err = c.Message(CommitData{Kind: CommitTranslations})
+		require.EqualError(t, err, "commits.messages.translations rendered an empty message")
+	})
+
+	t.Run("rejects what could never render", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			cfg  CommitsConfig
+			want string
+		}{
+			"an unknown kind":              {CommitsConfig{Messages: map[CommitKind]string{"composer": "x"}}, `unknown commit kind "composer" in commits.messages: use coding_styles, dependencies, deprecations, patches, phpcs_config, tool_install, tool_removal, translations`},
+			"a broken template":            {CommitsConfig{Messages: map[CommitKind]string{CommitPatches: "{{ .Packages"}}, "commits.messages.patches:"},
+			"a line that is not a trailer": {CommitsConfig{Trailers: []string{"Closes #12"}}, `commits.trailers[0] "Closes #12": a trailer is a line like "Signed-off-by: Name <email>"`},
+		} {
+			_, err := NewCommitter(tc.cfg, "normal", "")
+			require.ErrorContains(t, err, tc.want, name)
+		}
+	})
+}
+
+func TestCommitterFrom(t *testing.T) {
+	assert.NotNil(t, CommitterFrom(context.Background()), "a context without one commits with the defaults")
+
+	c, err := NewCommitter(CommitsConfig{}, "major", "")
+	require.NoError(t, err)
+	assert.Same(t, c, CommitterFrom(WithCommitter(context.Background(), c)))
+}
+
+func TestCommitterSignsCommits(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(key, "")
+	require.NoError(t, err)
+	signer, err := signing.Parse(pem.EncodeToMemory(block), nil)
+	require.NoError(t, err)
+
+	repository, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(t, err)
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+
+	c, err := NewCommitter(CommitsConfig{}, "normal", "")
+	require.NoError(t, err)
+	c.SetSigner(signer)
+	require.NoError(t, c.Commit(allowEmpty{worktree}, CommitData{Kind: CommitDependencies}))
+
+	head, err := repository.Head()
+	require.NoError(t, err)
+	commit, err := repository.CommitObject(head.Hash())
+	require.NoError(t, err)
+	require.NotEmpty(t, commit.PGPSignature, "the signature git verify-commit reads")
+
+	unsigned := &plumbing.MemoryObject{}
+	require.NoError(t, commit.EncodeWithoutSignature(unsigned))
+	reader, err := unsigned.Reader()
+	require.NoError(t, err)
+	message, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.NoError(t, signer.Verify(message, []byte(commit.PGPSignature)))
+}
+
+// allowEmpty commits with nothing staged, which is all a signature needs.
+type allowEmpty struct{ *git.Worktree }
+
+func (w allowEmpty) Commit(msg string, opts *git.CommitOptions) (plumbing.Hash, error) {
+	opts.AllowEmptyCommits = true
+	opts.Author = &object.Signature{Name: "drupdater", Email: "drupdater@example.com"}
+	return w.Worktree.Commit(msg, opts)
+}
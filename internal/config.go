@@ -1,6 +1,14 @@
 package internal
 
-import "time"
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/drupdater/drupdater/internal/codehosting"
+	"github.com/drupdater/drupdater/internal/signing"
+	"github.com/drupdater/drupdater/pkg/repo"
+)
 
 // Version is set at build time via -ldflags, and stays "dev" for builds that skip the Makefile.
 var Version = "dev"
@@ -13,15 +21,67 @@ type Config struct {
 	Clone         bool
 	Sites         []string
 	Security      bool
-	DryRun        bool
-	Verbose       bool
-	Timeout       time.Duration
-	RunTypes      RunTypesConfig
+	// Major raises composer.json constraints to each package's next major release, rather than
+	// updating within them.
+	Major    bool
+	DryRun   bool
+	Verbose  bool
+	Timeout  time.Duration
+	RunTypes RunTypesConfig
+	// Provider names the code hosting platform, skipping detection; empty auto-detects.
+	Provider string
+	// GithubAPIURL is the GitHub REST API root; empty derives it from the repository host.
+	GithubAPIURL string
+	// Groups splits a normal run into one merge request per group. Empty means one request for
+	// everything.
+	Groups UpdateGroups
+	// Group names the group this run updates; empty for an ungrouped run. Set per run by the
+	// caller, never by the config file.
+	Group string
+	// Ignore keeps packages at their installed version; Hold keeps them within a constraint.
+	Ignore []PackageRule
+	Hold   []PackageRule
+	// MinimumReleaseAge is how many days a release must have been out before an update adopts
+	// it; 0 adopts releases immediately.
+	MinimumReleaseAge int
+	// Notifications are where the summary of a finished run is posted; empty posts it nowhere.
+	Notifications []Notification
+	// Templates are the project's own merge request templates, their paths absolute; empty
+	// keeps the embedded ones.
+	Templates MergeRequestTemplates
+	// Commits words the run's commits; empty keeps the default messages.
+	Commits CommitsConfig
+	// SSH authenticates clone, fetch and push for an SSH repository URL; the token is then left
+	// to the platform's API.
+	SSH repo.SSHOptions
+	// SigningKey is the path --signing-key names; empty falls back to DRUPDATER_SIGNING_KEY.
+	SigningKey string
+	// Signer signs every commit of the run; nil leaves them unsigned. Resolved from SigningKey
+	// by the caller, never by the config file: a key is a secret, not a project setting.
+	Signer signing.Signer
 	// Concurrency bounds how many sites run at once; <= 0 means GOMAXPROCS(0). A CLI flag, not
 	// a config key: it describes the machine, not the project.
 	Concurrency int
 	// ReportPath is where the run report is written; empty disables it.
 	ReportPath string
+	// SARIFPath is where the advisories of the run report are written as SARIF; empty disables it.
+	SARIFPath string
+	// SBOMDir is where the CycloneDX SBOMs of the project before and after the update are written;
+	// empty disables them.
+	SBOMDir string
+	// TraceFile is where the run's OTLP/JSON trace is appended; empty disables it.
+	TraceFile string
+	// OTLPEndpoint is the base URL of the OTLP/HTTP collector the trace is sent to; empty falls
+	// back to the OTEL_EXPORTER_OTLP_* environment, and without that disables it.
+	OTLPEndpoint string
+	// MetricsFile is where the run's Prometheus metrics are written; empty disables it.
+	MetricsFile string
+	// PushgatewayURL is the Pushgateway the run's metrics are pushed to; empty disables it.
+	PushgatewayURL string
+	// LogFormat is how stderr log lines are encoded: "console" (the default) or "json".
+	LogFormat string
+	// LogFile is where log lines are also appended, always as JSON; empty disables it.
+	LogFile string
 }
 
 // RunTypesConfig is keyed on the run type, not the setting, so configuring one mode means
@@ -29,6 +89,7 @@ type Config struct {
 type RunTypesConfig struct {
 	Normal   RunTypeConfig `yaml:"normal"`
 	Security RunTypeConfig `yaml:"security"`
+	Major    RunTypeConfig `yaml:"major"`
 }
 
 // RunTypeConfig is what a single run type configures.
@@ -38,12 +99,213 @@ type RunTypeConfig struct {
 
 	// AutoMerge asks the platform to merge the MR/PR once its pipeline passes.
 	AutoMerge bool `yaml:"auto_merge"`
+
+	// Draft opens the MR/PR as a draft: visible from the start, but not up for review.
+	Draft bool `yaml:"draft"`
+
+	// MarkReady waits for a draft's pipeline to pass, then marks it ready for review.
+	MarkReady bool `yaml:"mark_ready"`
+
+	// UpdateLevel caps how far a locked package may move. composer.json still has the final say.
+	UpdateLevel UpdateLevel `yaml:"update_level"`
+
+	// Labels, Assignees, Reviewers and Milestone triage the merge request the run opens, so it
+	// reaches the right queue rather than landing unassigned.
+	Labels    []string `yaml:"labels,omitempty"`
+	Assignees []string `yaml:"assignees,omitempty"`
+	Reviewers []string `yaml:"reviewers,omitempty"`
+	Milestone string   `yaml:"milestone,omitempty"`
 }
 
-// ActiveRunType is where --security maps to a config block — the only place that mapping lives.
+// MergeRequestMetadata is the triage the run type asks for.
+func (r RunTypeConfig) MergeRequestMetadata() codehosting.MergeRequestMetadata {
+	return codehosting.MergeRequestMetadata{
+		Labels:    r.Labels,
+		Assignees: r.Assignees,
+		Reviewers: r.Reviewers,
+		Milestone: r.Milestone,
+	}
+}
+
+// UpdateLevel is the largest semantic version step an update may take.
+type UpdateLevel string
+
+const (
+	// UpdateLevelPatch keeps every package within its locked minor version.
+	UpdateLevelPatch UpdateLevel = "patch"
+	// UpdateLevelMinor keeps every package within its locked major version.
+	UpdateLevelMinor UpdateLevel = "minor"
+	// UpdateLevelMajor adds no cap: whatever composer.json allows.
+	UpdateLevelMajor UpdateLevel = "major"
+)
+
+// HostOptions is what the project states about its code hosting platform.
+func (c Config) HostOptions() codehosting.Options {
+	return codehosting.Options{Provider: c.Provider, GithubAPIURL: c.GithubAPIURL}
+}
+
+// ActiveRunType is where --security and --major map to a config block — the only place that
+// mapping lives.
 func (c Config) ActiveRunType() RunTypeConfig {
-	if c.Security {
+	switch {
+	case c.Security:
 		return c.RunTypes.Security
+	case c.Major:
+		return c.RunTypes.Major
 	}
 	return c.RunTypes.Normal
 }
+
+// ReleaseAge is the cooldown the run applies. A security run takes none: it exists to ship a
+// fix the day it is released. Nor does a major run, which raises constraints rather than
+// running the update the cooldown vets.
+func (c Config) ReleaseAge() time.Duration {
+	if c.Security || c.Major {
+		return 0
+	}
+	return time.Duration(c.MinimumReleaseAge) * 24 * time.Hour
+}
+
+// UpdateGroup is one merge request's worth of packages, named by glob patterns over the package
+// names composer.json requires.
+type UpdateGroup struct {
+	Name     string
+	Patterns []string
+}
+
+// UpdateGroups keeps the order of .drupdater.yaml: a package belongs to the first group with a
+// matching pattern, so a catch-all goes last.
+type UpdateGroups []UpdateGroup
+
+// RunGroups returns the group names to run, in order, or a single empty name for one ungrouped
+// run. A security run is never split: a fix should not wait behind a review of unrelated groups.
+// Nor is a major run, whose upgrades are tried one package at a time anyway.
+func (c Config) RunGroups() []string {
+	if c.Security || c.Major || len(c.Groups) == 0 {
+		return []string{""}
+	}
+	names := make([]string, len(c.Groups))
+	for i, g := range c.Groups {
+		names[i] = g.Name
+	}
+	return names
+}
+
+// Packages returns the packages of required that fall to the named group.
+func (g UpdateGroups) Packages(name string, required []string) []string {
+	var out []string
+	for _, pkg := range required {
+		if g.groupOf(pkg) == name {
+			out = append(out, pkg)
+		}
+	}
+	return out
+}
+
+// groupOf returns the first group claiming pkg, or "" when none does.
+func (g UpdateGroups) groupOf(pkg string) string {
+	for _, group := range g {
+		for _, pattern := range group.Patterns {
+			if globMatch(pattern, pkg) {
+				return group.Name
+			}
+		}
+	}
+	return ""
+}
+
+// globMatch matches a package name against a pattern in which * stands for any run of
+// characters, "/" included — so "*" alone is a catch-all, which path.Match cannot express.
+func globMatch(pattern, name string) bool {
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(pattern)), `\*`, ".*") + "$"
+	return regexp.MustCompile(expr).MatchString(strings.ToLower(name))
+}
+
+// PackageRule keeps the packages matching a glob out of an update. An ignore rule has no
+// Constraint and leaves them at their installed version; a hold rule lets them update, but only
+// within Constraint.
+type PackageRule struct {
+	// Package is a glob over package names, matched as in groups.
+	Package    string `yaml:"package"`
+	Constraint string `yaml:"constraint,omitempty"`
+	// Until is the last day the rule applies, as YYYY-MM-DD; empty for a rule that never lapses.
+	Until  string `yaml:"until,omitempty"`
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// Matches reports whether the rule covers the named package.
+func (r PackageRule) Matches(pkg string) bool {
+	return globMatch(r.Package, pkg)
+}
+
+// Expired reports whether the rule has lapsed by now. Until is inclusive, in UTC: a rule until
+// the 31st still applies on the 31st.
+func (r PackageRule) Expired(now time.Time) bool {
+	if r.Until == "" {
+		return false
+	}
+	until, err := time.Parse(time.DateOnly, r.Until)
+	if err != nil {
+		// LoadConfigFile rejects it; a rule built in code that cannot say when it ends never does.
+		return false
+	}
+	return !now.UTC().Before(until.AddDate(0, 0, 1))
+}
+
+// Notification posts a summary of the finished run to one chat room or webhook.
+type Notification struct {
+	// Type is the destination's kind: one of NotificationTypes.
+	Type NotificationType `yaml:"type"`
+	// URL is the incoming webhook, or a Matrix homeserver's base URL. Like Token it may name
+	// environment variables as ${NAME}, read when the run ends, so a secret stays out of the
+	// repository.
+	URL string `yaml:"url"`
+	// Room and Token are Matrix's: the room id to post in and the access token of the account
+	// posting.
+	Room  string `yaml:"room,omitempty"`
+	Token string `yaml:"token,omitempty"`
+	// On lists the outcomes worth posting about; empty means DefaultNotifyTriggers.
+	On []NotifyTrigger `yaml:"on,omitempty"`
+}
+
+// NotificationType is where a Notification posts.
+type NotificationType string
+
+const (
+	NotificationSlack   NotificationType = "slack"
+	NotificationTeams   NotificationType = "teams"
+	NotificationMatrix  NotificationType = "matrix"
+	NotificationWebhook NotificationType = "webhook"
+)
+
+// NotificationTypes lists every NotificationType, in the order errors name them.
+var NotificationTypes = []NotificationType{NotificationSlack, NotificationTeams, NotificationMatrix, NotificationWebhook}
+
+// NotifyTrigger is a run outcome a Notification can be limited to.
+type NotifyTrigger string
+
+const (
+	NotifyOnSuccess   NotifyTrigger = "success"
+	NotifyOnNoChanges NotifyTrigger = "no_changes"
+	NotifyOnFailed    NotifyTrigger = "failed"
+	// NotifyOnUnresolvedAdvisories fires whatever the status, when an advisory still affects the
+	// project after the update.
+	NotifyOnUnresolvedAdvisories NotifyTrigger = "unresolved_advisories"
+	// NotifyOnPatchConflicts fires whatever the status, when a patch no longer applies.
+	NotifyOnPatchConflicts NotifyTrigger = "patch_conflicts"
+)
+
+// NotifyTriggers lists every NotifyTrigger, in the order errors name them.
+var NotifyTriggers = []NotifyTrigger{NotifyOnSuccess, NotifyOnNoChanges, NotifyOnFailed, NotifyOnUnresolvedAdvisories, NotifyOnPatchConflicts}
+
+// DefaultNotifyTriggers is what a Notification without on fires for: every run that did
+// something, or failed to. A run with nothing to update needs nobody's attention.
+var DefaultNotifyTriggers = []NotifyTrigger{NotifyOnSuccess, NotifyOnFailed}
+
+// Triggers returns the outcomes n fires for, its defaults applied.
+func (n Notification) Triggers() []NotifyTrigger {
+	if len(n.On) == 0 {
+		return DefaultNotifyTriggers
+	}
+	return n.On
+}
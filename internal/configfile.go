@@ -5,9 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/drupdater/drupdater/internal/codehosting"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,12 +36,58 @@ func (t *flexTimeout) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
-// fileConfig mirrors the YAML-settable keys of .drupdater.yaml. Split by scope: sites and timeout
-// describe the whole run, per-mode settings live under run_types where they cannot collide.
+// fileConfig mirrors the YAML-settable keys of .drupdater.yaml. Split by scope: sites, timeout and
+// the host settings describe the whole run, per-mode settings live under run_types where they
+// cannot collide.
 type fileConfig struct {
-	Sites    []string       `yaml:"sites"`
-	Timeout  flexTimeout    `yaml:"timeout"`
-	RunTypes RunTypesConfig `yaml:"run_types"`
+	Sites        []string      `yaml:"sites"`
+	Timeout      flexTimeout   `yaml:"timeout"`
+	Provider     string        `yaml:"provider"`
+	GithubAPIURL string        `yaml:"github_api_url"`
+	Groups       UpdateGroups  `yaml:"groups,omitempty"`
+	Ignore       []PackageRule `yaml:"ignore,omitempty"`
+	Hold         []PackageRule `yaml:"hold,omitempty"`
+	// MinimumReleaseAge is in days: release cadences are counted in days, and a Go duration has
+	// no day unit.
+	MinimumReleaseAge int                   `yaml:"minimum_release_age"`
+	RunTypes          RunTypesConfig        `yaml:"run_types"`
+	Notifications     []Notification        `yaml:"notifications,omitempty"`
+	Templates         MergeRequestTemplates `yaml:"templates"`
+	Commits           CommitsConfig         `yaml:"commits"`
+}
+
+// UnmarshalYAML reads groups as a mapping of name to patterns, keeping the file's order — a
+// Go map would lose the order that decides which group claims a package.
+func (g *UpdateGroups) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: groups must map each group name to a list of package patterns", node.Line)
+	}
+	groups := make(UpdateGroups, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var group UpdateGroup
+		if err := node.Content[i].Decode(&group.Name); err != nil {
+			return err
+		}
+		if err := node.Content[i+1].Decode(&group.Patterns); err != nil {
+			return fmt.Errorf("group %q: %w", group.Name, err)
+		}
+		groups = append(groups, group)
+	}
+	*g = groups
+	return nil
+}
+
+// MarshalYAML writes groups back as the mapping UnmarshalYAML reads.
+func (g UpdateGroups) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, group := range g {
+		var patterns yaml.Node
+		if err := patterns.Encode(group.Patterns); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: group.Name}, &patterns)
+	}
+	return node, nil
 }
 
 // legacyProbe detects the pre-run_types layout. Strict decoding rejects it already, but says
@@ -50,9 +103,11 @@ func defaultFileConfig() fileConfig {
 		Sites:   []string{"default"},
 		Timeout: "30m",
 		RunTypes: RunTypesConfig{
-			Normal: RunTypeConfig{Addons: defaultNormalAddons},
+			Normal: RunTypeConfig{Addons: defaultNormalAddons, UpdateLevel: UpdateLevelMajor},
 			// Mandatory addons only, so a security update stays a focused fix.
-			Security: RunTypeConfig{},
+			Security: RunTypeConfig{UpdateLevel: UpdateLevelMajor},
+			// The normal set: raising a major is when deprecated code is most likely to break.
+			Major: RunTypeConfig{Addons: defaultNormalAddons, UpdateLevel: UpdateLevelMajor},
 		},
 	}
 }
@@ -81,6 +136,8 @@ func LoadConfigFile(path string, c *Config) (found bool, err error) {
 		return true, fmt.Errorf("parsing %s: %w", path, err)
 	}
 
+	// Relative to the file, like any path a file names: --config may point anywhere.
+	fc.Templates = fc.Templates.resolve(filepath.Dir(path))
 	if err := applyFileConfig(fc, c); err != nil {
 		return true, fmt.Errorf("in %s: %w", path, err)
 	}
@@ -121,8 +178,187 @@ func applyFileConfig(fc fileConfig, c *Config) error {
 	if len(fc.Sites) == 0 {
 		return errors.New(`no sites configured: "sites" must list at least one Drupal site name`)
 	}
+	if err := codehosting.ValidateProvider(fc.Provider); err != nil {
+		return err
+	}
+	if fc.GithubAPIURL != "" {
+		if u, err := url.Parse(fc.GithubAPIURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid github_api_url %q: expected an absolute URL like \"https://github.example.com/api/v3\"", fc.GithubAPIURL)
+		}
+	}
+	if err := validateGroups(fc.Groups); err != nil {
+		return err
+	}
+	if err := validatePackageRules(fc.Ignore, fc.Hold); err != nil {
+		return err
+	}
+	if err := validateDraft("normal", fc.RunTypes.Normal); err != nil {
+		return err
+	}
+	if err := validateDraft("security", fc.RunTypes.Security); err != nil {
+		return err
+	}
+	if err := validateDraft("major", fc.RunTypes.Major); err != nil {
+		return err
+	}
+	if err := validateMergeRequestMetadata("normal", fc.RunTypes.Normal); err != nil {
+		return err
+	}
+	if err := validateMergeRequestMetadata("security", fc.RunTypes.Security); err != nil {
+		return err
+	}
+	if err := validateMergeRequestMetadata("major", fc.RunTypes.Major); err != nil {
+		return err
+	}
+	if err := validateUpdateLevel("normal", fc.RunTypes.Normal.UpdateLevel); err != nil {
+		return err
+	}
+	if err := validateUpdateLevel("security", fc.RunTypes.Security.UpdateLevel); err != nil {
+		return err
+	}
+	if err := validateNotifications(fc.Notifications); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(fc.Templates.Addons)) {
+		if fc.Templates.Addons[name] == "" {
+			return fmt.Errorf("templates.addons.%s needs a path", name)
+		}
+	}
+	if err := validateCommits(fc.Commits); err != nil {
+		return err
+	}
+	if fc.MinimumReleaseAge < 0 {
+		return fmt.Errorf("invalid minimum_release_age %d: use a number of days, or 0 to adopt releases immediately", fc.MinimumReleaseAge)
+	}
+	// Any lower level would pin every package the run is meant to upgrade.
+	if fc.RunTypes.Major.UpdateLevel != UpdateLevelMajor {
+		return fmt.Errorf("invalid run_types.major.update_level %q: a major run only makes sense at major", fc.RunTypes.Major.UpdateLevel)
+	}
 	c.Sites = fc.Sites
 	c.Timeout = timeout
+	c.Provider = fc.Provider
+	c.GithubAPIURL = fc.GithubAPIURL
+	c.Groups = fc.Groups
+	c.Ignore = fc.Ignore
+	c.Hold = fc.Hold
+	c.MinimumReleaseAge = fc.MinimumReleaseAge
 	c.RunTypes = fc.RunTypes
+	c.Notifications = fc.Notifications
+	c.Templates = fc.Templates
+	c.Commits = fc.Commits
+	return nil
+}
+
+func validateUpdateLevel(runType string, level UpdateLevel) error {
+	switch level {
+	case UpdateLevelPatch, UpdateLevelMinor, UpdateLevelMajor:
+		return nil
+	}
+	return fmt.Errorf("invalid run_types.%s.update_level %q: use patch, minor or major", runType, level)
+}
+
+// validateDraft rejects the combinations that could never do what they say: mark_ready has no
+// draft to promote, and no platform merges a draft, so auto-merge needs it promoted first.
+func validateDraft(runType string, rt RunTypeConfig) error {
+	if rt.MarkReady && !rt.Draft {
+		return fmt.Errorf("run_types.%s.mark_ready needs draft: true", runType)
+	}
+	if rt.AutoMerge && rt.Draft && !rt.MarkReady {
+		return fmt.Errorf("run_types.%s: a draft is never merged, so auto_merge with draft needs mark_ready: true", runType)
+	}
+	return nil
+}
+
+// validateMergeRequestMetadata rejects the blank entries a stray "-" leaves in a YAML list, which
+// every platform would reject only once the merge request exists.
+func validateMergeRequestMetadata(runType string, rt RunTypeConfig) error {
+	for _, list := range []struct {
+		key    string
+		values []string
+	}{{"labels", rt.Labels}, {"assignees", rt.Assignees}, {"reviewers", rt.Reviewers}} {
+		if slices.Contains(list.values, "") {
+			return fmt.Errorf("run_types.%s.%s has an empty entry", runType, list.key)
+		}
+	}
+	return nil
+}
+
+// groupNamePattern keeps a group name usable in a branch name.
+var groupNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func validateGroups(groups UpdateGroups) error {
+	seen := map[string]bool{}
+	for _, g := range groups {
+		if !groupNamePattern.MatchString(g.Name) {
+			return fmt.Errorf("invalid group name %q: use lowercase letters, digits, \"-\" and \"_\"", g.Name)
+		}
+		if seen[g.Name] {
+			return fmt.Errorf("group %q is defined twice", g.Name)
+		}
+		seen[g.Name] = true
+		if len(g.Patterns) == 0 || slices.Contains(g.Patterns, "") {
+			return fmt.Errorf("group %q needs at least one non-empty package pattern", g.Name)
+		}
+	}
+	return nil
+}
+
+// validatePackageRules checks what the file can get wrong without composer: composer itself
+// judges a hold's constraint when the update runs.
+func validatePackageRules(ignore []PackageRule, hold []PackageRule) error {
+	for _, set := range []struct {
+		kind  string
+		rules []PackageRule
+	}{{"ignore", ignore}, {"hold", hold}} {
+		kind := set.kind
+		for i, r := range set.rules {
+			if r.Package == "" {
+				return fmt.Errorf("%s rule %d needs a package", kind, i+1)
+			}
+			if kind == "hold" && r.Constraint == "" {
+				return fmt.Errorf("hold rule for %q needs a constraint, like \"<4.3\" or \"4.2.*\"", r.Package)
+			}
+			if kind == "ignore" && r.Constraint != "" {
+				return fmt.Errorf("ignore rule for %q has a constraint: move it to hold to allow updates within it", r.Package)
+			}
+			if r.Until != "" {
+				if _, err := time.Parse(time.DateOnly, r.Until); err != nil {
+					return fmt.Errorf("%s rule for %q: invalid until %q, expected a date like \"2026-12-31\"", kind, r.Package, r.Until)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateNotifications checks each destination's shape. Whether the variables its URL and token
+// name are set is drupdater check's business: they need only be set where the run happens.
+func validateNotifications(notifications []Notification) error {
+	for i, n := range notifications {
+		if !slices.Contains(NotificationTypes, n.Type) {
+			return fmt.Errorf("notification %d: unknown type %q: use slack, teams, matrix or webhook", i+1, n.Type)
+		}
+		if n.URL == "" {
+			return fmt.Errorf("notification %d (%s) needs a url", i+1, n.Type)
+		}
+		// A URL built from variables is only known once they are read.
+		if !strings.Contains(n.URL, "$") {
+			if u, err := url.Parse(n.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return fmt.Errorf("notification %d (%s): invalid url %q: expected an absolute URL, or one read from the environment like \"${SLACK_WEBHOOK_URL}\"", i+1, n.Type, n.URL)
+			}
+		}
+		if n.Type == NotificationMatrix {
+			if n.Room == "" || n.Token == "" {
+				return fmt.Errorf("notification %d (matrix) needs a room and a token", i+1)
+			}
+		} else if n.Room != "" || n.Token != "" {
+			return fmt.Errorf("notification %d (%s): room and token are for matrix only; a %s webhook's url is its credential", i+1, n.Type, n.Type)
+		}
+		for _, trigger := range n.On {
+			if !slices.Contains(NotifyTriggers, trigger) {
+				return fmt.Errorf("notification %d (%s): unknown trigger %q in on: use success, no_changes, failed, unresolved_advisories or patch_conflicts", i+1, n.Type, trigger)
+			}
+		}
+	}
 	return nil
 }
@@ -21,19 +21,77 @@ import (
 // rather than the ones an example filled in.
 func fileConfigGen() *rapid.Generator[fileConfig] {
 	addonsGen := rapid.SliceOfNDistinct(rapid.SampledFrom(defaultNormalAddons), 0, len(defaultNormalAddons), rapid.ID)
+	levelGen := rapid.SampledFrom([]UpdateLevel{UpdateLevelPatch, UpdateLevelMinor, UpdateLevelMajor})
+	labelsGen := rapid.SliceOfN(rapid.StringMatching(`[a-z][a-z0-9 :-]{0,15}`), 1, 3)
+	usersGen := rapid.SliceOfN(rapid.StringMatching(`[a-z][a-z0-9-]{0,10}(/[a-z][a-z0-9-]{0,10})?`), 1, 3)
+	milestoneGen := rapid.StringMatching(`[A-Z][a-z]{2,8} [0-9]{1,3}`)
+	// auto_merge, draft and mark_ready are drawn together: validateDraft rejects some combinations.
+	publishGen := rapid.Custom(func(t *rapid.T) RunTypeConfig {
+		rt := RunTypeConfig{Draft: rapid.Bool().Draw(t, "draft")}
+		rt.MarkReady = rt.Draft && rapid.Bool().Draw(t, "markReady")
+		rt.AutoMerge = rapid.Bool().Draw(t, "autoMerge") && (!rt.Draft || rt.MarkReady)
+		return rt
+	})
+
+	pathGen := rapid.StringMatching(`[a-z][a-z0-9_]{0,8}/[a-z][a-z0-9_]{0,8}\.md\.tmpl`)
+	templatesGen := rapid.Custom(func(t *rapid.T) MergeRequestTemplates {
+		return MergeRequestTemplates{
+			Title:       rapid.StringMatching(`\{\{ \.Title \}\}( \[[a-z]{1,8}\])?`).Draw(t, "title"),
+			Description: pathGen.Draw(t, "description"),
+			Addons:      rapid.MapOfN(rapid.SampledFrom([]string{"composer_diff", "update_hooks", "composer_audit"}), pathGen, 1, 3).Draw(t, "addons"),
+		}
+	})
+
+	commitsGen := rapid.Custom(func(t *rapid.T) CommitsConfig {
+		return CommitsConfig{
+			Messages: rapid.MapOfN(rapid.SampledFrom(CommitKinds), rapid.StringMatching(`(chore|fix)\([a-z]{1,8}\): [a-z ]{1,20}`), 1, len(CommitKinds)).Draw(t, "messages"),
+			Trailers: rapid.SliceOfN(rapid.StringMatching(`(Signed-off-by|Refs): [A-Za-z0-9 <>@.-]{1,20}[A-Za-z0-9>]`), 1, 3).Draw(t, "trailers"),
+		}
+	})
 
 	return rapid.Custom(func(t *rapid.T) fileConfig {
+		normal := publishGen.Draw(t, "normalPublish")
+		security := publishGen.Draw(t, "securityPublish")
+		major := publishGen.Draw(t, "majorPublish")
 		return fileConfig{
-			Sites:   rapid.SliceOfNDistinct(rapid.StringMatching(`[a-z][a-z0-9_]{0,10}`), 1, 4, rapid.ID).Draw(t, "sites"),
-			Timeout: flexTimeout(rapid.SampledFrom([]string{"0", "45s", "30m", "2h", "1h30m"}).Draw(t, "timeout")),
+			Sites:             rapid.SliceOfNDistinct(rapid.StringMatching(`[a-z][a-z0-9_]{0,10}`), 1, 4, rapid.ID).Draw(t, "sites"),
+			Timeout:           flexTimeout(rapid.SampledFrom([]string{"0", "45s", "30m", "2h", "1h30m"}).Draw(t, "timeout")),
+			MinimumReleaseAge: rapid.IntRange(0, 30).Draw(t, "minimumReleaseAge"),
+			Templates:         templatesGen.Draw(t, "templates"),
+			Commits:           commitsGen.Draw(t, "commits"),
 			RunTypes: RunTypesConfig{
 				Normal: RunTypeConfig{
-					Addons:    addonsGen.Draw(t, "normalAddons"),
-					AutoMerge: rapid.Bool().Draw(t, "normalAutoMerge"),
+					Addons:      addonsGen.Draw(t, "normalAddons"),
+					AutoMerge:   normal.AutoMerge,
+					Draft:       normal.Draft,
+					MarkReady:   normal.MarkReady,
+					UpdateLevel: levelGen.Draw(t, "normalUpdateLevel"),
+					Labels:      labelsGen.Draw(t, "normalLabels"),
+					Assignees:   usersGen.Draw(t, "normalAssignees"),
+					Reviewers:   usersGen.Draw(t, "normalReviewers"),
+					Milestone:   milestoneGen.Draw(t, "normalMilestone"),
 				},
 				Security: RunTypeConfig{
-					Addons:    addonsGen.Draw(t, "securityAddons"),
-					AutoMerge: rapid.Bool().Draw(t, "securityAutoMerge"),
+					Addons:      addonsGen.Draw(t, "securityAddons"),
+					AutoMerge:   security.AutoMerge,
+					Draft:       security.Draft,
+					MarkReady:   security.MarkReady,
+					UpdateLevel: levelGen.Draw(t, "securityUpdateLevel"),
+					Labels:      labelsGen.Draw(t, "securityLabels"),
+					Assignees:   usersGen.Draw(t, "securityAssignees"),
+					Reviewers:   usersGen.Draw(t, "securityReviewers"),
+					Milestone:   milestoneGen.Draw(t, "securityMilestone"),
+				},
+				Major: RunTypeConfig{
+					Addons:      addonsGen.Draw(t, "majorAddons"),
+					AutoMerge:   major.AutoMerge,
+					Draft:       major.Draft,
+					MarkReady:   major.MarkReady,
+					UpdateLevel: UpdateLevelMajor,
+					Labels:      labelsGen.Draw(t, "majorLabels"),
+					Assignees:   usersGen.Draw(t, "majorAssignees"),
+					Reviewers:   usersGen.Draw(t, "majorReviewers"),
+					Milestone:   milestoneGen.Draw(t, "majorMilestone"),
 				},
 			},
 		}
@@ -66,6 +124,9 @@ func TestPropertyLoadConfigFileRoundTripsEveryKey(t *testing.T) {
 		// renamed key would fail the decode rather than silently fall back to a default.
 		assert.Equal(t, want.Sites, got.Sites)
 		assert.Equal(t, want.RunTypes, got.RunTypes)
+		assert.Equal(t, want.MinimumReleaseAge, got.MinimumReleaseAge)
+		assert.Equal(t, want.Templates.resolve(dir), got.Templates)
+		assert.Equal(t, want.Commits, got.Commits)
 
 		wantTimeout, err := time.ParseDuration(string(want.Timeout))
 		require.NoError(t, err)
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/drupdater/drupdater/internal/codehosting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +63,33 @@ func TestLoadConfigFile(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("provider is applied", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "provider: gitea\n"), &c)
+		require.NoError(t, err)
+		assert.Equal(t, "gitea", c.Provider)
+	})
+
+	t.Run("unknown provider is rejected", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "provider: gogs\n"), &c)
+		require.ErrorContains(t, err, `unknown provider "gogs"`)
+	})
+
+	t.Run("github_api_url is applied", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "github_api_url: https://github.example.com/api/v3\n"), &c)
+		require.NoError(t, err)
+		assert.Equal(t, "https://github.example.com/api/v3", c.GithubAPIURL)
+		assert.Equal(t, "https://github.example.com/api/v3", c.HostOptions().GithubAPIURL)
+	})
+
+	t.Run("a relative github_api_url is rejected", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "github_api_url: github.example.com/api/v3\n"), &c)
+		require.ErrorContains(t, err, "invalid github_api_url")
+	})
+
 	t.Run("unknown key is rejected", func(t *testing.T) {
 		var c Config
 		_, err := LoadConfigFile(writeConfig(t, "timout: 30m\n"), &c) // typo
@@ -145,6 +173,66 @@ func TestLoadConfigFile(t *testing.T) {
 		assert.False(t, c.RunTypes.Security.AutoMerge)
 	})
 
+	t.Run("update_level defaults to major and is set per run type", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "run_types:\n  normal:\n    update_level: minor\n"), &c)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateLevelMinor, c.RunTypes.Normal.UpdateLevel)
+		assert.Equal(t, []string{"code_beautifier", "deprecations_remover", "translations_updater", "composer_normalizer"}, c.RunTypes.Normal.Addons)
+		assert.Equal(t, UpdateLevelMajor, c.RunTypes.Security.UpdateLevel)
+	})
+
+	t.Run("an unknown update_level is rejected", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "run_types:\n  security:\n    update_level: minors\n"), &c)
+		require.ErrorContains(t, err, "run_types.security.update_level")
+	})
+
+	t.Run("a run type opens drafts and marks them ready", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "run_types:\n  normal:\n    draft: true\n    mark_ready: true\n    auto_merge: true\n"), &c)
+		require.NoError(t, err)
+		assert.True(t, c.RunTypes.Normal.Draft)
+		assert.True(t, c.RunTypes.Normal.MarkReady)
+		assert.False(t, c.RunTypes.Security.Draft)
+	})
+
+	t.Run("draft settings that could never apply are rejected", func(t *testing.T) {
+		for body, want := range map[string]string{
+			"run_types:\n  security:\n    mark_ready: true\n":                "run_types.security.mark_ready needs draft: true",
+			"run_types:\n  normal:\n    draft: true\n    auto_merge: true\n": "auto_merge with draft needs mark_ready: true",
+		} {
+			var c Config
+			_, err := LoadConfigFile(writeConfig(t, body), &c)
+			require.ErrorContains(t, err, want)
+		}
+	})
+
+	t.Run("a run type sets what its merge requests are triaged with", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, `run_types:
+  security:
+    labels: [dependencies, "priority::high"]
+    assignees: [alice]
+    reviewers: [bob, acme/maintainers]
+    milestone: Sprint 42
+`), &c)
+		require.NoError(t, err)
+		assert.Equal(t, codehosting.MergeRequestMetadata{
+			Labels:    []string{"dependencies", "priority::high"},
+			Assignees: []string{"alice"},
+			Reviewers: []string{"bob", "acme/maintainers"},
+			Milestone: "Sprint 42",
+		}, c.RunTypes.Security.MergeRequestMetadata())
+		assert.True(t, c.RunTypes.Normal.MergeRequestMetadata().IsZero())
+	})
+
+	t.Run("an empty label, assignee or reviewer is rejected", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "run_types:\n  normal:\n    reviewers: [bob, \"\"]\n"), &c)
+		require.ErrorContains(t, err, "run_types.normal.reviewers has an empty entry")
+	})
+
 	t.Run("ActiveRunType follows the security flag", func(t *testing.T) {
 		c := Config{RunTypes: RunTypesConfig{
 			Normal:   RunTypeConfig{Addons: []string{"code_beautifier"}, AutoMerge: false},
@@ -158,6 +246,187 @@ func TestLoadConfigFile(t *testing.T) {
 		assert.True(t, c.ActiveRunType().AutoMerge)
 	})
 
+	t.Run("ActiveRunType follows the major flag", func(t *testing.T) {
+		c := Config{Major: true, RunTypes: RunTypesConfig{
+			Normal: RunTypeConfig{Addons: []string{"code_beautifier"}},
+			Major:  RunTypeConfig{Addons: []string{"deprecations_remover"}},
+		}}
+		assert.Equal(t, []string{"deprecations_remover"}, c.ActiveRunType().Addons)
+	})
+
+	t.Run("the major run type defaults to the normal addons", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(filepath.Join(t.TempDir(), "absent.yaml"), &c)
+		require.NoError(t, err)
+		assert.Equal(t, defaultNormalAddons, c.RunTypes.Major.Addons)
+		assert.False(t, c.RunTypes.Major.AutoMerge)
+	})
+
+	t.Run("the major run type rejects a lower update_level", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "run_types:\n  major:\n    update_level: minor\n"), &c)
+		require.ErrorContains(t, err, "run_types.major.update_level")
+	})
+
+	t.Run("groups keep their file order", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "groups:\n  other: ['*']\n  core: [drupal/core*]\n"), &c)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateGroups{
+			{Name: "other", Patterns: []string{"*"}},
+			{Name: "core", Patterns: []string{"drupal/core*"}},
+		}, c.Groups)
+	})
+
+	t.Run("invalid groups are rejected", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"a list":            "groups: [core]\n",
+			"no patterns":       "groups:\n  core: []\n",
+			"an empty pattern":  "groups:\n  core: ['']\n",
+			"an unsafe name":    "groups:\n  Core/Stuff: ['*']\n",
+			"a duplicated name": "groups:\n  core: [a/*]\n  core: [b/*]\n",
+		} {
+			var c Config
+			_, err := LoadConfigFile(writeConfig(t, body), &c)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("ignore and hold rules are applied", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, `ignore:
+  - package: drupal/legacy_*
+    reason: Replaced in Q3
+hold:
+  - package: drupal/search_api_solr
+    constraint: "<4.3"
+    until: 2026-12-31
+`), &c)
+		require.NoError(t, err)
+		assert.Equal(t, []PackageRule{{Package: "drupal/legacy_*", Reason: "Replaced in Q3"}}, c.Ignore)
+		assert.Equal(t, []PackageRule{{Package: "drupal/search_api_solr", Constraint: "<4.3", Until: "2026-12-31"}}, c.Hold)
+	})
+
+	t.Run("invalid package rules are rejected", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"no package":                "ignore:\n  - reason: why\n",
+			"a hold without constraint": "hold:\n  - package: drupal/core\n",
+			"an ignore with constraint": "ignore:\n  - package: drupal/core\n    constraint: ^10\n",
+			"an unparsable until":       "ignore:\n  - package: drupal/core\n    until: next week\n",
+		} {
+			var c Config
+			_, err := LoadConfigFile(writeConfig(t, body), &c)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("minimum_release_age is applied in days", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "minimum_release_age: 7\n"), &c)
+		require.NoError(t, err)
+		assert.Equal(t, 7, c.MinimumReleaseAge)
+		assert.Equal(t, 7*24*time.Hour, c.ReleaseAge())
+	})
+
+	t.Run("a negative minimum_release_age is rejected", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "minimum_release_age: -1\n"), &c)
+		require.ErrorContains(t, err, "minimum_release_age")
+	})
+
+	t.Run("notifications are applied", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, `notifications:
+  - type: slack
+    url: ${SLACK_WEBHOOK_URL}
+    on: [failed, unresolved_advisories]
+  - type: matrix
+    url: https://matrix.example.org
+    room: "!ops:example.org"
+    token: ${MATRIX_TOKEN}
+`), &c)
+		require.NoError(t, err)
+		assert.Equal(t, []Notification{
+			{Type: NotificationSlack, URL: "${SLACK_WEBHOOK_URL}", On: []NotifyTrigger{NotifyOnFailed, NotifyOnUnresolvedAdvisories}},
+			{Type: NotificationMatrix, URL: "https://matrix.example.org", Room: "!ops:example.org", Token: "${MATRIX_TOKEN}"},
+		}, c.Notifications)
+		assert.Equal(t, DefaultNotifyTriggers, c.Notifications[1].Triggers())
+	})
+
+	t.Run("invalid notifications are rejected", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"an unknown type":       "notifications:\n  - type: email\n    url: https://example.com\n",
+			"no url":                "notifications:\n  - type: slack\n",
+			"a relative url":        "notifications:\n  - type: webhook\n    url: /hook\n",
+			"matrix without a room": "notifications:\n  - type: matrix\n    url: https://matrix.example.org\n    token: x\n",
+			"a token on a webhook":  "notifications:\n  - type: teams\n    url: https://example.com\n    token: x\n",
+			"an unknown trigger":    "notifications:\n  - type: slack\n    url: https://example.com\n    on: [always]\n",
+		} {
+			var c Config
+			_, err := LoadConfigFile(writeConfig(t, body), &c)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("template paths are relative to the file", func(t *testing.T) {
+		path := writeConfig(t, `templates:
+  title: "{{ .Title }} [{{ .Mode }}]"
+  description: .drupdater/merge_request.md.tmpl
+  addons:
+    composer_diff: /etc/drupdater/composer_diff.md.tmpl
+    update_hooks: .drupdater/update_hooks.md.tmpl
+`)
+		var c Config
+		_, err := LoadConfigFile(path, &c)
+		require.NoError(t, err)
+		dir := filepath.Dir(path)
+		assert.Equal(t, MergeRequestTemplates{
+			Title:       "{{ .Title }} [{{ .Mode }}]",
+			Description: filepath.Join(dir, ".drupdater/merge_request.md.tmpl"),
+			Addons: map[string]string{
+				"composer_diff": "/etc/drupdater/composer_diff.md.tmpl",
+				"update_hooks":  filepath.Join(dir, ".drupdater/update_hooks.md.tmpl"),
+			},
+		}, c.Templates)
+	})
+
+	t.Run("an addon template without a path is rejected", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, "templates:\n  addons:\n    composer_diff: \"\"\n"), &c)
+		require.ErrorContains(t, err, "templates.addons.composer_diff needs a path")
+	})
+
+	t.Run("commit messages and trailers are applied", func(t *testing.T) {
+		var c Config
+		_, err := LoadConfigFile(writeConfig(t, `commits:
+  messages:
+    dependencies: "chore(deps): update {{ len .Packages }} packages"
+    translations: "chore(i18n): update {{ .Site }} translations"
+  trailers:
+    - "Signed-off-by: ACME Bot <bot@acme.example>"
+`), &c)
+		require.NoError(t, err)
+		assert.Equal(t, CommitsConfig{
+			Messages: map[CommitKind]string{
+				CommitDependencies: "chore(deps): update {{ len .Packages }} packages",
+				CommitTranslations: "chore(i18n): update {{ .Site }} translations",
+			},
+			Trailers: []string{"Signed-off-by: ACME Bot <bot@acme.example>"},
+		}, c.Commits)
+	})
+
+	t.Run("commit templates that could not render are rejected", func(t *testing.T) {
+		for body, want := range map[string]string{
+			"commits:\n  messages:\n    composer: x\n":                      `unknown commit kind "composer"`,
+			"commits:\n  messages:\n    dependencies: \"{{ .Pakages }}\"\n": "commits.messages.dependencies:",
+			"commits:\n  trailers: [\"Refs {{ .Group }}\"]\n":               "commits.trailers[0]",
+		} {
+			var c Config
+			_, err := LoadConfigFile(writeConfig(t, body), &c)
+			require.ErrorContains(t, err, want, body)
+		}
+	})
+
 	t.Run("the pre-run_types layout fails with a migration message", func(t *testing.T) {
 		// Strict decoding alone would say "field addons not found in type internal.fileConfig",
 		// which does not tell the reader what to write instead.
@@ -173,3 +442,52 @@ func TestLoadConfigFile(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdateGroups(t *testing.T) {
+	groups := UpdateGroups{
+		{Name: "core", Patterns: []string{"drupal/core*"}},
+		{Name: "contrib", Patterns: []string{"drupal/*"}},
+		{Name: "other", Patterns: []string{"*"}},
+	}
+	required := []string{"Drupal/Core-Recommended", "drupal/core-dev", "drupal/token", "drush/drush"}
+
+	t.Run("a package goes to the first matching group", func(t *testing.T) {
+		assert.Equal(t, []string{"Drupal/Core-Recommended", "drupal/core-dev"}, groups.Packages("core", required))
+		assert.Equal(t, []string{"drupal/token"}, groups.Packages("contrib", required))
+		assert.Equal(t, []string{"drush/drush"}, groups.Packages("other", required))
+	})
+
+	t.Run("a group nothing falls to is empty", func(t *testing.T) {
+		assert.Empty(t, groups[:2].Packages("contrib", []string{"drush/drush"}))
+	})
+
+	t.Run("RunGroups splits only a normal run", func(t *testing.T) {
+		assert.Equal(t, []string{"core", "contrib", "other"}, Config{Groups: groups}.RunGroups())
+		assert.Equal(t, []string{""}, Config{Groups: groups, Security: true}.RunGroups())
+		assert.Equal(t, []string{""}, Config{Groups: groups, Major: true}.RunGroups())
+		assert.Equal(t, []string{""}, Config{}.RunGroups())
+	})
+}
+
+func TestReleaseAge(t *testing.T) {
+	c := Config{MinimumReleaseAge: 3}
+	assert.Equal(t, 72*time.Hour, c.ReleaseAge())
+
+	// A fix is adopted the day it ships.
+	c.Security = true
+	assert.Zero(t, c.ReleaseAge())
+
+	c.Security, c.Major = false, true
+	assert.Zero(t, c.ReleaseAge())
+}
+
+func TestPackageRule(t *testing.T) {
+	rule := PackageRule{Package: "drupal/legacy_*", Until: "2026-05-31"}
+
+	assert.True(t, rule.Matches("drupal/legacy_theme"))
+	assert.False(t, rule.Matches("drupal/core"))
+
+	assert.False(t, rule.Expired(time.Date(2026, time.May, 31, 23, 59, 0, 0, time.UTC)), "until is inclusive")
+	assert.True(t, rule.Expired(time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, PackageRule{Package: "drupal/core"}.Expired(time.Now()), "no until never lapses")
+}
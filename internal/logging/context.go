@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"context"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+type fieldsKey struct{}
+
+// WithFields returns ctx carrying fields on top of those it already carries. The workflow puts
+// the phase and the site here, since the loggers that log inside them are built long before.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	carried, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	return context.WithValue(ctx, fieldsKey{}, slices.Concat(carried, fields))
+}
+
+// For returns logger with the fields ctx carries, or logger itself when it carries none.
+func For(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if ctx == nil {
+		return logger
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
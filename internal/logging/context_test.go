@@ -0,0 +1,28 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestForAddsTheFieldsTheContextCarries(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core).With(zap.String("addon", "update_hooks"))
+
+	phase := WithFields(context.Background(), zap.String("phase", "site update"))
+	site := WithFields(phase, zap.String("site", "default"))
+
+	For(site, logger).Info("update hooks found")
+	For(phase, logger).Info("phase only")
+	For(context.Background(), logger).Info("no fields")
+
+	entries := logs.All()
+	assert.Equal(t, map[string]any{"addon": "update_hooks", "phase": "site update", "site": "default"}, entries[0].ContextMap())
+	assert.Equal(t, map[string]any{"addon": "update_hooks", "phase": "site update"}, entries[1].ContextMap(), "a child context does not leak into its parent")
+	assert.Equal(t, map[string]any{"addon": "update_hooks"}, entries[2].ContextMap())
+}
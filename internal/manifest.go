@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/drupdater/drupdater/internal/codehosting"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest lists the repositories "drupdater fleet" updates, in the order their runs start.
+type Manifest struct {
+	Repositories []ManifestRepository `yaml:"repositories"`
+}
+
+// ManifestRepository is one repository of a fleet.
+type ManifestRepository struct {
+	// Name labels the repository's log lines and names its report file. Derived from the URL
+	// when empty.
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Branch is the branch to update and target; main when empty.
+	Branch string `yaml:"branch"`
+	// Config is the repository's .drupdater.yaml, relative to the manifest. Empty means the
+	// fleet-wide one. The repository's own copy is not read: it is only there once cloned, and
+	// the clone happens inside the run the config describes.
+	Config string `yaml:"config"`
+}
+
+// manifestNamePattern keeps a name usable as a file name on every platform the report is read on.
+var manifestNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// manifestNameInvalid matches the runs of characters a derived name replaces with '-'.
+var manifestNameInvalid = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// LoadManifest reads a fleet manifest. Unlike .drupdater.yaml a missing file is an error: there
+// is no default fleet. Unknown keys are rejected so typos fail loudly.
+func LoadManifest(path string) (Manifest, error) {
+	var m Manifest
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return m, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if err := m.resolve(filepath.Dir(path)); err != nil {
+		return m, fmt.Errorf("in %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// resolve fills in each entry's defaults and validates the result. Config paths become relative
+// to dir, so the manifest means the same thing whichever directory the fleet runs from.
+func (m *Manifest) resolve(dir string) error {
+	if len(m.Repositories) == 0 {
+		return errors.New("no repositories listed: add at least one under repositories")
+	}
+
+	seen := make(map[string]int, len(m.Repositories))
+	for i := range m.Repositories {
+		entry := &m.Repositories[i]
+		if entry.URL == "" {
+			return fmt.Errorf("repository %d has no url", i+1)
+		}
+		if err := codehosting.ValidateRepositoryURL(entry.URL); err != nil {
+			return fmt.Errorf("repository %d: invalid url: %w", i+1, err)
+		}
+		if entry.Branch == "" {
+			entry.Branch = "main"
+		}
+		if entry.Name == "" {
+			entry.Name = manifestName(entry.URL)
+		}
+		if !manifestNamePattern.MatchString(entry.Name) {
+			return fmt.Errorf("repository %d: invalid name %q: use letters, digits, '.', '_' and '-'", i+1, entry.Name)
+		}
+		if first, ok := seen[entry.Name]; ok {
+			return fmt.Errorf("repositories %d and %d are both named %q: set name on one of them", first, i+1, entry.Name)
+		}
+		seen[entry.Name] = i + 1
+
+		if entry.Config != "" {
+			if !filepath.IsAbs(entry.Config) {
+				entry.Config = filepath.Join(dir, entry.Config)
+			}
+			// A missing .drupdater.yaml means defaults, which for a listed path would quietly
+			// update a site the project configured differently.
+			if _, err := os.Stat(entry.Config); err != nil {
+				return fmt.Errorf("repository %q: config: %w", entry.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// manifestName derives a name from the repository's owner and project, so
+// https://github.com/acme/site.git and git@github.com:acme/site.git are both "acme-site".
+func manifestName(rawURL string) string {
+	trimmed := strings.TrimSuffix(strings.TrimRight(rawURL, "/"), ".git")
+	// The scp-like form separates host and path with a colon rather than a slash.
+	trimmed = strings.ReplaceAll(trimmed, ":", "/")
+
+	parts := strings.FieldsFunc(trimmed, func(r rune) bool { return r == '/' })
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	name := strings.Join(parts, "-")
+	return manifestNameInvalid.ReplaceAllString(name, "-")
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "repos.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	t.Run("fills in branch and name", func(t *testing.T) {
+		m, err := LoadManifest(writeManifest(t, "repositories:\n  - url: https://github.com/acme/site-a.git\n  - url: git@gitlab.com:acme/site-b.git\n    branch: develop\n"))
+		require.NoError(t, err)
+
+		require.Len(t, m.Repositories, 2)
+		assert.Equal(t, ManifestRepository{Name: "acme-site-a", URL: "https://github.com/acme/site-a.git", Branch: "main"}, m.Repositories[0])
+		assert.Equal(t, ManifestRepository{Name: "acme-site-b", URL: "git@gitlab.com:acme/site-b.git", Branch: "develop"}, m.Repositories[1])
+	})
+
+	t.Run("config is relative to the manifest", func(t *testing.T) {
+		path := writeManifest(t, "repositories:\n  - url: https://github.com/acme/site.git\n    config: site.drupdater.yaml\n")
+		require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(path), "site.drupdater.yaml"), []byte("sites: [default]\n"), 0o600))
+
+		m, err := LoadManifest(path)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "site.drupdater.yaml"), m.Repositories[0].Config)
+	})
+
+	t.Run("a missing config is an error", func(t *testing.T) {
+		// Falling back to defaults would update sites the project configured differently.
+		_, err := LoadManifest(writeManifest(t, "repositories:\n  - url: https://github.com/acme/site.git\n    config: absent.yaml\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `repository "acme-site": config`)
+	})
+
+	t.Run("a missing manifest is an error", func(t *testing.T) {
+		_, err := LoadManifest(filepath.Join(t.TempDir(), "absent.yaml"))
+		require.Error(t, err)
+	})
+
+	t.Run("an empty manifest is an error", func(t *testing.T) {
+		_, err := LoadManifest(writeManifest(t, "# nothing yet\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no repositories listed")
+	})
+
+	t.Run("unknown keys are rejected", func(t *testing.T) {
+		_, err := LoadManifest(writeManifest(t, "repositories:\n  - url: https://github.com/acme/site.git\n    brnach: main\n"))
+		require.Error(t, err)
+	})
+
+	t.Run("an entry without url is an error", func(t *testing.T) {
+		_, err := LoadManifest(writeManifest(t, "repositories:\n  - branch: main\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "repository 1 has no url")
+	})
+
+	t.Run("duplicate names are rejected", func(t *testing.T) {
+		// Two repositories of one name would write the same report file.
+		_, err := LoadManifest(writeManifest(t, "repositories:\n  - url: https://github.com/acme/site.git\n  - url: https://gitlab.com/acme/site.git\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `repositories 1 and 2 are both named "acme-site"`)
+	})
+
+	t.Run("a name must be usable as a file name", func(t *testing.T) {
+		_, err := LoadManifest(writeManifest(t, "repositories:\n  - url: https://github.com/acme/site.git\n    name: ../escape\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid name")
+	})
+}
+
+func TestManifestName(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/acme/site.git":         "acme-site",
+		"https://gitlab.com/group/sub/site":        "sub-site",
+		"git@github.com:acme/site.git":             "acme-site",
+		"https://git.example.com/acme/site%20two/": "acme-site-20two",
+		"ssh://git@git.example.com:2222/acme/site": "acme-site",
+	} {
+		assert.Equal(t, want, manifestName(url), url)
+	}
+}
@@ -0,0 +1,234 @@
+// Package notify posts the summary of a finished run to Slack, Microsoft Teams, Matrix or a
+// generic JSON webhook, for the scheduled runs nobody watches.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/report"
+)
+
+// Triggered returns the triggers of n that summary meets, in n's order; none means n stays
+// silent about this run.
+func Triggered(n internal.Notification, summary report.Summary) []internal.NotifyTrigger {
+	var met []internal.NotifyTrigger
+	for _, trigger := range n.Triggers() {
+		var ok bool
+		switch trigger {
+		case internal.NotifyOnSuccess, internal.NotifyOnNoChanges, internal.NotifyOnFailed:
+			ok = string(trigger) == string(summary.Status)
+		case internal.NotifyOnUnresolvedAdvisories:
+			ok = len(summary.UnresolvedAdvisories) > 0
+		case internal.NotifyOnPatchConflicts:
+			ok = len(summary.PatchConflicts) > 0
+		}
+		if ok && !slices.Contains(met, trigger) {
+			met = append(met, trigger)
+		}
+	}
+	return met
+}
+
+// Expand reads the ${NAME} variables in n's url, room and token through lookup. A variable that
+// is unset or empty is an error naming it: posting to half a URL would fail less clearly.
+func Expand(n internal.Notification, lookup func(string) (string, bool)) (internal.Notification, error) {
+	var missing []string
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string {
+			value, ok := lookup(name)
+			if !ok || value == "" {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	}
+	n.URL, n.Room, n.Token = expand(n.URL), expand(n.Room), expand(n.Token)
+	if len(missing) > 0 {
+		return n, fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return n, nil
+}
+
+// Secret is the part of n, once expanded, that grants posting: a webhook's URL, or a Matrix
+// token. It is what a caller registers with the log redactor.
+func Secret(n internal.Notification) string {
+	if n.Type == internal.NotificationMatrix {
+		return n.Token
+	}
+	return n.URL
+}
+
+// Send posts summary to n, whose variables Expand has already read. triggers are the ones
+// Triggered found, passed on to a webhook. The whole payload goes through redact; nil suits only
+// tests.
+func Send(ctx context.Context, client *http.Client, n internal.Notification, summary report.Summary, triggers []internal.NotifyTrigger, redact func(string) string) error {
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+
+	method, endpoint := http.MethodPost, n.URL
+	headers := map[string]string{}
+	var payload any
+	switch n.Type {
+	case internal.NotificationSlack:
+		payload = slackMessage(summary)
+	case internal.NotificationTeams:
+		payload = teamsMessage(summary)
+	case internal.NotificationMatrix:
+		// The transaction id makes a retried PUT idempotent; each run's message is new.
+		var txn [8]byte
+		_, _ = rand.Read(txn[:])
+		method = http.MethodPut
+		endpoint = strings.TrimSuffix(n.URL, "/") + "/_matrix/client/v3/rooms/" + url.PathEscape(n.Room) +
+			"/send/m.room.message/" + hex.EncodeToString(txn[:])
+		headers["Authorization"] = "Bearer " + n.Token
+		payload = matrixMessage(summary)
+	case internal.NotificationWebhook:
+		payload = webhookPayload{Summary: summary, Triggers: triggers, Text: text(summary)}
+	default:
+		return fmt.Errorf("unknown notification type %q", n.Type)
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	return post(ctx, client, method, endpoint, headers, []byte(redact(string(encoded))))
+}
+
+func post(ctx context.Context, client *http.Client, method string, endpoint string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification rejected: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// webhookPayload is the generic webhook's body: the summary's fields at the top level, with what
+// triggered it and the text a chat message would carry.
+type webhookPayload struct {
+	report.Summary
+	Triggers []internal.NotifyTrigger `json:"triggers"`
+	Text     string                   `json:"text"`
+}
+
+// slackMessage is an incoming webhook message, in Slack's mrkdwn.
+func slackMessage(summary report.Summary) map[string]any {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+	lines := []string{"*" + escape(title(summary)) + "*"}
+	for _, line := range details(summary) {
+		lines = append(lines, escape(line))
+	}
+	return map[string]any{"text": strings.Join(lines, "\n")}
+}
+
+// teamsMessage is an Adaptive Card, the message a Teams Workflows webhook posts; the retired
+// Office 365 connectors took the same card.
+func teamsMessage(summary report.Summary) map[string]any {
+	body := []map[string]any{{"type": "TextBlock", "text": title(summary), "weight": "Bolder", "size": "Medium", "wrap": true}}
+	for _, line := range details(summary) {
+		body = append(body, map[string]any{"type": "TextBlock", "text": line, "wrap": true})
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+}
+
+// matrixMessage is a plain m.text event: every client renders it, and it carries nothing HTML
+// would add.
+func matrixMessage(summary report.Summary) map[string]any {
+	return map[string]any{"msgtype": "m.text", "body": text(summary)}
+}
+
+// text is the message as plain lines: the title, then the details.
+func text(summary report.Summary) string {
+	return strings.Join(append([]string{title(summary)}, details(summary)...), "\n")
+}
+
+// title says in one line what happened, and to which repository.
+func title(summary report.Summary) string {
+	run := "Update"
+	switch summary.Mode {
+	case report.ModeSecurity:
+		run = "Security update"
+	case report.ModeMajor:
+		run = "Major upgrade"
+	case report.ModeNormal:
+	}
+	target := summary.Repository + " (" + summary.BaseBranch + ")"
+
+	switch summary.Status {
+	case report.StatusFailed:
+		if summary.FailedPhase != "" {
+			return run + " of " + target + " failed in " + summary.FailedPhase
+		}
+		return run + " of " + target + " failed"
+	case report.StatusNoChanges:
+		return run + " of " + target + ": nothing to update"
+	case report.StatusSuccess:
+	}
+	if summary.DryRun {
+		return run + " of " + target + " finished as a dry run"
+	}
+	return run + " of " + target + " is ready for review"
+}
+
+// details lists what to look at: the merge requests, the error, and what needs a person.
+func details(summary report.Summary) []string {
+	var lines []string
+	for _, mr := range summary.MergeRequests {
+		lines = append(lines, "Merge request: "+mr)
+	}
+	if summary.Error != "" {
+		lines = append(lines, "Error: "+summary.Error)
+	}
+	for _, list := range []struct {
+		name  string
+		items []string
+	}{
+		{"Fixed advisories", summary.FixedAdvisories},
+		{"Unresolved advisories", summary.UnresolvedAdvisories},
+		{"Patch conflicts", summary.PatchConflicts},
+	} {
+		if len(list.items) > 0 {
+			lines = append(lines, fmt.Sprintf("%s (%d): %s", list.name, len(list.items), strings.Join(list.items, ", ")))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,239 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// noValue marks the side of a diff row where the thing was absent.
+const noValue = "—"
+
+// Diff lays out what changed between two runs of one repository: its summary, the package
+// changes, advisories, phase durations and patch conflicts. Rows the runs agree on are left out,
+// except for phases, whose durations are the point. A run split into groups is compared across
+// all of them.
+func Diff(before, after Report) View {
+	title := fmt.Sprintf("%s: %s → %s", after.Repository,
+		before.StartedAt.UTC().Format(time.DateOnly), after.StartedAt.UTC().Format(time.DateOnly))
+
+	return View{Title: title, Sections: []Section{
+		diffSummary(before, after),
+		diffPackages(before, after),
+		diffAdvisories(before, after),
+		diffPhases(before, after),
+		diffPatchConflicts(before, after),
+	}}
+}
+
+// runs is what a report ran: its groups when split, else the report itself.
+func runs(rep Report) []Report {
+	if len(rep.Groups) > 0 {
+		return rep.Groups
+	}
+	return []Report{rep}
+}
+
+func diffSummary(before, after Report) Section {
+	mergeRequests := func(rep Report) string {
+		var urls []string
+		for _, r := range runs(rep) {
+			if r.MergeRequest != nil {
+				urls = append(urls, mergeRequestText(*r.MergeRequest))
+			}
+		}
+		if len(urls) == 0 {
+			return noValue
+		}
+		return strings.Join(urls, ", ")
+	}
+	packageCount := func(rep Report) string {
+		n := 0
+		for _, r := range runs(rep) {
+			n += len(r.Packages)
+		}
+		return fmt.Sprint(n)
+	}
+
+	return Section{Heading: "Summary", Columns: []string{"Field", "Before", "After"}, Rows: [][]string{
+		{"Started", before.StartedAt.UTC().Format(time.RFC3339), after.StartedAt.UTC().Format(time.RFC3339)},
+		{"Status", string(before.Status), string(after.Status)},
+		{"Mode", string(before.Mode), string(after.Mode)},
+		{"Duration", seconds(before.DurationSeconds), seconds(after.DurationSeconds)},
+		{"Packages changed", packageCount(before), packageCount(after)},
+		{"Merge requests", mergeRequests(before), mergeRequests(after)},
+	}}
+}
+
+// keyed is one side of a comparison: a value per key, and the keys in first-seen order.
+type keyed struct {
+	order  []string
+	values map[string]string
+}
+
+func (k *keyed) set(key, value string) {
+	if k.values == nil {
+		k.values = map[string]string{}
+	}
+	if _, seen := k.values[key]; !seen {
+		k.order = append(k.order, key)
+	}
+	k.values[key] = value
+}
+
+// get returns noValue for a key the side does not have.
+func (k keyed) get(key string) string {
+	if v, ok := k.values[key]; ok {
+		return v
+	}
+	return noValue
+}
+
+// changedKeys lists every key whose value differs, after's order first: the newer run is the one
+// being read.
+func changedKeys(before, after keyed) []string {
+	var keys []string
+	for _, key := range slices.Concat(after.order, before.order) {
+		if !slices.Contains(keys, key) && before.get(key) != after.get(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func packageChanges(rep Report) keyed {
+	var k keyed
+	for _, r := range runs(rep) {
+		for _, p := range r.Packages {
+			change := p.Action
+			switch {
+			case p.From != "" && p.To != "":
+				change += " " + p.From + " → " + p.To
+			case p.To != "":
+				change += " " + p.To
+			case p.From != "":
+				change += " " + p.From
+			}
+			k.set(p.Package, change)
+		}
+	}
+	return k
+}
+
+func diffPackages(before, after Report) Section {
+	section := Section{Heading: "Packages", Columns: []string{"Package", "Before", "After"}, Empty: "Both runs made the same dependency changes."}
+	b, a := packageChanges(before), packageChanges(after)
+	for _, pkg := range changedKeys(b, a) {
+		section.Rows = append(section.Rows, []string{pkg, b.get(pkg), a.get(pkg)})
+	}
+	return section
+}
+
+// advisoryStates keys on package and advisory: one advisory can name several packages. A group
+// that still has an advisory outweighs one that fixed it.
+func advisoryStates(rep Report) (keyed, map[string]advisory) {
+	var k keyed
+	found := map[string]advisory{}
+	for _, r := range runs(rep) {
+		audit, ok := auditOf(r)
+		if !ok {
+			continue
+		}
+		for _, a := range audit.Fixed {
+			key := a.PackageName + " " + a.id()
+			if k.get(key) != advisoryRemaining {
+				k.set(key, advisoryFixed)
+			}
+			found[key] = a
+		}
+		for _, a := range audit.Remaining {
+			key := a.PackageName + " " + a.id()
+			k.set(key, advisoryRemaining)
+			found[key] = a
+		}
+	}
+	return k, found
+}
+
+func diffAdvisories(before, after Report) Section {
+	section := Section{Heading: "Advisories", Columns: []string{"Package", "Advisory", "Severity", "Before", "After"}, Empty: "Both runs saw the same advisories."}
+	b, beforeFound := advisoryStates(before)
+	a, afterFound := advisoryStates(after)
+	for _, key := range changedKeys(b, a) {
+		adv, ok := afterFound[key]
+		if !ok {
+			adv = beforeFound[key]
+		}
+		section.Rows = append(section.Rows, []string{adv.PackageName, adv.id(), adv.Severity, b.get(key), a.get(key)})
+	}
+	return section
+}
+
+// phaseDurations names a group's phases after the group: every group runs the same phases.
+func phaseDurations(rep Report) (keyed, map[string]float64) {
+	var k keyed
+	durations := map[string]float64{}
+	for _, r := range runs(rep) {
+		for _, p := range r.Phases {
+			name := p.Name
+			if r.Group != "" {
+				name = r.Group + ": " + name
+			}
+			result := seconds(p.DurationSeconds)
+			if !p.OK {
+				result += " (failed)"
+			}
+			k.set(name, result)
+			durations[name] = p.DurationSeconds
+		}
+	}
+	return k, durations
+}
+
+func diffPhases(before, after Report) Section {
+	section := Section{Heading: "Phase durations", Columns: []string{"Phase", "Before", "After", "Change"}, Empty: "Neither run entered a phase."}
+	b, beforeSeconds := phaseDurations(before)
+	a, afterSeconds := phaseDurations(after)
+
+	var names []string
+	for _, name := range slices.Concat(a.order, b.order) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		change := ""
+		old, inBefore := beforeSeconds[name]
+		current, inAfter := afterSeconds[name]
+		if inBefore && inAfter {
+			change = fmt.Sprintf("%+.1fs", current-old)
+		}
+		section.Rows = append(section.Rows, []string{name, b.get(name), a.get(name), change})
+	}
+	return section
+}
+
+func patchConflicts(rep Report) keyed {
+	var k keyed
+	for _, r := range runs(rep) {
+		patches, ok := patchesOf(r)
+		if !ok {
+			continue
+		}
+		for _, c := range patches.Conflicts {
+			k.set(c.Package+"\x00"+c.patch(), "conflicts with "+c.NewVersion)
+		}
+	}
+	return k
+}
+
+func diffPatchConflicts(before, after Report) Section {
+	section := Section{Heading: "Patch conflicts", Columns: []string{"Package", "Patch", "Before", "After"}, Empty: "Both runs had the same patch conflicts."}
+	b, a := patchConflicts(before), patchConflicts(after)
+	for _, key := range changedKeys(b, a) {
+		pkg, patch, _ := strings.Cut(key, "\x00")
+		section.Rows = append(section.Rows, []string{pkg, patch, b.get(key), a.get(key)})
+	}
+	return section
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// junitSuiteName is what a pipeline's test tab groups the preflight checks under.
+const junitSuiteName = "drupdater check"
+
+// JUnitTestSuites is the JUnit XML document "drupdater check" emits with --format junit and
+// --junit-report: one testcase per check, so GitLab and Jenkins list preflight failures in
+// their test reports. Only the subset of the format both read is written.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds every check of one run.
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is one check. Failure is nil for a pass.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure"`
+}
+
+// JUnitFailure carries the check's detail: its first line as the message a test tab lists, the
+// whole of it as the body shown on expanding.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Detail  string `xml:",chardata"`
+}
+
+// NewJUnit converts a check document. Names and details go through redact one by one, before
+// encoding: XML escapes a credential containing & or <, which a pass over the encoded document
+// would then no longer recognise. nil redact suits only tests.
+func NewJUnit(check Check, redact func(string) string) JUnitTestSuites {
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+
+	suite := JUnitTestSuite{
+		Name:      junitSuiteName,
+		Timestamp: check.CheckedAt.UTC().Format("2006-01-02T15:04:05"),
+		Cases:     make([]JUnitTestCase, 0, len(check.Results)),
+	}
+	for _, r := range check.Results {
+		tc := JUnitTestCase{Name: redact(r.Name), ClassName: "drupdater.check"}
+		if !r.OK {
+			detail := redact(r.Detail)
+			message, _, _ := strings.Cut(detail, "\n")
+			if message == "" {
+				message = "check failed"
+			}
+			tc.Failure = &JUnitFailure{Message: message, Detail: detail}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+
+	return JUnitTestSuites{Name: junitSuiteName, Tests: suite.Tests, Failures: suite.Failures, Suites: []JUnitTestSuite{suite}}
+}
+
+// EncodeJUnit renders the document with its XML declaration.
+func EncodeJUnit(doc JUnitTestSuites) ([]byte, error) {
+	encoded, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	return append([]byte(xml.Header), append(encoded, '\n')...), nil
+}
+
+// WriteJUnit writes the document atomically, like Write. Redaction already happened in NewJUnit.
+func WriteJUnit(fs afero.Fs, path string, doc JUnitTestSuites) error {
+	encoded, err := EncodeJUnit(doc)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(fs, path, encoded)
+}
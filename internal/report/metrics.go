@@ -0,0 +1,178 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// MetricsContentType is the Prometheus text exposition format EncodeMetrics writes, which both
+// node_exporter's textfile collector and the Pushgateway read.
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// packageActions are the composer actions drupdater_packages_changed always has a series for, so
+// a run that upgraded nothing reads 0 rather than leaving a gap in the graph.
+var packageActions = []string{"Install", "Upgrade", "Downgrade", "Remove"}
+
+// statuses are the values drupdater_run_status has a series for, in the order they are written.
+var statuses = []Status{StatusSuccess, StatusNoChanges, StatusFailed}
+
+// metricFamily is one metric name with its samples across every run encoded.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+// unsupportedModule is the part of unsupported_modules' section the metrics count.
+type unsupportedModule struct {
+	Name string `json:"name"`
+}
+
+// EncodeMetrics renders reports as Prometheus gauges, one set per run: a split run contributes
+// one per group. Every sample is labelled with the run's repository, base branch, mode and group,
+// so one file or Pushgateway can hold a whole fleet. Label values go through redact one by one,
+// before escaping; nil redact suits only tests.
+//
+// The addon counts read 0 when the addon found nothing and when the run failed before it ran;
+// drupdater_run_status tells the two apart.
+func EncodeMetrics(reports []Report, redact func(string) string) []byte {
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+
+	info := &metricFamily{name: "drupdater_run_info", help: "The versions a run used; always 1."}
+	status := &metricFamily{name: "drupdater_run_status", help: "1 for the run's outcome, 0 for the others."}
+	finished := &metricFamily{name: "drupdater_run_finished_timestamp_seconds", help: "When the run finished, in seconds since the epoch."}
+	duration := &metricFamily{name: "drupdater_run_duration_seconds", help: "How long the run took."}
+	phaseDuration := &metricFamily{name: "drupdater_phase_duration_seconds", help: "How long each phase the run entered took."}
+	phaseSuccess := &metricFamily{name: "drupdater_phase_success", help: "1 when the phase succeeded, 0 when it failed."}
+	packages := &metricFamily{name: "drupdater_packages_changed", help: "Dependency changes composer made, by action."}
+	fixed := &metricFamily{name: "drupdater_advisories_fixed", help: "Security advisories the update fixed."}
+	unresolved := &metricFamily{name: "drupdater_advisories_unresolved", help: "Security advisories still affecting the project after the update."}
+	conflicts := &metricFamily{name: "drupdater_patch_conflicts", help: "Patches that no longer apply and need a human."}
+	unsupported := &metricFamily{name: "drupdater_unsupported_modules", help: "Installed modules without a supported release."}
+
+	for _, rep := range reports {
+		for _, run := range runs(rep) {
+			labels := [][2]string{
+				{"repository", redact(run.Repository)},
+				{"base_branch", redact(run.BaseBranch)},
+				{"mode", string(run.Mode)},
+				{"group", redact(run.Group)},
+			}
+			with := func(name string, value string) [][2]string {
+				return append(append([][2]string{}, labels...), [2]string{name, value})
+			}
+
+			info.add(1, append(with("drupdater_version", redact(run.DrupdaterVersion)),
+				[2]string{"composer_version", redact(run.ComposerVersion)},
+				[2]string{"php_version", redact(run.PHPVersion)},
+			))
+			for _, s := range statuses {
+				status.add(boolValue(run.Status == s), with("status", string(s)))
+			}
+			if !run.FinishedAt.IsZero() {
+				finished.add(float64(run.FinishedAt.UnixMilli())/1000, labels)
+			}
+			duration.add(run.DurationSeconds, labels)
+
+			for _, p := range run.Phases {
+				phaseDuration.add(p.DurationSeconds, with("phase", p.Name))
+				phaseSuccess.add(boolValue(p.OK), with("phase", p.Name))
+			}
+
+			changed := map[string]int{}
+			for _, p := range run.Packages {
+				changed[p.Action]++
+			}
+			for _, action := range packageActions {
+				packages.add(float64(changed[action]), with("action", action))
+			}
+
+			audit, _ := auditOf(run)
+			fixed.add(float64(len(audit.Fixed)), labels)
+			unresolved.add(float64(len(audit.Remaining)), labels)
+
+			patches, _ := patchesOf(run)
+			conflicts.add(float64(len(patches.Conflicts)), labels)
+
+			var modules []unsupportedModule
+			addonSection(run, "unsupported_modules", &modules)
+			unsupported.add(float64(len(modules)), labels)
+		}
+	}
+
+	var b strings.Builder
+	for _, family := range []*metricFamily{info, status, finished, duration, phaseDuration, phaseSuccess, packages, fixed, unresolved, conflicts, unsupported} {
+		family.write(&b)
+	}
+	return []byte(b.String())
+}
+
+// MetricsGroupingKey is the labels that tell one Pushgateway group from another: a run replaces
+// the series of the previous run of the same repository, base branch and mode, and no other, so a
+// --security run and a normal run of one repository keep theirs side by side. The values are the
+// ones EncodeMetrics writes, since the Pushgateway rejects a sample whose label disagrees with the
+// key.
+func MetricsGroupingKey(rep Report, redact func(string) string) [][2]string {
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+	return [][2]string{
+		{"repository", redact(rep.Repository)},
+		{"base_branch", redact(rep.BaseBranch)},
+		{"mode", string(rep.Mode)},
+	}
+}
+
+// WriteMetrics writes reports' metrics to path, atomically: the textfile collector may read the
+// directory at any moment, and skips the temporary file for not ending in .prom.
+func WriteMetrics(fs afero.Fs, path string, reports []Report, redact func(string) string) error {
+	return writeAtomic(fs, path, EncodeMetrics(reports, redact))
+}
+
+func (f *metricFamily) add(value float64, labels [][2]string) {
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+// write leaves out a family without samples: HELP and TYPE alone describe nothing.
+func (f *metricFamily) write(b *strings.Builder) {
+	if len(f.samples) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", f.name, f.help, f.name)
+	for _, s := range f.samples {
+		b.WriteString(f.name)
+		b.WriteByte('{')
+		for i, label := range s.labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", label[0], escapeLabelValue(label[1]))
+		}
+		b.WriteString("} ")
+		b.WriteString(strconv.FormatFloat(s.value, 'f', -1, 64))
+		b.WriteByte('\n')
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drupdater/drupdater/internal/golden"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func metricsFixture() Report {
+	rep := sarifFixture()
+	rep.BaseBranch = "main"
+	rep.Mode = ModeSecurity
+	rep.ToolVersions = ToolVersions{ComposerVersion: "2.8.4", PHPVersion: "8.3.14"}
+	rep.FinishedAt = time.Date(2026, 6, 15, 3, 4, 12, 500_000_000, time.UTC)
+	rep.DurationSeconds = 252.5
+	rep.Packages = []PackageChange{
+		{Action: "Upgrade", Package: "drupal/core", From: "10.2.1", To: "10.2.9"},
+		{Action: "Upgrade", Package: "drupal/token", From: "1.13.0", To: "1.14.0"},
+		{Action: "Install", Package: "psr/log", To: "3.0.0"},
+	}
+	rep.Phases = []Phase{
+		{Name: "clone", DurationSeconds: 3.25, OK: true},
+		{Name: "update code", DurationSeconds: 41, OK: true},
+	}
+	rep.Addons["composer_patches"] = map[string]any{
+		"conflicts": []map[string]any{{"package": "drupal/token", "patch_path": "patches/token.patch"}},
+	}
+	rep.Addons["unsupported_modules"] = []map[string]any{{"name": "legacy_feature"}, {"name": "old_widget"}}
+	return rep
+}
+
+func TestEncodeMetricsGolden(t *testing.T) {
+	golden.Assert(t, "testdata/run.prom", string(EncodeMetrics([]Report{metricsFixture()}, nil)))
+}
+
+// A split run is one set of series per group, and a fleet one per repository: the labels keep
+// them apart, and each family is still declared once.
+func TestEncodeMetricsLabelsEveryRun(t *testing.T) {
+	core, contrib := metricsFixture(), metricsFixture()
+	core.Group, contrib.Group = "core", "contrib"
+	contrib.Status, contrib.FailedPhase = StatusFailed, "update code"
+	other := metricsFixture()
+	other.Repository = "https://gitlab.com/acme/other"
+
+	encoded := string(EncodeMetrics([]Report{Combine([]Report{core, contrib}), other}, nil))
+
+	assert.Equal(t, 1, strings.Count(encoded, "# TYPE drupdater_run_status gauge"))
+	assert.Contains(t, encoded, `drupdater_run_status{repository="https://github.com/acme/site",base_branch="main",mode="security",group="contrib",status="failed"} 1`)
+	assert.Contains(t, encoded, `drupdater_run_status{repository="https://github.com/acme/site",base_branch="main",mode="security",group="core",status="failed"} 0`)
+	assert.Contains(t, encoded, `drupdater_run_status{repository="https://gitlab.com/acme/other",base_branch="main",mode="security",group="",status="success"} 1`)
+}
+
+// A run that failed before composer update still has a series for every action, at 0.
+func TestEncodeMetricsWithoutFindings(t *testing.T) {
+	rep := Report{Repository: "https://github.com/acme/site", Status: StatusFailed, Mode: ModeNormal}
+
+	encoded := string(EncodeMetrics([]Report{rep}, nil))
+
+	assert.Contains(t, encoded, `action="Downgrade"} 0`)
+	assert.Contains(t, encoded, `drupdater_advisories_unresolved{repository="https://github.com/acme/site",base_branch="",mode="normal",group=""} 0`)
+	assert.NotContains(t, encoded, "drupdater_phase_duration_seconds", "a family without samples is left out")
+	assert.NotContains(t, encoded, "drupdater_run_finished_timestamp_seconds")
+}
+
+func TestEncodeMetricsRedactsAndEscapesLabels(t *testing.T) {
+	rep := metricsFixture()
+	rep.BaseBranch = "s3cret\"\\\n"
+	redact := func(s string) string { return strings.ReplaceAll(s, "s3cret", "***") }
+
+	encoded := string(EncodeMetrics([]Report{rep}, redact))
+
+	assert.NotContains(t, encoded, "s3cret")
+	assert.Contains(t, encoded, `base_branch="***\"\\\n"`)
+}
+
+// The key has to agree with the labels every sample carries, or the Pushgateway rejects the push.
+func TestMetricsGroupingKey(t *testing.T) {
+	rep := metricsFixture()
+	rep.BaseBranch = "s3cret"
+	redact := func(s string) string { return strings.ReplaceAll(s, "s3cret", "***") }
+
+	key := MetricsGroupingKey(rep, redact)
+
+	assert.Equal(t, [][2]string{{"repository", "https://github.com/acme/site"}, {"base_branch", "***"}, {"mode", "security"}}, key)
+	assert.Contains(t, string(EncodeMetrics([]Report{rep}, redact)), `{repository="https://github.com/acme/site",base_branch="***",mode="security",`)
+}
+
+func TestWriteMetrics(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, WriteMetrics(fs, "/textfile/drupdater.prom", []Report{metricsFixture()}, nil))
+
+	written, err := afero.ReadFile(fs, "/textfile/drupdater.prom")
+	require.NoError(t, err)
+	assert.Equal(t, EncodeMetrics([]Report{metricsFixture()}, nil), written)
+
+	entries, err := afero.ReadDir(fs, "/textfile")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+}
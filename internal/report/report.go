@@ -33,12 +33,14 @@ const (
 	StatusNoChanges Status = "no_changes"
 )
 
-// Mode records whether the run applied all available updates or only security ones.
+// Mode records whether the run applied all available updates, only security ones, or raised
+// constraints to new major versions.
 type Mode string
 
 const (
 	ModeNormal   Mode = "normal"
 	ModeSecurity Mode = "security"
+	ModeMajor    Mode = "major"
 )
 
 // ToolVersions attributes a fleet-wide failure to an upstream release. Embedded in both documents
@@ -66,6 +68,8 @@ type Report struct {
 
 	Mode   Mode `json:"mode"`
 	DryRun bool `json:"dry_run"`
+	// Group names the update group this run was limited to, empty for an ungrouped run.
+	Group string `json:"group,omitempty"`
 
 	Repository   string `json:"repository"`
 	BaseBranch   string `json:"base_branch"`
@@ -85,6 +89,10 @@ type Report struct {
 	// composer update, or that found nothing to update.
 	Packages []PackageChange `json:"packages"`
 
+	// MajorUpgrades is the outcome of each constraint a --major run tried to raise. Nil on any
+	// other run.
+	MajorUpgrades *MajorUpgrades `json:"major_upgrades,omitempty"`
+
 	// Phases records every phase the run entered, in order. The timings make a run's cost
 	// measurable without separate instrumentation.
 	Phases []Phase `json:"phases"`
@@ -92,13 +100,27 @@ type Report struct {
 	// Addons holds each reporting addon's structured section, keyed by its report key. Addons
 	// that do not implement report.Reporter are absent rather than present and empty.
 	Addons map[string]any `json:"addons,omitempty"`
+
+	// Groups holds one report per update group when the run was split. The top level then only
+	// summarises them: its packages, phases and merge request stay empty.
+	Groups []Report `json:"groups,omitempty"`
 }
 
-// MergeRequest identifies the merge/pull request a successful run opened.
+// MergeRequest identifies the merge/pull request a successful run opened or updated.
 type MergeRequest struct {
 	URL string `json:"url"`
+	// Updated is true when the run rewrote the open request an earlier run opened, rather than
+	// opening a new one.
+	Updated bool `json:"updated,omitempty"`
+	// Unchanged is true when the request already held the run's update, and the run only closed
+	// the requests it supersedes.
+	Unchanged bool `json:"unchanged,omitempty"`
+	// Superseded lists the older open requests the run closed in favour of this one.
+	Superseded []string `json:"superseded,omitempty"`
 	// AutoMerge is nil when not requested, so that reads differently from "requested and failed".
 	AutoMerge *AutoMerge `json:"auto_merge,omitempty"`
+	// Draft is nil when the request is not a draft.
+	Draft *Draft `json:"draft,omitempty"`
 }
 
 // AutoMerge is the outcome of the auto-merge request. Reported because it is best-effort: without
@@ -110,6 +132,16 @@ type AutoMerge struct {
 	Error string `json:"error,omitempty"`
 }
 
+// Draft is what became of a draft request. Reported because marking it ready is best-effort, like
+// auto-merge: without it a consumer cannot tell a draft still waiting for its pipeline from one the
+// run gave up on.
+type Draft struct {
+	// MarkedReady is true when the run took the request out of draft.
+	MarkedReady bool `json:"marked_ready"`
+	// Error is why the run did not mark it ready although asked to, empty otherwise.
+	Error string `json:"error,omitempty"`
+}
+
 // PackageChange mirrors composer.PackageChange: published schema, so an internal refactor must
 // not be able to rename a field here.
 type PackageChange struct {
@@ -120,6 +152,22 @@ type PackageChange struct {
 	To      string `json:"to,omitempty"`
 }
 
+// MajorUpgrades splits a --major run's candidates by whether composer could resolve them.
+type MajorUpgrades struct {
+	Upgraded []MajorUpgrade `json:"upgraded"`
+	Failed   []MajorUpgrade `json:"failed"`
+}
+
+// MajorUpgrade is one package whose composer.json constraint a --major run tried to raise.
+type MajorUpgrade struct {
+	Package string `json:"package"`
+	// From is the locked version, To the constraint tried.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Error is composer's reason the constraint did not resolve, empty for an upgrade.
+	Error string `json:"error,omitempty"`
+}
+
 // Phase is one step of the workflow with its duration and outcome.
 type Phase struct {
 	Name            string    `json:"name"`
@@ -203,6 +251,13 @@ func (r *Recorder) SetNoChanges() {
 	r.report.Error = ""
 }
 
+// SetGroup records the update group the run was limited to.
+func (r *Recorder) SetGroup(group string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Group = group
+}
+
 // SetToolVersions is a setter because reading the versions costs a subprocess the recorder outlives.
 func (r *Recorder) SetToolVersions(versions ToolVersions) {
 	r.mu.Lock()
@@ -217,6 +272,13 @@ func (r *Recorder) SetPackages(changes []PackageChange) {
 	r.report.Packages = changes
 }
 
+// SetMajorUpgrades records which raised constraints resolved and which did not.
+func (r *Recorder) SetMajorUpgrades(upgrades MajorUpgrades) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.MajorUpgrades = &upgrades
+}
+
 // SetUpdateBranch records the branch the update commits were made on, even if never pushed.
 func (r *Recorder) SetUpdateBranch(branch string) {
 	r.mu.Lock()
@@ -231,6 +293,32 @@ func (r *Recorder) SetMergeRequest(url string) {
 	r.report.MergeRequest = &MergeRequest{URL: SanitizeURL(url)}
 }
 
+// SetUpdatedMergeRequest records an earlier run's merge request that this run brought up to date.
+func (r *Recorder) SetUpdatedMergeRequest(url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.MergeRequest = &MergeRequest{URL: SanitizeURL(url), Updated: true}
+}
+
+// SetUnchangedMergeRequest records an earlier run's merge request that already held this run's
+// update, so the superseded requests closed in its favour have one to be listed under.
+func (r *Recorder) SetUnchangedMergeRequest(url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.MergeRequest = &MergeRequest{URL: SanitizeURL(url), Unchanged: true}
+}
+
+// AddSupersededMergeRequest records an older request closed in favour of the recorded one. A
+// no-op when no merge request was recorded.
+func (r *Recorder) AddSupersededMergeRequest(url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report.MergeRequest == nil {
+		return
+	}
+	r.report.MergeRequest.Superseded = append(r.report.MergeRequest.Superseded, SanitizeURL(url))
+}
+
 // SetMergeRequestContent is independent of SetMergeRequest: the content exists once rendered,
 // which is before — and under --dry-run without — any merge request.
 func (r *Recorder) SetMergeRequestContent(title, description string) {
@@ -254,6 +342,21 @@ func (r *Recorder) SetAutoMerge(err error) {
 	r.report.MergeRequest.AutoMerge = am
 }
 
+// SetDraft records that the merge request is a draft, and whether the run marked it ready; err is
+// why not, when it tried. A no-op when no merge request was recorded.
+func (r *Recorder) SetDraft(markedReady bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report.MergeRequest == nil {
+		return
+	}
+	d := &Draft{MarkedReady: markedReady}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	r.report.MergeRequest.Draft = d
+}
+
 // AddAddons collects a section per Reporter, skipping nil data so nothing adds an empty key.
 func (r *Recorder) AddAddons(addons []internal.Addon) {
 	r.mu.Lock()
@@ -286,6 +389,150 @@ func (r *Recorder) Finish() Report {
 	return r.report
 }
 
+// Combine summarises the reports of a run split into update groups, in the order they ran. The
+// status is the one needing most attention: failed if any group failed, success if any opened
+// something, no_changes only when every group found nothing.
+func Combine(groups []Report) Report {
+	if len(groups) == 0 {
+		return Report{SchemaVersion: SchemaVersion, Status: StatusNoChanges}
+	}
+	first, last := groups[0], groups[len(groups)-1]
+	combined := Report{
+		SchemaVersion:    SchemaVersion,
+		DrupdaterVersion: first.DrupdaterVersion,
+		ToolVersions:     first.ToolVersions,
+		StartedAt:        first.StartedAt,
+		FinishedAt:       last.FinishedAt,
+		DurationSeconds:  last.FinishedAt.Sub(first.StartedAt).Seconds(),
+		Status:           StatusNoChanges,
+		Mode:             first.Mode,
+		DryRun:           first.DryRun,
+		Repository:       first.Repository,
+		BaseBranch:       first.BaseBranch,
+		Sites:            first.Sites,
+		Groups:           groups,
+	}
+	for _, g := range groups {
+		if g.Status == StatusFailed && combined.Status != StatusFailed {
+			combined.FailedPhase = g.FailedPhase
+			combined.Error = g.Error
+		}
+		combined.Status = mostUrgent(combined.Status, g.Status)
+	}
+	return combined
+}
+
+// mostUrgent is whichever of two statuses needs more attention: failed over success over
+// no_changes.
+func mostUrgent(current, next Status) Status {
+	switch next {
+	case StatusFailed:
+		return StatusFailed
+	case StatusSuccess:
+		if current == StatusNoChanges {
+			return StatusSuccess
+		}
+	case StatusNoChanges:
+	}
+	return current
+}
+
+// Fleet is the document "drupdater fleet" writes beside its per-repository reports. It only
+// summarises them: everything else about a repository's run is in the report Report names.
+type Fleet struct {
+	SchemaVersion    int       `json:"schema_version"`
+	DrupdaterVersion string    `json:"drupdater_version"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+	DurationSeconds  float64   `json:"duration_seconds"`
+
+	// Status is the one needing most attention, by the same rule as Combine.
+	Status  Status       `json:"status"`
+	Summary FleetSummary `json:"summary"`
+
+	// Repositories are in manifest order, not the order their runs finished.
+	Repositories []FleetRepository `json:"repositories"`
+}
+
+// FleetSummary counts the repositories per status, so a dashboard needs no loop.
+type FleetSummary struct {
+	Success   int `json:"success"`
+	NoChanges int `json:"no_changes"`
+	Failed    int `json:"failed"`
+}
+
+// FleetRepository is one repository's line in the fleet report.
+type FleetRepository struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	BaseBranch string `json:"base_branch"`
+
+	Status      Status `json:"status"`
+	FailedPhase string `json:"failed_phase,omitempty"`
+	Error       string `json:"error,omitempty"`
+
+	// Packages counts the dependency changes, across every group of a split run.
+	Packages int `json:"packages"`
+	// MergeRequests lists what the run opened or updated: one per group of a split run.
+	MergeRequests []MergeRequest `json:"merge_requests"`
+
+	// Report is the repository's own report, relative to the fleet report. Empty when the run
+	// never started, which the failed phase then explains.
+	Report string `json:"report,omitempty"`
+}
+
+// NewFleetRepository summarises a repository's report, which reportFile names.
+func NewFleetRepository(name string, reportFile string, rep Report) FleetRepository {
+	entry := FleetRepository{
+		Name:          name,
+		Repository:    rep.Repository,
+		BaseBranch:    rep.BaseBranch,
+		Status:        rep.Status,
+		FailedPhase:   rep.FailedPhase,
+		Error:         rep.Error,
+		MergeRequests: []MergeRequest{},
+		Report:        reportFile,
+	}
+	for _, r := range append([]Report{rep}, rep.Groups...) {
+		entry.Packages += len(r.Packages)
+		if r.MergeRequest != nil {
+			entry.MergeRequests = append(entry.MergeRequests, *r.MergeRequest)
+		}
+	}
+	return entry
+}
+
+// NewFleet assembles the fleet document from its repositories' entries.
+func NewFleet(version string, startedAt time.Time, repositories []FleetRepository) Fleet {
+	fleet := Fleet{
+		SchemaVersion:    SchemaVersion,
+		DrupdaterVersion: version,
+		StartedAt:        startedAt,
+		FinishedAt:       time.Now(),
+		Status:           StatusNoChanges,
+		Repositories:     repositories,
+	}
+	fleet.DurationSeconds = fleet.FinishedAt.Sub(startedAt).Seconds()
+
+	for _, r := range repositories {
+		fleet.Status = mostUrgent(fleet.Status, r.Status)
+		switch r.Status {
+		case StatusSuccess:
+			fleet.Summary.Success++
+		case StatusNoChanges:
+			fleet.Summary.NoChanges++
+		case StatusFailed:
+			fleet.Summary.Failed++
+		}
+	}
+	return fleet
+}
+
+// WriteFleet serialises a fleet summary, with the same guarantees as Write.
+func WriteFleet(fs afero.Fs, path string, fleet Fleet, redact func(string) string) error {
+	return writeJSON(fs, path, fleet, redact)
+}
+
 // Check is the document "drupdater check --report" writes. Its own shape, because a preflight has
 // no phases, packages or branch.
 type Check struct {
@@ -330,7 +577,7 @@ func Write(fs afero.Fs, path string, rep Report, redact func(string) string) err
 	return writeJSON(fs, path, rep, redact)
 }
 
-// writeJSON is the shared, atomic, redacting write behind Write and WriteCheck.
+// writeJSON is the shared, atomic, redacting write behind Write, WriteCheck and WriteFleet.
 func writeJSON(fs afero.Fs, path string, doc any, redact func(string) string) error {
 	encoded, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
@@ -341,7 +588,11 @@ func writeJSON(fs afero.Fs, path string, doc any, redact func(string) string) er
 	if redact != nil {
 		encoded = []byte(redact(string(encoded)))
 	}
+	return writeAtomic(fs, path, encoded)
+}
 
+// writeAtomic writes encoded to a temporary file beside path and renames it into place.
+func writeAtomic(fs afero.Fs, path string, encoded []byte) error {
 	dir := filepath.Dir(path)
 	if err := fs.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("failed to create report directory %s: %w", dir, err)
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/drupdater/drupdater/internal"
 	"github.com/gookit/event"
@@ -195,6 +196,59 @@ func TestAutoMergeOmittedFromJSONWhenNotRequested(t *testing.T) {
 	assert.Contains(t, string(encoded), `"auto_merge":{"enabled":true}`)
 }
 
+func TestRecorderDraftOutcome(t *testing.T) {
+	t.Run("absent unless the request is a draft", func(t *testing.T) {
+		rec := newTestRecorder()
+		rec.SetMergeRequest("https://example.com/mr/1")
+
+		encoded, err := json.Marshal(rec.Finish().MergeRequest)
+		require.NoError(t, err)
+		assert.NotContains(t, string(encoded), "draft")
+	})
+
+	t.Run("a draft the run marked ready", func(t *testing.T) {
+		rec := newTestRecorder()
+		rec.SetMergeRequest("https://example.com/mr/1")
+		rec.SetDraft(true, nil)
+
+		encoded, err := json.Marshal(rec.Finish().MergeRequest)
+		require.NoError(t, err)
+		assert.Contains(t, string(encoded), `"draft":{"marked_ready":true}`)
+	})
+
+	t.Run("a draft the run gave up on records why", func(t *testing.T) {
+		rec := newTestRecorder()
+		rec.SetMergeRequest("https://example.com/mr/1")
+		rec.SetDraft(false, errors.New("the pipeline failed"))
+
+		draft := rec.Finish().MergeRequest.Draft
+		require.NotNil(t, draft)
+		assert.False(t, draft.MarkedReady)
+		assert.Equal(t, "the pipeline failed", draft.Error)
+	})
+}
+
+func TestRecorderSupersededMergeRequests(t *testing.T) {
+	t.Run("attached to the recorded merge request", func(t *testing.T) {
+		rec := newTestRecorder()
+		rec.SetUpdatedMergeRequest("https://example.com/mr/3")
+		rec.AddSupersededMergeRequest("https://example.com/mr/2")
+		rec.AddSupersededMergeRequest("https://example.com/mr/1")
+
+		rep := rec.Finish()
+		require.NotNil(t, rep.MergeRequest)
+		assert.True(t, rep.MergeRequest.Updated)
+		assert.Equal(t, []string{"https://example.com/mr/2", "https://example.com/mr/1"}, rep.MergeRequest.Superseded)
+	})
+
+	t.Run("no merge request means nothing to attach to", func(t *testing.T) {
+		rec := newTestRecorder()
+		rec.AddSupersededMergeRequest("https://example.com/mr/1")
+
+		assert.Nil(t, rec.Finish().MergeRequest)
+	})
+}
+
 // reportingAddon implements both internal.Addon and Reporter.
 type reportingAddon struct {
 	key  string
@@ -465,3 +519,98 @@ func TestRecorderSanitizesRepositoryURL(t *testing.T) {
 	assert.Equal(t, "https://example.com/org/site.git", rep.Repository)
 	assert.NotContains(t, rep.Repository, "token")
 }
+
+func TestCombine(t *testing.T) {
+	group := func(name string, status Status) Report {
+		rec := newTestRecorder()
+		rec.SetGroup(name)
+		if status == StatusFailed {
+			_ = rec.Run("site update", func() error { return errors.New(name + " broke") })
+		}
+		if status == StatusNoChanges {
+			rec.SetNoChanges()
+		}
+		return rec.Finish()
+	}
+
+	t.Run("a failed group fails the run", func(t *testing.T) {
+		combined := Combine([]Report{group("core", StatusSuccess), group("contrib", StatusFailed), group("other", StatusFailed)})
+
+		assert.Equal(t, StatusFailed, combined.Status)
+		assert.Equal(t, "site update", combined.FailedPhase)
+		assert.Equal(t, "contrib broke", combined.Error)
+		require.Len(t, combined.Groups, 3)
+		assert.Equal(t, "core", combined.Groups[0].Group)
+		assert.Equal(t, "https://example.com/org/site.git", combined.Repository)
+		assert.Empty(t, combined.Phases)
+	})
+
+	t.Run("one successful group is a success", func(t *testing.T) {
+		combined := Combine([]Report{group("core", StatusNoChanges), group("contrib", StatusSuccess)})
+		assert.Equal(t, StatusSuccess, combined.Status)
+		assert.False(t, combined.FinishedAt.Before(combined.StartedAt))
+	})
+
+	t.Run("nothing anywhere is no changes", func(t *testing.T) {
+		combined := Combine([]Report{group("core", StatusNoChanges), group("contrib", StatusNoChanges)})
+		assert.Equal(t, StatusNoChanges, combined.Status)
+	})
+}
+
+func TestNewFleetRepositorySummarisesEveryGroup(t *testing.T) {
+	core := newTestRecorder()
+	core.SetGroup("core")
+	core.SetPackages([]PackageChange{{Action: "Upgrade", Package: "drupal/core"}})
+	core.SetMergeRequest("https://example.com/org/site/-/merge_requests/1")
+	contrib := newTestRecorder()
+	contrib.SetGroup("contrib")
+	contrib.SetPackages([]PackageChange{{Action: "Upgrade", Package: "drupal/token"}, {Action: "Install", Package: "drupal/ctools"}})
+	contrib.SetNoChanges()
+
+	entry := NewFleetRepository("org-site", "org-site.json", Combine([]Report{core.Finish(), contrib.Finish()}))
+
+	assert.Equal(t, "org-site", entry.Name)
+	assert.Equal(t, "org-site.json", entry.Report)
+	assert.Equal(t, "https://example.com/org/site.git", entry.Repository)
+	assert.Equal(t, StatusSuccess, entry.Status)
+	assert.Equal(t, 3, entry.Packages)
+	assert.Equal(t, []MergeRequest{{URL: "https://example.com/org/site/-/merge_requests/1"}}, entry.MergeRequests)
+}
+
+func TestNewFleet(t *testing.T) {
+	started := time.Now().Add(-time.Minute)
+	fleet := NewFleet("1.2.3", started, []FleetRepository{
+		{Name: "a", Status: StatusNoChanges},
+		{Name: "b", Status: StatusFailed, FailedPhase: "composer install"},
+		{Name: "c", Status: StatusSuccess},
+		{Name: "d", Status: StatusSuccess},
+	})
+
+	assert.Equal(t, StatusFailed, fleet.Status)
+	assert.Equal(t, FleetSummary{Success: 2, NoChanges: 1, Failed: 1}, fleet.Summary)
+	assert.Equal(t, "a", fleet.Repositories[0].Name, "manifest order, not completion order")
+	assert.GreaterOrEqual(t, fleet.DurationSeconds, 60.0)
+
+	assert.Equal(t, StatusNoChanges, NewFleet("1.2.3", started, []FleetRepository{{Status: StatusNoChanges}}).Status)
+}
+
+func TestWriteFleetProducesRedactedJSON(t *testing.T) {
+	const token = "fleet-secret"
+	fs := afero.NewMemMapFs()
+
+	fleet := NewFleet("dev", time.Now(), []FleetRepository{
+		{Name: "org-site", Status: StatusFailed, Error: "clone failed: " + token, MergeRequests: []MergeRequest{}},
+	})
+	redact := func(s string) string { return strings.ReplaceAll(s, token, "***") }
+
+	require.NoError(t, WriteFleet(fs, "/out/fleet.json", fleet, redact))
+
+	raw, err := afero.ReadFile(fs, "/out/fleet.json")
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), token)
+
+	var decoded Fleet
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, 1, decoded.Summary.Failed)
+	assert.Equal(t, "org-site", decoded.Repositories[0].Name)
+}
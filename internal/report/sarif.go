@@ -0,0 +1,243 @@
+package report
+
+import (
+	"github.com/spf13/afero"
+)
+
+// SARIF identifiers a code scanning tool keys on; changing either reopens every known alert.
+const (
+	sarifSchema      = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion     = "2.1.0"
+	sarifFingerprint = "drupdaterAdvisory/v1"
+	// sarifLockFile is where every finding points: the advisory is against the locked version.
+	sarifLockFile = "composer.lock"
+)
+
+// SARIF is the SARIF 2.1.0 log --sarif-report writes beside the run report: one result per
+// package and advisory composer_audit saw, for GitHub code scanning, DefectDojo and the like. Only
+// the subset of the format those ingest is written.
+type SARIF struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is the one run of the log.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool names drupdater as the producer.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver lists a rule per advisory, which is what a scanner shows as the alert's
+// description.
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is one advisory, whichever packages it names.
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+	Properties           SARIFRuleProperty  `json:"properties"`
+}
+
+// SARIFConfiguration is a rule's level before any result overrides it.
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFRuleProperty carries security-severity, the score GitHub buckets into its own
+// critical/high/medium/low.
+type SARIFRuleProperty struct {
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+// SARIFMessage is SARIF's plain-text message object.
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult is one package affected by one advisory. A fixed one is a pass whose baseline state
+// is absent, so a scanner closes the alert the previous run opened.
+type SARIFResult struct {
+	RuleID              string              `json:"ruleId"`
+	Kind                string              `json:"kind"`
+	Level               string              `json:"level"`
+	BaselineState       string              `json:"baselineState,omitempty"`
+	Message             SARIFMessage        `json:"message"`
+	Locations           []SARIFLocation     `json:"locations"`
+	PartialFingerprints map[string]string   `json:"partialFingerprints"`
+	Properties          SARIFResultProperty `json:"properties"`
+}
+
+// SARIFResultProperty repeats the advisory's facts, for a tool that shows properties but not rules.
+type SARIFResultProperty struct {
+	Package          string `json:"package"`
+	AffectedVersions string `json:"affectedVersions,omitempty"`
+	CVE              string `json:"cve,omitempty"`
+	Severity         string `json:"severity,omitempty"`
+	State            string `json:"state"`
+}
+
+// SARIFLocation points at the package in composer.lock.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation has no region for a package the update removed.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is relative to the repository root.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is the line of the package's "name" key.
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// NewSARIF converts the composer_audit section of rep, across all its groups. A report without
+// one, an update that failed before the audit or found nothing, gives a log with no results: that
+// is what tells a scanner every earlier alert is gone.
+func NewSARIF(rep Report) SARIF {
+	driver := SARIFDriver{
+		Name:           "drupdater",
+		Version:        rep.DrupdaterVersion,
+		InformationURI: "https://github.com/drupdater/drupdater",
+		Rules:          []SARIFRule{},
+	}
+	results := []SARIFResult{}
+
+	states, found := advisoryStates(rep)
+	lockLines := map[string]int{}
+	for _, r := range runs(rep) {
+		if audit, ok := auditOf(r); ok {
+			for pkg, line := range audit.LockLines {
+				lockLines[pkg] = line
+			}
+		}
+	}
+
+	ruleIndex := map[string]bool{}
+	for _, key := range states.order {
+		adv := found[key]
+		rule := adv.ruleID()
+		if !ruleIndex[rule] {
+			ruleIndex[rule] = true
+			driver.Rules = append(driver.Rules, adv.sarifRule(rule))
+		}
+		results = append(results, adv.sarifResult(rule, states.get(key), lockLines[adv.PackageName]))
+	}
+
+	return SARIF{Schema: sarifSchema, Version: sarifVersion, Runs: []SARIFRun{{Tool: SARIFTool{Driver: driver}, Results: results}}}
+}
+
+// ruleID falls back to a fixed id for the rare advisory with neither a CVE nor an advisory id,
+// which still has to be reported.
+func (a advisory) ruleID() string {
+	if id := a.id(); id != "" {
+		return id
+	}
+	return "unidentified-advisory"
+}
+
+func (a advisory) sarifRule(id string) SARIFRule {
+	description := a.Title
+	if description == "" {
+		description = "Security advisory " + id
+	}
+	return SARIFRule{
+		ID:                   id,
+		Name:                 a.AdvisoryID,
+		ShortDescription:     SARIFMessage{Text: description},
+		HelpURI:              a.Link,
+		DefaultConfiguration: SARIFConfiguration{Level: sarifLevel(a.Severity)},
+		Properties: SARIFRuleProperty{
+			Tags:             []string{"security", "dependency"},
+			SecuritySeverity: securitySeverity(a.Severity),
+		},
+	}
+}
+
+func (a advisory) sarifResult(rule string, state string, lockLine int) SARIFResult {
+	result := SARIFResult{
+		RuleID:              rule,
+		Kind:                "fail",
+		Level:               sarifLevel(a.Severity),
+		Message:             SARIFMessage{Text: a.PackageName + " is affected by " + rule},
+		PartialFingerprints: map[string]string{sarifFingerprint: a.PackageName + ":" + rule},
+		Properties: SARIFResultProperty{
+			Package:          a.PackageName,
+			AffectedVersions: a.AffectedVersions,
+			CVE:              a.CVE,
+			Severity:         a.Severity,
+			State:            state,
+		},
+	}
+	if state == advisoryFixed {
+		result.Kind = "pass"
+		result.Level = "none"
+		result.BaselineState = "absent"
+		result.Message.Text = a.PackageName + " is no longer affected by " + rule + ": the update fixed it"
+	}
+	if a.Title != "" {
+		result.Message.Text += " (" + a.Title + ")"
+	}
+
+	location := SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: sarifLockFile}}
+	if lockLine > 0 {
+		location.Region = &SARIFRegion{StartLine: lockLine}
+	}
+	result.Locations = []SARIFLocation{{PhysicalLocation: location}}
+	return result
+}
+
+// sarifLevel maps composer's severities. An unknown one is a warning: the advisory is real, only
+// its weight is not.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "low":
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+// securitySeverity is a score inside the CVSS band GitHub maps back to the same severity.
+func securitySeverity(severity string) string {
+	switch severity {
+	case "critical":
+		return "9.5"
+	case "high":
+		return "8.0"
+	case "medium":
+		return "5.5"
+	case "low":
+		return "2.0"
+	default:
+		return ""
+	}
+}
+
+// WriteSARIF serialises the log with the same guarantees as Write.
+func WriteSARIF(fs afero.Fs, path string, doc SARIF, redact func(string) string) error {
+	return writeJSON(fs, path, doc, redact)
+}
@@ -0,0 +1,64 @@
+package report
+
+import "slices"
+
+// Summary is a run cut down to what a notification says about it: the outcome, where to look,
+// and what needs a person. A run split into groups is summarised across all of them.
+type Summary struct {
+	Repository  string `json:"repository"`
+	BaseBranch  string `json:"base_branch"`
+	Mode        Mode   `json:"mode"`
+	DryRun      bool   `json:"dry_run"`
+	Status      Status `json:"status"`
+	FailedPhase string `json:"failed_phase,omitempty"`
+	Error       string `json:"error,omitempty"`
+	// MergeRequests are the URLs of the requests the run opened or updated, one per group.
+	MergeRequests []string `json:"merge_requests"`
+	// Advisories are named as "<package> <CVE or advisory id>".
+	FixedAdvisories      []string `json:"fixed_advisories"`
+	UnresolvedAdvisories []string `json:"unresolved_advisories"`
+	// PatchConflicts are named as "<package>: <patch>".
+	PatchConflicts []string `json:"patch_conflicts"`
+}
+
+// NewSummary summarises rep. The lists are empty rather than nil, so a webhook consumer reads
+// [] for "none". An advisory every group's audit found is listed once.
+func NewSummary(rep Report) Summary {
+	summary := Summary{
+		Repository:           rep.Repository,
+		BaseBranch:           rep.BaseBranch,
+		Mode:                 rep.Mode,
+		DryRun:               rep.DryRun,
+		Status:               rep.Status,
+		FailedPhase:          rep.FailedPhase,
+		Error:                rep.Error,
+		MergeRequests:        []string{},
+		FixedAdvisories:      []string{},
+		UnresolvedAdvisories: []string{},
+		PatchConflicts:       []string{},
+	}
+	for _, run := range runs(rep) {
+		if run.MergeRequest != nil {
+			summary.MergeRequests = append(summary.MergeRequests, run.MergeRequest.URL)
+		}
+		audit, _ := auditOf(run)
+		for _, a := range audit.Fixed {
+			summary.FixedAdvisories = appendNew(summary.FixedAdvisories, a.PackageName+" "+a.id())
+		}
+		for _, a := range audit.Remaining {
+			summary.UnresolvedAdvisories = appendNew(summary.UnresolvedAdvisories, a.PackageName+" "+a.id())
+		}
+		patches, _ := patchesOf(run)
+		for _, c := range patches.Conflicts {
+			summary.PatchConflicts = appendNew(summary.PatchConflicts, c.Package+": "+c.patch())
+		}
+	}
+	return summary
+}
+
+func appendNew(list []string, item string) []string {
+	if slices.Contains(list, item) {
+		return list
+	}
+	return append(list, item)
+}
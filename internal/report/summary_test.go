@@ -0,0 +1,47 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSummary(t *testing.T) {
+	rep := metricsFixture()
+	rep.MergeRequest = &MergeRequest{URL: "https://github.com/acme/site/pull/42"}
+
+	assert.Equal(t, Summary{
+		Repository:           "https://github.com/acme/site",
+		BaseBranch:           "main",
+		Mode:                 ModeSecurity,
+		Status:               StatusSuccess,
+		MergeRequests:        []string{"https://github.com/acme/site/pull/42"},
+		FixedAdvisories:      []string{"drupal/core CVE-2026-1111"},
+		UnresolvedAdvisories: []string{"symfony/http-kernel PKSA-2026-0042", "drupal/removed SA-CONTRIB-2026-007"},
+		PatchConflicts:       []string{"drupal/token: patches/token.patch"},
+	}, NewSummary(rep))
+}
+
+// A split run links every group's request, and lists the advisories each group's audit found
+// again only once.
+func TestNewSummaryOfASplitRun(t *testing.T) {
+	core, contrib := metricsFixture(), metricsFixture()
+	core.Group, contrib.Group = "core", "contrib"
+	core.MergeRequest = &MergeRequest{URL: "https://github.com/acme/site/pull/1"}
+	contrib.Status, contrib.FailedPhase, contrib.Error = StatusFailed, "update code", "composer failed"
+
+	summary := NewSummary(Combine([]Report{core, contrib}))
+
+	assert.Equal(t, StatusFailed, summary.Status)
+	assert.Equal(t, "update code", summary.FailedPhase)
+	assert.Equal(t, []string{"https://github.com/acme/site/pull/1"}, summary.MergeRequests)
+	assert.Len(t, summary.UnresolvedAdvisories, 2)
+}
+
+func TestNewSummaryWithoutFindingsListsNothing(t *testing.T) {
+	summary := NewSummary(Report{Status: StatusNoChanges})
+
+	assert.NotNil(t, summary.MergeRequests)
+	assert.Empty(t, summary.MergeRequests)
+	assert.Empty(t, summary.FixedAdvisories)
+}
@@ -0,0 +1,359 @@
+package report
+
+import (
+	"cmp"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/drupdater/drupdater/internal"
+	"github.com/spf13/afero"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Format is how "drupdater report" prints a View.
+type Format string
+
+const (
+	// FormatTerminal is aligned plain text, for reading in a shell.
+	FormatTerminal Format = "terminal"
+	// FormatMarkdown pastes into an issue, a wiki page or a chat message.
+	FormatMarkdown Format = "markdown"
+	// FormatHTML is a standalone page, for mailing or publishing as a CI artifact.
+	FormatHTML Format = "html"
+)
+
+// Formats lists every Format, in the order the command's help names them.
+var Formats = []Format{FormatTerminal, FormatMarkdown, FormatHTML}
+
+// ParseFormat accepts any of Formats.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q: use terminal, markdown or html", s)
+}
+
+// View is a report laid out for a person: titled sections of tables. Built by NewView and Diff,
+// printed by WriteViews, so the three formats cannot disagree on content.
+type View struct {
+	Title    string
+	Sections []Section
+}
+
+// Section is one heading and its table.
+type Section struct {
+	Heading string
+	Columns []string
+	Rows    [][]string
+	// Empty is printed instead of the table when there are no rows, so an absent section
+	// reads as "nothing happened" rather than "not reported".
+	Empty string
+}
+
+// viewTemplates parses the embedded templates once: the FS is compiled in, so the result is fixed.
+var viewTemplates = sync.OnceValues(func() (*template.Template, error) {
+	return template.New("").Funcs(template.FuncMap{
+		"cell":      internal.MarkdownCell,
+		"separator": func(columns []string) string { return strings.Repeat("|---", len(columns)) + "|" },
+	}).ParseFS(templates, "templates/view.md.tmpl")
+})
+
+// viewHTMLTemplate is html/template, not text/template: every value is escaped, and a package
+// name or error message is not markup.
+var viewHTMLTemplate = sync.OnceValues(func() (*htmltemplate.Template, error) {
+	return htmltemplate.ParseFS(templates, "templates/view.html.tmpl")
+})
+
+// WriteViews prints views to w in format.
+func WriteViews(w io.Writer, format Format, views []View) error {
+	switch format {
+	case FormatTerminal:
+		return writeTerminal(w, views)
+	case FormatMarkdown:
+		tmpl, err := viewTemplates()
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		return tmpl.ExecuteTemplate(w, "view.md.tmpl", views)
+	case FormatHTML:
+		tmpl, err := viewHTMLTemplate()
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		return tmpl.ExecuteTemplate(w, "view.html.tmpl", views)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// writeTerminal aligns each table with a tabwriter, which a template cannot do.
+func writeTerminal(w io.Writer, views []View) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, view := range views {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "%s\n%s\n", view.Title, strings.Repeat("=", len([]rune(view.Title))))
+		for _, section := range view.Sections {
+			fmt.Fprintf(tw, "\n%s\n%s\n", section.Heading, strings.Repeat("-", len([]rune(section.Heading))))
+			if len(section.Rows) == 0 {
+				fmt.Fprintln(tw, section.Empty)
+				continue
+			}
+			fmt.Fprintln(tw, strings.ToUpper(strings.Join(section.Columns, "\t")))
+			for _, row := range section.Rows {
+				cells := make([]string, len(row))
+				for i, cell := range row {
+					// A blank cell reads as a misaligned column in plain text.
+					cells[i] = cmp.Or(cell, "-")
+				}
+				fmt.Fprintln(tw, strings.Join(cells, "\t"))
+			}
+			// Each table aligns on its own columns, not the whole document's.
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// Read decodes a run report written by --report. A document of another kind, or from a newer
+// drupdater whose fields this one would silently drop, is an error.
+func Read(fs afero.Fs, path string) (Report, error) {
+	var rep Report
+	raw, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return rep, err
+	}
+	if err := json.Unmarshal(raw, &rep); err != nil {
+		return rep, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if rep.SchemaVersion == 0 || rep.Status == "" {
+		return rep, fmt.Errorf("%s is not a run report: write one with --report", path)
+	}
+	if rep.SchemaVersion > SchemaVersion {
+		return rep, fmt.Errorf("%s has schema version %d, newer than this drupdater reads (%d): use a newer drupdater", path, rep.SchemaVersion, SchemaVersion)
+	}
+	return rep, nil
+}
+
+// NewView lays a report out for reading. A run split into groups gets one View for the
+// summary and one per group after it.
+func NewView(rep Report) []View {
+	title := rep.Repository + " (" + rep.BaseBranch + ")"
+	if rep.Group != "" {
+		title += " — group " + rep.Group
+	}
+
+	if len(rep.Groups) > 0 {
+		views := []View{{Title: title, Sections: []Section{summarySection(rep), groupsSection(rep.Groups)}}}
+		for _, g := range rep.Groups {
+			views = append(views, NewView(g)...)
+		}
+		return views
+	}
+
+	sections := []Section{summarySection(rep), packagesSection(rep.Packages)}
+	if rep.MajorUpgrades != nil {
+		sections = append(sections, majorUpgradesSection(*rep.MajorUpgrades))
+	}
+	if audit, ok := auditOf(rep); ok {
+		sections = append(sections, advisoriesSection(audit))
+	}
+	if patches, ok := patchesOf(rep); ok {
+		sections = append(sections, patchConflictsSection(patches))
+	}
+	sections = append(sections, phasesSection(rep.Phases))
+	return []View{{Title: title, Sections: sections}}
+}
+
+func summarySection(rep Report) Section {
+	rows := [][]string{
+		{"Status", string(rep.Status)},
+		{"Mode", string(rep.Mode)},
+		{"Dry run", fmt.Sprint(rep.DryRun)},
+		{"Started", rep.StartedAt.UTC().Format(time.RFC3339)},
+		{"Duration", seconds(rep.DurationSeconds)},
+	}
+	if rep.FailedPhase != "" {
+		rows = append(rows, []string{"Failed phase", rep.FailedPhase}, []string{"Error", rep.Error})
+	}
+	if rep.UpdateBranch != "" {
+		rows = append(rows, []string{"Update branch", rep.UpdateBranch})
+	}
+	if rep.MergeRequest != nil {
+		rows = append(rows, []string{"Merge request", mergeRequestText(*rep.MergeRequest)})
+	}
+	rows = append(rows, []string{"Sites", strings.Join(rep.Sites, ", ")})
+	if rep.ComposerVersion != "" || rep.PHPVersion != "" {
+		rows = append(rows, []string{"Composer / PHP", rep.ComposerVersion + " / " + rep.PHPVersion})
+	}
+	rows = append(rows, []string{"Drupdater", rep.DrupdaterVersion})
+	return Section{Heading: "Summary", Columns: []string{"Field", "Value"}, Rows: rows}
+}
+
+func groupsSection(groups []Report) Section {
+	section := Section{Heading: "Groups", Columns: []string{"Group", "Status", "Packages", "Merge request"}}
+	for _, g := range groups {
+		mr := ""
+		if g.MergeRequest != nil {
+			mr = mergeRequestText(*g.MergeRequest)
+		}
+		section.Rows = append(section.Rows, []string{g.Group, string(g.Status), fmt.Sprint(len(g.Packages)), mr})
+	}
+	return section
+}
+
+func packagesSection(packages []PackageChange) Section {
+	section := Section{Heading: "Packages", Columns: []string{"Action", "Package", "From", "To"}, Empty: "No dependency changes."}
+	for _, p := range packages {
+		section.Rows = append(section.Rows, []string{p.Action, p.Package, p.From, p.To})
+	}
+	return section
+}
+
+func majorUpgradesSection(upgrades MajorUpgrades) Section {
+	section := Section{Heading: "Major upgrades", Columns: []string{"Package", "From", "To", "Result"}, Empty: "No major releases to upgrade to."}
+	for _, u := range upgrades.Upgraded {
+		section.Rows = append(section.Rows, []string{u.Package, u.From, u.To, "upgraded"})
+	}
+	for _, u := range upgrades.Failed {
+		section.Rows = append(section.Rows, []string{u.Package, u.From, u.To, u.Error})
+	}
+	return section
+}
+
+func advisoriesSection(audit auditSection) Section {
+	section := Section{Heading: "Advisories", Columns: []string{"Package", "Advisory", "Severity", "Title", "State"}, Empty: "No known advisories."}
+	for _, a := range audit.Fixed {
+		section.Rows = append(section.Rows, []string{a.PackageName, a.id(), a.Severity, a.Title, advisoryFixed})
+	}
+	for _, a := range audit.Remaining {
+		section.Rows = append(section.Rows, []string{a.PackageName, a.id(), a.Severity, a.Title, advisoryRemaining})
+	}
+	return section
+}
+
+func patchConflictsSection(patches patchesSection) Section {
+	section := Section{Heading: "Patch conflicts", Columns: []string{"Package", "Patch", "Patched version", "New version"}, Empty: "No patch conflicts."}
+	for _, c := range patches.Conflicts {
+		section.Rows = append(section.Rows, []string{c.Package, c.patch(), c.FixedVersion, c.NewVersion})
+	}
+	return section
+}
+
+func phasesSection(phases []Phase) Section {
+	section := Section{Heading: "Phases", Columns: []string{"Phase", "Duration", "Result"}, Empty: "No phase ran."}
+	for _, p := range phases {
+		result := "ok"
+		if !p.OK {
+			result = "failed: " + p.Error
+		}
+		section.Rows = append(section.Rows, []string{p.Name, seconds(p.DurationSeconds), result})
+	}
+	return section
+}
+
+func mergeRequestText(mr MergeRequest) string {
+	if mr.Updated {
+		return mr.URL + " (updated)"
+	}
+	if mr.Unchanged {
+		return mr.URL + " (unchanged)"
+	}
+	return mr.URL
+}
+
+// seconds prints a duration the way a person reads one: 1m12.5s rather than 72.5.
+func seconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(100 * time.Millisecond).String()
+}
+
+// Advisory states, as the views print them.
+const (
+	advisoryFixed     = "fixed"
+	advisoryRemaining = "remaining"
+)
+
+// auditSection is the part of composer_audit's section the views read. Declared here rather
+// than imported: the addon package depends on this one, and the JSON is the published contract.
+type auditSection struct {
+	Fixed     []advisory     `json:"fixed"`
+	Remaining []advisory     `json:"remaining"`
+	LockLines map[string]int `json:"lock_lines"`
+}
+
+type advisory struct {
+	AdvisoryID       string `json:"advisoryId"`
+	CVE              string `json:"cve"`
+	PackageName      string `json:"packageName"`
+	Severity         string `json:"severity"`
+	Title            string `json:"title"`
+	Link             string `json:"link"`
+	AffectedVersions string `json:"affectedVersions"`
+}
+
+// id prefers the CVE, which is what a reader searches for.
+func (a advisory) id() string {
+	if a.CVE != "" {
+		return a.CVE
+	}
+	return a.AdvisoryID
+}
+
+// patchesSection is the part of composer_patches' section the views read.
+type patchesSection struct {
+	Conflicts []patchConflict `json:"conflicts"`
+}
+
+type patchConflict struct {
+	Package          string `json:"package"`
+	FixedVersion     string `json:"fixed_version"`
+	NewVersion       string `json:"new_version"`
+	PatchPath        string `json:"patch_path"`
+	PatchDescription string `json:"patch_description"`
+}
+
+func (c patchConflict) patch() string {
+	if c.PatchDescription != "" {
+		return c.PatchDescription
+	}
+	return c.PatchPath
+}
+
+func auditOf(rep Report) (auditSection, bool) {
+	var audit auditSection
+	return audit, addonSection(rep, "composer_audit", &audit)
+}
+
+func patchesOf(rep Report) (patchesSection, bool) {
+	var patches patchesSection
+	return patches, addonSection(rep, "composer_patches", &patches)
+}
+
+// addonSection decodes the section under key into v, false when the report has none or it does
+// not decode. Round-tripped through JSON: a report read from disk holds generic maps, one still
+// in memory holds the addon's own type.
+func addonSection(rep Report, key string, v any) bool {
+	data, ok := rep.Addons[key]
+	if !ok {
+		return false
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
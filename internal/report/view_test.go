@@ -0,0 +1,211 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/drupdater/drupdater/internal/golden"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// viewFixture is a report as a week of runs would leave it: written, then read back, so the addon
+// sections are the generic JSON a real file holds rather than the addons' own types.
+func viewFixture(t *testing.T, rep Report) Report {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	require.NoError(t, Write(fs, "/report.json", rep, nil))
+	read, err := Read(fs, "/report.json")
+	require.NoError(t, err)
+	return read
+}
+
+func lastWeek() Report {
+	started := time.Date(2026, 6, 8, 3, 0, 0, 0, time.UTC)
+	return Report{
+		SchemaVersion:    SchemaVersion,
+		DrupdaterVersion: "v1.3.0",
+		ToolVersions:     ToolVersions{ComposerVersion: "2.10.1", PHPVersion: "8.3.14"},
+		StartedAt:        started,
+		FinishedAt:       started.Add(12 * time.Minute),
+		DurationSeconds:  720,
+		Status:           StatusSuccess,
+		Mode:             ModeNormal,
+		Repository:       "https://github.com/org/site.git",
+		BaseBranch:       "main",
+		UpdateBranch:     "update-1a2b3c4",
+		MergeRequest:     &MergeRequest{URL: "https://github.com/org/site/pull/41"},
+		Sites:            []string{"default"},
+		Packages: []PackageChange{
+			{Action: "Upgrade", Package: "drupal/core", From: "10.3.7", To: "10.3.8"},
+			{Action: "Upgrade", Package: "drupal/token", From: "1.13.0", To: "1.14.0"},
+		},
+		Phases: []Phase{
+			{Name: "composer install", StartedAt: started, DurationSeconds: 95.2, OK: true},
+			{Name: "update shared code", StartedAt: started, DurationSeconds: 310.4, OK: true},
+		},
+		Addons: map[string]any{
+			"composer_audit": map[string]any{
+				"fixed": []map[string]any{},
+				"remaining": []map[string]any{
+					{"advisoryId": "PKSA-2026-0002", "cve": "CVE-2026-2222", "packageName": "drupal/token", "severity": "moderate", "title": "Access bypass"},
+				},
+			},
+			"composer_patches": map[string]any{
+				"conflicts": []map[string]any{
+					{"package": "drupal/webform", "fixed_version": "6.2.6", "new_version": "6.2.7", "patch_path": "https://www.drupal.org/files/issues/3003-2.patch", "patch_description": "Adjust the form"},
+				},
+			},
+		},
+	}
+}
+
+func thisWeek() Report {
+	started := time.Date(2026, 6, 15, 3, 0, 0, 0, time.UTC)
+	return Report{
+		SchemaVersion:    SchemaVersion,
+		DrupdaterVersion: "v1.4.0",
+		ToolVersions:     ToolVersions{ComposerVersion: "2.10.2", PHPVersion: "8.3.14"},
+		StartedAt:        started,
+		FinishedAt:       started.Add(9 * time.Minute),
+		DurationSeconds:  540,
+		Status:           StatusFailed,
+		FailedPhase:      "site update",
+		Error:            "site default: drush updatedb failed | exit status 1",
+		Mode:             ModeNormal,
+		Repository:       "https://github.com/org/site.git",
+		BaseBranch:       "main",
+		UpdateBranch:     "update-5d6e7f8",
+		Sites:            []string{"default"},
+		Packages: []PackageChange{
+			{Action: "Upgrade", Package: "drupal/core", From: "10.3.7", To: "10.3.8"},
+			{Action: "Upgrade", Package: "drupal/token", From: "1.14.0", To: "1.15.0"},
+			{Action: "Install", Package: "drupal/redirect", To: "1.10.0"},
+		},
+		Phases: []Phase{
+			{Name: "composer install", StartedAt: started, DurationSeconds: 61.0, OK: true},
+			{Name: "update shared code", StartedAt: started, DurationSeconds: 342.9, OK: true},
+			{Name: "site update", StartedAt: started, DurationSeconds: 40.1, OK: false, Error: "site default: drush updatedb failed | exit status 1"},
+		},
+		Addons: map[string]any{
+			"composer_audit": map[string]any{
+				"fixed": []map[string]any{
+					{"advisoryId": "PKSA-2026-0002", "cve": "CVE-2026-2222", "packageName": "drupal/token", "severity": "moderate", "title": "Access bypass"},
+				},
+				"remaining": []map[string]any{
+					{"advisoryId": "PKSA-2026-0007", "packageName": "drupal/paragraphs", "severity": "high", "title": "Information disclosure <script>"},
+				},
+			},
+			"composer_patches": map[string]any{"conflicts": []map[string]any{}},
+		},
+	}
+}
+
+func TestWriteViewsGolden(t *testing.T) {
+	views := NewView(viewFixture(t, thisWeek()))
+
+	for format, path := range map[Format]string{
+		FormatTerminal: "testdata/view.txt",
+		FormatMarkdown: "testdata/view.md",
+		FormatHTML:     "testdata/view.html",
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, WriteViews(&out, format, views))
+			golden.Assert(t, path, out.String())
+		})
+	}
+}
+
+func TestWriteViewsEscapesHTML(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteViews(&out, FormatHTML, NewView(viewFixture(t, thisWeek()))))
+
+	assert.NotContains(t, out.String(), "<script>", "a report value is text, never markup")
+	assert.Contains(t, out.String(), "&lt;script&gt;")
+}
+
+func TestDiffGolden(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteViews(&out, FormatMarkdown, []View{Diff(viewFixture(t, lastWeek()), viewFixture(t, thisWeek()))}))
+	golden.Assert(t, "testdata/diff.md", out.String())
+}
+
+func TestDiffOfARunWithItselfListsOnlyPhases(t *testing.T) {
+	rep := viewFixture(t, lastWeek())
+	view := Diff(rep, rep)
+
+	for _, section := range view.Sections {
+		switch section.Heading {
+		case "Summary", "Phase durations":
+			assert.NotEmpty(t, section.Rows, section.Heading)
+		default:
+			assert.Empty(t, section.Rows, "%s: nothing changed", section.Heading)
+		}
+	}
+}
+
+func TestDiffComparesAcrossGroups(t *testing.T) {
+	core, contrib := lastWeek(), lastWeek()
+	core.Group, contrib.Group = "core", "contrib"
+	contrib.Packages = []PackageChange{{Action: "Upgrade", Package: "drupal/pathauto", From: "1.12.0", To: "1.13.0"}}
+
+	view := Diff(lastWeek(), Combine([]Report{core, contrib}))
+
+	packages := view.Sections[1]
+	require.Equal(t, "Packages", packages.Heading)
+	assert.Equal(t, [][]string{{"drupal/pathauto", noValue, "Upgrade 1.12.0 → 1.13.0"}}, packages.Rows)
+
+	phases := view.Sections[3]
+	assert.Equal(t, "core: composer install", phases.Rows[0][0], "a group's phases are named after it")
+}
+
+func TestNewViewSplitRunHasOneViewPerGroup(t *testing.T) {
+	core, contrib := lastWeek(), thisWeek()
+	core.Group, contrib.Group = "core", "contrib"
+
+	views := NewView(Combine([]Report{core, contrib}))
+
+	require.Len(t, views, 3)
+	assert.Equal(t, "Groups", views[0].Sections[1].Heading)
+	assert.Equal(t, "https://github.com/org/site.git (main) — group contrib", views[2].Title)
+}
+
+func TestRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	t.Run("a check report is not a run report", func(t *testing.T) {
+		require.NoError(t, WriteCheck(fs, "/check.json", NewCheck("dev", ToolVersions{}, nil), nil))
+		_, err := Read(fs, "/check.json")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not a run report")
+	})
+
+	t.Run("a newer schema is refused", func(t *testing.T) {
+		rep := lastWeek()
+		rep.SchemaVersion = SchemaVersion + 1
+		require.NoError(t, Write(fs, "/newer.json", rep, nil))
+		_, err := Read(fs, "/newer.json")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "use a newer drupdater")
+	})
+
+	t.Run("invalid JSON names the file", func(t *testing.T) {
+		require.NoError(t, afero.WriteFile(fs, "/broken.json", []byte("{"), 0o600))
+		_, err := Read(fs, "/broken.json")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "/broken.json")
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		got, err := ParseFormat(string(f))
+		require.NoError(t, err)
+		assert.Equal(t, f, got)
+	}
+	_, err := ParseFormat("pdf")
+	assert.Error(t, err)
+}
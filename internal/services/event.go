@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gookit/event"
 )
@@ -24,6 +25,11 @@ func (e *BasicAddonEvent) Context() context.Context {
 	return e.ctx
 }
 
+// SetContext replaces the event's context, so each listener can run under its own span.
+func (e *BasicAddonEvent) SetContext(ctx context.Context) {
+	e.ctx = ctx
+}
+
 func (e *BasicAddonEvent) Path() string {
 	return e.path
 }
@@ -56,6 +62,36 @@ func NewPreComposerUpdateEvent(ctx context.Context, path string, worktree Worktr
 	return evt
 }
 
+// KeepPackage limits name to constraint for the update. Composer honours only the last --with
+// per package, so one already kept is narrowed to both constraints, and an exact version, the
+// strictest pin there is, replaces any range. False when name is already pinned to an exact
+// version, which nothing narrows further: constraint then has no effect.
+func (e *PreComposerUpdateEvent) KeepPackage(name string, constraint string) bool {
+	for i, entry := range e.PackagesToKeep {
+		kept, existing, _ := strings.Cut(entry, ":")
+		if kept != name {
+			continue
+		}
+		switch {
+		case isExactVersion(existing):
+			return false
+		case isExactVersion(constraint):
+			e.PackagesToKeep[i] = name + ":" + constraint
+		default:
+			e.PackagesToKeep[i] = name + ":" + existing + ", " + constraint
+		}
+		return true
+	}
+	e.PackagesToKeep = append(e.PackagesToKeep, name+":"+constraint)
+	return true
+}
+
+// isExactVersion tells a version like 6.2.7 or dev-main from a constraint, which always carries
+// an operator, a wildcard or a separator.
+func isExactVersion(constraint string) bool {
+	return constraint != "" && !strings.ContainsAny(constraint, "~^<>=!*|, ")
+}
+
 type PostComposerUpdateEvent struct {
 	event.BasicEvent
 	BasicAddonEvent
@@ -26,6 +26,36 @@ func TestNewPreComposerUpdateEvent(t *testing.T) {
 	assert.True(t, evt.MinimalChanges)
 }
 
+func TestPreComposerUpdateEventKeepPackage(t *testing.T) {
+	newEvent := func(keep ...string) *PreComposerUpdateEvent {
+		return NewPreComposerUpdateEvent(context.Background(), "/repo", nil, nil, keep, false)
+	}
+
+	t.Run("a package not yet kept is added", func(t *testing.T) {
+		evt := newEvent("drupal/token:1.14.0")
+		assert.True(t, evt.KeepPackage("drupal/webform", "~6.2.7"))
+		assert.Equal(t, []string{"drupal/token:1.14.0", "drupal/webform:~6.2.7"}, evt.PackagesToKeep)
+	})
+
+	t.Run("an exact pin is never loosened", func(t *testing.T) {
+		evt := newEvent("drupal/webform:6.2.7")
+		assert.False(t, evt.KeepPackage("drupal/webform", "~6.2.7"))
+		assert.Equal(t, []string{"drupal/webform:6.2.7"}, evt.PackagesToKeep)
+	})
+
+	t.Run("an exact pin replaces a range", func(t *testing.T) {
+		evt := newEvent("drupal/webform:~6.2.7")
+		assert.True(t, evt.KeepPackage("drupal/webform", "6.2.7"))
+		assert.Equal(t, []string{"drupal/webform:6.2.7"}, evt.PackagesToKeep)
+	})
+
+	t.Run("two ranges are intersected", func(t *testing.T) {
+		evt := newEvent("drupal/webform:~6.2.7")
+		assert.True(t, evt.KeepPackage("drupal/webform", "<6.2.10"))
+		assert.Equal(t, []string{"drupal/webform:~6.2.7, <6.2.10"}, evt.PackagesToKeep)
+	})
+}
+
 func TestNewPostComposerUpdateEvent(t *testing.T) {
 	ctx := context.Background()
 	worktree := NewMockWorktree(t)
@@ -6,6 +6,7 @@ import (
 	"github.com/drupdater/drupdater/internal/codehosting"
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/gookit/event"
 )
 
@@ -13,9 +14,13 @@ type Composer interface {
 	Install(ctx context.Context, dir string) error
 	Update(ctx context.Context, dir string, packagesToUpdate []string, packagesToKeep []string, minimalChanges bool, dryRun bool) ([]composer.PackageChange, error)
 	GetLockHash(dir string) (string, error)
+	GetLockedPackagesHash(dir string) (string, error)
+	GetRequiredPackages(dir string) ([]string, error)
 	CheckPlatformReqs(ctx context.Context, dir string) (string, error)
 	GetConfig(ctx context.Context, dir string, key string) (string, error)
 	Version(ctx context.Context) (composer.Versions, error)
+	OutdatedMajor(ctx context.Context, dir string) ([]composer.OutdatedPackage, error)
+	RequireUpgrade(ctx context.Context, dir string, pkg string, constraint string, packagesToKeep []string) ([]composer.PackageChange, error)
 }
 
 type Drush interface {
@@ -25,8 +30,8 @@ type Drush interface {
 }
 
 type Repository interface {
-	BranchExists(repository repo.Repository, branch string, token string) (bool, error)
-	CloneRepository(repository string, branch string, token string, username string, email string) (repo.Repository, repo.Worktree, string, error)
+	BranchExists(repository repo.Repository, branch string, auth transport.AuthMethod) (bool, error)
+	CloneRepository(repository string, branch string, auth transport.AuthMethod, username string, email string) (repo.Repository, repo.Worktree, string, error)
 	OpenRepository(path string, username string, email string) (repo.Repository, repo.Worktree, string, error)
 	GetRemoteURL(path string) (string, error)
 	GetCurrentBranch(path string) (string, error)
@@ -45,10 +50,16 @@ type Installer interface {
 }
 
 type Platform interface {
-	CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (codehosting.MergeRequest, error)
+	CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (codehosting.MergeRequest, error)
 	DeleteBranch(ctx context.Context, branch string) error
 	GetUser(ctx context.Context) (name string, email string)
 	EnableAutoMerge(ctx context.Context, mr codehosting.MergeRequest) error
+	ListMergeRequests(ctx context.Context, targetBranch string) ([]codehosting.MergeRequest, error)
+	UpdateMergeRequest(ctx context.Context, mr codehosting.MergeRequest, title string, description string) error
+	CloseMergeRequest(ctx context.Context, mr codehosting.MergeRequest, comment string) error
+	SetMergeRequestMetadata(ctx context.Context, mr codehosting.MergeRequest, metadata codehosting.MergeRequestMetadata) error
+	PipelineStatus(ctx context.Context, mr codehosting.MergeRequest) (codehosting.PipelineStatus, error)
+	MarkMergeRequestReady(ctx context.Context, mr codehosting.MergeRequest) error
 }
 
 // EventDispatcher abstracts the event bus so it can be injected and tested independently.
@@ -12,6 +12,7 @@ import (
 	"github.com/drupdater/drupdater/pkg/repo"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/gookit/event"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -241,6 +242,128 @@ func (_c *MockComposer_GetLockHash_Call) RunAndReturn(run func(dir string) (stri
 	return _c
 }
 
+// GetLockedPackagesHash provides a mock function for the type MockComposer
+func (_mock *MockComposer) GetLockedPackagesHash(dir string) (string, error) {
+	ret := _mock.Called(dir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLockedPackagesHash")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return returnFunc(dir)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) string); ok {
+		r0 = returnFunc(dir)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(dir)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockComposer_GetLockedPackagesHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLockedPackagesHash'
+type MockComposer_GetLockedPackagesHash_Call struct {
+	*mock.Call
+}
+
+// GetLockedPackagesHash is a helper method to define mock.On call
+//   - dir string
+func (_e *MockComposer_Expecter) GetLockedPackagesHash(dir any) *MockComposer_GetLockedPackagesHash_Call {
+	return &MockComposer_GetLockedPackagesHash_Call{Call: _e.mock.On("GetLockedPackagesHash", dir)}
+}
+
+func (_c *MockComposer_GetLockedPackagesHash_Call) Run(run func(dir string)) *MockComposer_GetLockedPackagesHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockComposer_GetLockedPackagesHash_Call) Return(s string, err error) *MockComposer_GetLockedPackagesHash_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockComposer_GetLockedPackagesHash_Call) RunAndReturn(run func(dir string) (string, error)) *MockComposer_GetLockedPackagesHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRequiredPackages provides a mock function for the type MockComposer
+func (_mock *MockComposer) GetRequiredPackages(dir string) ([]string, error) {
+	ret := _mock.Called(dir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRequiredPackages")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+		return returnFunc(dir)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = returnFunc(dir)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(dir)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockComposer_GetRequiredPackages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRequiredPackages'
+type MockComposer_GetRequiredPackages_Call struct {
+	*mock.Call
+}
+
+// GetRequiredPackages is a helper method to define mock.On call
+//   - dir string
+func (_e *MockComposer_Expecter) GetRequiredPackages(dir any) *MockComposer_GetRequiredPackages_Call {
+	return &MockComposer_GetRequiredPackages_Call{Call: _e.mock.On("GetRequiredPackages", dir)}
+}
+
+func (_c *MockComposer_GetRequiredPackages_Call) Run(run func(dir string)) *MockComposer_GetRequiredPackages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockComposer_GetRequiredPackages_Call) Return(strings []string, err error) *MockComposer_GetRequiredPackages_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockComposer_GetRequiredPackages_Call) RunAndReturn(run func(dir string) ([]string, error)) *MockComposer_GetRequiredPackages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Install provides a mock function for the type MockComposer
 func (_mock *MockComposer) Install(ctx context.Context, dir string) error {
 	ret := _mock.Called(ctx, dir)
@@ -298,6 +421,160 @@ func (_c *MockComposer_Install_Call) RunAndReturn(run func(ctx context.Context,
 	return _c
 }
 
+// OutdatedMajor provides a mock function for the type MockComposer
+func (_mock *MockComposer) OutdatedMajor(ctx context.Context, dir string) ([]composer.OutdatedPackage, error) {
+	ret := _mock.Called(ctx, dir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OutdatedMajor")
+	}
+
+	var r0 []composer.OutdatedPackage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]composer.OutdatedPackage, error)); ok {
+		return returnFunc(ctx, dir)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []composer.OutdatedPackage); ok {
+		r0 = returnFunc(ctx, dir)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]composer.OutdatedPackage)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, dir)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockComposer_OutdatedMajor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OutdatedMajor'
+type MockComposer_OutdatedMajor_Call struct {
+	*mock.Call
+}
+
+// OutdatedMajor is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dir string
+func (_e *MockComposer_Expecter) OutdatedMajor(ctx any, dir any) *MockComposer_OutdatedMajor_Call {
+	return &MockComposer_OutdatedMajor_Call{Call: _e.mock.On("OutdatedMajor", ctx, dir)}
+}
+
+func (_c *MockComposer_OutdatedMajor_Call) Run(run func(ctx context.Context, dir string)) *MockComposer_OutdatedMajor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockComposer_OutdatedMajor_Call) Return(r0 []composer.OutdatedPackage, err error) *MockComposer_OutdatedMajor_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *MockComposer_OutdatedMajor_Call) RunAndReturn(run func(ctx context.Context, dir string) ([]composer.OutdatedPackage, error)) *MockComposer_OutdatedMajor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RequireUpgrade provides a mock function for the type MockComposer
+func (_mock *MockComposer) RequireUpgrade(ctx context.Context, dir string, pkg string, constraint string, packagesToKeep []string) ([]composer.PackageChange, error) {
+	ret := _mock.Called(ctx, dir, pkg, constraint, packagesToKeep)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequireUpgrade")
+	}
+
+	var r0 []composer.PackageChange
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, []string) ([]composer.PackageChange, error)); ok {
+		return returnFunc(ctx, dir, pkg, constraint, packagesToKeep)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, []string) []composer.PackageChange); ok {
+		r0 = returnFunc(ctx, dir, pkg, constraint, packagesToKeep)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]composer.PackageChange)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, []string) error); ok {
+		r1 = returnFunc(ctx, dir, pkg, constraint, packagesToKeep)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockComposer_RequireUpgrade_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequireUpgrade'
+type MockComposer_RequireUpgrade_Call struct {
+	*mock.Call
+}
+
+// RequireUpgrade is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dir string
+//   - pkg string
+//   - constraint string
+//   - packagesToKeep []string
+func (_e *MockComposer_Expecter) RequireUpgrade(ctx any, dir any, pkg any, constraint any, packagesToKeep any) *MockComposer_RequireUpgrade_Call {
+	return &MockComposer_RequireUpgrade_Call{Call: _e.mock.On("RequireUpgrade", ctx, dir, pkg, constraint, packagesToKeep)}
+}
+
+func (_c *MockComposer_RequireUpgrade_Call) Run(run func(ctx context.Context, dir string, pkg string, constraint string, packagesToKeep []string)) *MockComposer_RequireUpgrade_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 []string
+		if args[4] != nil {
+			arg4 = args[4].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockComposer_RequireUpgrade_Call) Return(r0 []composer.PackageChange, err error) *MockComposer_RequireUpgrade_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *MockComposer_RequireUpgrade_Call) RunAndReturn(run func(ctx context.Context, dir string, pkg string, constraint string, packagesToKeep []string) ([]composer.PackageChange, error)) *MockComposer_RequireUpgrade_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Update provides a mock function for the type MockComposer
 func (_mock *MockComposer) Update(ctx context.Context, dir string, packagesToUpdate []string, packagesToKeep []string, minimalChanges bool, dryRun bool) ([]composer.PackageChange, error) {
 	ret := _mock.Called(ctx, dir, packagesToUpdate, packagesToKeep, minimalChanges, dryRun)
@@ -694,8 +971,8 @@ func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
 }
 
 // BranchExists provides a mock function for the type MockRepository
-func (_mock *MockRepository) BranchExists(repository repo.Repository, branch string, token string) (bool, error) {
-	ret := _mock.Called(repository, branch, token)
+func (_mock *MockRepository) BranchExists(repository repo.Repository, branch string, auth transport.AuthMethod) (bool, error) {
+	ret := _mock.Called(repository, branch, auth)
 
 	if len(ret) == 0 {
 		panic("no return value specified for BranchExists")
@@ -703,16 +980,16 @@ func (_mock *MockRepository) BranchExists(repository repo.Repository, branch str
 
 	var r0 bool
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(repo.Repository, string, string) (bool, error)); ok {
-		return returnFunc(repository, branch, token)
+	if returnFunc, ok := ret.Get(0).(func(repo.Repository, string, transport.AuthMethod) (bool, error)); ok {
+		return returnFunc(repository, branch, auth)
 	}
-	if returnFunc, ok := ret.Get(0).(func(repo.Repository, string, string) bool); ok {
-		r0 = returnFunc(repository, branch, token)
+	if returnFunc, ok := ret.Get(0).(func(repo.Repository, string, transport.AuthMethod) bool); ok {
+		r0 = returnFunc(repository, branch, auth)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
-	if returnFunc, ok := ret.Get(1).(func(repo.Repository, string, string) error); ok {
-		r1 = returnFunc(repository, branch, token)
+	if returnFunc, ok := ret.Get(1).(func(repo.Repository, string, transport.AuthMethod) error); ok {
+		r1 = returnFunc(repository, branch, auth)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -727,12 +1004,12 @@ type MockRepository_BranchExists_Call struct {
 // BranchExists is a helper method to define mock.On call
 //   - repository repo.Repository
 //   - branch string
-//   - token string
-func (_e *MockRepository_Expecter) BranchExists(repository any, branch any, token any) *MockRepository_BranchExists_Call {
-	return &MockRepository_BranchExists_Call{Call: _e.mock.On("BranchExists", repository, branch, token)}
+//   - auth transport.AuthMethod
+func (_e *MockRepository_Expecter) BranchExists(repository any, branch any, auth any) *MockRepository_BranchExists_Call {
+	return &MockRepository_BranchExists_Call{Call: _e.mock.On("BranchExists", repository, branch, auth)}
 }
 
-func (_c *MockRepository_BranchExists_Call) Run(run func(repository repo.Repository, branch string, token string)) *MockRepository_BranchExists_Call {
+func (_c *MockRepository_BranchExists_Call) Run(run func(repository repo.Repository, branch string, auth transport.AuthMethod)) *MockRepository_BranchExists_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 repo.Repository
 		if args[0] != nil {
@@ -742,9 +1019,9 @@ func (_c *MockRepository_BranchExists_Call) Run(run func(repository repo.Reposit
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 string
+		var arg2 transport.AuthMethod
 		if args[2] != nil {
-			arg2 = args[2].(string)
+			arg2 = args[2].(transport.AuthMethod)
 		}
 		run(
 			arg0,
@@ -760,14 +1037,14 @@ func (_c *MockRepository_BranchExists_Call) Return(b bool, err error) *MockRepos
 	return _c
 }
 
-func (_c *MockRepository_BranchExists_Call) RunAndReturn(run func(repository repo.Repository, branch string, token string) (bool, error)) *MockRepository_BranchExists_Call {
+func (_c *MockRepository_BranchExists_Call) RunAndReturn(run func(repository repo.Repository, branch string, auth transport.AuthMethod) (bool, error)) *MockRepository_BranchExists_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // CloneRepository provides a mock function for the type MockRepository
-func (_mock *MockRepository) CloneRepository(repository string, branch string, token string, username string, email string) (repo.Repository, repo.Worktree, string, error) {
-	ret := _mock.Called(repository, branch, token, username, email)
+func (_mock *MockRepository) CloneRepository(repository string, branch string, auth transport.AuthMethod, username string, email string) (repo.Repository, repo.Worktree, string, error) {
+	ret := _mock.Called(repository, branch, auth, username, email)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CloneRepository")
@@ -777,30 +1054,30 @@ func (_mock *MockRepository) CloneRepository(repository string, branch string, t
 	var r1 repo.Worktree
 	var r2 string
 	var r3 error
-	if returnFunc, ok := ret.Get(0).(func(string, string, string, string, string) (repo.Repository, repo.Worktree, string, error)); ok {
-		return returnFunc(repository, branch, token, username, email)
+	if returnFunc, ok := ret.Get(0).(func(string, string, transport.AuthMethod, string, string) (repo.Repository, repo.Worktree, string, error)); ok {
+		return returnFunc(repository, branch, auth, username, email)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string, string, string, string, string) repo.Repository); ok {
-		r0 = returnFunc(repository, branch, token, username, email)
+	if returnFunc, ok := ret.Get(0).(func(string, string, transport.AuthMethod, string, string) repo.Repository); ok {
+		r0 = returnFunc(repository, branch, auth, username, email)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(repo.Repository)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string, string, string, string, string) repo.Worktree); ok {
-		r1 = returnFunc(repository, branch, token, username, email)
+	if returnFunc, ok := ret.Get(1).(func(string, string, transport.AuthMethod, string, string) repo.Worktree); ok {
+		r1 = returnFunc(repository, branch, auth, username, email)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(repo.Worktree)
 		}
 	}
-	if returnFunc, ok := ret.Get(2).(func(string, string, string, string, string) string); ok {
-		r2 = returnFunc(repository, branch, token, username, email)
+	if returnFunc, ok := ret.Get(2).(func(string, string, transport.AuthMethod, string, string) string); ok {
+		r2 = returnFunc(repository, branch, auth, username, email)
 	} else {
 		r2 = ret.Get(2).(string)
 	}
-	if returnFunc, ok := ret.Get(3).(func(string, string, string, string, string) error); ok {
-		r3 = returnFunc(repository, branch, token, username, email)
+	if returnFunc, ok := ret.Get(3).(func(string, string, transport.AuthMethod, string, string) error); ok {
+		r3 = returnFunc(repository, branch, auth, username, email)
 	} else {
 		r3 = ret.Error(3)
 	}
@@ -815,14 +1092,14 @@ type MockRepository_CloneRepository_Call struct {
 // CloneRepository is a helper method to define mock.On call
 //   - repository string
 //   - branch string
-//   - token string
+//   - auth transport.AuthMethod
 //   - username string
 //   - email string
-func (_e *MockRepository_Expecter) CloneRepository(repository any, branch any, token any, username any, email any) *MockRepository_CloneRepository_Call {
-	return &MockRepository_CloneRepository_Call{Call: _e.mock.On("CloneRepository", repository, branch, token, username, email)}
+func (_e *MockRepository_Expecter) CloneRepository(repository any, branch any, auth any, username any, email any) *MockRepository_CloneRepository_Call {
+	return &MockRepository_CloneRepository_Call{Call: _e.mock.On("CloneRepository", repository, branch, auth, username, email)}
 }
 
-func (_c *MockRepository_CloneRepository_Call) Run(run func(repository string, branch string, token string, username string, email string)) *MockRepository_CloneRepository_Call {
+func (_c *MockRepository_CloneRepository_Call) Run(run func(repository string, branch string, auth transport.AuthMethod, username string, email string)) *MockRepository_CloneRepository_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
@@ -832,9 +1109,9 @@ func (_c *MockRepository_CloneRepository_Call) Run(run func(repository string, b
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 string
+		var arg2 transport.AuthMethod
 		if args[2] != nil {
-			arg2 = args[2].(string)
+			arg2 = args[2].(transport.AuthMethod)
 		}
 		var arg3 string
 		if args[3] != nil {
@@ -860,7 +1137,7 @@ func (_c *MockRepository_CloneRepository_Call) Return(repository1 repo.Repositor
 	return _c
 }
 
-func (_c *MockRepository_CloneRepository_Call) RunAndReturn(run func(repository string, branch string, token string, username string, email string) (repo.Repository, repo.Worktree, string, error)) *MockRepository_CloneRepository_Call {
+func (_c *MockRepository_CloneRepository_Call) RunAndReturn(run func(repository string, branch string, auth transport.AuthMethod, username string, email string) (repo.Repository, repo.Worktree, string, error)) *MockRepository_CloneRepository_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1952,9 +2229,72 @@ func (_m *MockPlatform) EXPECT() *MockPlatform_Expecter {
 	return &MockPlatform_Expecter{mock: &_m.Mock}
 }
 
+// CloseMergeRequest provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) CloseMergeRequest(ctx context.Context, mr codehosting.MergeRequest, comment string) error {
+	ret := _mock.Called(ctx, mr, comment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CloseMergeRequest")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, codehosting.MergeRequest, string) error); ok {
+		r0 = returnFunc(ctx, mr, comment)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_CloseMergeRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CloseMergeRequest'
+type MockPlatform_CloseMergeRequest_Call struct {
+	*mock.Call
+}
+
+// CloseMergeRequest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr codehosting.MergeRequest
+//   - comment string
+func (_e *MockPlatform_Expecter) CloseMergeRequest(ctx any, mr any, comment any) *MockPlatform_CloseMergeRequest_Call {
+	return &MockPlatform_CloseMergeRequest_Call{Call: _e.mock.On("CloseMergeRequest", ctx, mr, comment)}
+}
+
+func (_c *MockPlatform_CloseMergeRequest_Call) Run(run func(ctx context.Context, mr codehosting.MergeRequest, comment string)) *MockPlatform_CloseMergeRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 codehosting.MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(codehosting.MergeRequest)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_CloseMergeRequest_Call) Return(err error) *MockPlatform_CloseMergeRequest_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_CloseMergeRequest_Call) RunAndReturn(run func(ctx context.Context, mr codehosting.MergeRequest, comment string) error) *MockPlatform_CloseMergeRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateMergeRequest provides a mock function for the type MockPlatform
-func (_mock *MockPlatform) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (codehosting.MergeRequest, error) {
-	ret := _mock.Called(ctx, title, description, sourceBranch, targetBranch)
+func (_mock *MockPlatform) CreateMergeRequest(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (codehosting.MergeRequest, error) {
+	ret := _mock.Called(ctx, title, description, sourceBranch, targetBranch, draft)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateMergeRequest")
@@ -1962,16 +2302,16 @@ func (_mock *MockPlatform) CreateMergeRequest(ctx context.Context, title string,
 
 	var r0 codehosting.MergeRequest
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) (codehosting.MergeRequest, error)); ok {
-		return returnFunc(ctx, title, description, sourceBranch, targetBranch)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string, bool) (codehosting.MergeRequest, error)); ok {
+		return returnFunc(ctx, title, description, sourceBranch, targetBranch, draft)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) codehosting.MergeRequest); ok {
-		r0 = returnFunc(ctx, title, description, sourceBranch, targetBranch)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string, bool) codehosting.MergeRequest); ok {
+		r0 = returnFunc(ctx, title, description, sourceBranch, targetBranch, draft)
 	} else {
 		r0 = ret.Get(0).(codehosting.MergeRequest)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
-		r1 = returnFunc(ctx, title, description, sourceBranch, targetBranch)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, string, bool) error); ok {
+		r1 = returnFunc(ctx, title, description, sourceBranch, targetBranch, draft)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1989,11 +2329,12 @@ type MockPlatform_CreateMergeRequest_Call struct {
 //   - description string
 //   - sourceBranch string
 //   - targetBranch string
-func (_e *MockPlatform_Expecter) CreateMergeRequest(ctx any, title any, description any, sourceBranch any, targetBranch any) *MockPlatform_CreateMergeRequest_Call {
-	return &MockPlatform_CreateMergeRequest_Call{Call: _e.mock.On("CreateMergeRequest", ctx, title, description, sourceBranch, targetBranch)}
+//   - draft bool
+func (_e *MockPlatform_Expecter) CreateMergeRequest(ctx any, title any, description any, sourceBranch any, targetBranch any, draft any) *MockPlatform_CreateMergeRequest_Call {
+	return &MockPlatform_CreateMergeRequest_Call{Call: _e.mock.On("CreateMergeRequest", ctx, title, description, sourceBranch, targetBranch, draft)}
 }
 
-func (_c *MockPlatform_CreateMergeRequest_Call) Run(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string)) *MockPlatform_CreateMergeRequest_Call {
+func (_c *MockPlatform_CreateMergeRequest_Call) Run(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool)) *MockPlatform_CreateMergeRequest_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2015,12 +2356,17 @@ func (_c *MockPlatform_CreateMergeRequest_Call) Run(run func(ctx context.Context
 		if args[4] != nil {
 			arg4 = args[4].(string)
 		}
+		var arg5 bool
+		if args[5] != nil {
+			arg5 = args[5].(bool)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
 			arg3,
 			arg4,
+			arg5,
 		)
 	})
 	return _c
@@ -2031,7 +2377,7 @@ func (_c *MockPlatform_CreateMergeRequest_Call) Return(mergeRequest codehosting.
 	return _c
 }
 
-func (_c *MockPlatform_CreateMergeRequest_Call) RunAndReturn(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string) (codehosting.MergeRequest, error)) *MockPlatform_CreateMergeRequest_Call {
+func (_c *MockPlatform_CreateMergeRequest_Call) RunAndReturn(run func(ctx context.Context, title string, description string, sourceBranch string, targetBranch string, draft bool) (codehosting.MergeRequest, error)) *MockPlatform_CreateMergeRequest_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -2210,6 +2556,329 @@ func (_c *MockPlatform_GetUser_Call) RunAndReturn(run func(ctx context.Context)
 	return _c
 }
 
+// ListMergeRequests provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) ListMergeRequests(ctx context.Context, targetBranch string) ([]codehosting.MergeRequest, error) {
+	ret := _mock.Called(ctx, targetBranch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMergeRequests")
+	}
+
+	var r0 []codehosting.MergeRequest
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]codehosting.MergeRequest, error)); ok {
+		return returnFunc(ctx, targetBranch)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []codehosting.MergeRequest); ok {
+		r0 = returnFunc(ctx, targetBranch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]codehosting.MergeRequest)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, targetBranch)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPlatform_ListMergeRequests_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMergeRequests'
+type MockPlatform_ListMergeRequests_Call struct {
+	*mock.Call
+}
+
+// ListMergeRequests is a helper method to define mock.On call
+//   - ctx context.Context
+//   - targetBranch string
+func (_e *MockPlatform_Expecter) ListMergeRequests(ctx any, targetBranch any) *MockPlatform_ListMergeRequests_Call {
+	return &MockPlatform_ListMergeRequests_Call{Call: _e.mock.On("ListMergeRequests", ctx, targetBranch)}
+}
+
+func (_c *MockPlatform_ListMergeRequests_Call) Run(run func(ctx context.Context, targetBranch string)) *MockPlatform_ListMergeRequests_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_ListMergeRequests_Call) Return(mergeRequests []codehosting.MergeRequest, err error) *MockPlatform_ListMergeRequests_Call {
+	_c.Call.Return(mergeRequests, err)
+	return _c
+}
+
+func (_c *MockPlatform_ListMergeRequests_Call) RunAndReturn(run func(ctx context.Context, targetBranch string) ([]codehosting.MergeRequest, error)) *MockPlatform_ListMergeRequests_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkMergeRequestReady provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) MarkMergeRequestReady(ctx context.Context, mr codehosting.MergeRequest) error {
+	ret := _mock.Called(ctx, mr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkMergeRequestReady")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, codehosting.MergeRequest) error); ok {
+		r0 = returnFunc(ctx, mr)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_MarkMergeRequestReady_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkMergeRequestReady'
+type MockPlatform_MarkMergeRequestReady_Call struct {
+	*mock.Call
+}
+
+// MarkMergeRequestReady is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr codehosting.MergeRequest
+func (_e *MockPlatform_Expecter) MarkMergeRequestReady(ctx any, mr any) *MockPlatform_MarkMergeRequestReady_Call {
+	return &MockPlatform_MarkMergeRequestReady_Call{Call: _e.mock.On("MarkMergeRequestReady", ctx, mr)}
+}
+
+func (_c *MockPlatform_MarkMergeRequestReady_Call) Run(run func(ctx context.Context, mr codehosting.MergeRequest)) *MockPlatform_MarkMergeRequestReady_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 codehosting.MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(codehosting.MergeRequest)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_MarkMergeRequestReady_Call) Return(err error) *MockPlatform_MarkMergeRequestReady_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_MarkMergeRequestReady_Call) RunAndReturn(run func(ctx context.Context, mr codehosting.MergeRequest) error) *MockPlatform_MarkMergeRequestReady_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PipelineStatus provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) PipelineStatus(ctx context.Context, mr codehosting.MergeRequest) (codehosting.PipelineStatus, error) {
+	ret := _mock.Called(ctx, mr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PipelineStatus")
+	}
+
+	var r0 codehosting.PipelineStatus
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, codehosting.MergeRequest) (codehosting.PipelineStatus, error)); ok {
+		return returnFunc(ctx, mr)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, codehosting.MergeRequest) codehosting.PipelineStatus); ok {
+		r0 = returnFunc(ctx, mr)
+	} else {
+		r0 = ret.Get(0).(codehosting.PipelineStatus)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, codehosting.MergeRequest) error); ok {
+		r1 = returnFunc(ctx, mr)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPlatform_PipelineStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PipelineStatus'
+type MockPlatform_PipelineStatus_Call struct {
+	*mock.Call
+}
+
+// PipelineStatus is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr codehosting.MergeRequest
+func (_e *MockPlatform_Expecter) PipelineStatus(ctx any, mr any) *MockPlatform_PipelineStatus_Call {
+	return &MockPlatform_PipelineStatus_Call{Call: _e.mock.On("PipelineStatus", ctx, mr)}
+}
+
+func (_c *MockPlatform_PipelineStatus_Call) Run(run func(ctx context.Context, mr codehosting.MergeRequest)) *MockPlatform_PipelineStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 codehosting.MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(codehosting.MergeRequest)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_PipelineStatus_Call) Return(pipelineStatus codehosting.PipelineStatus, err error) *MockPlatform_PipelineStatus_Call {
+	_c.Call.Return(pipelineStatus, err)
+	return _c
+}
+
+func (_c *MockPlatform_PipelineStatus_Call) RunAndReturn(run func(ctx context.Context, mr codehosting.MergeRequest) (codehosting.PipelineStatus, error)) *MockPlatform_PipelineStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMergeRequestMetadata provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) SetMergeRequestMetadata(ctx context.Context, mr codehosting.MergeRequest, metadata codehosting.MergeRequestMetadata) error {
+	ret := _mock.Called(ctx, mr, metadata)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMergeRequestMetadata")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, codehosting.MergeRequest, codehosting.MergeRequestMetadata) error); ok {
+		r0 = returnFunc(ctx, mr, metadata)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_SetMergeRequestMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMergeRequestMetadata'
+type MockPlatform_SetMergeRequestMetadata_Call struct {
+	*mock.Call
+}
+
+// SetMergeRequestMetadata is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr codehosting.MergeRequest
+//   - metadata codehosting.MergeRequestMetadata
+func (_e *MockPlatform_Expecter) SetMergeRequestMetadata(ctx any, mr any, metadata any) *MockPlatform_SetMergeRequestMetadata_Call {
+	return &MockPlatform_SetMergeRequestMetadata_Call{Call: _e.mock.On("SetMergeRequestMetadata", ctx, mr, metadata)}
+}
+
+func (_c *MockPlatform_SetMergeRequestMetadata_Call) Run(run func(ctx context.Context, mr codehosting.MergeRequest, metadata codehosting.MergeRequestMetadata)) *MockPlatform_SetMergeRequestMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 codehosting.MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(codehosting.MergeRequest)
+		}
+		var arg2 codehosting.MergeRequestMetadata
+		if args[2] != nil {
+			arg2 = args[2].(codehosting.MergeRequestMetadata)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_SetMergeRequestMetadata_Call) Return(err error) *MockPlatform_SetMergeRequestMetadata_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_SetMergeRequestMetadata_Call) RunAndReturn(run func(ctx context.Context, mr codehosting.MergeRequest, metadata codehosting.MergeRequestMetadata) error) *MockPlatform_SetMergeRequestMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateMergeRequest provides a mock function for the type MockPlatform
+func (_mock *MockPlatform) UpdateMergeRequest(ctx context.Context, mr codehosting.MergeRequest, title string, description string) error {
+	ret := _mock.Called(ctx, mr, title, description)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMergeRequest")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, codehosting.MergeRequest, string, string) error); ok {
+		r0 = returnFunc(ctx, mr, title, description)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPlatform_UpdateMergeRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateMergeRequest'
+type MockPlatform_UpdateMergeRequest_Call struct {
+	*mock.Call
+}
+
+// UpdateMergeRequest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mr codehosting.MergeRequest
+//   - title string
+//   - description string
+func (_e *MockPlatform_Expecter) UpdateMergeRequest(ctx any, mr any, title any, description any) *MockPlatform_UpdateMergeRequest_Call {
+	return &MockPlatform_UpdateMergeRequest_Call{Call: _e.mock.On("UpdateMergeRequest", ctx, mr, title, description)}
+}
+
+func (_c *MockPlatform_UpdateMergeRequest_Call) Run(run func(ctx context.Context, mr codehosting.MergeRequest, title string, description string)) *MockPlatform_UpdateMergeRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 codehosting.MergeRequest
+		if args[1] != nil {
+			arg1 = args[1].(codehosting.MergeRequest)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPlatform_UpdateMergeRequest_Call) Return(err error) *MockPlatform_UpdateMergeRequest_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPlatform_UpdateMergeRequest_Call) RunAndReturn(run func(ctx context.Context, mr codehosting.MergeRequest, title string, description string) error) *MockPlatform_UpdateMergeRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockEventDispatcher creates a new instance of MockEventDispatcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockEventDispatcher(t interface {
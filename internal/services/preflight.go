@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
+	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/report"
 	composerpkg "github.com/drupdater/drupdater/pkg/composer"
 	"github.com/spf13/afero"
 )
@@ -84,3 +87,28 @@ func CheckSiteSettings(ctx context.Context, composer ComposerConfigGetter, fs af
 	}
 	return CheckOK(name)
 }
+
+// CheckMergeRequestTemplates parses the project's templates and renders its title and description
+// as a normal run with nothing to report would, so a typo in a field name fails here rather than
+// after the update. An addon's template is only parsed: its data depends on what the addon found.
+func CheckMergeRequestTemplates(templates internal.MergeRequestTemplates) CheckResult {
+	const name = "merge request templates valid"
+
+	parsed, err := internal.ParseTemplates(templates)
+	if err != nil {
+		return CheckFailed(name, err.Error())
+	}
+	if parsed.Title != nil {
+		now := time.Now()
+		sample := TitleData{Title: now.Format("January 2006") + ": Drupal Maintenance Updates", Date: now, Mode: report.ModeNormal}
+		if _, err := renderTitle(parsed.Title, sample); err != nil {
+			return CheckFailed(name, fmt.Sprintf("templates.title: %s", err))
+		}
+	}
+	if parsed.Description != nil {
+		if _, err := executeTemplate(parsed.Description, TemplateData{}); err != nil {
+			return CheckFailed(name, fmt.Sprintf("templates.description: %s", err))
+		}
+	}
+	return CheckOK(name)
+}
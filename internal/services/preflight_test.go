@@ -3,8 +3,11 @@ package services
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/drupdater/drupdater/internal"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -110,3 +113,41 @@ func TestCheckSiteSettings(t *testing.T) {
 		assert.Contains(t, result.Detail, "composer.json not found")
 	})
 }
+
+func TestCheckMergeRequestTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, body string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+		return path
+	}
+
+	t.Run("templates that render pass", func(t *testing.T) {
+		result := CheckMergeRequestTemplates(internal.MergeRequestTemplates{
+			Title:       "{{ .Title }} [{{ .Mode }}]",
+			Description: write("description.md.tmpl", "{{ with .MajorUpgrades }}{{ .Upgraded }}{{ end }}{{ range .Addons }}{{ .RenderTemplate }}{{ end }}"),
+			Addons:      map[string]string{"composer_diff": write("composer_diff.md.tmpl", "{{ . }}")},
+		})
+		assert.True(t, result.OK, result.Detail)
+	})
+
+	t.Run("a missing file fails", func(t *testing.T) {
+		result := CheckMergeRequestTemplates(internal.MergeRequestTemplates{Description: filepath.Join(dir, "absent.md.tmpl")})
+		assert.False(t, result.OK)
+		assert.Contains(t, result.Detail, "templates.description")
+	})
+
+	// Parsing alone accepts a misspelt field; only rendering finds it.
+	t.Run("a field the data lacks fails", func(t *testing.T) {
+		result := CheckMergeRequestTemplates(internal.MergeRequestTemplates{Title: "{{ .Titel }}"})
+		assert.False(t, result.OK)
+		assert.Contains(t, result.Detail, "templates.title")
+		assert.Contains(t, result.Detail, "Titel")
+	})
+
+	t.Run("a description that assumes a major run fails", func(t *testing.T) {
+		result := CheckMergeRequestTemplates(internal.MergeRequestTemplates{Description: write("major.md.tmpl", "{{ .MajorUpgrades.Upgraded }}")})
+		assert.False(t, result.OK)
+		assert.Contains(t, result.Detail, "templates.description")
+	})
+}
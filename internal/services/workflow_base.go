@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"slices"
 	"strings"
@@ -16,13 +17,17 @@ import (
 	"time"
 
 	"github.com/drupdater/drupdater/internal"
+	"github.com/drupdater/drupdater/internal/codehosting"
+	"github.com/drupdater/drupdater/internal/logging"
 	"github.com/drupdater/drupdater/internal/report"
 	"github.com/drupdater/drupdater/pkg/composer"
 	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/drupdater/drupdater/pkg/tracing"
 
 	git "github.com/go-git/go-git/v5"
 	gitConfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -32,6 +37,18 @@ var templates embed.FS
 
 type TemplateData struct {
 	Addons []internal.Addon
+	// MajorUpgrades is nil on any run but --major.
+	MajorUpgrades *report.MajorUpgrades
+}
+
+// TitleData is what a project's title template renders from.
+type TitleData struct {
+	// Title is the title the run would use, as the addons left it.
+	Title string
+	Date  time.Time
+	Mode  report.Mode
+	// Group is empty for an ungrouped run.
+	Group string
 }
 
 type WorkflowBaseService struct {
@@ -51,6 +68,13 @@ type WorkflowBaseService struct {
 
 	// reportSink receives the run report on every exit path. nil when --report was not given.
 	reportSink func(report.Report)
+
+	// templates are the project's own title and description templates; nil ones keep the embedded.
+	templates internal.ProjectTemplates
+
+	// majorUpgrades is written once while the shared code updates, before the merge request
+	// that lists it is rendered — no lock needed.
+	majorUpgrades *report.MajorUpgrades
 }
 
 // Option configures a WorkflowBaseService. Variadic so adding one does not disturb call sites.
@@ -63,6 +87,13 @@ func WithReportSink(sink func(report.Report)) Option {
 	}
 }
 
+// WithTemplates renders the merge request title and description from the project's templates.
+func WithTemplates(templates internal.ProjectTemplates) Option {
+	return func(ws *WorkflowBaseService) {
+		ws.templates = templates
+	}
+}
+
 func NewWorkflowBaseService(
 	logger *zap.Logger,
 	config internal.Config,
@@ -95,11 +126,8 @@ func NewWorkflowBaseService(
 func (ws *WorkflowBaseService) StartUpdate(ctx context.Context, addons []internal.Addon) (err error) {
 	start := time.Now()
 
-	mode := report.ModeNormal
-	if ws.config.Security {
-		mode = report.ModeSecurity
-	}
-	rec := report.NewRecorder(internal.Version, mode, ws.config.DryRun, ws.config.RepositoryURL, ws.config.Branch, ws.config.Sites)
+	rec := report.NewRecorder(internal.Version, ws.mode(), ws.config.DryRun, ws.config.RepositoryURL, ws.config.Branch, ws.config.Sites)
+	rec.SetGroup(ws.config.Group)
 
 	// Registered first, so it runs last and covers every exit path. An AbortError means there
 	// was nothing to do, not that the run failed.
@@ -115,6 +143,17 @@ func (ws *WorkflowBaseService) StartUpdate(ctx context.Context, addons []interna
 		ws.reportSink(rec.Finish())
 	}()
 
+	ctx, span := tracing.Start(ctx, "update",
+		tracing.String("drupdater.repository", report.SanitizeURL(ws.config.RepositoryURL)),
+		tracing.String("drupdater.branch", ws.config.Branch),
+		tracing.String("drupdater.mode", string(ws.mode())),
+		tracing.String("drupdater.group", ws.config.Group),
+		tracing.Int("drupdater.sites", len(ws.config.Sites)),
+	)
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	// Bound the whole run so a wedged subprocess or network call can't hang forever.
 	if ws.config.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -122,6 +161,15 @@ func (ws *WorkflowBaseService) StartUpdate(ctx context.Context, addons []interna
 		defer cancel()
 	}
 
+	// Every commit of the run, the addons' included, is worded by the project's templates and
+	// signed with the run's key, if any. The file was validated when it loaded.
+	committer, err := internal.NewCommitter(ws.config.Commits, string(ws.mode()), ws.config.Group)
+	if err != nil {
+		return err
+	}
+	committer.SetSigner(ws.config.Signer)
+	ctx = internal.WithCommitter(ctx, committer)
+
 	// After the timeout, so the lookup is bounded too. Not a phase: it cannot fail the run.
 	rec.SetToolVersions(LookupToolVersions(ctx, ws.logger, ws.composer))
 
@@ -139,7 +187,7 @@ func (ws *WorkflowBaseService) StartUpdate(ctx context.Context, addons []interna
 		worktree   Worktree
 		path       string
 	)
-	if err = rec.Run("acquire working copy", func() error {
+	if err = ws.phase(ctx, rec, "acquire working copy", func(ctx context.Context) error {
 		var acquireErr error
 		repository, worktree, path, acquireErr = ws.acquireWorkingCopy(username, email)
 		return acquireErr
@@ -150,9 +198,10 @@ func (ws *WorkflowBaseService) StartUpdate(ctx context.Context, addons []interna
 	// Before any branch is created, so a failed run can be put back — see captureOriginalHead.
 	originalRef := ws.captureOriginalHead(repository)
 
+	// A group's run is followed by the next group's, which must start from the same checkout.
 	defer func() {
 		ws.logger.Info("update run finished", zap.Duration("duration", time.Since(start)))
-		if err != nil && originalRef != nil {
+		if (err != nil || ws.config.Group != "") && originalRef != nil {
 			ws.restoreOriginalCheckout(worktree, originalRef)
 		}
 		ws.cleanup(path)
@@ -173,7 +222,7 @@ func (ws *WorkflowBaseService) runPhases(
 ) error {
 	// Fail fast on the prerequisites "drupdater check" shares. Extension requirements are
 	// deliberately not among them — see CheckPlatformReqs.
-	if err := rec.Run("preflight", func() error {
+	if err := ws.phase(ctx, rec, "preflight", func(ctx context.Context) error {
 		if result := CheckGitHistoryComplete(ws.repository, path); !result.OK {
 			return fmt.Errorf("%s: %s", result.Name, result.Detail)
 		}
@@ -185,8 +234,8 @@ func (ws *WorkflowBaseService) runPhases(
 		return err
 	}
 
-	if err := rec.Run("composer install", func() error {
-		ws.logger.Info("running composer install")
+	if err := ws.phase(ctx, rec, "composer install", func(ctx context.Context) error {
+		logging.For(ctx, ws.logger).Info("running composer install")
 		if err := ws.composer.Install(ctx, path); err != nil {
 			return fmt.Errorf("failed to run composer install: %w", err)
 		}
@@ -196,7 +245,7 @@ func (ws *WorkflowBaseService) runPhases(
 	}
 
 	// Install each site at the current (old) code to create the baseline database.
-	if err := rec.Run("baseline site install", func() error {
+	if err := ws.phase(ctx, rec, "baseline site install", func(ctx context.Context) error {
 		return ws.forEachSite(ctx, func(ctx context.Context, site string) error {
 			if err := ws.installer.Install(ctx, path, site); err != nil {
 				return fmt.Errorf("site %s installation failed: %w", site, err)
@@ -208,18 +257,18 @@ func (ws *WorkflowBaseService) runPhases(
 	}
 
 	// Update the shared code: composer update, commit, and create the update branch.
-	var updateBranchName string
-	if err := rec.Run("update shared code", func() error {
+	var target updateTarget
+	if err := ws.phase(ctx, rec, "update shared code", func(ctx context.Context) error {
 		var err error
-		updateBranchName, err = ws.updateSharedCode(ctx, repository, worktree, path, rec)
+		target, err = ws.updateSharedCode(ctx, repository, worktree, path, rec)
 		return err
 	}); err != nil {
 		return err
 	}
-	rec.SetUpdateBranch(updateBranchName)
+	rec.SetUpdateBranch(target.remoteBranch())
 
 	// Run the update hooks and export config per site against the now-updated code.
-	if err := rec.Run("site update", func() error {
+	if err := ws.phase(ctx, rec, "site update", func(ctx context.Context) error {
 		return ws.forEachSite(ctx, func(ctx context.Context, site string) error {
 			return ws.updateSite(ctx, path, worktree, site)
 		})
@@ -230,9 +279,9 @@ func (ws *WorkflowBaseService) runPhases(
 	// Ahead of publish so it runs under --dry-run too: rendered later, a broken template would
 	// only surface after the branch had been pushed.
 	var mrTitle, mrDescription string
-	if err := rec.Run("render merge request", func() error {
+	if err := ws.phase(ctx, rec, "render merge request", func(ctx context.Context) error {
 		var renderErr error
-		mrTitle, mrDescription, renderErr = ws.renderMergeRequest(addons)
+		mrTitle, mrDescription, renderErr = ws.renderMergeRequest(addons, target)
 		return renderErr
 	}); err != nil {
 		return err
@@ -240,27 +289,94 @@ func (ws *WorkflowBaseService) runPhases(
 	rec.SetMergeRequestContent(mrTitle, mrDescription)
 
 	if !ws.config.DryRun {
-		return rec.Run("publish", func() error {
-			return ws.publishWork(ctx, repository, updateBranchName, mrTitle, mrDescription, rec)
+		return ws.phase(ctx, rec, "publish", func(ctx context.Context) error {
+			return ws.publishWork(ctx, repository, target, mrTitle, mrDescription, rec)
 		})
 	}
 	return nil
 }
 
+// phase records fn as a phase of rec, under a span of the same name that fn's context carries.
+func (ws *WorkflowBaseService) phase(ctx context.Context, rec *report.Recorder, name string, fn func(context.Context) error) error {
+	ctx, span := tracing.Start(ctx, name)
+	ctx = logging.WithFields(ctx, zap.String("phase", name))
+	err := rec.Run(name, func() error { return fn(ctx) })
+	endSpan(span, err)
+	return err
+}
+
+// endSpan ends span with err's outcome. An AbortError is not a failure: the run found nothing to do.
+func endSpan(span *tracing.Span, err error) {
+	if errors.As(err, &AbortError{}) {
+		span.SetAttributes(tracing.String("drupdater.aborted", err.Error()))
+	} else {
+		span.SetError(err)
+	}
+	span.End()
+}
+
+// mode is the run type as the report and the ownership marker name it.
+func (ws *WorkflowBaseService) mode() report.Mode {
+	switch {
+	case ws.config.Security:
+		return report.ModeSecurity
+	case ws.config.Major:
+		return report.ModeMajor
+	}
+	return report.ModeNormal
+}
+
 // renderMergeRequest produces the title and description. The title starts as the maintenance
-// default and is offered to the addons — how composer_audit re-labels a security run.
-func (ws *WorkflowBaseService) renderMergeRequest(addons []internal.Addon) (string, string, error) {
-	e := NewPreMergeRequestCreateEvent(fmt.Sprintf("%s: Drupal Maintenance Updates", ws.current.Format("January 2006")))
+// default and is offered to the addons — how composer_audit re-labels a security run — and a
+// project's title template has the last word.
+func (ws *WorkflowBaseService) renderMergeRequest(addons []internal.Addon, target updateTarget) (string, string, error) {
+	title := fmt.Sprintf("%s: Drupal Maintenance Updates", ws.current.Format("January 2006"))
+	if ws.config.Major {
+		title = fmt.Sprintf("%s: Drupal Major Upgrades", ws.current.Format("January 2006"))
+	}
+	if ws.config.Group != "" {
+		title += fmt.Sprintf(" (%s)", ws.config.Group)
+	}
+	e := NewPreMergeRequestCreateEvent(title)
 	if err := ws.dispatcher.FireEvent(e); err != nil {
 		return "", "", fmt.Errorf("failed to fire event: %w", err)
 	}
 
-	description, err := ws.GenerateDescription(TemplateData{Addons: addons}, "dependency_update.go.tmpl")
+	title = e.Title
+	var err error
+	if ws.templates.Title != nil {
+		title, err = renderTitle(ws.templates.Title, TitleData{Title: e.Title, Date: ws.current, Mode: ws.mode(), Group: ws.config.Group})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to render title: %w", err)
+		}
+	}
+
+	data := TemplateData{Addons: addons, MajorUpgrades: ws.majorUpgrades}
+	var description string
+	if ws.templates.Description != nil {
+		description, err = executeTemplate(ws.templates.Description, data)
+	} else {
+		description, err = ws.GenerateDescription(data, "dependency_update.go.tmpl")
+	}
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate description: %w", err)
 	}
 
-	return e.Title, description, nil
+	return title, strings.TrimRight(description, "\n") + "\n\n" + ws.owner(target.lockHash, target.packagesHash).marker() + "\n", nil
+}
+
+// renderTitle renders a project's title template on one line: every platform would otherwise
+// cut the title at the first newline, or reject it.
+func renderTitle(tmpl *template.Template, data TitleData) (string, error) {
+	out, err := executeTemplate(tmpl, data)
+	if err != nil {
+		return "", err
+	}
+	title := strings.Join(strings.Fields(out), " ")
+	if title == "" {
+		return "", errors.New("the title template rendered an empty title")
+	}
+	return title, nil
 }
 
 // captureOriginalHead returns the checkout's HEAD so a failed run can be put back rather than
@@ -296,11 +412,25 @@ func (ws *WorkflowBaseService) restoreOriginalCheckout(worktree Worktree, origin
 func (ws *WorkflowBaseService) acquireWorkingCopy(username, email string) (GitRepository, Worktree, string, error) {
 	if ws.config.Clone {
 		ws.logger.Info("cloning repository", zap.String("url", ws.config.RepositoryURL), zap.String("branch", ws.config.Branch))
-		return ws.repository.CloneRepository(ws.config.RepositoryURL, ws.config.Branch, ws.config.Token, username, email)
+		auth, err := ws.gitAuth()
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return ws.repository.CloneRepository(ws.config.RepositoryURL, ws.config.Branch, auth, username, email)
 	}
 	return ws.repository.OpenRepository(ws.config.WorkingDir, username, email)
 }
 
+// gitAuth is the credential for the repository's remote: SSH for an SSH URL, the token otherwise.
+func (ws *WorkflowBaseService) gitAuth() (transport.AuthMethod, error) {
+	username := codehosting.GitUsername(ws.config.RepositoryURL)
+	auth, err := repo.Auth(ws.config.RepositoryURL, username, ws.config.Token, ws.config.SSH)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up git authentication: %w", err)
+	}
+	return auth, nil
+}
+
 // stageScaffoldChanges stages the web-root files drupal-scaffold rewrites on a core update --
 // .htaccess, robots.txt, index.php -- which the composer.* glob does not cover.
 //
@@ -352,7 +482,7 @@ func (ws *WorkflowBaseService) stageScaffoldChanges(ctx context.Context, path st
 		staged = append(staged, file)
 	}
 	if len(staged) > 0 {
-		ws.logger.Info("staged scaffold changes", zap.Strings("files", staged))
+		logging.For(ctx, ws.logger).Info("staged scaffold changes", zap.Strings("files", staged))
 	}
 	return nil
 }
@@ -368,7 +498,10 @@ func (ws *WorkflowBaseService) forEachSite(ctx context.Context, fn func(context.
 	g.SetLimit(limit)
 	for _, site := range ws.config.Sites {
 		g.Go(func() error {
-			return fn(groupCtx, site)
+			siteCtx, span := tracing.Start(groupCtx, "site "+site, tracing.String("drupdater.site", site))
+			err := fn(logging.WithFields(siteCtx, zap.String("site", site)), site)
+			endSpan(span, err)
+			return err
 		})
 	}
 	return g.Wait()
@@ -400,8 +533,8 @@ func CleanupSiteArtifacts(parent string, sites []string) {
 	os.Remove(filepath.Join(parent, "private"))
 }
 
-func (ws *WorkflowBaseService) updateSharedCode(ctx context.Context, repository GitRepository, worktree Worktree, path string, rec *report.Recorder) (string, error) {
-	ws.logger.Info("updating dependencies")
+func (ws *WorkflowBaseService) updateSharedCode(ctx context.Context, repository GitRepository, worktree Worktree, path string, rec *report.Recorder) (updateTarget, error) {
+	logging.For(ctx, ws.logger).Info("updating dependencies")
 
 	// A dedicated branch: the addons commit as they go, and a mid-run failure would otherwise
 	// strand those commits on the user's own branch. Flat name, not "drupdater/work-<ts>":
@@ -412,84 +545,339 @@ func (ws *WorkflowBaseService) updateSharedCode(ctx context.Context, repository
 		Create: true,
 		Keep:   true,
 	}); err != nil {
-		return "", fmt.Errorf("failed to create work branch: %w", err)
+		return updateTarget{}, fmt.Errorf("failed to create work branch: %w", err)
+	}
+
+	changes, err := ws.updateDependencies(ctx, path, worktree, rec)
+	if err != nil {
+		return updateTarget{}, err
 	}
+	rec.SetPackages(toReportPackages(changes))
+	internal.CommitterFrom(ctx).SetPackages(changes)
+	tracing.FromContext(ctx).SetAttributes(tracing.Int("drupdater.packages_changed", len(changes)))
 
-	preComposerUpdateEvent := NewPreComposerUpdateEvent(ctx, path, worktree, []string{}, []string{}, false)
+	postComposerUpdateEvent := NewPostComposerUpdateEvent(ctx, path, worktree)
+	if err := ws.dispatcher.FireEvent(postComposerUpdateEvent); err != nil {
+		return updateTarget{}, fmt.Errorf("failed to fire event: %w", err)
+	}
+
+	if err := worktree.AddGlob("composer.*"); err != nil {
+		return updateTarget{}, fmt.Errorf("failed to add composer.* files: %w", err)
+	}
+	if err := ws.stageScaffoldChanges(ctx, path, worktree); err != nil {
+		return updateTarget{}, err
+	}
+	if err := internal.CommitterFrom(ctx).Commit(worktree, internal.CommitData{Kind: internal.CommitDependencies}); err != nil {
+		return updateTarget{}, fmt.Errorf("failed to commit composer.json and composer.lock: %w", err)
+	}
+
+	postCodeUpdateEvent := NewPostCodeUpdateEvent(ctx, path, worktree)
+	if err := ws.dispatcher.FireEvent(postCodeUpdateEvent); err != nil {
+		return updateTarget{}, fmt.Errorf("failed to fire event: %w", err)
+	}
+
+	composerLockHash, err := ws.composer.GetLockHash(path)
+	if err != nil {
+		return updateTarget{}, err
+	}
+	packagesHash, err := ws.composer.GetLockedPackagesHash(path)
+	if err != nil {
+		return updateTarget{}, err
+	}
+
+	target, err := ws.resolveUpdateTarget(ctx, repository, composerLockHash, packagesHash, rec)
+	if err != nil {
+		return updateTarget{}, err
+	}
+
+	// Create the final branch from the work branch's tip (carrying the accumulated commits).
+	if err := worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(target.branch),
+		Create: true,
+		Force:  false,
+		Keep:   true,
+	}); err != nil {
+		return updateTarget{}, fmt.Errorf("failed to checkout branch: %w", err)
+	}
+
+	return target, nil
+}
+
+// updateDependencies runs composer update on what the addons and the run's group select. An
+// ungrouped run starts from an empty selection, which composer takes as everything. A --major
+// run raises constraints instead, see upgradeMajors.
+func (ws *WorkflowBaseService) updateDependencies(ctx context.Context, path string, worktree Worktree, rec *report.Recorder) ([]composer.PackageChange, error) {
+	packages, err := ws.groupPackages(path)
+	if err != nil {
+		return nil, err
+	}
+
+	preComposerUpdateEvent := NewPreComposerUpdateEvent(ctx, path, worktree, packages, []string{}, false)
 	if err := ws.dispatcher.FireEvent(preComposerUpdateEvent); err != nil {
-		return "", fmt.Errorf("failed to fire event: %w", err)
+		return nil, fmt.Errorf("failed to fire event: %w", err)
 	}
 
-	changes, err := ws.composer.Update(ctx, path, preComposerUpdateEvent.PackagesToUpdate, preComposerUpdateEvent.PackagesToKeep, preComposerUpdateEvent.MinimalChanges, false)
+	var changes []composer.PackageChange
+	if ws.config.Major {
+		changes, err = ws.upgradeMajors(ctx, path, preComposerUpdateEvent.PackagesToKeep, rec)
+	} else {
+		changes, err = ws.composer.Update(ctx, path, preComposerUpdateEvent.PackagesToUpdate, preComposerUpdateEvent.PackagesToKeep, preComposerUpdateEvent.MinimalChanges, false)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to update dependencies: %w", err)
+		return nil, fmt.Errorf("failed to update dependencies: %w", err)
 	}
-	rec.SetPackages(toReportPackages(changes))
 	if len(changes) == 0 {
-		return "", AbortError{Msg: "no changes detected"}
+		return nil, AbortError{Msg: "no changes detected"}
 	}
 
 	byAction := map[string]int{}
 	for _, c := range changes {
 		byAction[c.Action]++
 	}
-	ws.logger.Info("dependencies updated",
+	logging.For(ctx, ws.logger).Info("dependencies updated",
 		zap.Int("total", len(changes)),
 		zap.Int("installed", byAction["Install"]),
 		zap.Int("upgraded", byAction["Upgrade"]),
 		zap.Int("downgraded", byAction["Downgrade"]),
 		zap.Int("removed", byAction["Remove"]),
 	)
+	return changes, nil
+}
 
-	postComposerUpdateEvent := NewPostComposerUpdateEvent(ctx, path, worktree)
-	if err := ws.dispatcher.FireEvent(postComposerUpdateEvent); err != nil {
-		return "", fmt.Errorf("failed to fire event: %w", err)
+// upgradeMajors raises the constraint of every direct requirement with a newer major release,
+// one package at a time so one that cannot resolve does not take the others down with it.
+// Packages the addons pinned are left alone: a hold or ignore rule outranks the upgrade. Their
+// pins also hold while the dependencies of every upgrade move with it.
+func (ws *WorkflowBaseService) upgradeMajors(ctx context.Context, path string, pinned []string, rec *report.Recorder) ([]composer.PackageChange, error) {
+	candidates, err := ws.composer.OutdatedMajor(ctx, path)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := worktree.AddGlob("composer.*"); err != nil {
-		return "", fmt.Errorf("failed to add composer.* files: %w", err)
+	upgrades := report.MajorUpgrades{Upgraded: []report.MajorUpgrade{}, Failed: []report.MajorUpgrade{}}
+	var changes []composer.PackageChange
+	for _, pkg := range candidates {
+		if slices.ContainsFunc(pinned, func(keep string) bool { return strings.HasPrefix(keep, pkg.Name+":") }) {
+			logging.For(ctx, ws.logger).Info("skipping major upgrade of a pinned package", zap.String("package", pkg.Name))
+			continue
+		}
+		constraint, ok := majorConstraint(pkg.Latest)
+		if !ok {
+			continue
+		}
+
+		upgrade := report.MajorUpgrade{Package: pkg.Name, From: pkg.Version, To: constraint}
+		pkgChanges, err := ws.composer.RequireUpgrade(ctx, path, pkg.Name, constraint, pinned)
+		var upgradeErr *composer.UpgradeError
+		switch {
+		case errors.As(err, &upgradeErr):
+			logging.For(ctx, ws.logger).Warn("major upgrade did not resolve", zap.String("package", pkg.Name), zap.String("constraint", constraint), zap.String("problem", upgradeErr.Problem))
+			upgrade.Error = upgradeErr.Problem
+			upgrades.Failed = append(upgrades.Failed, upgrade)
+			continue
+		case err != nil:
+			return nil, err
+		}
+		logging.For(ctx, ws.logger).Info("major upgrade resolved", zap.String("package", pkg.Name), zap.String("constraint", constraint))
+		upgrades.Upgraded = append(upgrades.Upgraded, upgrade)
+		changes = mergePackageChanges(changes, pkgChanges)
 	}
-	if err := ws.stageScaffoldChanges(ctx, path, worktree); err != nil {
-		return "", err
+
+	if len(upgrades.Upgraded) > 0 || len(upgrades.Failed) > 0 {
+		ws.majorUpgrades = &upgrades
+		rec.SetMajorUpgrades(upgrades)
 	}
-	if _, err := worktree.Commit("Update composer.json and composer.lock", &git.CommitOptions{}); err != nil {
-		return "", fmt.Errorf("failed to commit composer.json and composer.lock: %w", err)
+	return changes, nil
+}
+
+// majorVersionPattern reads the major and minor of the release an upgrade targets.
+var majorVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// majorConstraint is the caret constraint for latest's line, from its own minor: ^3.1 for 3.1.2,
+// so the raised constraint never admits a release older than the one composer offered.
+func majorConstraint(latest string) (string, bool) {
+	m := majorVersionPattern.FindStringSubmatch(latest)
+	if m == nil {
+		return "", false
 	}
+	return fmt.Sprintf("^%s.%s", m[1], m[2]), true
+}
 
-	postCodeUpdateEvent := NewPostCodeUpdateEvent(ctx, path, worktree)
-	if err := ws.dispatcher.FireEvent(postCodeUpdateEvent); err != nil {
-		return "", fmt.Errorf("failed to fire event: %w", err)
+// mergePackageChanges folds one require's changes into the run's, so a package two upgrades
+// moved is listed once, from its original version to its final one.
+func mergePackageChanges(all []composer.PackageChange, next []composer.PackageChange) []composer.PackageChange {
+	for _, change := range next {
+		i := slices.IndexFunc(all, func(c composer.PackageChange) bool { return c.Package == change.Package })
+		if i < 0 {
+			all = append(all, change)
+			continue
+		}
+		switch {
+		case change.Action == "Remove":
+			all[i].Action, all[i].To = change.Action, ""
+		case all[i].Action == "Install":
+			// Installed by an earlier upgrade and moved by this one: still new to the project.
+			all[i].To = change.To
+		default:
+			all[i].Action, all[i].To = change.Action, change.To
+		}
 	}
+	return all
+}
 
-	composerLockHash, err := ws.composer.GetLockHash(path)
+// groupPackages returns the required packages that fall to this run's group. A group claiming
+// none has nothing to do — and must not reach composer with an empty list, which means all.
+func (ws *WorkflowBaseService) groupPackages(path string) ([]string, error) {
+	if ws.config.Group == "" {
+		return []string{}, nil
+	}
+	required, err := ws.composer.GetRequiredPackages(path)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to read required packages: %w", err)
+	}
+	packages := ws.config.Groups.Packages(ws.config.Group, required)
+	if len(packages) == 0 {
+		return nil, AbortError{Msg: fmt.Sprintf("group %s matches no required package", ws.config.Group)}
 	}
+	ws.logger.Info("group packages selected", zap.String("group", ws.config.Group), zap.Strings("packages", packages))
+	return packages, nil
+}
 
-	updateBranchName := fmt.Sprintf("update-%s", composerLockHash)
+// updateTarget is where a run's commits end up: a new update branch, or the branch of the open
+// merge request a previous run opened, which this run then brings up to date.
+type updateTarget struct {
+	// branch is the local branch holding the update, named after the lock hash.
+	branch   string
+	lockHash string
+	// packagesHash digests the locked packages, which the lock hash does not follow: see
+	// GetLockedPackagesHash.
+	packagesHash string
+	// existing is the merge request to update instead of opening one. Its ID is zero when none.
+	existing codehosting.MergeRequest
+	// superseded are older requests of the same run type, closed once this one is published.
+	superseded []codehosting.MergeRequest
+}
 
-	if err := ws.ensureUpdateBranchAvailable(repository, updateBranchName); err != nil {
-		return "", err
+func (t updateTarget) updatesExisting() bool {
+	return t.existing.ID != 0
+}
+
+// remoteBranch is the branch the update is pushed to.
+func (t updateTarget) remoteBranch() string {
+	if t.updatesExisting() {
+		return t.existing.SourceBranch
 	}
+	return t.branch
+}
 
-	// Create the final branch from the work branch's tip (carrying the accumulated commits).
-	if err := worktree.Checkout(&git.CheckoutOptions{
-		Branch: plumbing.NewBranchReferenceName(updateBranchName),
-		Create: true,
-		Force:  false,
-		Keep:   true,
-	}); err != nil {
-		return "", fmt.Errorf("failed to checkout branch: %w", err)
+// resolveUpdateTarget decides where this update is published. A previous run's open merge
+// request is reused, so a project only ever has one live maintenance request per run type; one
+// whose marker records the same locked packages means there is nothing new to publish, and the
+// stale requests are closed in its favour right away. The lock hash cannot tell that: Composer
+// derives it from composer.json, so every rerun against an unchanged composer.json shares it.
+// For the same reason the update branch may already exist, as the branch of our own request.
+func (ws *WorkflowBaseService) resolveUpdateTarget(ctx context.Context, repository GitRepository, lockHash string, packagesHash string, rec *report.Recorder) (updateTarget, error) {
+	target := updateTarget{branch: updateBranchName(ws.config.Group, lockHash), lockHash: lockHash, packagesHash: packagesHash}
+
+	own, err := ws.findOwnMergeRequests(ctx)
+	if err != nil {
+		return updateTarget{}, err
+	}
+	if err := ws.ensureUpdateBranchAvailable(repository, target.branch, own); err != nil {
+		return updateTarget{}, err
+	}
+	if len(own) == 0 {
+		return target, nil
+	}
+	existing, superseded := own[0], own[1:]
+	// A marker from before packages-hash was recorded never matches, so that request is refreshed.
+	if previous, _ := parseOwnerMarker(existing.Description); previous.packagesHash != "" && previous.packagesHash == packagesHash {
+		if len(superseded) > 0 {
+			rec.SetUnchangedMergeRequest(existing.URL)
+			ws.closeSuperseded(ctx, superseded, existing, rec)
+		}
+		return updateTarget{}, AbortError{Msg: fmt.Sprintf("merge request %s already carries this update, skipping", existing.URL)}
+	}
+	target.existing = existing
+	target.superseded = superseded
+	return target, nil
+}
+
+// findOwnMergeRequests returns the open requests previous runs of this run type and group opened
+// against the base branch, newest first. A request without a marker is never among them, even on
+// an update branch: every run type used to name its branches alike, so nothing shows which run
+// opened it. Skipped for a dry run, which publishes nothing and so updates nothing.
+func (ws *WorkflowBaseService) findOwnMergeRequests(ctx context.Context) ([]codehosting.MergeRequest, error) {
+	if ws.platform == nil || ws.config.DryRun {
+		return nil, nil
 	}
 
-	return updateBranchName, nil
+	mrs, err := ws.platform.ListMergeRequests(ctx, ws.config.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open merge requests: %w", err)
+	}
+	var own []codehosting.MergeRequest
+	for _, mr := range mrs {
+		if o, ok := parseOwnerMarker(mr.Description); ok && o.mode == ws.mode() && o.group == ws.config.Group && strings.HasPrefix(mr.SourceBranch, "update-") {
+			own = append(own, mr)
+		}
+	}
+	return own, nil
+}
+
+// updateBranchName names the branch an update is committed to. A group's name goes first, so the
+// groups of one run, which share a lock hash, do not collide.
+func updateBranchName(group string, lockHash string) string {
+	if group == "" {
+		return fmt.Sprintf("update-%s", lockHash)
+	}
+	return fmt.Sprintf("update-%s-%s", group, lockHash)
+}
+
+// owner identifies the run that wrote a merge request: its run type, its group if it had one,
+// and the lock hash and locked packages it published.
+type owner struct {
+	mode         report.Mode
+	group        string
+	lockHash     string
+	packagesHash string
+}
+
+func (ws *WorkflowBaseService) owner(lockHash string, packagesHash string) owner {
+	return owner{mode: ws.mode(), group: ws.config.Group, lockHash: lockHash, packagesHash: packagesHash}
+}
+
+// marker tags a description with its owner. An HTML comment, so it is invisible once rendered; a
+// later run recognises its own requests by it, and leaves any request without one alone.
+func (o owner) marker() string {
+	group := ""
+	if o.group != "" {
+		group = " group=" + o.group
+	}
+	packages := ""
+	if o.packagesHash != "" {
+		packages = " packages-hash=" + o.packagesHash
+	}
+	return fmt.Sprintf("<!-- drupdater run-type=%s%s lock-hash=%s%s -->", o.mode, group, o.lockHash, packages)
+}
+
+var ownerMarkerPattern = regexp.MustCompile(`<!-- drupdater run-type=(\w+)(?: group=(\S+))? lock-hash=(\S*?)(?: packages-hash=(\S+))? -->`)
+
+// parseOwnerMarker reads back what marker wrote. ok is false for a description without one.
+func parseOwnerMarker(description string) (o owner, ok bool) {
+	m := ownerMarkerPattern.FindStringSubmatch(description)
+	if m == nil {
+		return owner{}, false
+	}
+	return owner{mode: report.Mode(m[1]), group: m[2], lockHash: m[3], packagesHash: m[4]}, true
 }
 
 // ensureUpdateBranchAvailable returns an AbortError if updateBranchName is already taken, locally
-// or on the remote, and a plain error if either check itself fails.
+// or on the remote, and a plain error if either check itself fails. A remote branch one of own
+// holds is not taken: it is the previous run's, which this one reuses or closes.
 // The local check runs first: a prior failed run leaves its branch behind, and without it the
 // checkout below fails on go-git's raw message instead of a clean AbortError.
-func (ws *WorkflowBaseService) ensureUpdateBranchAvailable(repository GitRepository, updateBranchName string) error {
+func (ws *WorkflowBaseService) ensureUpdateBranchAvailable(repository GitRepository, updateBranchName string, own []codehosting.MergeRequest) error {
 	if _, err := repository.Reference(plumbing.NewBranchReferenceName(updateBranchName), false); err == nil {
 		return AbortError{Msg: fmt.Sprintf("branch %s already exists locally, skipping", updateBranchName)}
 	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
@@ -502,18 +890,23 @@ func (ws *WorkflowBaseService) ensureUpdateBranchAvailable(repository GitReposit
 		return nil
 	}
 
-	exists, err := ws.repository.BranchExists(repository, updateBranchName, ws.config.Token)
+	auth, err := ws.gitAuth()
+	if err != nil {
+		return err
+	}
+	exists, err := ws.repository.BranchExists(repository, updateBranchName, auth)
 	if err != nil {
 		return fmt.Errorf("failed to check if branch exists: %w", err)
 	}
-	if exists {
+	ours := slices.ContainsFunc(own, func(mr codehosting.MergeRequest) bool { return mr.SourceBranch == updateBranchName })
+	if exists && !ours {
 		return AbortError{Msg: fmt.Sprintf("branch %s already exists, skipping", updateBranchName)}
 	}
 	return nil
 }
 
 func (ws *WorkflowBaseService) updateSite(ctx context.Context, path string, worktree Worktree, site string) error {
-	ws.logger.Info("updating site", zap.String("site", site))
+	logging.For(ctx, ws.logger).Info("updating site")
 
 	if err := ws.installer.ConfigureDatabase(ctx, path, site); err != nil {
 		return fmt.Errorf("failed to configure database: %w", err)
@@ -548,7 +941,7 @@ func (ws *WorkflowBaseService) commitSiteChanges(ctx context.Context, path strin
 		return fmt.Errorf("failed to fire event: %w", err)
 	}
 
-	ws.logger.Info("exporting configuration", zap.String("site", site))
+	logging.For(ctx, ws.logger).Info("exporting configuration")
 	if err := ws.drush.ExportConfiguration(ctx, path, site); err != nil {
 		return fmt.Errorf("failed to export configuration: %w", err)
 	}
@@ -574,31 +967,54 @@ func toReportPackages(changes []composer.PackageChange) []report.PackageChange {
 	return out
 }
 
-func (ws *WorkflowBaseService) publishWork(ctx context.Context, repository GitRepository, updateBranchName, title, description string, rec *report.Recorder) error {
-	err := repository.Push(&git.PushOptions{
+func (ws *WorkflowBaseService) publishWork(ctx context.Context, repository GitRepository, target updateTarget, title, description string, rec *report.Recorder) error {
+	// Forced onto an existing request's branch: the update is rebuilt from the base branch, not
+	// stacked on last time's commits.
+	refSpec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", target.branch, target.remoteBranch())
+	if target.updatesExisting() {
+		refSpec = "+" + refSpec
+	}
+	auth, err := ws.gitAuth()
+	if err != nil {
+		return err
+	}
+	err = repository.Push(&git.PushOptions{
 		RemoteName: "origin",
-		RefSpecs: []gitConfig.RefSpec{
-			gitConfig.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", updateBranchName, updateBranchName)),
-		},
-		Auth: repo.BasicAuth(ws.config.Token),
+		RefSpecs:   []gitConfig.RefSpec{gitConfig.RefSpec(refSpec)},
+		Auth:       auth,
 	})
 
 	if err != nil {
 		return fmt.Errorf("failed to push changes: %w", err)
 	}
 
-	mr, err := ws.platform.CreateMergeRequest(ctx, title, description, updateBranchName, ws.config.Branch)
+	mr, err := ws.publishMergeRequest(ctx, target, title, description)
 	if err != nil {
-		if deleteErr := ws.platform.DeleteBranch(ctx, updateBranchName); deleteErr != nil {
-			ws.logger.Warn("failed to delete remote branch after MR creation failure",
-				zap.String("branch", updateBranchName),
-				zap.Error(deleteErr),
-			)
+		return err
+	}
+	if target.updatesExisting() {
+		logging.For(ctx, ws.logger).Info("merge request updated", zap.String("url", mr.URL))
+		rec.SetUpdatedMergeRequest(mr.URL)
+	} else {
+		logging.For(ctx, ws.logger).Info("merge request created", zap.String("url", mr.URL))
+		rec.SetMergeRequest(mr.URL)
+	}
+
+	// Only on a request this run opened: one it rewrites keeps whatever triage people have
+	// changed since. Best-effort, like auto-merge below.
+	if metadata := ws.config.ActiveRunType().MergeRequestMetadata(); !metadata.IsZero() && !target.updatesExisting() {
+		if err := ws.platform.SetMergeRequestMetadata(ctx, mr, metadata); err != nil {
+			logging.For(ctx, ws.logger).Warn("failed to set merge request labels, assignees, reviewers or milestone", zap.String("url", mr.URL), zap.Error(err))
 		}
-		return fmt.Errorf("failed to create merge request: %w", err)
 	}
-	ws.logger.Info("merge request created", zap.String("url", mr.URL))
-	rec.SetMergeRequest(mr.URL)
+
+	ws.closeSuperseded(ctx, target.superseded, mr, rec)
+
+	// Before auto-merge, which no platform applies to a draft. After everything else, since it
+	// waits for the pipeline.
+	if mr.Draft {
+		ws.promoteDraft(ctx, mr, rec)
+	}
 
 	// Best-effort: the MR already exists, so failing here would redden a perfectly good job.
 	// Recorded either way, or the report shows a clean success for an MR that will never merge.
@@ -606,18 +1022,123 @@ func (ws *WorkflowBaseService) publishWork(ctx context.Context, repository GitRe
 		err := ws.platform.EnableAutoMerge(ctx, mr)
 		rec.SetAutoMerge(err)
 		if err != nil {
-			ws.logger.Warn("failed to enable auto merge", zap.String("url", mr.URL), zap.Error(err))
+			logging.For(ctx, ws.logger).Warn("failed to enable auto merge", zap.String("url", mr.URL), zap.Error(err))
 		} else {
-			ws.logger.Info("auto merge enabled", zap.String("url", mr.URL))
+			logging.For(ctx, ws.logger).Info("auto merge enabled", zap.String("url", mr.URL))
 		}
 	}
 
 	return nil
 }
 
+// How promoteDraft waits for a draft's pipeline. Variables so tests need not wait.
+var (
+	readyPollInterval = 30 * time.Second
+	readyTimeout      = 30 * time.Minute
+	// readyNoPipelineGrace is how long a commit nothing has reported on yet may still be about to
+	// get a pipeline: CI picks a push up asynchronously. After it, there is nothing to wait for.
+	readyNoPipelineGrace = 2 * time.Minute
+)
+
+// promoteDraft marks a draft ready for review once its pipeline passes, when the run type asks for
+// that. Best-effort like auto-merge: the request is up either way, and a person can still mark it.
+func (ws *WorkflowBaseService) promoteDraft(ctx context.Context, mr codehosting.MergeRequest, rec *report.Recorder) {
+	if !ws.config.ActiveRunType().MarkReady {
+		rec.SetDraft(false, nil)
+		return
+	}
+
+	logging.For(ctx, ws.logger).Info("waiting for the draft's pipeline", zap.String("url", mr.URL))
+	err := ws.waitForPipeline(ctx, mr)
+	if err == nil {
+		err = ws.platform.MarkMergeRequestReady(ctx, mr)
+	}
+	rec.SetDraft(err == nil, err)
+	if err != nil {
+		logging.For(ctx, ws.logger).Warn("failed to mark merge request ready", zap.String("url", mr.URL), zap.Error(err))
+		return
+	}
+	logging.For(ctx, ws.logger).Info("merge request marked ready", zap.String("url", mr.URL))
+}
+
+// waitForPipeline polls the request's pipeline until it passes, for at most readyTimeout. A request
+// nothing checks passes once readyNoPipelineGrace is over.
+func (ws *WorkflowBaseService) waitForPipeline(ctx context.Context, mr codehosting.MergeRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	start := time.Now()
+	for {
+		status, err := ws.platform.PipelineStatus(ctx, mr)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case codehosting.PipelineSuccess:
+			return nil
+		case codehosting.PipelineFailed:
+			return errors.New("the pipeline failed")
+		case codehosting.PipelineNone:
+			if time.Since(start) >= readyNoPipelineGrace {
+				return nil
+			}
+		case codehosting.PipelinePending:
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for the pipeline (last %s): %w", status, ctx.Err())
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// closeSuperseded closes the older requests the published one replaces, pointing each at it, and
+// removes their branches. Best-effort like auto-merge: the new request is already up, and a
+// request left open only costs a reviewer a click.
+func (ws *WorkflowBaseService) closeSuperseded(ctx context.Context, superseded []codehosting.MergeRequest, by codehosting.MergeRequest, rec *report.Recorder) {
+	for _, old := range superseded {
+		comment := fmt.Sprintf("Superseded by %s, which carries a newer update.", by.URL)
+		if err := ws.platform.CloseMergeRequest(ctx, old, comment); err != nil {
+			logging.For(ctx, ws.logger).Warn("failed to close superseded merge request", zap.String("url", old.URL), zap.Error(err))
+			continue
+		}
+		logging.For(ctx, ws.logger).Info("superseded merge request closed", zap.String("url", old.URL))
+		rec.AddSupersededMergeRequest(old.URL)
+
+		if err := ws.platform.DeleteBranch(ctx, old.SourceBranch); err != nil {
+			logging.For(ctx, ws.logger).Warn("failed to delete superseded branch", zap.String("branch", old.SourceBranch), zap.Error(err))
+		}
+	}
+}
+
+// publishMergeRequest opens the merge request for a pushed branch, or rewrites the existing one
+// to describe what its branch now holds.
+func (ws *WorkflowBaseService) publishMergeRequest(ctx context.Context, target updateTarget, title, description string) (codehosting.MergeRequest, error) {
+	if target.updatesExisting() {
+		// The branch is not deleted on failure: it belongs to a request that predates this run.
+		if err := ws.platform.UpdateMergeRequest(ctx, target.existing, title, description); err != nil {
+			return codehosting.MergeRequest{}, fmt.Errorf("failed to update merge request: %w", err)
+		}
+		return target.existing, nil
+	}
+
+	mr, err := ws.platform.CreateMergeRequest(ctx, title, description, target.branch, ws.config.Branch, ws.config.ActiveRunType().Draft)
+	if err != nil {
+		if deleteErr := ws.platform.DeleteBranch(ctx, target.branch); deleteErr != nil {
+			logging.For(ctx, ws.logger).Warn("failed to delete remote branch after MR creation failure",
+				zap.String("branch", target.branch),
+				zap.Error(deleteErr),
+			)
+		}
+		return codehosting.MergeRequest{}, fmt.Errorf("failed to create merge request: %w", err)
+	}
+	return mr, nil
+}
+
 // descriptionTemplates parses the embedded templates once: the FS is compiled in, result fixed.
 var descriptionTemplates = sync.OnceValues(func() (*template.Template, error) {
-	return template.ParseFS(templates, "templates/*.go.tmpl")
+	return template.New("").Funcs(internal.TemplateFuncs()).ParseFS(templates, "templates/*.go.tmpl")
 })
 
 func (ws *WorkflowBaseService) GenerateDescription(data any, filename string) (string, error) {
@@ -635,3 +1156,12 @@ func (ws *WorkflowBaseService) GenerateDescription(data any, filename string) (s
 
 	return output.String(), nil
 }
+
+// executeTemplate renders one of the project's templates, failing as GenerateDescription does.
+func executeTemplate(tmpl *template.Template, data any) (string, error) {
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return output.String(), nil
+}
@@ -2,26 +2,37 @@ package services
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/drupdater/drupdater/internal"
 	"github.com/drupdater/drupdater/internal/codehosting"
 	"github.com/drupdater/drupdater/internal/golden"
+	"github.com/drupdater/drupdater/internal/logging"
+	"github.com/drupdater/drupdater/internal/report"
 	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/drupdater/drupdater/pkg/repo"
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/gookit/event"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestStartUpdate(t *testing.T) {
@@ -57,11 +68,12 @@ func TestStartUpdate(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
@@ -71,8 +83,8 @@ func TestStartUpdate(t *testing.T) {
 	var description string
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch).
-		RunAndReturn(func(_ context.Context, _ string, got string, _ string, _ string) (codehosting.MergeRequest, error) {
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch, false).
+		RunAndReturn(func(_ context.Context, _ string, got string, _ string, _ string, _ bool) (codehosting.MergeRequest, error) {
 			description = got
 			return codehosting.MergeRequest{}, nil
 		})
@@ -86,6 +98,7 @@ func TestStartUpdate(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
@@ -135,11 +148,12 @@ func TestStartUpdatePublishUsesLiveContext(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
@@ -150,8 +164,8 @@ func TestStartUpdatePublishUsesLiveContext(t *testing.T) {
 
 	// Capture the context handed to publishWork's MR creation and assert it is not cancelled.
 	var publishCtxErr error
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch).
-		Run(func(ctx context.Context, _ string, _ string, _ string, _ string) {
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch, false).
+		Run(func(ctx context.Context, _ string, _ string, _ string, _ string, _ bool) {
 			publishCtxErr = ctx.Err()
 		}).Return(codehosting.MergeRequest{}, nil)
 
@@ -160,6 +174,7 @@ func TestStartUpdatePublishUsesLiveContext(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err = workflowService.StartUpdate(ctx, nil)
@@ -197,11 +212,12 @@ func TestStartUpdateSiteFailureDoesNotPublish(t *testing.T) {
 	worktree.EXPECT().Status().Return(git.Status{}, nil).Maybe()
 	worktree.EXPECT().Checkout(workBranchCheckout).Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
 
@@ -210,6 +226,7 @@ func TestStartUpdateSiteFailureDoesNotPublish(t *testing.T) {
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	installer.EXPECT().Install(anyCtx, "/tmp", "site1").Return(nil)
 	installer.EXPECT().ConfigureDatabase(anyCtx, "/tmp", "site1").Return(nil)
@@ -225,7 +242,7 @@ func TestStartUpdateSiteFailureDoesNotPublish(t *testing.T) {
 	// Assert: the error surfaces and no MR was published.
 	require.ErrorIs(t, err, updateErr)
 	repository.AssertNotCalled(t, "Push", mock.Anything)
-	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestStartUpdateTimeout(t *testing.T) {
@@ -252,7 +269,7 @@ func TestStartUpdateTimeout(t *testing.T) {
 
 	worktree := NewMockWorktree(t)
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 
@@ -273,7 +290,7 @@ func TestStartUpdateTimeout(t *testing.T) {
 	// Assert: the deadline propagates and nothing is published.
 	require.ErrorIs(t, err, context.DeadlineExceeded)
 	repository.AssertNotCalled(t, "Push", mock.Anything)
-	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestStartUpdatePlatformReqsFail(t *testing.T) {
@@ -299,7 +316,7 @@ func TestStartUpdatePlatformReqsFail(t *testing.T) {
 
 	worktree := NewMockWorktree(t)
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 
 	// The platform check fails → updateSharedCode aborts.
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
@@ -316,7 +333,7 @@ func TestStartUpdatePlatformReqsFail(t *testing.T) {
 	require.ErrorContains(t, err, "PHP platform requirements not satisfied")
 	mockComposer.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	repository.AssertNotCalled(t, "Push", mock.Anything)
-	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestStartUpdateNoChanges(t *testing.T) {
@@ -349,7 +366,7 @@ func TestStartUpdateNoChanges(t *testing.T) {
 
 	// installCode: one CloneRepository + Install
 	// updateSharedCode: one CloneRepository + Update (returns empty → AbortError)
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 
@@ -405,8 +422,9 @@ func TestStartUpdateBranchAlreadyExists(t *testing.T) {
 	worktree.EXPECT().Checkout(mock.Anything).Return(nil).Maybe()
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, "main").Return(nil, nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
@@ -421,6 +439,7 @@ func TestStartUpdateBranchAlreadyExists(t *testing.T) {
 		},
 	}, nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	installer.EXPECT().Install(anyCtx, "/tmp", "site1").Return(nil).Maybe()
 	installer.EXPECT().ConfigureDatabase(anyCtx, "/tmp", "site1").Return(nil).Maybe()
@@ -468,8 +487,9 @@ func TestStartUpdateLocalBranchAlreadyExists(t *testing.T) {
 	worktree.EXPECT().Checkout(mock.Anything).Return(nil).Maybe()
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, "main").Return(nil, nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(plumbing.NewBranchReferenceName("update-dummy-hash"), false).
@@ -480,6 +500,7 @@ func TestStartUpdateLocalBranchAlreadyExists(t *testing.T) {
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	installer.EXPECT().Install(anyCtx, "/tmp", "site1").Return(nil).Maybe()
 	installer.EXPECT().ConfigureDatabase(anyCtx, "/tmp", "site1").Return(nil).Maybe()
@@ -525,8 +546,9 @@ func TestStartUpdateLocalBranchLookupError(t *testing.T) {
 	worktree.EXPECT().Checkout(mock.Anything).Return(nil).Maybe()
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, "main").Return(nil, nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	lookupErr := errors.New("corrupt ref")
@@ -537,6 +559,7 @@ func TestStartUpdateLocalBranchLookupError(t *testing.T) {
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	installer.EXPECT().Install(anyCtx, "/tmp", "site1").Return(nil).Maybe()
 	installer.EXPECT().ConfigureDatabase(anyCtx, "/tmp", "site1").Return(nil).Maybe()
@@ -584,7 +607,7 @@ func TestStartUpdateWithDryRun(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
@@ -600,6 +623,7 @@ func TestStartUpdateWithDryRun(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
@@ -665,6 +689,7 @@ func TestStartUpdateCheckoutDryRunWithoutPlatform(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	// Execute: platform is nil, exactly as cmd/root.go leaves it for this configuration.
 	workflowService := NewWorkflowBaseService(logger, config, drush, nil, repositoryService, installer, mockComposer, event.NewManager(""))
@@ -716,18 +741,19 @@ func TestPublishWorkDeletesBranchOnMRFailure(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
 
 	mrErr := errors.New("API rate limit exceeded")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch).Return(codehosting.MergeRequest{}, mrErr)
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch, false).Return(codehosting.MergeRequest{}, mrErr)
 	vcsProvider.EXPECT().DeleteBranch(anyCtx, mock.Anything).Return(nil)
 
 	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
@@ -735,6 +761,7 @@ func TestPublishWorkDeletesBranchOnMRFailure(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
@@ -778,11 +805,12 @@ func TestPublishWorkLogsWarningWhenDeleteBranchFails(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
@@ -790,7 +818,7 @@ func TestPublishWorkLogsWarningWhenDeleteBranchFails(t *testing.T) {
 
 	mrErr := errors.New("API rate limit exceeded")
 	deleteErr := errors.New("permission denied")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch).Return(codehosting.MergeRequest{}, mrErr)
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch, false).Return(codehosting.MergeRequest{}, mrErr)
 	vcsProvider.EXPECT().DeleteBranch(anyCtx, mock.Anything).Return(deleteErr)
 
 	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
@@ -798,6 +826,7 @@ func TestPublishWorkLogsWarningWhenDeleteBranchFails(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
@@ -842,11 +871,12 @@ func TestPublishWorkPushFails(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 
 	pushErr := errors.New("authentication failed")
 	repository.EXPECT().Push(mock.Anything).Return(pushErr)
@@ -858,12 +888,13 @@ func TestPublishWorkPushFails(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
 
 	require.ErrorIs(t, err, pushErr)
-	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestStartUpdateGetLockHashError(t *testing.T) {
@@ -895,7 +926,7 @@ func TestStartUpdateGetLockHashError(t *testing.T) {
 	worktree.EXPECT().Checkout(mock.Anything).Return(nil).Maybe()
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
@@ -915,6 +946,54 @@ func TestStartUpdateGetLockHashError(t *testing.T) {
 	repository.AssertNotCalled(t, "Push", mock.Anything)
 }
 
+func TestStartUpdateCommitsWithTheProjectsMessages(t *testing.T) {
+	installer := NewMockInstaller(t)
+	repositoryService := NewMockRepository(t)
+	vcsProvider := NewMockPlatform(t)
+	repository := NewMockGitRepository(t)
+	mockComposer := NewMockComposer(t)
+	expectVersionLookup(mockComposer)
+
+	config := internal.Config{
+		RepositoryURL: "https://example.com/repo.git",
+		Branch:        "main",
+		Token:         "token",
+		Clone:         true,
+		Sites:         []string{"site1"},
+		Commits: internal.CommitsConfig{
+			Messages: map[internal.CommitKind]string{
+				internal.CommitDependencies: "chore(deps): {{ .RunType }} update of {{ range .Packages }}{{ .Package }} {{ .To }}{{ end }}",
+			},
+			Trailers: []string{"Refs: MAINT-1"},
+		},
+	}
+
+	worktree := NewMockWorktree(t)
+	worktree.EXPECT().Commit("chore(deps): normal update of drupal/core 9.1.0\n\nRefs: MAINT-1", &git.CommitOptions{}).Return(plumbing.NewHash(""), nil)
+	worktree.EXPECT().AddGlob(mock.Anything).Return(nil)
+	worktree.EXPECT().Status().Return(git.Status{}, nil).Maybe()
+	worktree.EXPECT().Checkout(mock.Anything).Return(nil).Maybe()
+
+	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
+	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
+	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
+	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
+		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
+	}, nil)
+	// Stops the run once the dependencies are committed.
+	hashErr := errors.New("composer.lock not found")
+	mockComposer.EXPECT().GetLockHash("/tmp").Return("", hashErr)
+	installer.EXPECT().Install(anyCtx, "/tmp", "site1").Return(nil).Maybe()
+
+	workflowService := NewWorkflowBaseService(zap.NewNop(), config, NewMockDrush(t), vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
+	err := workflowService.StartUpdate(context.Background(), nil)
+
+	require.ErrorIs(t, err, hashErr)
+	worktree.AssertExpectations(t)
+}
+
 func TestStartUpdateBranchExistsError(t *testing.T) {
 	// If BranchExists returns an error, updateSharedCode propagates it.
 	logger := zap.NewNop()
@@ -944,7 +1023,8 @@ func TestStartUpdateBranchExistsError(t *testing.T) {
 	worktree.EXPECT().Checkout(mock.Anything).Return(nil).Maybe()
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, "main").Return(nil, nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
@@ -952,6 +1032,7 @@ func TestStartUpdateBranchExistsError(t *testing.T) {
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	branchErr := errors.New("git remote unreachable")
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
@@ -1001,16 +1082,18 @@ func TestStartUpdateConfigResaveError(t *testing.T) {
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(resaveErr)
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
@@ -1055,16 +1138,18 @@ func TestStartUpdateExportConfigurationError(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(exportErr)
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
@@ -1108,11 +1193,12 @@ func TestStartUpdateWithAutoMerge(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
 	fixture, err := os.ReadFile("testdata/dependency_update.md")
@@ -1120,7 +1206,7 @@ func TestStartUpdateWithAutoMerge(t *testing.T) {
 
 	createdMR := codehosting.MergeRequest{ID: 42, URL: "http://example.com/mr/42"}
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch).Return(createdMR, nil)
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch, false).Return(createdMR, nil)
 	vcsProvider.EXPECT().EnableAutoMerge(anyCtx, createdMR).Return(nil)
 
 	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
@@ -1128,6 +1214,7 @@ func TestStartUpdateWithAutoMerge(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err = workflowService.StartUpdate(ctx, nil)
@@ -1173,16 +1260,17 @@ func TestStartUpdateAutoMergeError(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
 	createdMR := codehosting.MergeRequest{ID: 42, URL: "http://example.com/mr/42"}
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch).Return(createdMR, nil)
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch, false).Return(createdMR, nil)
 	autoMergeErr := errors.New("auto-merge not allowed")
 	vcsProvider.EXPECT().EnableAutoMerge(anyCtx, createdMR).Return(autoMergeErr)
 
@@ -1191,6 +1279,7 @@ func TestStartUpdateAutoMergeError(t *testing.T) {
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err := workflowService.StartUpdate(ctx, nil)
@@ -1237,24 +1326,26 @@ func TestStartUpdateAutoMergeSkippedWhenDisabled(t *testing.T) {
 	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
 	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
 	fixture, err := os.ReadFile("testdata/dependency_update.md")
 	require.NoError(t, err)
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch).Return(codehosting.MergeRequest{}, nil)
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch, false).Return(codehosting.MergeRequest{}, nil)
 
 	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err = workflowService.StartUpdate(ctx, nil)
@@ -1263,6 +1354,35 @@ func TestStartUpdateAutoMergeSkippedWhenDisabled(t *testing.T) {
 	vcsProvider.AssertNotCalled(t, "EnableAutoMerge", mock.Anything, mock.Anything)
 }
 
+// Labels, assignees, reviewers and a milestone go on the request the run opened, and failing to
+// set them, like auto-merge, only warns.
+func TestStartUpdateSetsMergeRequestMetadata(t *testing.T) {
+	metadata := codehosting.MergeRequestMetadata{
+		Labels:    []string{"dependencies"},
+		Assignees: []string{"alice"},
+		Reviewers: []string{"acme/maintainers"},
+		Milestone: "Sprint 42",
+	}
+	created := codehosting.MergeRequest{ID: 7, URL: "https://example.com/mr/7"}
+
+	for name, setErr := range map[string]error{"set": nil, "rejected": errors.New("no open milestone titled \"Sprint 42\"")} {
+		t.Run(name, func(t *testing.T) {
+			h := newReportHarness(t, false)
+			h.config.RunTypes.Normal = internal.RunTypeConfig{
+				Labels: metadata.Labels, Assignees: metadata.Assignees, Reviewers: metadata.Reviewers, Milestone: metadata.Milestone,
+			}
+			h.expectFullRun(t)
+			h.repository.EXPECT().Push(mock.Anything).Return(nil)
+			h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).Return(created, nil)
+			h.vcsProvider.EXPECT().SetMergeRequestMetadata(anyCtx, created, metadata).Return(setErr)
+
+			require.NoError(t, h.run(t))
+
+			assert.Equal(t, report.StatusSuccess, h.got.Status)
+		})
+	}
+}
+
 func TestGenerateDescription_UnknownTemplate(t *testing.T) {
 	logger := zap.NewNop()
 	ws := NewWorkflowBaseService(logger, internal.Config{}, nil, nil, nil, nil, nil, nil)
@@ -1301,7 +1421,7 @@ func TestStartUpdateFireEventError(t *testing.T) {
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
 
 	// The run acquires the working copy by cloning once (--clone mode).
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").
 		Return(repository, worktree, "/tmp", nil)
 
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
@@ -1367,6 +1487,7 @@ func TestStartUpdateUsesExistingCheckout(t *testing.T) {
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, checkout).Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 	// Checkout mode: HEAD is captured up front to restore the checkout on failure. This run
 	// succeeds, so it is never used.
@@ -1374,13 +1495,14 @@ func TestStartUpdateUsesExistingCheckout(t *testing.T) {
 
 	fixture, err := os.ReadFile("testdata/dependency_update.md")
 	require.NoError(t, err, "Failed to read test fixture")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch).Return(codehosting.MergeRequest{}, nil)
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch, false).Return(codehosting.MergeRequest{}, nil)
 
 	mockComposer.EXPECT().Update(anyCtx, checkout, mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, checkout).Return(nil)
 	mockComposer.EXPECT().GetLockHash(checkout).Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash(checkout).Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err = workflowService.StartUpdate(ctx, nil)
@@ -1416,7 +1538,7 @@ func TestStartUpdateWorkBranchCheckoutError(t *testing.T) {
 	worktree.EXPECT().Checkout(mock.Anything).Return(checkoutErr)
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
@@ -1529,18 +1651,20 @@ func TestStartUpdateDoesNotRestoreCheckoutOnSuccess(t *testing.T) {
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, checkout).Return("", nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 	repository.EXPECT().Head().Return(plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), plumbing.NewHash("a")), nil)
 
 	fixture, err := os.ReadFile("testdata/dependency_update.md")
 	require.NoError(t, err, "Failed to read test fixture")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch).Return(codehosting.MergeRequest{}, nil)
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, string(fixture), mock.Anything, config.Branch, false).Return(codehosting.MergeRequest{}, nil)
 
 	mockComposer.EXPECT().Update(anyCtx, checkout, mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
 		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
 	}, nil)
 	mockComposer.EXPECT().Install(anyCtx, checkout).Return(nil)
 	mockComposer.EXPECT().GetLockHash(checkout).Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash(checkout).Return("dummy-packages", nil)
 
 	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
 	err = workflowService.StartUpdate(ctx, nil)
@@ -1600,6 +1724,23 @@ func TestForEachSite(t *testing.T) {
 
 		require.ErrorIs(t, err, boom)
 	})
+
+	t.Run("logs each site's lines with its site and phase", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		ws := &WorkflowBaseService{logger: zap.New(core), config: internal.Config{Sites: []string{"site1"}}}
+
+		ctx := logging.WithFields(context.Background(), zap.String("phase", "site update"))
+		err := ws.forEachSite(ctx, func(ctx context.Context, _ string) error {
+			logging.For(ctx, ws.logger).Info("updating site")
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "site1", fields["site"])
+		assert.Equal(t, "site update", fields["phase"])
+	})
 }
 
 func TestRestoreOriginalCheckout(t *testing.T) {
@@ -1700,50 +1841,125 @@ func TestEnsureUpdateBranchAvailable(t *testing.T) {
 			config:     internal.Config{DryRun: true},
 		}
 
-		require.NoError(t, ws.ensureUpdateBranchAvailable(newCheckout(t), branch))
+		require.NoError(t, ws.ensureUpdateBranchAvailable(newCheckout(t), branch, nil))
 	})
 
 	t.Run("a real run asks the remote and aborts when the branch is taken", func(t *testing.T) {
 		checkout := newCheckout(t)
 		repository := NewMockRepository(t)
-		repository.EXPECT().BranchExists(checkout, branch, "tok").Return(true, nil)
+		repository.EXPECT().BranchExists(checkout, branch, repo.BasicAuth("", "tok")).Return(true, nil)
 		ws := &WorkflowBaseService{
 			logger:     zap.NewNop(),
 			repository: repository,
 			config:     internal.Config{DryRun: false, Token: "tok"},
 		}
 
-		err := ws.ensureUpdateBranchAvailable(checkout, branch)
+		err := ws.ensureUpdateBranchAvailable(checkout, branch, nil)
 		var abort AbortError
 		require.ErrorAs(t, err, &abort)
 	})
 
+	t.Run("a remote branch one of our own requests holds is not taken", func(t *testing.T) {
+		checkout := newCheckout(t)
+		repository := NewMockRepository(t)
+		repository.EXPECT().BranchExists(checkout, branch, repo.BasicAuth("", "tok")).Return(true, nil)
+		ws := &WorkflowBaseService{
+			logger:     zap.NewNop(),
+			repository: repository,
+			config:     internal.Config{DryRun: false, Token: "tok"},
+		}
+
+		require.NoError(t, ws.ensureUpdateBranchAvailable(checkout, branch, []codehosting.MergeRequest{{ID: 3, SourceBranch: branch}}))
+	})
+
 	t.Run("a real run proceeds when the remote does not have the branch", func(t *testing.T) {
 		checkout := newCheckout(t)
 		repository := NewMockRepository(t)
-		repository.EXPECT().BranchExists(checkout, branch, "tok").Return(false, nil)
+		repository.EXPECT().BranchExists(checkout, branch, repo.BasicAuth("", "tok")).Return(false, nil)
 		ws := &WorkflowBaseService{
 			logger:     zap.NewNop(),
 			repository: repository,
 			config:     internal.Config{DryRun: false, Token: "tok"},
 		}
 
-		require.NoError(t, ws.ensureUpdateBranchAvailable(checkout, branch))
+		require.NoError(t, ws.ensureUpdateBranchAvailable(checkout, branch, nil))
 	})
 
 	t.Run("a remote failure is surfaced", func(t *testing.T) {
 		checkout := newCheckout(t)
 		repository := NewMockRepository(t)
-		repository.EXPECT().BranchExists(checkout, branch, "").Return(false, assert.AnError)
+		repository.EXPECT().BranchExists(checkout, branch, repo.BasicAuth("", "")).Return(false, assert.AnError)
 		ws := &WorkflowBaseService{
 			logger:     zap.NewNop(),
 			repository: repository,
 			config:     internal.Config{DryRun: false},
 		}
 
-		err := ws.ensureUpdateBranchAvailable(checkout, branch)
+		err := ws.ensureUpdateBranchAvailable(checkout, branch, nil)
 		require.ErrorContains(t, err, "failed to check if branch exists")
 	})
+
+	t.Run("a Bitbucket Cloud remote is asked as x-token-auth", func(t *testing.T) {
+		checkout := newCheckout(t)
+		repository := NewMockRepository(t)
+		repository.EXPECT().BranchExists(checkout, branch, repo.BasicAuth("x-token-auth", "tok")).Return(false, nil)
+		ws := &WorkflowBaseService{
+			logger:     zap.NewNop(),
+			repository: repository,
+			config:     internal.Config{Token: "tok", RepositoryURL: "https://bitbucket.org/acme/site.git"},
+		}
+
+		require.NoError(t, ws.ensureUpdateBranchAvailable(checkout, branch, nil))
+	})
+
+	t.Run("an SSH remote is asked with the key, never the token", func(t *testing.T) {
+		dir := t.TempDir()
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		block, err := ssh.MarshalPrivateKey(key, "")
+		require.NoError(t, err)
+		keyFile := filepath.Join(dir, "id_ed25519")
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600))
+		hostKey, err := ssh.NewPublicKey(key.Public())
+		require.NoError(t, err)
+		knownHosts := filepath.Join(dir, "known_hosts")
+		require.NoError(t, os.WriteFile(knownHosts, append([]byte("github.com "), ssh.MarshalAuthorizedKey(hostKey)...), 0o600))
+
+		checkout := newCheckout(t)
+		repository := NewMockRepository(t)
+		repository.EXPECT().BranchExists(checkout, branch, mock.MatchedBy(func(auth transport.AuthMethod) bool {
+			keys, ok := auth.(*gitssh.PublicKeys)
+			return ok && keys.User == "git"
+		})).Return(false, nil)
+		ws := &WorkflowBaseService{
+			logger:     zap.NewNop(),
+			repository: repository,
+			config: internal.Config{
+				Token:         "tok",
+				RepositoryURL: "git@github.com:acme/site.git",
+				SSH:           repo.SSHOptions{KeyFile: keyFile, KnownHosts: knownHosts},
+			},
+		}
+
+		require.NoError(t, ws.ensureUpdateBranchAvailable(checkout, branch, nil))
+	})
+
+	t.Run("an SSH host known_hosts does not list is refused before the remote is asked", func(t *testing.T) {
+		knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+		require.NoError(t, os.WriteFile(knownHosts, nil, 0o600))
+		repository := NewMockRepository(t)
+		ws := &WorkflowBaseService{
+			logger:     zap.NewNop(),
+			repository: repository,
+			config: internal.Config{
+				RepositoryURL: "git@github.com:acme/site.git",
+				SSH:           repo.SSHOptions{KnownHosts: knownHosts},
+			},
+		}
+
+		err := ws.ensureUpdateBranchAvailable(newCheckout(t), branch, nil)
+		require.ErrorContains(t, err, "github.com is not in known_hosts")
+	})
 }
 
 // anyCtx matches any non-nil context.Context: the errgroup derives a child, so the exact value
@@ -1925,7 +2141,7 @@ func TestStartUpdateFailsWhenScaffoldChangesCannotBeStaged(t *testing.T) {
 
 	installer.EXPECT().Install(anyCtx, "/tmp", "site1").Return(nil)
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").
 		Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound).Maybe()
@@ -1942,3 +2158,562 @@ func TestStartUpdateFailsWhenScaffoldChangesCannotBeStaged(t *testing.T) {
 	require.ErrorIs(t, err, assert.AnError)
 	assert.Contains(t, err.Error(), "failed to read worktree status")
 }
+
+func TestStartUpdateUpdatesOwnMergeRequest(t *testing.T) {
+	// An open request from an earlier run is brought up to date instead of opening a second one:
+	// its branch is force-pushed and its title and description rewritten. Its labels and the
+	// rest are left as people have triaged them.
+	logger := zap.NewNop()
+	installer := NewMockInstaller(t)
+	repositoryService := NewMockRepository(t)
+	vcsProvider := NewMockPlatform(t)
+	repository := NewMockGitRepository(t)
+	mockComposer := NewMockComposer(t)
+	expectVersionLookup(mockComposer)
+	drush := NewMockDrush(t)
+	ctx := context.Background()
+
+	config := internal.Config{
+		RepositoryURL: "https://example.com/repo.git",
+		Branch:        "main",
+		Token:         "token",
+		Clone:         true,
+		Sites:         []string{"site1"},
+		DryRun:        false,
+		RunTypes:      internal.RunTypesConfig{Normal: internal.RunTypeConfig{Labels: []string{"dependencies"}}},
+	}
+
+	worktree := NewMockWorktree(t)
+	worktree.EXPECT().Commit(mock.Anything, mock.Anything).Return(plumbing.NewHash(""), nil)
+	worktree.EXPECT().AddGlob(mock.Anything).Return(nil)
+	worktree.EXPECT().Status().Return(git.Status{}, nil).Maybe()
+	worktree.EXPECT().Checkout(workBranchCheckout).Return(nil)
+
+	installer.EXPECT().Install(anyCtx, "/tmp", "site1").Return(nil)
+	installer.EXPECT().ConfigureDatabase(anyCtx, "/tmp", "site1").Return(nil)
+
+	drush.EXPECT().UpdateSite(anyCtx, "/tmp", "site1").Return(nil)
+	drush.EXPECT().ExportConfiguration(anyCtx, "/tmp", "site1").Return(nil)
+	drush.EXPECT().ConfigResave(anyCtx, "/tmp", "site1").Return(nil)
+
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
+	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
+	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
+	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+
+	existing := codehosting.MergeRequest{
+		ID:           12,
+		URL:          "https://example.com/repo/-/merge_requests/12",
+		SourceBranch: "update-old-hash",
+		Description:  "Older updates\n\n" + owner{mode: report.ModeNormal, lockHash: "old-hash"}.marker() + "\n",
+	}
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return([]codehosting.MergeRequest{existing}, nil)
+
+	var pushed git.PushOptions
+	repository.EXPECT().Push(mock.Anything).RunAndReturn(func(o *git.PushOptions) error {
+		pushed = *o
+		return nil
+	})
+
+	fixture, err := os.ReadFile("testdata/dependency_update.md")
+	require.NoError(t, err, "Failed to read test fixture")
+
+	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
+	vcsProvider.EXPECT().UpdateMergeRequest(anyCtx, existing, mock.Anything, string(fixture)).Return(nil)
+
+	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).Return([]composer.PackageChange{
+		{Package: "drupal/core", From: "9.0.0", To: "9.1.0"},
+	}, nil)
+	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
+	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
+
+	workflowService := NewWorkflowBaseService(logger, config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
+	err = workflowService.StartUpdate(ctx, nil)
+
+	require.NoError(t, err)
+	require.Len(t, pushed.RefSpecs, 1)
+	assert.Equal(t, "+refs/heads/update-dummy-hash:refs/heads/update-old-hash", pushed.RefSpecs[0].String())
+	vcsProvider.AssertNotCalled(t, "CreateMergeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	vcsProvider.AssertNotCalled(t, "SetMergeRequestMetadata", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResolveUpdateTarget(t *testing.T) {
+	const (
+		lockHash     = "new-hash"
+		packagesHash = "packages-of-new-hash"
+	)
+
+	// own is a request a previous run opened on the branch it named after hash, recording the
+	// packages it locked as "packages-of-" + hash.
+	own := func(mode report.Mode, hash string) codehosting.MergeRequest {
+		return codehosting.MergeRequest{
+			ID:           3,
+			URL:          "https://example.com/repo/pull/3",
+			SourceBranch: "update-" + hash,
+			Description:  "Updates\n\n" + owner{mode: mode, lockHash: hash, packagesHash: "packages-of-" + hash}.marker() + "\n",
+		}
+	}
+
+	// remote lists the branches that already exist on the remote.
+	newService := func(t *testing.T, checkout *git.Repository, config internal.Config, mrs []codehosting.MergeRequest, listErr error, remote ...string) *WorkflowBaseService {
+		t.Helper()
+		repository := NewMockRepository(t)
+		branch := updateBranchName(config.Group, lockHash)
+		if listErr == nil {
+			repository.EXPECT().BranchExists(checkout, branch, repo.BasicAuth("", "tok")).Return(slices.Contains(remote, branch), nil)
+		}
+		platform := NewMockPlatform(t)
+		platform.EXPECT().ListMergeRequests(anyCtx, "main").Return(mrs, listErr)
+		config.Branch = "main"
+		config.Token = "tok"
+		return &WorkflowBaseService{logger: zap.NewNop(), repository: repository, platform: platform, config: config}
+	}
+
+	newCheckout := func(t *testing.T) *git.Repository {
+		t.Helper()
+		checkout, err := git.PlainInit(t.TempDir(), false)
+		require.NoError(t, err)
+		return checkout
+	}
+
+	newRecorder := func() *report.Recorder {
+		return report.NewRecorder("test", report.ModeNormal, false, "https://example.com/repo.git", "main", []string{"default"})
+	}
+
+	// stale is a request from before the marker: on an update branch, with a plain description.
+	stale := codehosting.MergeRequest{
+		ID:           1,
+		URL:          "https://example.com/repo/pull/1",
+		SourceBranch: "update-0f3c9a4e5b6d7c8f9a0b1c2d3e4f5a6b",
+		Description:  "This is an automated Drupal update.",
+	}
+
+	t.Run("no open request opens a new one", func(t *testing.T) {
+		checkout := newCheckout(t)
+		ws := newService(t, checkout, internal.Config{}, nil, nil)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.False(t, target.updatesExisting())
+		assert.Equal(t, "update-new-hash", target.remoteBranch())
+	})
+
+	t.Run("the run type's own request is reused", func(t *testing.T) {
+		checkout := newCheckout(t)
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{own(report.ModeNormal, "old-hash")}, nil, "update-old-hash")
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.True(t, target.updatesExisting())
+		assert.Equal(t, "update-new-hash", target.branch)
+		assert.Equal(t, "update-old-hash", target.remoteBranch())
+	})
+
+	t.Run("another run type's request is left alone", func(t *testing.T) {
+		checkout := newCheckout(t)
+		ws := newService(t, checkout, internal.Config{Security: true}, []codehosting.MergeRequest{own(report.ModeNormal, "old-hash")}, nil)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.False(t, target.updatesExisting())
+	})
+
+	t.Run("a group reuses only its own request", func(t *testing.T) {
+		checkout := newCheckout(t)
+		ungrouped := own(report.ModeNormal, "old-hash")
+		grouped := own(report.ModeNormal, "core-old-hash")
+		grouped.ID = 5
+		grouped.Description = "Updates\n\n" + owner{mode: report.ModeNormal, group: "core", lockHash: "old-hash"}.marker() + "\n"
+		ws := newService(t, checkout, internal.Config{Group: "core"}, []codehosting.MergeRequest{ungrouped, grouped}, nil)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.Equal(t, "update-core-new-hash", target.branch)
+		assert.Equal(t, grouped, target.existing)
+		assert.Empty(t, target.superseded)
+	})
+
+	t.Run("a request without the marker is left alone", func(t *testing.T) {
+		// Someone else's branch that happens to share the naming scheme.
+		checkout := newCheckout(t)
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{{ID: 4, SourceBranch: "update-mine", Description: "hand-made"}}, nil)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.False(t, target.updatesExisting())
+	})
+
+	t.Run("older requests of the run type are superseded", func(t *testing.T) {
+		checkout := newCheckout(t)
+		newest, older := own(report.ModeNormal, "old-hash"), own(report.ModeNormal, "older-hash")
+		older.ID = 2
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{newest, older}, nil, newest.SourceBranch, older.SourceBranch)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.Equal(t, newest, target.existing)
+		assert.Equal(t, []codehosting.MergeRequest{older}, target.superseded)
+	})
+
+	t.Run("a request already carrying the locked packages aborts", func(t *testing.T) {
+		// Its branch still carries the name of the hash it was opened with.
+		checkout := newCheckout(t)
+		mr := own(report.ModeNormal, lockHash)
+		mr.SourceBranch = "update-old-hash"
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{mr}, nil, mr.SourceBranch)
+
+		_, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		var abort AbortError
+		require.ErrorAs(t, err, &abort)
+		assert.Contains(t, abort.Msg, mr.URL)
+	})
+
+	t.Run("a rerun against the same composer.json refreshes its own request", func(t *testing.T) {
+		// The lock hash follows composer.json alone, so the previous run pushed the branch this
+		// one would name, and locked other versions under it.
+		checkout := newCheckout(t)
+		mr := own(report.ModeNormal, lockHash)
+		mr.Description = "Updates\n\n" + owner{mode: report.ModeNormal, lockHash: lockHash, packagesHash: "packages-of-last-week"}.marker() + "\n"
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{mr}, nil, mr.SourceBranch)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.Equal(t, mr, target.existing)
+		assert.Equal(t, "update-new-hash", target.remoteBranch())
+	})
+
+	t.Run("a request from before packages were recorded is refreshed", func(t *testing.T) {
+		checkout := newCheckout(t)
+		mr := own(report.ModeNormal, lockHash)
+		mr.Description = "Updates\n\n<!-- drupdater run-type=normal lock-hash=new-hash -->\n"
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{mr}, nil, mr.SourceBranch)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.True(t, target.updatesExisting())
+	})
+
+	t.Run("a remote branch that is not ours aborts", func(t *testing.T) {
+		checkout := newCheckout(t)
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{own(report.ModeNormal, "old-hash")}, nil, "update-new-hash")
+
+		_, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		var abort AbortError
+		require.ErrorAs(t, err, &abort)
+		assert.Contains(t, abort.Msg, "branch update-new-hash already exists")
+	})
+
+	t.Run("a request from before the marker is left open", func(t *testing.T) {
+		// Every run type once named its branches alike: stale may well be an open security
+		// request, which a normal run must not close.
+		checkout := newCheckout(t)
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{stale}, nil)
+
+		target, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.NoError(t, err)
+		assert.False(t, target.updatesExisting())
+		assert.Empty(t, target.superseded)
+	})
+
+	t.Run("a request already carrying the locked packages still closes the older ones", func(t *testing.T) {
+		checkout := newCheckout(t)
+		// current is on update-new-hash, so the branch this run names is already on the remote:
+		// it is current's own, which must not abort the run before the older request is closed.
+		current, older := own(report.ModeNormal, lockHash), own(report.ModeNormal, "older-hash")
+		older.ID = 2
+		ws := newService(t, checkout, internal.Config{}, []codehosting.MergeRequest{current, older}, nil, current.SourceBranch, older.SourceBranch)
+		platform := ws.platform.(*MockPlatform)
+		platform.EXPECT().CloseMergeRequest(anyCtx, older, "Superseded by "+current.URL+", which carries a newer update.").Return(nil)
+		platform.EXPECT().DeleteBranch(anyCtx, older.SourceBranch).Return(nil)
+		rec := newRecorder()
+
+		_, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, rec)
+		var abort AbortError
+		require.ErrorAs(t, err, &abort)
+		mr := rec.Finish().MergeRequest
+		require.NotNil(t, mr)
+		assert.Equal(t, current.URL, mr.URL)
+		assert.True(t, mr.Unchanged)
+		assert.Equal(t, []string{older.URL}, mr.Superseded)
+	})
+
+	t.Run("a listing failure is surfaced", func(t *testing.T) {
+		checkout := newCheckout(t)
+		ws := newService(t, checkout, internal.Config{}, nil, assert.AnError)
+
+		_, err := ws.resolveUpdateTarget(context.Background(), checkout, lockHash, packagesHash, newRecorder())
+		require.ErrorContains(t, err, "failed to list open merge requests")
+	})
+}
+
+func TestPublishWorkRecordsTheUpdatedMergeRequest(t *testing.T) {
+	existing := codehosting.MergeRequest{ID: 12, URL: "https://example.com/repo/-/merge_requests/12", SourceBranch: "update-old"}
+	target := updateTarget{branch: "update-new", lockHash: "new", existing: existing}
+
+	t.Run("success is recorded as an update", func(t *testing.T) {
+		repository := NewMockGitRepository(t)
+		repository.EXPECT().Push(mock.Anything).Return(nil)
+		platform := NewMockPlatform(t)
+		platform.EXPECT().UpdateMergeRequest(anyCtx, existing, "Title", "Body").Return(nil)
+		ws := &WorkflowBaseService{logger: zap.NewNop(), platform: platform, config: internal.Config{Branch: "main"}}
+		rec := report.NewRecorder("test", report.ModeNormal, false, "https://example.com/repo.git", "main", []string{"default"})
+
+		require.NoError(t, ws.publishWork(context.Background(), repository, target, "Title", "Body", rec))
+		assert.Equal(t, &report.MergeRequest{URL: existing.URL, Updated: true}, rec.Finish().MergeRequest)
+	})
+
+	t.Run("a failed update keeps the branch", func(t *testing.T) {
+		// DeleteBranch is left unstubbed: the branch belongs to a request that predates this run.
+		repository := NewMockGitRepository(t)
+		repository.EXPECT().Push(mock.Anything).Return(nil)
+		platform := NewMockPlatform(t)
+		platform.EXPECT().UpdateMergeRequest(anyCtx, existing, "Title", "Body").Return(assert.AnError)
+		ws := &WorkflowBaseService{logger: zap.NewNop(), platform: platform, config: internal.Config{Branch: "main"}}
+		rec := report.NewRecorder("test", report.ModeNormal, false, "https://example.com/repo.git", "main", []string{"default"})
+
+		err := ws.publishWork(context.Background(), repository, target, "Title", "Body", rec)
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, rec.Finish().MergeRequest)
+	})
+}
+
+func TestOwnerMarker(t *testing.T) {
+	for _, want := range []owner{
+		{mode: report.ModeSecurity, lockHash: "abc123"},
+		{mode: report.ModeNormal, group: "drupal-core", lockHash: "abc123"},
+		{mode: report.ModeNormal, lockHash: "abc123", packagesHash: "def456"},
+		{mode: report.ModeMajor, group: "contrib", lockHash: "abc123", packagesHash: "def456"},
+	} {
+		got, ok := parseOwnerMarker("Some description\n\n" + want.marker() + "\n")
+		require.True(t, ok)
+		assert.Equal(t, want, got)
+	}
+
+	_, ok := parseOwnerMarker("A description someone wrote by hand")
+	assert.False(t, ok)
+}
+
+func TestPublishWorkClosesSupersededMergeRequests(t *testing.T) {
+	older := codehosting.MergeRequest{ID: 2, URL: "https://example.com/repo/pull/2", SourceBranch: "update-b"}
+	oldest := codehosting.MergeRequest{ID: 1, URL: "https://example.com/repo/pull/1", SourceBranch: "update-a"}
+	existing := codehosting.MergeRequest{ID: 3, URL: "https://example.com/repo/pull/3", SourceBranch: "update-c"}
+	target := updateTarget{branch: "update-d", lockHash: "d", existing: existing, superseded: []codehosting.MergeRequest{older, oldest}}
+
+	repository := NewMockGitRepository(t)
+	repository.EXPECT().Push(mock.Anything).Return(nil)
+	platform := NewMockPlatform(t)
+	platform.EXPECT().UpdateMergeRequest(anyCtx, existing, "Title", "Body").Return(nil)
+	platform.EXPECT().CloseMergeRequest(anyCtx, older, "Superseded by https://example.com/repo/pull/3, which carries a newer update.").Return(nil)
+	platform.EXPECT().DeleteBranch(anyCtx, "update-b").Return(assert.AnError)
+	// A request that cannot be closed keeps its branch, and is not reported as closed.
+	platform.EXPECT().CloseMergeRequest(anyCtx, oldest, mock.Anything).Return(assert.AnError)
+
+	core, logs := observer.New(zap.WarnLevel)
+	ws := &WorkflowBaseService{logger: zap.New(core), platform: platform, config: internal.Config{Branch: "main"}}
+	rec := report.NewRecorder("test", report.ModeNormal, false, "https://example.com/repo.git", "main", []string{"default"})
+
+	require.NoError(t, ws.publishWork(context.Background(), repository, target, "Title", "Body", rec), "closing is best-effort")
+	assert.Equal(t, []string{older.URL}, rec.Finish().MergeRequest.Superseded)
+	assert.Equal(t, 1, logs.FilterMessage("failed to delete superseded branch").Len())
+	assert.Equal(t, 1, logs.FilterMessage("failed to close superseded merge request").Len())
+}
+
+func TestGroupPackages(t *testing.T) {
+	groups := internal.UpdateGroups{
+		{Name: "core", Patterns: []string{"drupal/core*"}},
+		{Name: "contrib", Patterns: []string{"drupal/*"}},
+	}
+
+	t.Run("an ungrouped run leaves the selection to the addons", func(t *testing.T) {
+		ws := &WorkflowBaseService{logger: zap.NewNop(), config: internal.Config{Groups: groups}}
+
+		packages, err := ws.groupPackages("/tmp")
+		require.NoError(t, err)
+		assert.Empty(t, packages)
+	})
+
+	t.Run("a group selects the required packages it claims", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().GetRequiredPackages("/tmp").Return([]string{"drupal/core-recommended", "drupal/token", "drush/drush"}, nil)
+		ws := &WorkflowBaseService{logger: zap.NewNop(), composer: mockComposer, config: internal.Config{Groups: groups, Group: "contrib"}}
+
+		packages, err := ws.groupPackages("/tmp")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"drupal/token"}, packages)
+	})
+
+	t.Run("a group claiming nothing aborts rather than updating everything", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().GetRequiredPackages("/tmp").Return([]string{"drush/drush"}, nil)
+		ws := &WorkflowBaseService{logger: zap.NewNop(), composer: mockComposer, config: internal.Config{Groups: groups, Group: "core"}}
+
+		_, err := ws.groupPackages("/tmp")
+		var abort AbortError
+		require.ErrorAs(t, err, &abort)
+		assert.Contains(t, abort.Msg, "core")
+	})
+
+	t.Run("an unreadable composer.json is an error", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().GetRequiredPackages("/tmp").Return(nil, assert.AnError)
+		ws := &WorkflowBaseService{logger: zap.NewNop(), composer: mockComposer, config: internal.Config{Groups: groups, Group: "core"}}
+
+		_, err := ws.groupPackages("/tmp")
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestRenderMergeRequestNamesTheGroup(t *testing.T) {
+	config := internal.Config{Groups: internal.UpdateGroups{{Name: "core", Patterns: []string{"drupal/core*"}}}, Group: "core"}
+	ws := NewWorkflowBaseService(zap.NewNop(), config, nil, nil, nil, nil, nil, event.NewManager(""))
+	ws.current = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	title, description, err := ws.renderMergeRequest(nil, updateTarget{lockHash: "abc123"})
+	require.NoError(t, err)
+	assert.Equal(t, "March 2026: Drupal Maintenance Updates (core)", title)
+	assert.Contains(t, description, "<!-- drupdater run-type=normal group=core lock-hash=abc123 -->")
+}
+
+func TestRenderMergeRequestFromProjectTemplates(t *testing.T) {
+	parse := func(text string) *template.Template {
+		return template.Must(template.New("").Funcs(internal.TemplateFuncs()).Parse(text))
+	}
+	config := internal.Config{Groups: internal.UpdateGroups{{Name: "core", Patterns: []string{"drupal/core*"}}}, Group: "core"}
+
+	t.Run("renders the title and description from the project's templates", func(t *testing.T) {
+		ws := NewWorkflowBaseService(zap.NewNop(), config, nil, nil, nil, nil, nil, event.NewManager(""), WithTemplates(internal.ProjectTemplates{
+			Title:       parse("[{{ .Mode }}/{{ .Group }}]\n{{ .Title }} ({{ .Date.Format \"2006-01-02\" }})"),
+			Description: parse("Deploy with `drush deploy`.\n{{ range .Addons }}{{ .RenderTemplate }}{{ end }}"),
+		}))
+		ws.current = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+		title, description, err := ws.renderMergeRequest(nil, updateTarget{lockHash: "abc123"})
+		require.NoError(t, err)
+		// One line: the default title the addons left, then the project's own framing.
+		assert.Equal(t, "[normal/core] March 2026: Drupal Maintenance Updates (core) (2026-03-01)", title)
+		assert.Equal(t, "Deploy with `drush deploy`.\n\n<!-- drupdater run-type=normal group=core lock-hash=abc123 -->\n", description)
+	})
+
+	t.Run("an empty title is an error", func(t *testing.T) {
+		ws := NewWorkflowBaseService(zap.NewNop(), config, nil, nil, nil, nil, nil, event.NewManager(""), WithTemplates(internal.ProjectTemplates{
+			Title: parse("{{ if false }}{{ .Title }}{{ end }}"),
+		}))
+
+		_, _, err := ws.renderMergeRequest(nil, updateTarget{lockHash: "abc123"})
+		require.ErrorContains(t, err, "rendered an empty title")
+	})
+
+	t.Run("a field the data lacks fails the run", func(t *testing.T) {
+		ws := NewWorkflowBaseService(zap.NewNop(), config, nil, nil, nil, nil, nil, event.NewManager(""), WithTemplates(internal.ProjectTemplates{
+			Description: parse("{{ .Changelog }}"),
+		}))
+
+		_, _, err := ws.renderMergeRequest(nil, updateTarget{lockHash: "abc123"})
+		require.ErrorContains(t, err, "failed to generate description")
+	})
+}
+
+func TestUpgradeMajors(t *testing.T) {
+	candidates := []composer.OutdatedPackage{
+		{Name: "drupal/paragraphs", Version: "1.17.0", Latest: "2.0.1"},
+		{Name: "drupal/search_api_solr", Version: "4.2.12", Latest: "5.1.0"},
+		{Name: "drupal/webform", Version: "6.2.7", Latest: "7.0.0-beta1"},
+	}
+
+	t.Run("keeps what resolves and reports what does not", func(t *testing.T) {
+		// search_api_solr is held by a rule, which outranks the upgrade. entity_reference_revisions
+		// carries a patch and is a dependency of paragraphs: its pin has to hold while paragraphs
+		// moves with its dependencies.
+		pinned := []string{"drupal/search_api_solr:4.2.*", "drupal/entity_reference_revisions:1.11.0"}
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().OutdatedMajor(mock.Anything, "/tmp").Return(candidates, nil)
+		mockComposer.EXPECT().RequireUpgrade(mock.Anything, "/tmp", "drupal/paragraphs", "^2.0", pinned).Return([]composer.PackageChange{
+			{Action: "Upgrade", Package: "drupal/paragraphs", From: "1.17.0", To: "2.0.1"},
+		}, nil)
+		mockComposer.EXPECT().RequireUpgrade(mock.Anything, "/tmp", "drupal/webform", "^7.0", pinned).Return(nil, &composer.UpgradeError{
+			Package: "drupal/webform", Constraint: "^7.0", Problem: "drupal/webform 7.0.0-beta1 requires drupal/core ^11", Err: assert.AnError,
+		})
+		ws := &WorkflowBaseService{logger: zap.NewNop(), composer: mockComposer, config: internal.Config{Major: true}}
+		rec := report.NewRecorder("test", report.ModeMajor, false, "", "main", nil)
+
+		changes, err := ws.upgradeMajors(t.Context(), "/tmp", pinned, rec)
+		require.NoError(t, err)
+
+		assert.Equal(t, []composer.PackageChange{{Action: "Upgrade", Package: "drupal/paragraphs", From: "1.17.0", To: "2.0.1"}}, changes)
+		want := &report.MajorUpgrades{
+			Upgraded: []report.MajorUpgrade{{Package: "drupal/paragraphs", From: "1.17.0", To: "^2.0"}},
+			Failed:   []report.MajorUpgrade{{Package: "drupal/webform", From: "6.2.7", To: "^7.0", Error: "drupal/webform 7.0.0-beta1 requires drupal/core ^11"}},
+		}
+		assert.Equal(t, want, ws.majorUpgrades)
+		assert.Equal(t, want, rec.Finish().MajorUpgrades)
+	})
+
+	t.Run("a composer failure that is not a conflict stops the run", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().OutdatedMajor(mock.Anything, "/tmp").Return(candidates[:1], nil)
+		mockComposer.EXPECT().RequireUpgrade(mock.Anything, "/tmp", "drupal/paragraphs", "^2.0", []string(nil)).Return(nil, assert.AnError)
+		ws := &WorkflowBaseService{logger: zap.NewNop(), composer: mockComposer, config: internal.Config{Major: true}}
+
+		_, err := ws.upgradeMajors(t.Context(), "/tmp", nil, report.NewRecorder("test", report.ModeMajor, false, "", "main", nil))
+		require.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("nothing to upgrade records nothing", func(t *testing.T) {
+		mockComposer := NewMockComposer(t)
+		mockComposer.EXPECT().OutdatedMajor(mock.Anything, "/tmp").Return(nil, nil)
+		ws := &WorkflowBaseService{logger: zap.NewNop(), composer: mockComposer, config: internal.Config{Major: true}}
+		rec := report.NewRecorder("test", report.ModeMajor, false, "", "main", nil)
+
+		changes, err := ws.upgradeMajors(t.Context(), "/tmp", nil, rec)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+		assert.Nil(t, rec.Finish().MajorUpgrades)
+	})
+}
+
+func TestMergePackageChanges(t *testing.T) {
+	all := []composer.PackageChange{
+		{Action: "Upgrade", Package: "drupal/core", From: "10.3.8", To: "10.4.0"},
+		{Action: "Install", Package: "drupal/new_dependency", To: "1.0.0"},
+		{Action: "Upgrade", Package: "drupal/old_dependency", From: "1.0.0", To: "1.1.0"},
+	}
+
+	merged := mergePackageChanges(all, []composer.PackageChange{
+		{Action: "Upgrade", Package: "drupal/core", From: "10.4.0", To: "11.0.0"},
+		{Action: "Upgrade", Package: "drupal/new_dependency", From: "1.0.0", To: "2.0.0"},
+		{Action: "Remove", Package: "drupal/old_dependency", From: "1.1.0"},
+	})
+
+	assert.Equal(t, []composer.PackageChange{
+		{Action: "Upgrade", Package: "drupal/core", From: "10.3.8", To: "11.0.0"},
+		{Action: "Install", Package: "drupal/new_dependency", To: "2.0.0"},
+		{Action: "Remove", Package: "drupal/old_dependency", From: "1.0.0"},
+	}, merged)
+}
+
+func TestMajorConstraint(t *testing.T) {
+	for latest, want := range map[string]string{"2.0.1": "^2.0", "v3.1.0": "^3.1", "0.5.2": "^0.5", "7.0.0-beta1": "^7.0"} {
+		got, ok := majorConstraint(latest)
+		assert.True(t, ok, latest)
+		assert.Equal(t, want, got, latest)
+	}
+
+	_, ok := majorConstraint("dev-main")
+	assert.False(t, ok)
+}
+
+func TestRenderMergeRequestListsMajorUpgrades(t *testing.T) {
+	ws := NewWorkflowBaseService(zap.NewNop(), internal.Config{Major: true}, nil, nil, nil, nil, nil, event.NewManager(""))
+	ws.current = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	ws.majorUpgrades = &report.MajorUpgrades{
+		Upgraded: []report.MajorUpgrade{{Package: "drupal/paragraphs", From: "1.17.0", To: "^2.0"}},
+		Failed:   []report.MajorUpgrade{{Package: "drupal/webform", From: "6.2.7", To: "^7.0", Error: "drupal/webform 7.0.0 requires drupal/core ^11"}},
+	}
+
+	title, description, err := ws.renderMergeRequest(nil, updateTarget{lockHash: "abc123"})
+	require.NoError(t, err)
+	assert.Equal(t, "March 2026: Drupal Major Upgrades", title)
+	golden.Assert(t, "testdata/major_upgrades.md", description)
+}
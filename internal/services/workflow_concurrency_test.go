@@ -9,6 +9,7 @@ import (
 	"github.com/drupdater/drupdater/internal"
 	"github.com/drupdater/drupdater/internal/codehosting"
 	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/drupdater/drupdater/pkg/repo"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/gookit/event"
@@ -87,20 +88,22 @@ func TestStartUpdateWithConcurrentSites(t *testing.T) {
 		drush.EXPECT().ConfigResave(anyCtx, "/tmp", site).Return(nil)
 	}
 
-	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, config.Token, "user", "mail").Return(repository, worktree, "/tmp", nil)
+	repositoryService.EXPECT().CloneRepository(config.RepositoryURL, config.Branch, repo.BasicAuth("", config.Token), "user", "mail").Return(repository, worktree, "/tmp", nil)
 	repositoryService.EXPECT().IsShallowClone("/tmp").Return(false, nil)
 	repositoryService.EXPECT().BranchExists(repository, mock.Anything, mock.Anything).Return(false, nil)
+	vcsProvider.EXPECT().ListMergeRequests(anyCtx, config.Branch).Return(nil, nil)
 	repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound)
 	repository.EXPECT().Push(mock.Anything).Return(nil)
 
 	mockComposer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil)
 	mockComposer.EXPECT().Install(anyCtx, "/tmp").Return(nil)
 	mockComposer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil)
+	mockComposer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil)
 	mockComposer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).
 		Return([]composer.PackageChange{{Package: "drupal/core", From: "9.0.0", To: "9.1.0"}}, nil)
 
 	vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail")
-	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch).
+	vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, config.Branch, false).
 		Return(codehosting.MergeRequest{}, nil)
 
 	workflowService := NewWorkflowBaseService(zap.NewNop(), config, drush, vcsProvider, repositoryService, installer, mockComposer, event.NewManager(""))
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/drupdater/drupdater/internal/codehosting"
 	"github.com/drupdater/drupdater/internal/report"
 	"github.com/drupdater/drupdater/pkg/composer"
+	"github.com/drupdater/drupdater/pkg/repo"
+	"github.com/drupdater/drupdater/pkg/tracing"
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/gookit/event"
@@ -40,6 +43,9 @@ type reportHarness struct {
 	versions    composer.Versions
 	versionsErr error
 
+	// ctx is what StartUpdate runs under; nil means context.Background().
+	ctx context.Context
+
 	got *report.Report
 }
 
@@ -74,7 +80,7 @@ func newReportHarness(t *testing.T, dryRun bool) *reportHarness {
 	h.composer.EXPECT().Version(anyCtx).
 		RunAndReturn(func(context.Context) (composer.Versions, error) { return h.versions, h.versionsErr }).Maybe()
 	h.vcsProvider.EXPECT().GetUser(mock.Anything).Return("user", "mail").Maybe()
-	h.repoSvc.EXPECT().CloneRepository(h.config.RepositoryURL, h.config.Branch, h.config.Token, "user", "mail").
+	h.repoSvc.EXPECT().CloneRepository(h.config.RepositoryURL, h.config.Branch, repo.BasicAuth("", h.config.Token), "user", "mail").
 		Return(h.repository, h.worktree, "/tmp", nil).Maybe()
 	h.repoSvc.EXPECT().IsShallowClone("/tmp").Return(false, nil).Maybe()
 	h.composer.EXPECT().CheckPlatformReqs(anyCtx, "/tmp").Return("", nil).Maybe()
@@ -100,7 +106,9 @@ func (h *reportHarness) expectFullRun(t *testing.T) {
 
 	h.repository.EXPECT().Reference(mock.Anything, mock.Anything).Return(nil, plumbing.ErrReferenceNotFound).Maybe()
 	h.repoSvc.EXPECT().BranchExists(h.repository, mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	h.vcsProvider.EXPECT().ListMergeRequests(anyCtx, mock.Anything).Return(nil, nil).Maybe()
 	h.composer.EXPECT().GetLockHash("/tmp").Return("dummy-hash", nil).Maybe()
+	h.composer.EXPECT().GetLockedPackagesHash("/tmp").Return("dummy-packages", nil).Maybe()
 	h.composer.EXPECT().Update(anyCtx, "/tmp", mock.Anything, mock.Anything, false, false).
 		Return([]composer.PackageChange{{Action: "Upgrade", Package: "drupal/core", From: "9.0.0", To: "9.1.0"}}, nil).Maybe()
 }
@@ -125,14 +133,18 @@ func (h *reportHarness) run(t *testing.T) error {
 		WithReportSink(func(rep report.Report) { h.got = &rep }),
 	)
 
-	return svc.StartUpdate(context.Background(), h.addons)
+	ctx := h.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return svc.StartUpdate(ctx, h.addons)
 }
 
 func TestReportWrittenOnSuccessfulRun(t *testing.T) {
 	h := newReportHarness(t, false)
 	h.expectFullRun(t)
 	h.repository.EXPECT().Push(mock.Anything).Return(nil)
-	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
+	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
 		Return(codehosting.MergeRequest{URL: "https://example.com/mr/1"}, nil)
 
 	require.NoError(t, h.run(t))
@@ -154,12 +166,57 @@ func TestReportWrittenOnSuccessfulRun(t *testing.T) {
 	assert.Equal(t, internal.Version, h.got.DrupdaterVersion)
 }
 
+// A traced run has one span per report phase and per site, all in the update span.
+func TestTracedRunSpansEveryPhaseAndSite(t *testing.T) {
+	h := newReportHarness(t, false)
+	h.expectFullRun(t)
+	h.repository.EXPECT().Push(mock.Anything).Return(nil)
+	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
+		Return(codehosting.MergeRequest{}, nil)
+	tracer := tracing.New("dev", nil)
+	h.ctx = tracing.WithTracer(context.Background(), tracer)
+
+	require.NoError(t, h.run(t))
+
+	payload, err := tracer.Encode()
+	require.NoError(t, err)
+	var trace struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &trace))
+
+	spans := trace.ResourceSpans[0].ScopeSpans[0].Spans
+	names := map[string]string{}
+	for _, span := range spans {
+		names[span.Name] = span.SpanID
+	}
+	for _, phase := range phaseNames(h.got.Phases) {
+		assert.Contains(t, names, phase)
+	}
+	assert.Contains(t, names, "site site1")
+
+	root := spans[len(spans)-1]
+	assert.Equal(t, "update", root.Name, "the update span ends last")
+	assert.Empty(t, root.ParentSpanID)
+	for _, span := range spans[:len(spans)-1] {
+		assert.NotEmpty(t, span.ParentSpanID, span.Name)
+	}
+}
+
 // Composer decides what a run does, so the report has to name the version that produced it.
 func TestReportRecordsTheToolVersions(t *testing.T) {
 	h := newReportHarness(t, false)
 	h.expectFullRun(t)
 	h.repository.EXPECT().Push(mock.Anything).Return(nil)
-	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
+	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
 		Return(codehosting.MergeRequest{}, nil)
 
 	require.NoError(t, h.run(t))
@@ -175,7 +232,7 @@ func TestUnreadableToolVersionsDoNotFailTheRun(t *testing.T) {
 	h.versionsErr = errors.New("composer: command not found")
 	h.expectFullRun(t)
 	h.repository.EXPECT().Push(mock.Anything).Return(nil)
-	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
+	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
 		Return(codehosting.MergeRequest{}, nil)
 
 	require.NoError(t, h.run(t))
@@ -192,7 +249,7 @@ func TestReportRepositoryURLHasNoCredentials(t *testing.T) {
 	h := newReportHarness(t, false)
 	h.expectFullRun(t)
 	h.repository.EXPECT().Push(mock.Anything).Return(nil)
-	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
+	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
 		Return(codehosting.MergeRequest{}, nil)
 
 	require.NoError(t, h.run(t))
@@ -349,8 +406,8 @@ func TestReportedMergeRequestContentIsWhatWasPublished(t *testing.T) {
 	h.repository.EXPECT().Push(mock.Anything).Return(nil)
 
 	var publishedTitle, publishedDescription string
-	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
-		RunAndReturn(func(_ context.Context, title, description, _, _ string) (codehosting.MergeRequest, error) {
+	h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
+		RunAndReturn(func(_ context.Context, title, description, _, _ string, _ bool) (codehosting.MergeRequest, error) {
 			publishedTitle, publishedDescription = title, description
 
 			return codehosting.MergeRequest{URL: "https://example.com/mr/1"}, nil
@@ -420,7 +477,7 @@ func TestReportRecordsAutoMergeOutcome(t *testing.T) {
 		h := newReportHarness(t, false)
 		h.expectFullRun(t)
 		h.repository.EXPECT().Push(mock.Anything).Return(nil)
-		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
+		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
 			Return(codehosting.MergeRequest{URL: "https://example.com/mr/1"}, nil)
 
 		require.NoError(t, h.run(t))
@@ -434,7 +491,7 @@ func TestReportRecordsAutoMergeOutcome(t *testing.T) {
 		h.config.RunTypes.Normal.AutoMerge = true
 		h.expectFullRun(t)
 		h.repository.EXPECT().Push(mock.Anything).Return(nil)
-		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
+		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
 			Return(codehosting.MergeRequest{URL: "https://example.com/mr/1"}, nil)
 		h.vcsProvider.EXPECT().EnableAutoMerge(anyCtx, mock.Anything).Return(nil)
 
@@ -450,7 +507,7 @@ func TestReportRecordsAutoMergeOutcome(t *testing.T) {
 		h.config.RunTypes.Normal.AutoMerge = true
 		h.expectFullRun(t)
 		h.repository.EXPECT().Push(mock.Anything).Return(nil)
-		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main").
+		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", false).
 			Return(codehosting.MergeRequest{URL: "https://example.com/mr/1"}, nil)
 		h.vcsProvider.EXPECT().EnableAutoMerge(anyCtx, mock.Anything).
 			Return(errors.New("auto-merge is not allowed for this repository"))
@@ -463,3 +520,90 @@ func TestReportRecordsAutoMergeOutcome(t *testing.T) {
 		assert.Contains(t, h.got.MergeRequest.AutoMerge.Error, "not allowed")
 	})
 }
+
+// fastReadyPolling stops promoteDraft from waiting between polls.
+func fastReadyPolling(t *testing.T) {
+	t.Helper()
+	interval, grace := readyPollInterval, readyNoPipelineGrace
+	readyPollInterval, readyNoPipelineGrace = 0, 0
+	t.Cleanup(func() { readyPollInterval, readyNoPipelineGrace = interval, grace })
+}
+
+// A draft is marked ready only once its pipeline has passed, and before auto-merge, which no
+// platform applies to a draft.
+func TestReportRecordsDraftOutcome(t *testing.T) {
+	draft := codehosting.MergeRequest{ID: 1, URL: "https://example.com/mr/1", Draft: true}
+
+	t.Run("a draft left for a person to mark ready", func(t *testing.T) {
+		h := newReportHarness(t, false)
+		h.config.RunTypes.Normal.Draft = true
+		h.expectFullRun(t)
+		h.repository.EXPECT().Push(mock.Anything).Return(nil)
+		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", true).Return(draft, nil)
+
+		require.NoError(t, h.run(t))
+
+		require.NotNil(t, h.got.MergeRequest.Draft)
+		assert.False(t, h.got.MergeRequest.Draft.MarkedReady)
+		assert.Empty(t, h.got.MergeRequest.Draft.Error)
+	})
+
+	t.Run("marked ready once the pipeline passes, then auto-merged", func(t *testing.T) {
+		fastReadyPolling(t)
+		h := newReportHarness(t, false)
+		h.config.RunTypes.Normal.Draft = true
+		h.config.RunTypes.Normal.MarkReady = true
+		h.config.RunTypes.Normal.AutoMerge = true
+		h.expectFullRun(t)
+		h.repository.EXPECT().Push(mock.Anything).Return(nil)
+		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", true).Return(draft, nil)
+		h.vcsProvider.EXPECT().PipelineStatus(anyCtx, draft).Return(codehosting.PipelinePending, nil).Twice()
+		h.vcsProvider.EXPECT().PipelineStatus(anyCtx, draft).Return(codehosting.PipelineSuccess, nil).Once()
+		var order []string
+		h.vcsProvider.EXPECT().MarkMergeRequestReady(anyCtx, draft).
+			RunAndReturn(func(context.Context, codehosting.MergeRequest) error { order = append(order, "ready"); return nil })
+		h.vcsProvider.EXPECT().EnableAutoMerge(anyCtx, draft).
+			RunAndReturn(func(context.Context, codehosting.MergeRequest) error { order = append(order, "auto-merge"); return nil })
+
+		require.NoError(t, h.run(t))
+
+		assert.Equal(t, []string{"ready", "auto-merge"}, order)
+		require.NotNil(t, h.got.MergeRequest.Draft)
+		assert.True(t, h.got.MergeRequest.Draft.MarkedReady)
+	})
+
+	t.Run("a failed pipeline leaves it a draft without failing the run", func(t *testing.T) {
+		fastReadyPolling(t)
+		h := newReportHarness(t, false)
+		h.config.RunTypes.Normal.Draft = true
+		h.config.RunTypes.Normal.MarkReady = true
+		h.expectFullRun(t)
+		h.repository.EXPECT().Push(mock.Anything).Return(nil)
+		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", true).Return(draft, nil)
+		h.vcsProvider.EXPECT().PipelineStatus(anyCtx, draft).Return(codehosting.PipelineFailed, nil)
+
+		require.NoError(t, h.run(t))
+
+		assert.Equal(t, report.StatusSuccess, h.got.Status)
+		require.NotNil(t, h.got.MergeRequest.Draft)
+		assert.False(t, h.got.MergeRequest.Draft.MarkedReady)
+		assert.Equal(t, "the pipeline failed", h.got.MergeRequest.Draft.Error)
+		h.vcsProvider.AssertNotCalled(t, "MarkMergeRequestReady", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a request nothing checks is marked ready after the grace period", func(t *testing.T) {
+		fastReadyPolling(t)
+		h := newReportHarness(t, false)
+		h.config.RunTypes.Normal.Draft = true
+		h.config.RunTypes.Normal.MarkReady = true
+		h.expectFullRun(t)
+		h.repository.EXPECT().Push(mock.Anything).Return(nil)
+		h.vcsProvider.EXPECT().CreateMergeRequest(anyCtx, mock.Anything, mock.Anything, mock.Anything, "main", true).Return(draft, nil)
+		h.vcsProvider.EXPECT().PipelineStatus(anyCtx, draft).Return(codehosting.PipelineNone, nil)
+		h.vcsProvider.EXPECT().MarkMergeRequestReady(anyCtx, draft).Return(nil)
+
+		require.NoError(t, h.run(t))
+
+		assert.True(t, h.got.MergeRequest.Draft.MarkedReady)
+	})
+}
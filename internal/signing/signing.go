@@ -0,0 +1,277 @@
+// Package signing signs the commits of a run with a GPG or SSH key, so the update branch
+// satisfies a host's "require signed commits" rule.
+package signing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Format is the kind of key a Signer signs with.
+type Format string
+
+const (
+	FormatGPG Format = "gpg"
+	FormatSSH Format = "ssh"
+)
+
+// ErrPassphraseRequired is returned for an encrypted key parsed without a passphrase.
+var ErrPassphraseRequired = errors.New("the key is passphrase-protected and no passphrase was given")
+
+// Signer signs a commit as git does, with gpg.format set to the signer's format. It satisfies
+// go-git's Signer.
+type Signer interface {
+	Sign(message io.Reader) ([]byte, error)
+	// Verify checks signature, as Sign returned it, against message and the signer's public key.
+	Verify(message []byte, signature []byte) error
+	Format() Format
+	// Fingerprint identifies the key the way the host lists it: the GPG fingerprint, or the SSH
+	// key's SHA256 one.
+	Fingerprint() string
+	// Identity is the GPG key's primary user ID; empty for an SSH key, which carries none.
+	Identity() string
+}
+
+// Parse reads an armored or binary GPG secret key, or a PEM-encoded SSH private key, decrypting
+// it with passphrase if it is protected. The format is told from the key itself.
+func Parse(key []byte, passphrase []byte) (Signer, error) {
+	key = bytes.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, errors.New("the key is empty")
+	}
+	if bytes.HasPrefix(key, []byte("-----BEGIN PGP")) || !bytes.HasPrefix(key, []byte("-----BEGIN")) {
+		return parseGPG(key, passphrase)
+	}
+	return parseSSH(key, passphrase)
+}
+
+type gpgSigner struct {
+	entity *openpgp.Entity
+}
+
+func parseGPG(key []byte, passphrase []byte) (Signer, error) {
+	var entities openpgp.EntityList
+	var err error
+	if bytes.HasPrefix(key, []byte("-----BEGIN")) {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GPG key: %w", err)
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if len(passphrase) > 0 {
+			if err := entity.DecryptPrivateKeys(passphrase); err != nil {
+				return nil, fmt.Errorf("failed to decrypt GPG key: %w", err)
+			}
+		}
+		signingKey, ok := entity.SigningKey(time.Now())
+		if !ok {
+			return nil, errors.New("the GPG key has no usable signing key: it may be expired or revoked")
+		}
+		if signingKey.PrivateKey == nil {
+			return nil, errors.New("the GPG key's signing subkey has no secret part")
+		}
+		if signingKey.PrivateKey.Encrypted {
+			return nil, ErrPassphraseRequired
+		}
+		return &gpgSigner{entity: entity}, nil
+	}
+	return nil, errors.New("no GPG secret key found: export it with gpg --export-secret-keys --armor")
+}
+
+func (s *gpgSigner) Sign(message io.Reader) ([]byte, error) {
+	var out bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&out, s.entity, message, nil); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (s *gpgSigner) Verify(message []byte, signature []byte) error {
+	_, err := openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{s.entity}, bytes.NewReader(message), bytes.NewReader(signature), nil)
+	return err
+}
+
+func (s *gpgSigner) Format() Format { return FormatGPG }
+
+func (s *gpgSigner) Fingerprint() string {
+	return strings.ToUpper(fmt.Sprintf("%x", s.entity.PrimaryKey.Fingerprint))
+}
+
+func (s *gpgSigner) Identity() string {
+	if identity := s.entity.PrimaryIdentity(); identity != nil {
+		return identity.Name
+	}
+	return ""
+}
+
+// sshNamespace is the namespace git signs commits under; a verifier checks it.
+const sshNamespace = "git"
+
+// sshSignatureMagic opens both the signed data and the signature blob. See
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig.
+const sshSignatureMagic = "SSHSIG"
+
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+func parseSSH(key []byte, passphrase []byte) (Signer, error) {
+	var signer ssh.Signer
+	var err error
+	if len(passphrase) > 0 {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, passphrase)
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, ErrPassphraseRequired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	return &sshSigner{signer: signer}, nil
+}
+
+// Sign returns an armored SSH signature, as ssh-keygen -Y sign writes it.
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	hash := sha512.New()
+	if _, err := io.Copy(hash, message); err != nil {
+		return nil, err
+	}
+	signed := sshSignedData(hash.Sum(nil))
+
+	var sig *ssh.Signature
+	var err error
+	// ssh-rsa signs with SHA-1, which ssh-keygen -Y verify rejects.
+	if algorithmSigner, ok := s.signer.(ssh.AlgorithmSigner); ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = algorithmSigner.SignWithAlgorithm(rand.Reader, signed, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.signer.Sign(rand.Reader, signed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var blob bytes.Buffer
+	blob.WriteString(sshSignatureMagic)
+	_ = binary.Write(&blob, binary.BigEndian, uint32(1))
+	writeSSHString(&blob, s.signer.PublicKey().Marshal())
+	writeSSHString(&blob, []byte(sshNamespace))
+	writeSSHString(&blob, nil)
+	writeSSHString(&blob, []byte("sha512"))
+	writeSSHString(&blob, ssh.Marshal(sig))
+	return armorSSHSignature(blob.Bytes()), nil
+}
+
+func (s *sshSigner) Verify(message []byte, signature []byte) error {
+	blob, err := dearmorSSHSignature(signature)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) || len(blob) < len(sshSignatureMagic)+4 {
+		return errors.New("not an SSH signature")
+	}
+	rest := blob[len(sshSignatureMagic)+4:]
+	var fields [5][]byte
+	for i := range fields {
+		if fields[i], rest, err = readSSHString(rest); err != nil {
+			return fmt.Errorf("malformed SSH signature: %w", err)
+		}
+	}
+	publicKey, namespace, hashAlgorithm, encodedSig := fields[0], fields[1], fields[3], fields[4]
+	if !bytes.Equal(publicKey, s.signer.PublicKey().Marshal()) {
+		return errors.New("the signature was made with another key")
+	}
+	if string(namespace) != sshNamespace || string(hashAlgorithm) != "sha512" {
+		return fmt.Errorf("unexpected namespace %q or hash %q", namespace, hashAlgorithm)
+	}
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(encodedSig, &sig); err != nil {
+		return fmt.Errorf("malformed SSH signature: %w", err)
+	}
+	digest := sha512.Sum512(message)
+	return s.signer.PublicKey().Verify(sshSignedData(digest[:]), &sig)
+}
+
+func (s *sshSigner) Format() Format { return FormatSSH }
+
+func (s *sshSigner) Fingerprint() string { return ssh.FingerprintSHA256(s.signer.PublicKey()) }
+
+func (s *sshSigner) Identity() string { return "" }
+
+// sshSignedData is what an SSH signature actually signs: the message's digest, framed so it
+// cannot be mistaken for an authentication challenge.
+func sshSignedData(digest []byte) []byte {
+	var data bytes.Buffer
+	data.WriteString(sshSignatureMagic)
+	writeSSHString(&data, []byte(sshNamespace))
+	writeSSHString(&data, nil)
+	writeSSHString(&data, []byte("sha512"))
+	writeSSHString(&data, digest)
+	return data.Bytes()
+}
+
+func writeSSHString(w *bytes.Buffer, s []byte) {
+	_ = binary.Write(w, binary.BigEndian, uint32(len(s)))
+	w.Write(s)
+}
+
+func readSSHString(data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	n := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(n) {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[4 : 4+n], data[4+n:], nil
+}
+
+const (
+	sshArmorBegin = "-----BEGIN SSH SIGNATURE-----"
+	sshArmorEnd   = "-----END SSH SIGNATURE-----"
+)
+
+// armorSSHSignature wraps blob at 70 columns, as ssh-keygen does.
+func armorSSHSignature(blob []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	var out strings.Builder
+	out.WriteString(sshArmorBegin + "\n")
+	for len(encoded) > 70 {
+		out.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	out.WriteString(encoded + "\n" + sshArmorEnd + "\n")
+	return []byte(out.String())
+}
+
+func dearmorSSHSignature(armored []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(armored))
+	body, ok := strings.CutPrefix(text, sshArmorBegin)
+	if ok {
+		body, ok = strings.CutSuffix(body, sshArmorEnd)
+	}
+	if !ok {
+		return nil, errors.New("not an armored SSH signature")
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+}
@@ -0,0 +1,140 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func sshKey(t *testing.T, key any, passphrase string) []byte {
+	t.Helper()
+	var block *pem.Block
+	var err error
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(key, "bot@acme.example", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(key, "bot@acme.example")
+	}
+	require.NoError(t, err)
+	return pem.EncodeToMemory(block)
+}
+
+func gpgKey(t *testing.T, passphrase string) []byte {
+	t.Helper()
+	entity, err := openpgp.NewEntity("ACME Bot", "", "bot@acme.example", nil)
+	require.NoError(t, err)
+	if passphrase != "" {
+		require.NoError(t, entity.EncryptPrivateKeys([]byte(passphrase), nil))
+	}
+	var out bytes.Buffer
+	w, err := armor.Encode(&out, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	require.NoError(t, w.Close())
+	return out.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		key        []byte
+		passphrase string
+		format     Format
+	}{
+		"GPG":                       {key: gpgKey(t, ""), format: FormatGPG},
+		"GPG with a passphrase":     {key: gpgKey(t, "s3cret"), passphrase: "s3cret", format: FormatGPG},
+		"SSH ed25519":               {key: sshKey(t, ed25519Key, ""), format: FormatSSH},
+		"SSH RSA":                   {key: sshKey(t, rsaKey, ""), format: FormatSSH},
+		"SSH with a passphrase":     {key: sshKey(t, ed25519Key, "s3cret"), passphrase: "s3cret", format: FormatSSH},
+		"surrounded by blank lines": {key: append(append([]byte("\n"), gpgKey(t, "")...), '\n'), format: FormatGPG},
+	} {
+		t.Run(name+" signs what it verifies", func(t *testing.T) {
+			signer, err := Parse(tc.key, []byte(tc.passphrase))
+			require.NoError(t, err)
+			assert.Equal(t, tc.format, signer.Format())
+			assert.NotEmpty(t, signer.Fingerprint())
+
+			message := []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nUpdate composer.json and composer.lock\n")
+			signature, err := signer.Sign(bytes.NewReader(message))
+			require.NoError(t, err)
+			require.NoError(t, signer.Verify(message, signature))
+			assert.Error(t, signer.Verify([]byte("tampered"), signature))
+		})
+	}
+
+	t.Run("a GPG key names its identity", func(t *testing.T) {
+		signer, err := Parse(gpgKey(t, ""), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "ACME Bot <bot@acme.example>", signer.Identity())
+	})
+
+	t.Run("an SSH signature is armored as ssh-keygen writes it", func(t *testing.T) {
+		signer, err := Parse(sshKey(t, ed25519Key, ""), nil)
+		require.NoError(t, err)
+		signature, err := signer.Sign(strings.NewReader("message"))
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+		assert.Equal(t, "-----BEGIN SSH SIGNATURE-----", lines[0])
+		assert.Equal(t, "-----END SSH SIGNATURE-----", lines[len(lines)-1])
+		for _, line := range lines[1 : len(lines)-1] {
+			assert.LessOrEqual(t, len(line), 70)
+		}
+	})
+
+	t.Run("an encrypted key without its passphrase is an error", func(t *testing.T) {
+		_, err := Parse(gpgKey(t, "s3cret"), nil)
+		require.ErrorIs(t, err, ErrPassphraseRequired)
+		_, err = Parse(sshKey(t, ed25519Key, "s3cret"), nil)
+		require.ErrorIs(t, err, ErrPassphraseRequired)
+	})
+
+	t.Run("a wrong passphrase is an error", func(t *testing.T) {
+		_, err := Parse(gpgKey(t, "s3cret"), []byte("guess"))
+		require.ErrorContains(t, err, "failed to decrypt GPG key")
+		_, err = Parse(sshKey(t, ed25519Key, "s3cret"), []byte("guess"))
+		require.ErrorContains(t, err, "failed to read SSH key")
+	})
+
+	t.Run("what is no private key is an error", func(t *testing.T) {
+		public, err := ssh.NewPublicKey(ed25519Key.Public())
+		require.NoError(t, err)
+		for key, want := range map[string]string{
+			"":                                       "the key is empty",
+			string(ssh.MarshalAuthorizedKey(public)): "failed to read GPG key",
+			"-----BEGIN CERTIFICATE-----\nMA==\n-----END CERTIFICATE-----\n": "failed to read SSH key",
+		} {
+			_, err := Parse([]byte(key), nil)
+			require.ErrorContains(t, err, want, key)
+		}
+	})
+}
+
+func TestSSHVerifyRejectsAnotherKeysSignature(t *testing.T) {
+	_, first, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, second, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := Parse(sshKey(t, first, ""), nil)
+	require.NoError(t, err)
+	other, err := Parse(sshKey(t, second, ""), nil)
+	require.NoError(t, err)
+
+	signature, err := other.Sign(strings.NewReader("message"))
+	require.NoError(t, err)
+
+	require.ErrorContains(t, signer.Verify([]byte("message"), signature), "another key")
+}
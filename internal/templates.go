@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/template"
+)
+
+// TemplateFuncs are the helpers every merge request template can call, embedded or the project's.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"cell": cellReplacer.Replace,
+	}
+}
+
+// MergeRequestTemplates points .drupdater.yaml at the project's own merge request templates.
+// Whatever is left empty keeps the embedded template.
+type MergeRequestTemplates struct {
+	// Title is a one-line template itself; the rest are paths, relative to .drupdater.yaml.
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	// Addons maps an addon name to the template that replaces its section.
+	Addons map[string]string `yaml:"addons,omitempty"`
+}
+
+// IsZero reports whether t overrides nothing.
+func (t MergeRequestTemplates) IsZero() bool {
+	return t.Title == "" && t.Description == "" && len(t.Addons) == 0
+}
+
+// resolve makes the template paths absolute, relative to dir, so they do not depend on where
+// drupdater runs from.
+func (t MergeRequestTemplates) resolve(dir string) MergeRequestTemplates {
+	join := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	t.Description = join(t.Description)
+	if t.Addons != nil {
+		addons := make(map[string]string, len(t.Addons))
+		for name, path := range t.Addons {
+			addons[name] = join(path)
+		}
+		t.Addons = addons
+	}
+	return t
+}
+
+// ProjectTemplates are a project's merge request templates, parsed. A nil template keeps the
+// embedded one.
+type ProjectTemplates struct {
+	Title       *template.Template
+	Description *template.Template
+	Addons      map[string]*template.Template
+}
+
+// ParseTemplates reads and parses the templates t points to, with TemplateFuncs. An error names
+// the key the broken template came from.
+func ParseTemplates(t MergeRequestTemplates) (ProjectTemplates, error) {
+	var parsed ProjectTemplates
+	var err error
+
+	if t.Title != "" {
+		parsed.Title, err = template.New("title").Funcs(TemplateFuncs()).Parse(t.Title)
+		if err != nil {
+			return ProjectTemplates{}, fmt.Errorf("templates.title: %w", err)
+		}
+	}
+	if t.Description != "" {
+		parsed.Description, err = parseTemplateFile("description", t.Description)
+		if err != nil {
+			return ProjectTemplates{}, fmt.Errorf("templates.description: %w", err)
+		}
+	}
+	// Sorted, so the same broken file is the one reported on every run.
+	for _, name := range slices.Sorted(maps.Keys(t.Addons)) {
+		tmpl, err := parseTemplateFile(name, t.Addons[name])
+		if err != nil {
+			return ProjectTemplates{}, fmt.Errorf("templates.addons.%s: %w", name, err)
+		}
+		if parsed.Addons == nil {
+			parsed.Addons = map[string]*template.Template{}
+		}
+		parsed.Addons[name] = tmpl
+	}
+	return parsed, nil
+}
+
+func parseTemplateFile(name string, path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(name).Funcs(TemplateFuncs()).Parse(string(data))
+}
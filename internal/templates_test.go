@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, body string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+		return path
+	}
+
+	t.Run("nothing configured keeps every embedded template", func(t *testing.T) {
+		parsed, err := ParseTemplates(MergeRequestTemplates{})
+		require.NoError(t, err)
+		assert.Equal(t, ProjectTemplates{}, parsed)
+	})
+
+	t.Run("parses each template with the cell helper", func(t *testing.T) {
+		parsed, err := ParseTemplates(MergeRequestTemplates{
+			Title:       "{{ .Title }}",
+			Description: write("description.md.tmpl", "Deploy notes: {{ cell . }}"),
+			Addons:      map[string]string{"composer_diff": write("composer_diff.md.tmpl", "| {{ cell . }} |")},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, parsed.Title)
+
+		var out bytes.Buffer
+		require.NoError(t, parsed.Description.Execute(&out, "a|b"))
+		assert.Equal(t, `Deploy notes: a\|b`, out.String())
+		assert.Contains(t, parsed.Addons, "composer_diff")
+	})
+
+	t.Run("a missing file names its key", func(t *testing.T) {
+		_, err := ParseTemplates(MergeRequestTemplates{Addons: map[string]string{"update_hooks": filepath.Join(dir, "absent.md.tmpl")}})
+		require.ErrorContains(t, err, "templates.addons.update_hooks:")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("a syntax error names its key", func(t *testing.T) {
+		_, err := ParseTemplates(MergeRequestTemplates{Description: write("broken.md.tmpl", "{{ range .Addons }}")})
+		require.ErrorContains(t, err, "templates.description:")
+
+		_, err = ParseTemplates(MergeRequestTemplates{Title: "{{ .Title"})
+		require.ErrorContains(t, err, "templates.title:")
+	})
+}
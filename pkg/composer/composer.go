@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"maps"
@@ -14,6 +16,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/afero"
 	"go.uber.org/zap"
@@ -135,12 +138,17 @@ func (s *CLI) Update(ctx context.Context, dir string, packages []string, package
 	} else {
 		args = append(args, "--bump-after-update")
 	}
-	var changes []PackageChange
 	out, err := s.execComposer(ctx, dir, args...)
 	if err != nil {
-		return changes, fmt.Errorf("failed to update dependencies: %w, output: %s, arg: %v", err, out, args)
+		return nil, fmt.Errorf("failed to update dependencies: %w, output: %s, arg: %v", err, out, args)
 	}
 
+	return parsePackageChanges(out), nil
+}
+
+// parsePackageChanges reads what an update or require did from composer's output.
+func parsePackageChanges(out string) []PackageChange {
+	var changes []PackageChange
 	// Grouped by action, not scanned line by line, so the result stays ordered however composer
 	// interleaved its output. Deduplicated because composer reports a version change twice, once
 	// for the lock and once for the install, in identical wording.
@@ -162,7 +170,65 @@ func (s *CLI) Update(ctx context.Context, dir string, packages []string, package
 		}
 	}
 
-	return changes, nil
+	return changes
+}
+
+// UpgradeError is a raised constraint composer could not resolve. RequireUpgrade puts
+// composer.json back, and composer never wrote composer.lock, so the project is left as it was.
+type UpgradeError struct {
+	Package    string
+	Constraint string
+	// Problem is composer's explanation of the first conflict: the rest usually follow from it.
+	Problem string
+	Err     error
+}
+
+func (e *UpgradeError) Error() string {
+	return fmt.Sprintf("failed to upgrade %s to %s: %s", e.Package, e.Constraint, e.Problem)
+}
+
+func (e *UpgradeError) Unwrap() error {
+	return e.Err
+}
+
+// RequireUpgrade raises pkg's constraint in composer.json and updates it together with its
+// dependencies, as a major upgrade needs, each packagesToKeep entry holding through --with. Two
+// steps rather than composer require -W, which has no --with: the dependencies it moves could
+// otherwise pass any pin.
+func (s *CLI) RequireUpgrade(ctx context.Context, dir string, pkg string, constraint string, packagesToKeep []string) ([]PackageChange, error) {
+	composerJSON, err := afero.ReadFile(s.fs, dir+"/composer.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read composer.json: %w", err)
+	}
+
+	out, err := s.execComposer(ctx, dir, "require", "--no-update", "--no-interaction", "--no-ansi", pkg+":"+constraint)
+	if err == nil {
+		args := []string{"update", pkg, "--with-all-dependencies", "--no-interaction", "--no-progress",
+			"--optimize-autoloader", "--no-ansi", "--ignore-platform-reqs"}
+		for _, packageToKeep := range packagesToKeep {
+			args = append(args, fmt.Sprintf("--with=%s", packageToKeep))
+		}
+		out, err = s.execComposer(ctx, dir, args...)
+	}
+	if err != nil {
+		if restoreErr := afero.WriteFile(s.fs, dir+"/composer.json", composerJSON, 0644); restoreErr != nil {
+			return nil, fmt.Errorf("failed to restore composer.json after a failed upgrade of %s: %w", pkg, restoreErr)
+		}
+		return nil, &UpgradeError{Package: pkg, Constraint: constraint, Problem: firstProblem(out), Err: err}
+	}
+	return parsePackageChanges(out), nil
+}
+
+// firstProblem returns the first line of composer's "Problem 1" block, or the last line of
+// output when composer failed some other way.
+func firstProblem(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "Problem 1" && i+1 < len(lines) {
+			return strings.TrimPrefix(strings.TrimSpace(lines[i+1]), "- ")
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
 }
 
 // packageChangePatterns matches composer update's report of what it did, one entry per action.
@@ -539,6 +605,136 @@ func (s *CLI) GetDependencyPatches(_ context.Context, dir string) (map[string]ma
 	return result, nil
 }
 
+// GetRequiredPackages returns the packages composer.json requires, dev ones included, sorted.
+// Platform requirements (php, ext-*, lib-*, composer-*-api) are left out: there is nothing to
+// update about them.
+func (s *CLI) GetRequiredPackages(dir string) ([]string, error) {
+	content, err := afero.ReadFile(s.fs, dir+"/composer.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read composer.json: %w", err)
+	}
+
+	var manifest struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal composer.json: %w", err)
+	}
+
+	var packages []string
+	for _, requires := range []map[string]string{manifest.Require, manifest.RequireDev} {
+		for name := range requires {
+			if isPlatformPackage(name) || slices.Contains(packages, name) {
+				continue
+			}
+			packages = append(packages, name)
+		}
+	}
+	slices.Sort(packages)
+	return packages, nil
+}
+
+// LockedPackage is one package as composer.lock pins it.
+type LockedPackage struct {
+	Name    string
+	Version string
+}
+
+// GetLockedPackages returns every package composer.lock pins, dev ones included, in lock order.
+func (s *CLI) GetLockedPackages(dir string) ([]LockedPackage, error) {
+	content, err := afero.ReadFile(s.fs, dir+"/composer.lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read composer.lock: %w", err)
+	}
+
+	type entry struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	var lock struct {
+		Packages    []entry `json:"packages"`
+		PackagesDev []entry `json:"packages-dev"`
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal composer.lock: %w", err)
+	}
+
+	packages := make([]LockedPackage, 0, len(lock.Packages)+len(lock.PackagesDev))
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		packages = append(packages, LockedPackage{Name: p.Name, Version: p.Version})
+	}
+	return packages, nil
+}
+
+// OutdatedPackage is one row of `composer outdated`. Latest is the newest release whatever
+// composer.json allows; LatestStatus says whether it does: "semver-safe-update" when the
+// constraints admit it, "update-possible" when they do not.
+type OutdatedPackage struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Latest       string `json:"latest"`
+	LatestStatus string `json:"latest-status"`
+}
+
+// Outdated lists every locked package with a newer release, dependencies included. It reads
+// composer.lock rather than vendor, so it answers for the lock an update starts from.
+func (s *CLI) Outdated(ctx context.Context, dir string) ([]OutdatedPackage, error) {
+	return s.outdated(ctx, dir)
+}
+
+// OutdatedMajor lists the packages composer.json requires that have a newer major release:
+// the ones only a raised constraint can update.
+func (s *CLI) OutdatedMajor(ctx context.Context, dir string) ([]OutdatedPackage, error) {
+	return s.outdated(ctx, dir, "--major-only", "--direct")
+}
+
+func (s *CLI) outdated(ctx context.Context, dir string, filters ...string) ([]OutdatedPackage, error) {
+	args := append([]string{"outdated", "--locked", "--format=json", "--no-ansi"}, filters...)
+	out, err := s.execComposerJSON(ctx, dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outdated packages: %w", err)
+	}
+
+	var outdated struct {
+		Locked []OutdatedPackage `json:"locked"`
+	}
+	if err := json.Unmarshal([]byte(out), &outdated); err != nil {
+		return nil, fmt.Errorf("failed to parse composer outdated output: %w, output: %s", err, out)
+	}
+	return outdated.Locked, nil
+}
+
+// ReleaseDate returns when version of pkg was released, as the project's repositories publish
+// it — Packagist's metadata, or the Drupal.org facade's. Zero when the repository does not say.
+func (s *CLI) ReleaseDate(ctx context.Context, dir string, pkg string, version string) (time.Time, error) {
+	out, err := s.execComposerJSON(ctx, dir, "show", "--all", "--format=json", "--no-ansi", pkg, version)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to look up %s %s: %w", pkg, version, err)
+	}
+
+	var info struct {
+		Released string `json:"released"`
+	}
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse composer show output: %w, output: %s", err, out)
+	}
+	if info.Released == "" {
+		return time.Time{}, nil
+	}
+	released, err := time.Parse(time.RFC3339, info.Released)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse release date of %s %s: %w", pkg, version, err)
+	}
+	return released, nil
+}
+
+// isPlatformPackage reports a requirement composer resolves against the environment, not a
+// repository. Package names always carry a vendor; these never do.
+func isPlatformPackage(name string) bool {
+	return !strings.Contains(name, "/")
+}
+
 type lockPackage struct {
 	Extra json.RawMessage `json:"extra"`
 }
@@ -875,6 +1071,42 @@ func (s *CLI) GetLockHash(dir string) (string, error) {
 	return composerLock.ContentHash, nil
 }
 
+// GetLockedPackagesHash digests what composer.lock installs: every package's name, version and
+// source and dist references, so a dev branch that moved counts too. Unlike the content-hash,
+// which Composer derives from composer.json alone, it changes whenever an update moves anything.
+func (s *CLI) GetLockedPackagesHash(dir string) (string, error) {
+	content, err := afero.ReadFile(s.fs, dir+"/composer.lock")
+	if err != nil {
+		return "", fmt.Errorf("failed to read composer.lock: %w", err)
+	}
+
+	type reference struct {
+		Reference string `json:"reference"`
+	}
+	type entry struct {
+		Name    string    `json:"name"`
+		Version string    `json:"version"`
+		Source  reference `json:"source"`
+		Dist    reference `json:"dist"`
+	}
+	var lock struct {
+		Packages    []entry `json:"packages"`
+		PackagesDev []entry `json:"packages-dev"`
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return "", fmt.Errorf("failed to unmarshal composer.lock: %w", err)
+	}
+
+	lines := make([]string, 0, len(lock.Packages)+len(lock.PackagesDev))
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		lines = append(lines, strings.Join([]string{p.Name, p.Version, p.Source.Reference, p.Dist.Reference}, " "))
+	}
+	// Lock order is Composer's business; the same packages are the same set.
+	slices.Sort(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func (s *CLI) UpdateLockHash(ctx context.Context, dir string) error {
 	_, err := s.execComposer(ctx, dir, "update", "--lock", "--no-install", "--ignore-platform-reqs")
 	return err
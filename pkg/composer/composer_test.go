@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -1191,3 +1192,250 @@ func TestGetDependencyPatches(t *testing.T) {
 		assert.Empty(t, patches)
 	})
 }
+
+func TestGetRequiredPackages(t *testing.T) {
+	data := `{
+		"require": {
+			"php": ">=8.1",
+			"ext-gd": "*",
+			"composer/installers": "^2.0",
+			"drupal/core-recommended": "^10.2",
+			"drupal/admin_toolbar": "^3.4"
+		},
+		"require-dev": {
+			"drupal/core-dev": "^10.2",
+			"composer-plugin-api": "^2.0"
+		}
+	}`
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/test/composer.json", []byte(data), 0644))
+
+	service := &CLI{logger: zap.NewNop(), fs: fs}
+	packages, err := service.GetRequiredPackages("/test")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"composer/installers", "drupal/admin_toolbar", "drupal/core-dev", "drupal/core-recommended"}, packages)
+
+	t.Run("error when composer.json is missing", func(t *testing.T) {
+		service := &CLI{logger: zap.NewNop(), fs: afero.NewMemMapFs()}
+		_, err := service.GetRequiredPackages("/missing")
+		require.ErrorContains(t, err, "failed to read composer.json")
+	})
+}
+
+func TestGetLockedPackagesHash(t *testing.T) {
+	hash := func(t *testing.T, lock string) string {
+		t.Helper()
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/test/composer.lock", []byte(lock), 0644))
+		got, err := (&CLI{logger: zap.NewNop(), fs: fs}).GetLockedPackagesHash("/test")
+		require.NoError(t, err)
+		return got
+	}
+	base := hash(t, `{"content-hash": "abc", "packages": [
+		{"name": "drupal/core", "version": "10.3.8"},
+		{"name": "drupal/token", "version": "dev-1.x", "source": {"reference": "aaa"}}
+	]}`)
+
+	// The content-hash follows composer.json; only what is installed counts.
+	assert.Equal(t, base, hash(t, `{"content-hash": "def", "packages": [
+		{"name": "drupal/token", "version": "dev-1.x", "source": {"reference": "aaa"}},
+		{"name": "drupal/core", "version": "10.3.8"}
+	]}`))
+	assert.NotEqual(t, base, hash(t, `{"content-hash": "abc", "packages": [
+		{"name": "drupal/core", "version": "10.3.9"},
+		{"name": "drupal/token", "version": "dev-1.x", "source": {"reference": "aaa"}}
+	]}`), "a new version")
+	assert.NotEqual(t, base, hash(t, `{"content-hash": "abc", "packages": [
+		{"name": "drupal/core", "version": "10.3.8"},
+		{"name": "drupal/token", "version": "dev-1.x", "source": {"reference": "bbb"}}
+	]}`), "a dev branch that moved")
+
+	t.Run("error when composer.lock is missing", func(t *testing.T) {
+		service := &CLI{logger: zap.NewNop(), fs: afero.NewMemMapFs()}
+		_, err := service.GetLockedPackagesHash("/missing")
+		require.ErrorContains(t, err, "failed to read composer.lock")
+	})
+}
+
+func TestGetLockedPackages(t *testing.T) {
+	data := `{
+		"content-hash": "abc",
+		"packages": [
+			{"name": "drupal/core", "version": "10.3.8"},
+			{"name": "drupal/token", "version": "1.15.0"}
+		],
+		"packages-dev": [
+			{"name": "drupal/core-dev", "version": "10.3.8"}
+		]
+	}`
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/test/composer.lock", []byte(data), 0644))
+
+	service := &CLI{logger: zap.NewNop(), fs: fs}
+	packages, err := service.GetLockedPackages("/test")
+
+	require.NoError(t, err)
+	assert.Equal(t, []LockedPackage{
+		{Name: "drupal/core", Version: "10.3.8"},
+		{Name: "drupal/token", Version: "1.15.0"},
+		{Name: "drupal/core-dev", Version: "10.3.8"},
+	}, packages)
+
+	t.Run("error when composer.lock is missing", func(t *testing.T) {
+		service := &CLI{logger: zap.NewNop(), fs: afero.NewMemMapFs()}
+		_, err := service.GetLockedPackages("/missing")
+		require.ErrorContains(t, err, "failed to read composer.lock")
+	})
+}
+
+func TestOutdated(t *testing.T) {
+	service := &CLI{logger: zap.NewNop()}
+
+	t.Run("parses the locked list", func(t *testing.T) {
+		data := `{"locked":[{"name":"drupal/token","direct-dependency":true,"version":"1.14.0","latest":"1.15.0","latest-status":"semver-safe-update"}]}`
+
+		execCommand = func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+			cs := []string{"-test.run=TestHelperProcess", "--", data}
+			cs = append(cs, arg...)
+			cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GOCOVERDIR=/tmp"}
+			return cmd
+		}
+		defer func() { execCommand = exec.CommandContext }()
+
+		outdated, err := service.Outdated(t.Context(), "/tmp")
+		require.NoError(t, err)
+		assert.Equal(t, []OutdatedPackage{{Name: "drupal/token", Version: "1.14.0", Latest: "1.15.0", LatestStatus: "semver-safe-update"}}, outdated)
+	})
+
+	t.Run("returns an error on unparsable output", func(t *testing.T) {
+		execCommand = func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+			cs := []string{"-test.run=TestHelperProcess", "--", "not json"}
+			cs = append(cs, arg...)
+			cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GOCOVERDIR=/tmp"}
+			return cmd
+		}
+		defer func() { execCommand = exec.CommandContext }()
+
+		_, err := service.Outdated(t.Context(), "/tmp")
+		require.ErrorContains(t, err, "failed to parse composer outdated output")
+	})
+}
+
+func TestOutdatedMajor(t *testing.T) {
+	service := &CLI{logger: zap.NewNop()}
+	data := `{"locked":[{"name":"drupal/paragraphs","direct-dependency":true,"version":"1.17.0","latest":"2.0.1","latest-status":"update-possible"}]}`
+
+	var args []string
+	execCommand = func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+		args = arg
+		cs := append([]string{"-test.run=TestHelperProcess", "--", data}, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GOCOVERDIR=/tmp"}
+		return cmd
+	}
+	defer func() { execCommand = exec.CommandContext }()
+
+	outdated, err := service.OutdatedMajor(t.Context(), "/tmp")
+	require.NoError(t, err)
+	assert.Equal(t, []OutdatedPackage{{Name: "drupal/paragraphs", Version: "1.17.0", Latest: "2.0.1", LatestStatus: "update-possible"}}, outdated)
+	// Only direct requirements: a transitive package has no constraint in composer.json to raise.
+	assert.Contains(t, args, "--major-only")
+	assert.Contains(t, args, "--direct")
+}
+
+func TestRequireUpgrade(t *testing.T) {
+	composerJSON := `{"require":{"drupal/paragraphs":"^1.17"}}`
+	newService := func(t *testing.T) (*CLI, afero.Fs) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/tmp/composer.json", []byte(composerJSON), 0644))
+		return &CLI{logger: zap.NewNop(), fs: fs}, fs
+	}
+
+	t.Run("returns what composer changed, every pin holding through the update", func(t *testing.T) {
+		service, _ := newService(t)
+		out := "- Upgrading drupal/paragraphs (1.17.0 => 2.0.1)\n- Installing drupal/entity_reference_revisions (1.12.0)"
+		var calls [][]string
+		execCommand = func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+			calls = append(calls, arg)
+			cs := append([]string{"-test.run=TestHelperProcess", "--", out}, arg...)
+			cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GOCOVERDIR=/tmp"}
+			return cmd
+		}
+		defer func() { execCommand = exec.CommandContext }()
+
+		changes, err := service.RequireUpgrade(t.Context(), "/tmp", "drupal/paragraphs", "^2.0", []string{"drupal/entity_reference_revisions:1.11.0"})
+		require.NoError(t, err)
+		assert.Equal(t, []PackageChange{
+			{Action: "Upgrade", Package: "drupal/paragraphs", From: "1.17.0", To: "2.0.1"},
+			{Action: "Install", Package: "drupal/entity_reference_revisions", To: "1.12.0"},
+		}, changes)
+
+		// The constraint is written without resolving, so the update that follows is the one
+		// place dependencies move, and it carries the pins.
+		require.Len(t, calls, 2)
+		assert.Equal(t, "require", calls[0][0])
+		assert.Contains(t, calls[0], "--no-update")
+		assert.Contains(t, calls[0], "drupal/paragraphs:^2.0")
+		assert.Equal(t, []string{"update", "drupal/paragraphs", "--with-all-dependencies"}, calls[1][:3])
+		assert.Contains(t, calls[1], "--with=drupal/entity_reference_revisions:1.11.0")
+	})
+
+	t.Run("an unresolvable constraint carries composer's first problem and puts composer.json back", func(t *testing.T) {
+		service, fs := newService(t)
+		out := "Your requirements could not be resolved to an installable set of packages.\n\n  Problem 1\n    - drupal/paragraphs 2.0.1 requires drupal/core ^11 -> found drupal/core[11.0.0] but it conflicts with your root composer.json require (^10.3).\n  Problem 2\n    - another one"
+		execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+			cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+			cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GOCOVERDIR=/tmp"}
+			if arg[0] == "update" {
+				cmd.Env = append(cmd.Env, "GO_HELPER_PROCESS_ERROR=1", "GO_HELPER_PROCESS_OUTPUT="+out)
+			} else {
+				// Stands in for what composer require --no-update writes.
+				require.NoError(t, afero.WriteFile(fs, "/tmp/composer.json", []byte(`{"require":{"drupal/paragraphs":"^2.0"}}`), 0644))
+			}
+			return cmd
+		}
+		defer func() { execCommand = exec.CommandContext }()
+
+		_, err := service.RequireUpgrade(t.Context(), "/tmp", "drupal/paragraphs", "^2.0", nil)
+		var upgradeErr *UpgradeError
+		require.ErrorAs(t, err, &upgradeErr)
+		assert.Equal(t, "drupal/paragraphs 2.0.1 requires drupal/core ^11 -> found drupal/core[11.0.0] but it conflicts with your root composer.json require (^10.3).", upgradeErr.Problem)
+
+		restored, err := afero.ReadFile(fs, "/tmp/composer.json")
+		require.NoError(t, err)
+		assert.Equal(t, composerJSON, string(restored))
+	})
+}
+
+func TestReleaseDate(t *testing.T) {
+	service := &CLI{logger: zap.NewNop()}
+
+	for name, tc := range map[string]struct {
+		output string
+		want   time.Time
+	}{
+		"reads the released field":           {`{"name":"drupal/token","versions":["1.15.0"],"released":"2026-05-28T14:02:11+00:00"}`, time.Date(2026, time.May, 28, 14, 2, 11, 0, time.UTC)},
+		"a repository without dates is zero": {`{"name":"acme/private","versions":["1.0.0"]}`, time.Time{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			execCommand = func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+				cs := append([]string{"-test.run=TestHelperProcess", "--", tc.output}, arg...)
+				cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+				cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GOCOVERDIR=/tmp"}
+				return cmd
+			}
+			defer func() { execCommand = exec.CommandContext }()
+
+			released, err := service.ReleaseDate(t.Context(), "/tmp", "drupal/token", "1.15.0")
+			require.NoError(t, err)
+			assert.True(t, tc.want.Equal(released), "got %s", released)
+		})
+	}
+}
@@ -3,9 +3,11 @@ package composer
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os/exec"
 	"strings"
 
+	"github.com/drupdater/drupdater/pkg/tracing"
 	"go.uber.org/zap"
 )
 
@@ -31,8 +33,38 @@ func (c Command) build(ctx context.Context, args ...string) *exec.Cmd {
 	return command
 }
 
+// trace starts the span of one invocation, named after the composer command, or the tool for
+// `composer exec`: "composer update", "composer exec drush".
+func (c Command) trace(ctx context.Context, args []string) (context.Context, *tracing.Span) {
+	name := "composer"
+	if len(args) > 0 {
+		name += " " + args[0]
+	}
+	if len(args) > 1 && args[0] == "exec" {
+		name += " " + args[1]
+	}
+	return tracing.Start(ctx, name,
+		tracing.String("process.command_line", "composer "+strings.Join(args, " ")),
+		tracing.String("process.working_directory", c.Dir),
+	)
+}
+
+// endTrace records how the invocation ended. An exit code is only known once the process ran.
+func endTrace(span *tracing.Span, err error) {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		span.SetAttributes(tracing.Int("process.exit_code", 0))
+	case errors.As(err, &exitErr):
+		span.SetAttributes(tracing.Int("process.exit_code", exitErr.ExitCode()))
+	}
+	span.SetError(err)
+	span.End()
+}
+
 // Combined merges stdout and stderr, and strips the output's trailing newline.
 func (c Command) Combined(ctx context.Context, args ...string) (string, error) {
+	ctx, span := c.trace(ctx, args)
 	command := c.build(ctx, args...)
 
 	// Hand-rolled because CombinedOutput refuses a command whose Stdout is already set.
@@ -40,6 +72,7 @@ func (c Command) Combined(ctx context.Context, args ...string) (string, error) {
 	command.Stdout = &merged
 	command.Stderr = &merged
 	err := command.Run()
+	endTrace(span, err)
 
 	output := strings.TrimSuffix(merged.String(), "\n")
 	c.Logger.Debug(command.String() + "\n" + output)
@@ -49,12 +82,14 @@ func (c Command) Combined(ctx context.Context, args ...string) (string, error) {
 
 // Split keeps the streams apart, so a PHP notice on stderr cannot corrupt a JSON payload.
 func (c Command) Split(ctx context.Context, args ...string) (stdout string, stderr string, err error) {
+	ctx, span := c.trace(ctx, args)
 	command := c.build(ctx, args...)
 
 	var so, se bytes.Buffer
 	command.Stdout = &so
 	command.Stderr = &se
 	err = command.Run()
+	endTrace(span, err)
 
 	stdout = strings.TrimSuffix(so.String(), "\n")
 	stderr = strings.TrimSuffix(se.String(), "\n")
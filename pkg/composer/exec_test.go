@@ -2,10 +2,12 @@ package composer
 
 import (
 	"context"
+	"encoding/json"
 	"os/exec"
 	"path/filepath"
 	"testing"
 
+	"github.com/drupdater/drupdater/pkg/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -162,3 +164,45 @@ func TestCommandSplit(t *testing.T) {
 		assert.Equal(t, "e", stderr)
 	})
 }
+
+func TestCommandTracesEachInvocation(t *testing.T) {
+	tracer := tracing.New("dev", nil)
+	ctx := tracing.WithTracer(t.Context(), tracer)
+	var seen []string
+
+	_, err := Command{New: shellCommand("exit 3", &seen), Logger: zap.NewNop()}.Combined(ctx, "exec", "drush", "updatedb")
+	require.Error(t, err)
+	_, _, err = Command{New: shellCommand("true", &seen), Logger: zap.NewNop()}.Split(ctx, "audit", "--format=json")
+	require.NoError(t, err)
+
+	payload, err := tracer.Encode()
+	require.NoError(t, err)
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name       string `json:"name"`
+					Attributes []struct {
+						Key   string `json:"key"`
+						Value struct {
+							IntValue string `json:"intValue"`
+						} `json:"value"`
+					} `json:"attributes"`
+					Status struct {
+						Code int `json:"code"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &request))
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "composer exec drush", spans[0].Name)
+	assert.Equal(t, 2, spans[0].Status.Code)
+	assert.Equal(t, "process.exit_code", spans[0].Attributes[2].Key)
+	assert.Equal(t, "3", spans[0].Attributes[2].Value.IntValue)
+	assert.Equal(t, "composer audit", spans[1].Name)
+	assert.Equal(t, "0", spans[1].Attributes[2].Value.IntValue)
+}
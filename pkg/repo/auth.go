@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// defaultSSHUser is who an SSH URL without a user connects as: every supported host serves git
+// over SSH as "git".
+const defaultSSHUser = "git"
+
+// SSHOptions configure the SSH transport, for a repository URL like git@host:owner/repo.git.
+type SSHOptions struct {
+	// KeyFile is the private key to authenticate with; empty uses the keys ssh-agent holds.
+	KeyFile string
+	// Passphrase unlocks KeyFile, if it is protected.
+	Passphrase string
+	// KnownHosts is the known_hosts file the host's key is verified against; empty reads
+	// SSH_KNOWN_HOSTS, else ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts.
+	KnownHosts string
+}
+
+// IsSSHURL reports whether go-git reaches repositoryURL over SSH: ssh://, or the scp-like
+// user@host:path.
+func IsSSHURL(repositoryURL string) bool {
+	endpoint, err := transport.NewEndpoint(repositoryURL)
+	return err == nil && endpoint.Protocol == "ssh"
+}
+
+// Auth is the credential go-git needs to clone, list or push repositoryURL: SSH for an SSH URL,
+// the token as username's otherwise. The token never goes to an SSH remote; it stays for the
+// platform's API.
+func Auth(repositoryURL string, username string, token string, opts SSHOptions) (transport.AuthMethod, error) {
+	if !IsSSHURL(repositoryURL) {
+		return BasicAuth(username, token), nil
+	}
+	endpoint, err := transport.NewEndpoint(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	user := endpoint.User
+	if user == "" {
+		user = defaultSSHUser
+	}
+
+	// Never skipped: an SSH remote whose host key is unknown is refused, as ssh itself would.
+	var files []string
+	if opts.KnownHosts != "" {
+		files = []string{opts.KnownHosts}
+	}
+	hosts, err := gitssh.NewKnownHostsDb(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known_hosts: %w", err)
+	}
+	port := endpoint.Port
+	if port == 0 {
+		port = 22
+	}
+	// A callback of our own disables go-git's own choice of algorithms, so the key type known_hosts
+	// lists has to be asked for here, or the host may offer another one and fail the check.
+	algorithms := hosts.HostKeyAlgorithms(net.JoinHostPort(endpoint.Host, strconv.Itoa(port)))
+	if len(algorithms) == 0 {
+		return nil, fmt.Errorf("%s is not in known_hosts: add its key with ssh-keyscan", endpoint.Host)
+	}
+	helper := gitssh.HostKeyCallbackHelper{
+		HostKeyCallback:   hosts.HostKeyCallback(),
+		HostKeyAlgorithms: algorithms,
+	}
+
+	if opts.KeyFile != "" {
+		auth, err := gitssh.NewPublicKeysFromFile(user, opts.KeyFile, opts.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		auth.HostKeyCallbackHelper = helper
+		return auth, nil
+	}
+	auth, err := gitssh.NewSSHAgentAuth(user)
+	if err != nil {
+		return nil, fmt.Errorf("no SSH key given and no ssh-agent to ask: %w", err)
+	}
+	auth.HostKeyCallbackHelper = helper
+	return auth, nil
+}
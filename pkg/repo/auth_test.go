@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshFixture writes a private key and a known_hosts file listing host, and returns their paths.
+func sshFixture(t *testing.T, host string) (keyFile string, knownHosts string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(key, "")
+	require.NoError(t, err)
+	keyFile = filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600))
+
+	hostPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewPublicKey(hostPublic)
+	require.NoError(t, err)
+	knownHosts = filepath.Join(dir, "known_hosts")
+	line := host + " " + string(ssh.MarshalAuthorizedKey(hostKey))
+	require.NoError(t, os.WriteFile(knownHosts, []byte(line), 0o600))
+	return keyFile, knownHosts
+}
+
+func TestIsSSHURL(t *testing.T) {
+	for url, want := range map[string]bool{
+		"git@github.com:acme/site.git":             true,
+		"ssh://git@gitlab.example.com:2222/acme/x": true,
+		"https://github.com/acme/site.git":         false,
+		"http://gitea.local/acme/site.git":         false,
+		"/srv/git/site.git":                        false,
+	} {
+		assert.Equal(t, want, IsSSHURL(url), url)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Run("HTTPS authenticates with the token", func(t *testing.T) {
+		auth, err := Auth("https://github.com/acme/site.git", "", "tok", SSHOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, BasicAuth("", "tok"), auth)
+	})
+
+	t.Run("HTTPS sends the username the host requires", func(t *testing.T) {
+		auth, err := Auth("https://bitbucket.org/acme/site.git", "x-token-auth", "tok", SSHOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, &http.BasicAuth{Username: "x-token-auth", Password: "tok"}, auth)
+	})
+
+	t.Run("SSH authenticates with the key, never the token", func(t *testing.T) {
+		keyFile, knownHosts := sshFixture(t, "github.com")
+		auth, err := Auth("git@github.com:acme/site.git", "", "tok", SSHOptions{KeyFile: keyFile, KnownHosts: knownHosts})
+		require.NoError(t, err)
+		keys, ok := auth.(*gitssh.PublicKeys)
+		require.True(t, ok, "%T", auth)
+		assert.Equal(t, "git", keys.User)
+		assert.Equal(t, []string{ssh.KeyAlgoED25519}, keys.HostKeyAlgorithms)
+		assert.NotContains(t, auth.String(), "tok")
+	})
+
+	t.Run("the URL's user and port are kept", func(t *testing.T) {
+		keyFile, knownHosts := sshFixture(t, "[gitlab.example.com]:2222")
+		auth, err := Auth("ssh://deploy@gitlab.example.com:2222/acme/site.git", "", "", SSHOptions{KeyFile: keyFile, KnownHosts: knownHosts})
+		require.NoError(t, err)
+		assert.Equal(t, "deploy", auth.(*gitssh.PublicKeys).User)
+	})
+
+	t.Run("a host known_hosts does not list is refused", func(t *testing.T) {
+		keyFile, knownHosts := sshFixture(t, "gitlab.com")
+		_, err := Auth("git@github.com:acme/site.git", "", "", SSHOptions{KeyFile: keyFile, KnownHosts: knownHosts})
+		require.ErrorContains(t, err, "github.com is not in known_hosts")
+	})
+
+	t.Run("a missing known_hosts or key is an error", func(t *testing.T) {
+		keyFile, knownHosts := sshFixture(t, "github.com")
+		_, err := Auth("git@github.com:acme/site.git", "", "", SSHOptions{KeyFile: keyFile, KnownHosts: filepath.Join(t.TempDir(), "absent")})
+		require.ErrorContains(t, err, "failed to read known_hosts")
+
+		_, err = Auth("git@github.com:acme/site.git", "", "", SSHOptions{KeyFile: filepath.Join(t.TempDir(), "absent"), KnownHosts: knownHosts})
+		require.ErrorContains(t, err, "failed to read SSH key")
+	})
+
+	t.Run("no key and no ssh-agent is an error", func(t *testing.T) {
+		t.Setenv("SSH_AUTH_SOCK", "")
+		_, knownHosts := sshFixture(t, "github.com")
+		_, err := Auth("git@github.com:acme/site.git", "", "", SSHOptions{KnownHosts: knownHosts})
+		require.ErrorContains(t, err, "no SSH key given and no ssh-agent to ask")
+	})
+}
@@ -13,6 +13,7 @@ import (
 	git "github.com/go-git/go-git/v5"
 	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"go.uber.org/zap"
 )
@@ -46,10 +47,14 @@ type GitRepositoryService struct {
 	fs     afero.Fs
 }
 
-// BasicAuth is the credential go-git needs for an authenticated fetch, list or push.
-func BasicAuth(token string) *http.BasicAuth {
+// BasicAuth is the credential go-git needs for an authenticated fetch, list or push over HTTPS.
+// username is the one the host requires with a token; empty picks one for hosts that ignore it.
+func BasicAuth(username string, token string) *http.BasicAuth {
+	if username == "" {
+		username = "du" // yes, this can be anything except an empty string
+	}
 	return &http.BasicAuth{
-		Username: "du", // yes, this can be anything except an empty string
+		Username: username,
 		Password: token,
 	}
 }
@@ -70,7 +75,8 @@ func NewGitRepositoryService(logger *zap.Logger) *GitRepositoryService {
 	}
 }
 
-func (rs *GitRepositoryService) CloneRepository(repository string, branch string, token string, username string, email string) (Repository, Worktree, string, error) {
+// CloneRepository clones branch of repository with auth, which Auth picks for the URL.
+func (rs *GitRepositoryService) CloneRepository(repository string, branch string, auth transport.AuthMethod, username string, email string) (Repository, Worktree, string, error) {
 
 	h := fnv.New64a()
 	_, _ = h.Write([]byte(repository))
@@ -88,7 +94,7 @@ func (rs *GitRepositoryService) CloneRepository(repository string, branch string
 		URL:           repository,
 		Depth:         1,
 		ReferenceName: plumbing.NewBranchReferenceName(branch),
-		Auth:          BasicAuth(token),
+		Auth:          auth,
 		Tags:          git.NoTags,
 	})
 
@@ -211,13 +217,13 @@ func (rs *GitRepositoryService) prepareCheckout(checkout *git.Repository, userna
 
 // BranchExists queries the remote: the cached refs/remotes/origin/* go stale the moment the host
 // auto-deletes a merged branch, giving a false positive.
-func (rs *GitRepositoryService) BranchExists(repository Repository, branch string, token string) (bool, error) {
+func (rs *GitRepositoryService) BranchExists(repository Repository, branch string, auth transport.AuthMethod) (bool, error) {
 	remote, err := repository.Remote("origin")
 	if err != nil {
 		return false, fmt.Errorf("failed to get origin remote: %w", err)
 	}
 
-	refs, err := remote.List(&git.ListOptions{Auth: BasicAuth(token)})
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
 	if err != nil {
 		return false, fmt.Errorf("failed to list remote refs: %w", err)
 	}
@@ -154,7 +154,7 @@ func TestCloneRepository(t *testing.T) {
 		head, err := r.Head()
 		require.NoError(t, err)
 
-		repository, worktree, path, err := service.CloneRepository(source, head.Name().Short(), "", "Bot", "bot@example.com")
+		repository, worktree, path, err := service.CloneRepository(source, head.Name().Short(), nil, "Bot", "bot@example.com")
 		require.NoError(t, err)
 		assert.NotNil(t, repository)
 		assert.NotNil(t, worktree)
@@ -198,7 +198,7 @@ func TestCloneRepository(t *testing.T) {
 		head, err := r.Head()
 		require.NoError(t, err)
 
-		_, _, path, err := service.CloneRepository(source, head.Name().Short(), "", "Bot", "bot@example.com")
+		_, _, path, err := service.CloneRepository(source, head.Name().Short(), nil, "Bot", "bot@example.com")
 		require.NoError(t, err)
 		t.Cleanup(func() { _ = os.RemoveAll(path) })
 
@@ -228,7 +228,7 @@ func TestCloneRepository(t *testing.T) {
 	})
 
 	t.Run("returns the clone error for an unreachable repository", func(t *testing.T) {
-		_, _, _, err := service.CloneRepository(t.TempDir(), "main", "", "Bot", "bot@example.com")
+		_, _, _, err := service.CloneRepository(t.TempDir(), "main", nil, "Bot", "bot@example.com")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "git clone")
 		require.ErrorIs(t, err, transport.ErrRepositoryNotFound)
@@ -237,14 +237,14 @@ func TestCloneRepository(t *testing.T) {
 	t.Run("returns the clone error for a branch that does not exist", func(t *testing.T) {
 		source, _ := initRepoWithCommit(t)
 
-		_, _, _, err := service.CloneRepository(source, "no-such-branch", "", "Bot", "bot@example.com")
+		_, _, _, err := service.CloneRepository(source, "no-such-branch", nil, "Bot", "bot@example.com")
 		require.Error(t, err)
 	})
 
 	t.Run("returns the error when the project directory cannot be created", func(t *testing.T) {
 		roService := &GitRepositoryService{logger: zap.NewNop(), fs: afero.NewReadOnlyFs(afero.NewMemMapFs())}
 
-		_, _, _, err := roService.CloneRepository("https://example.com/repo.git", "main", "", "Bot", "bot@example.com")
+		_, _, _, err := roService.CloneRepository("https://example.com/repo.git", "main", nil, "Bot", "bot@example.com")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to create project directory")
 	})
@@ -308,7 +308,7 @@ func TestIsShallowClone(t *testing.T) {
 		head, err := r.Head()
 		require.NoError(t, err)
 
-		_, _, path, err := service.CloneRepository(source, head.Name().Short(), "", "Bot", "bot@example.com")
+		_, _, path, err := service.CloneRepository(source, head.Name().Short(), nil, "Bot", "bot@example.com")
 		require.NoError(t, err)
 		t.Cleanup(func() { _ = os.RemoveAll(path) })
 
@@ -142,7 +142,7 @@ func TestBranchExists(t *testing.T) {
 		repo := NewMockRepository(t)
 		repo.EXPECT().Remote("origin").Return(remote, nil)
 
-		found, err := service.BranchExists(repo, "my-feature", "")
+		found, err := service.BranchExists(repo, "my-feature", nil)
 		require.NoError(t, err)
 		assert.True(t, found)
 	})
@@ -152,7 +152,7 @@ func TestBranchExists(t *testing.T) {
 		repo := NewMockRepository(t)
 		repo.EXPECT().Remote("origin").Return(remote, nil)
 
-		found, err := service.BranchExists(repo, "my-feature", "")
+		found, err := service.BranchExists(repo, "my-feature", nil)
 		require.NoError(t, err)
 		assert.False(t, found)
 	})
@@ -164,7 +164,7 @@ func TestBranchExists(t *testing.T) {
 		repo := NewMockRepository(t)
 		repo.EXPECT().Remote("origin").Return(remote, nil)
 
-		found, err := service.BranchExists(repo, "my-feature", "")
+		found, err := service.BranchExists(repo, "my-feature", nil)
 		require.NoError(t, err)
 		assert.False(t, found)
 	})
@@ -175,7 +175,7 @@ func TestBranchExists(t *testing.T) {
 		repo := NewMockRepository(t)
 		repo.EXPECT().Remote("origin").Return(nil, remoteErr)
 
-		found, err := service.BranchExists(repo, "my-feature", "")
+		found, err := service.BranchExists(repo, "my-feature", nil)
 		require.Error(t, err)
 		require.ErrorIs(t, err, remoteErr)
 		assert.False(t, found)
@@ -189,7 +189,7 @@ func TestBranchExists(t *testing.T) {
 		repo := NewMockRepository(t)
 		repo.EXPECT().Remote("origin").Return(remote, nil)
 
-		found, err := service.BranchExists(repo, "my-feature", "")
+		found, err := service.BranchExists(repo, "my-feature", nil)
 		require.Error(t, err)
 		assert.False(t, found)
 	})
@@ -0,0 +1,94 @@
+package sbom
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Changes is what differs between two BOMs of one project, by package name. Each list is sorted
+// by name.
+type Changes struct {
+	Added      []Package       `json:"added"`
+	Removed    []Package       `json:"removed"`
+	Updated    []VersionChange `json:"updated"`
+	Relicensed []LicenseChange `json:"relicensed"`
+}
+
+// Package is a component as vendor/package and version.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// VersionChange is a component present in both BOMs at different versions.
+type VersionChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// LicenseChange is a component whose declared license differs, whatever its version did.
+type LicenseChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Empty reports whether the BOMs list the same components under the same licenses.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Updated) == 0 && len(c.Relicensed) == 0
+}
+
+// Diff compares before and after.
+func Diff(before, after BOM) Changes {
+	changes := Changes{Added: []Package{}, Removed: []Package{}, Updated: []VersionChange{}, Relicensed: []LicenseChange{}}
+
+	old := byName(before)
+	current := byName(after)
+	for name, c := range current {
+		prev, ok := old[name]
+		if !ok {
+			changes.Added = append(changes.Added, Package{Name: name, Version: c.Version})
+			continue
+		}
+		if prev.Version != c.Version {
+			changes.Updated = append(changes.Updated, VersionChange{Name: name, From: prev.Version, To: c.Version})
+		}
+		if license(prev) != license(c) {
+			changes.Relicensed = append(changes.Relicensed, LicenseChange{Name: name, From: license(prev), To: license(c)})
+		}
+	}
+	for name, c := range old {
+		if _, ok := current[name]; !ok {
+			changes.Removed = append(changes.Removed, Package{Name: name, Version: c.Version})
+		}
+	}
+
+	slices.SortFunc(changes.Added, func(a, b Package) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(changes.Removed, func(a, b Package) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(changes.Updated, func(a, b VersionChange) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(changes.Relicensed, func(a, b LicenseChange) int { return cmp.Compare(a.Name, b.Name) })
+	return changes
+}
+
+// byName keys the components on vendor/package.
+func byName(bom BOM) map[string]Component {
+	components := make(map[string]Component, len(bom.Components))
+	for _, c := range bom.Components {
+		name := c.Name
+		if c.Group != "" {
+			name = c.Group + "/" + c.Name
+		}
+		components[name] = c
+	}
+	return components
+}
+
+func license(c Component) string {
+	expressions := make([]string, 0, len(c.Licenses))
+	for _, l := range c.Licenses {
+		expressions = append(expressions, l.Expression)
+	}
+	return strings.Join(expressions, " AND ")
+}
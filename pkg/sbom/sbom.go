@@ -0,0 +1,259 @@
+// Package sbom builds CycloneDX software bills of materials from a Composer project's lock file.
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// SpecVersion is the CycloneDX version written. 1.5 is the newest every major consumer ingests.
+const SpecVersion = "1.5"
+
+// BOM is a CycloneDX JSON document. Only the fields a lock file can fill are modelled.
+type BOM struct {
+	BOMFormat    string       `json:"bomFormat"`
+	SpecVersion  string       `json:"specVersion"`
+	Version      int          `json:"version"`
+	Metadata     Metadata     `json:"metadata"`
+	Components   []Component  `json:"components"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Metadata names the project the BOM describes and the tool that wrote it.
+type Metadata struct {
+	Timestamp time.Time `json:"timestamp"`
+	Tools     Tools     `json:"tools"`
+	Component Component `json:"component"`
+}
+
+// Tools is the 1.5 object form; the bare list is deprecated.
+type Tools struct {
+	Components []Component `json:"components"`
+}
+
+// Component is one package, or the project or tool in Metadata.
+type Component struct {
+	Type               string              `json:"type"`
+	BOMRef             string              `json:"bom-ref,omitempty"`
+	Group              string              `json:"group,omitempty"`
+	Name               string              `json:"name"`
+	Version            string              `json:"version,omitempty"`
+	Description        string              `json:"description,omitempty"`
+	Scope              string              `json:"scope,omitempty"`
+	Hashes             []Hash              `json:"hashes,omitempty"`
+	Licenses           []License           `json:"licenses,omitempty"`
+	PURL               string              `json:"purl,omitempty"`
+	ExternalReferences []ExternalReference `json:"externalReferences,omitempty"`
+	Properties         []Property          `json:"properties,omitempty"`
+}
+
+// Hash is a checksum of the package's distribution archive.
+type Hash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// License is always an SPDX expression: composer.json does not require SPDX ids, and an
+// expression is the one form a consumer accepts without checking the id against the SPDX list.
+type License struct {
+	Expression string `json:"expression"`
+}
+
+// ExternalReference is where the package comes from or is documented.
+type ExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Property carries Composer facts CycloneDX has no field for.
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Dependency lists the components one component requires directly.
+type Dependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// lockedPackage is the part of a composer.lock entry a component is built from.
+type lockedPackage struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Homepage    string            `json:"homepage"`
+	License     []string          `json:"license"`
+	Require     map[string]string `json:"require"`
+	Source      struct {
+		URL       string `json:"url"`
+		Reference string `json:"reference"`
+	} `json:"source"`
+	Dist struct {
+		URL    string `json:"url"`
+		Shasum string `json:"shasum"`
+	} `json:"dist"`
+}
+
+// Generate builds the BOM of the project in dir from its composer.json and composer.lock.
+// toolVersion is drupdater's own version, recorded as the producing tool.
+func Generate(fs afero.Fs, dir string, toolVersion string) (BOM, error) {
+	var manifest struct {
+		Name       string            `json:"name"`
+		Version    string            `json:"version"`
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if err := readJSON(fs, filepath.Join(dir, "composer.json"), &manifest); err != nil {
+		return BOM{}, err
+	}
+	var lock struct {
+		Packages    []lockedPackage `json:"packages"`
+		PackagesDev []lockedPackage `json:"packages-dev"`
+	}
+	if err := readJSON(fs, filepath.Join(dir, "composer.lock"), &lock); err != nil {
+		return BOM{}, err
+	}
+
+	root := Component{Type: "application", BOMRef: "root", Name: "project", Version: manifest.Version}
+	if manifest.Name != "" {
+		root.Group, root.Name = splitName(manifest.Name)
+		root.BOMRef = purl(manifest.Name, manifest.Version)
+	}
+
+	bom := BOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: SpecVersion,
+		Version:     1,
+		Metadata: Metadata{
+			Timestamp: time.Now().UTC().Truncate(time.Second),
+			Tools:     Tools{Components: []Component{{Type: "application", Name: "drupdater", Version: toolVersion}}},
+			Component: root,
+		},
+		Components:   make([]Component, 0, len(lock.Packages)+len(lock.PackagesDev)),
+		Dependencies: []Dependency{},
+	}
+
+	refs := map[string]string{}
+	for _, p := range slices.Concat(lock.Packages, lock.PackagesDev) {
+		refs[strings.ToLower(p.Name)] = purl(p.Name, p.Version)
+	}
+	// requires maps a require list onto the locked components, dropping platform packages.
+	requires := func(required ...map[string]string) []string {
+		dependsOn := []string{}
+		for _, list := range required {
+			for name := range list {
+				if ref, ok := refs[strings.ToLower(name)]; ok && !slices.Contains(dependsOn, ref) {
+					dependsOn = append(dependsOn, ref)
+				}
+			}
+		}
+		slices.Sort(dependsOn)
+		return dependsOn
+	}
+
+	bom.Dependencies = append(bom.Dependencies, Dependency{Ref: root.BOMRef, DependsOn: requires(manifest.Require, manifest.RequireDev)})
+	for _, p := range lock.Packages {
+		bom.Components = append(bom.Components, component(p, "required"))
+		bom.Dependencies = append(bom.Dependencies, Dependency{Ref: refs[strings.ToLower(p.Name)], DependsOn: requires(p.Require)})
+	}
+	for _, p := range lock.PackagesDev {
+		bom.Components = append(bom.Components, component(p, "optional"))
+		bom.Dependencies = append(bom.Dependencies, Dependency{Ref: refs[strings.ToLower(p.Name)], DependsOn: requires(p.Require)})
+	}
+	return bom, nil
+}
+
+func readJSON(fs afero.Fs, path string, v any) error {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// component converts one lock entry. scope is "optional" for packages-dev, which a production
+// install leaves out.
+func component(p lockedPackage, scope string) Component {
+	c := Component{
+		Type:        "library",
+		BOMRef:      purl(p.Name, p.Version),
+		Version:     p.Version,
+		Description: p.Description,
+		Scope:       scope,
+		PURL:        purl(p.Name, p.Version),
+	}
+	c.Group, c.Name = splitName(p.Name)
+
+	if p.Dist.Shasum != "" {
+		c.Hashes = []Hash{{Alg: "SHA-1", Content: p.Dist.Shasum}}
+	}
+	// Composer's license list is a choice: the package may be used under any one of them.
+	switch len(p.License) {
+	case 0:
+	case 1:
+		c.Licenses = []License{{Expression: p.License[0]}}
+	default:
+		c.Licenses = []License{{Expression: "(" + strings.Join(p.License, " OR ") + ")"}}
+	}
+	for _, ref := range []ExternalReference{{Type: "vcs", URL: p.Source.URL}, {Type: "distribution", URL: p.Dist.URL}, {Type: "website", URL: p.Homepage}} {
+		if ref.URL != "" {
+			c.ExternalReferences = append(c.ExternalReferences, ref)
+		}
+	}
+	if p.Type != "" {
+		c.Properties = append(c.Properties, Property{Name: "composer:type", Value: p.Type})
+	}
+	if p.Source.Reference != "" {
+		c.Properties = append(c.Properties, Property{Name: "composer:source-reference", Value: p.Source.Reference})
+	}
+	return c
+}
+
+// splitName splits vendor/package into the CycloneDX group and name.
+func splitName(name string) (string, string) {
+	if vendor, pkg, ok := strings.Cut(name, "/"); ok {
+		return vendor, pkg
+	}
+	return "", name
+}
+
+// purl is the package URL of a Composer package. Package URLs lowercase Composer names.
+func purl(name string, version string) string {
+	p := "pkg:composer/" + strings.ToLower(name)
+	if version != "" {
+		p += "@" + version
+	}
+	return p
+}
+
+// Encode renders the BOM as indented JSON with a trailing newline.
+func Encode(bom BOM) ([]byte, error) {
+	encoded, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SBOM: %w", err)
+	}
+	return append(encoded, '\n'), nil
+}
+
+// Write writes the BOM to path, creating its directory.
+func Write(fs afero.Fs, path string, bom BOM) error {
+	encoded, err := Encode(bom)
+	if err != nil {
+		return err
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create SBOM directory: %w", err)
+	}
+	return afero.WriteFile(fs, path, encoded, 0o644)
+}
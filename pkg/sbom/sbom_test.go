@@ -0,0 +1,91 @@
+package sbom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drupdater/drupdater/internal/golden"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateFixture(t *testing.T) BOM {
+	t.Helper()
+	bom, err := Generate(afero.NewOsFs(), "testdata/project", "v1.4.0")
+	require.NoError(t, err)
+	bom.Metadata.Timestamp = time.Date(2026, 6, 15, 3, 0, 0, 0, time.UTC)
+	return bom
+}
+
+func TestGenerateGolden(t *testing.T) {
+	encoded, err := Encode(generateFixture(t))
+	require.NoError(t, err)
+	golden.Assert(t, "testdata/project.cdx.json", string(encoded))
+}
+
+func TestGenerateMapsTheLockFile(t *testing.T) {
+	bom := generateFixture(t)
+
+	assert.Equal(t, "pkg:composer/acme/site", bom.Metadata.Component.BOMRef)
+	require.Len(t, bom.Components, 5)
+
+	core := bom.Components[0]
+	assert.Equal(t, "drupal", core.Group)
+	assert.Equal(t, "core", core.Name)
+	assert.Equal(t, "pkg:composer/drupal/core@10.2.1", core.PURL)
+	assert.Empty(t, core.Hashes, "an empty shasum is no hash")
+	assert.Equal(t, []Hash{{Alg: "SHA-1", Content: "a71b0c5c4e1a3b2d9f8e7c6b5a4d3c2b1a0f9e8d"}}, bom.Components[2].Hashes)
+
+	dev := bom.Components[4]
+	assert.Equal(t, "optional", dev.Scope)
+	assert.Equal(t, []License{{Expression: "(GPL-2.0-or-later OR MIT)"}}, dev.Licenses)
+}
+
+// Platform packages and packages the lock does not hold are not components, so nothing may
+// depend on them.
+func TestGenerateDependenciesOnlyReferenceComponents(t *testing.T) {
+	bom := generateFixture(t)
+
+	refs := map[string]bool{bom.Metadata.Component.BOMRef: true}
+	for _, c := range bom.Components {
+		refs[c.BOMRef] = true
+	}
+	for _, d := range bom.Dependencies {
+		assert.True(t, refs[d.Ref], d.Ref)
+		for _, on := range d.DependsOn {
+			assert.True(t, refs[on], "%s depends on unknown %s", d.Ref, on)
+		}
+	}
+	assert.Equal(t, []string{
+		"pkg:composer/drupal/core-dev@10.2.1",
+		"pkg:composer/drupal/core-recommended@10.2.1",
+		"pkg:composer/drupal/token@1.13.0",
+	}, bom.Dependencies[0].DependsOn)
+}
+
+func TestGenerateWithoutALockFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "site/composer.json", []byte(`{"name":"acme/site"}`), 0o644))
+
+	_, err := Generate(fs, "site", "dev")
+	require.ErrorContains(t, err, "composer.lock")
+}
+
+func TestDiff(t *testing.T) {
+	before := generateFixture(t)
+	after := generateFixture(t)
+	after.Components[0].Version = "10.2.9"
+	after.Components[3].Licenses = []License{{Expression: "Apache-2.0"}}
+	after.Components = append(after.Components[:2], after.Components[3:]...)
+	after.Components = append(after.Components, Component{Group: "psr", Name: "log", Version: "3.0.0"})
+
+	changes := Diff(before, after)
+
+	assert.Equal(t, []Package{{Name: "psr/log", Version: "3.0.0"}}, changes.Added)
+	assert.Equal(t, []Package{{Name: "drupal/token", Version: "1.13.0"}}, changes.Removed)
+	assert.Equal(t, []VersionChange{{Name: "drupal/core", From: "10.2.1", To: "10.2.9"}}, changes.Updated)
+	assert.Equal(t, []LicenseChange{{Name: "symfony/http-kernel", From: "MIT", To: "Apache-2.0"}}, changes.Relicensed)
+	assert.False(t, changes.Empty())
+	assert.True(t, Diff(before, before).Empty())
+}
@@ -0,0 +1,234 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// instrumentationScope names the code that recorded the spans.
+const instrumentationScope = "github.com/drupdater/drupdater"
+
+// OTLP/JSON status codes and span kind. Every span is internal: none crosses a process
+// boundary a collector could join it across.
+const (
+	statusOK         = 1
+	statusError      = 2
+	spanKindInternal = 1
+)
+
+// The OTLP/JSON shape of an ExportTraceServiceRequest, as far as these spans fill it. Ids are
+// hex and timestamps decimal strings, as the protobuf JSON mapping has them.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// Encode renders every finished span as one OTLP/JSON ExportTraceServiceRequest, with every
+// string passed through the tracer's redact. Spans still open are left out.
+func (t *Tracer) Encode() ([]byte, error) {
+	t.mu.Lock()
+	spans := make([]otlpSpan, 0, len(t.spans))
+	for _, s := range t.spans {
+		spans = append(spans, t.encodeSpan(s))
+	}
+	t.mu.Unlock()
+
+	request := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: t.encodeAttributes([]Attribute{
+			String("service.name", "drupdater"),
+			String("service.version", t.version),
+		})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: instrumentationScope, Version: t.version},
+			Spans: spans,
+		}},
+	}}}
+
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode trace: %w", err)
+	}
+	return encoded, nil
+}
+
+func (t *Tracer) encodeSpan(s *Span) otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(t.traceID[:]),
+		SpanID:            hex.EncodeToString(s.id[:]),
+		Name:              t.redact(s.name),
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(s.start),
+		EndTimeUnixNano:   unixNano(s.end),
+		Attributes:        t.encodeAttributes(s.attrs),
+		Status:            otlpStatus{Code: statusOK},
+	}
+	if s.parent != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	if s.err != "" {
+		span.Status = otlpStatus{Code: statusError, Message: t.redact(s.err)}
+	}
+	return span
+}
+
+func (t *Tracer) encodeAttributes(attrs []Attribute) []otlpAttribute {
+	encoded := make([]otlpAttribute, 0, len(attrs))
+	for _, attr := range attrs {
+		var value otlpValue
+		switch v := attr.Value.(type) {
+		case string:
+			redacted := t.redact(v)
+			value.StringValue = &redacted
+		case int64:
+			i := strconv.FormatInt(v, 10)
+			value.IntValue = &i
+		case bool:
+			value.BoolValue = &v
+		case float64:
+			value.DoubleValue = &v
+		default:
+			text := t.redact(fmt.Sprint(v))
+			value.StringValue = &text
+		}
+		encoded = append(encoded, otlpAttribute{Key: attr.Key, Value: value})
+	}
+	return encoded
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Exporter delivers an encoded trace.
+type Exporter interface {
+	Export(ctx context.Context, payload []byte) error
+}
+
+// FileExporter appends the trace to a file as one line, the OTLP JSON file format a collector's
+// otlpjsonfile receiver reads. Appending lets several runs share one file.
+type FileExporter struct {
+	Path string
+}
+
+// Export implements Exporter.
+func (e FileExporter) Export(_ context.Context, payload []byte) error {
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create trace directory: %w", err)
+	}
+	f, err := os.OpenFile(e.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644) //nolint:gosec // the user's --trace-file
+	if err != nil {
+		return fmt.Errorf("failed to open trace file: %w", err)
+	}
+	if _, err := f.Write(append(payload, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write trace file: %w", err)
+	}
+	return f.Close()
+}
+
+// HTTPExporter posts the trace to a collector's OTLP/HTTP endpoint with the JSON encoding.
+// Endpoint is the full URL, .../v1/traces included.
+type HTTPExporter struct {
+	Endpoint string
+	Headers  map[string]string
+	Client   *http.Client
+}
+
+// Export implements Exporter.
+func (e HTTPExporter) Export(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build trace request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send trace: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector rejected the trace: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Flush encodes every finished span and hands the result to each exporter. Every exporter is
+// tried; the first failure is returned.
+func (t *Tracer) Flush(ctx context.Context, exporters ...Exporter) error {
+	payload, err := t.Encode()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, exporter := range exporters {
+		if err := exporter.Export(ctx, payload); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
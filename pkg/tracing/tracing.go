@@ -0,0 +1,156 @@
+// Package tracing records a run as OpenTelemetry spans and exports them as OTLP/JSON, to a file
+// or to a collector. Hand-rolled rather than the OpenTelemetry SDK: a run is one short-lived
+// process exporting a few thousand spans once, at the end, which needs none of the SDK's batching,
+// sampling or propagation.
+//
+// Every function here is safe on a context without a Tracer and on a nil *Span, and then does
+// nothing, so instrumented code never checks whether tracing is on.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Tracer collects the finished spans of one process until Flush exports them.
+type Tracer struct {
+	version string
+	redact  func(string) string
+	traceID [16]byte
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// New creates a tracer whose spans all belong to one trace. version is drupdater's, reported as
+// service.version. redact runs over every string a span carries when it is exported; pass
+// logging.Redactor.Redact. nil exports unfiltered and suits only tests.
+func New(version string, redact func(string) string) *Tracer {
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+	t := &Tracer{version: version, redact: redact}
+	_, _ = rand.Read(t.traceID[:])
+	return t
+}
+
+// TraceID is the trace's id in the hex form a tracing UI searches for.
+func (t *Tracer) TraceID() string {
+	return hex.EncodeToString(t.traceID[:])
+}
+
+type tracerKey struct{}
+
+type spanKey struct{}
+
+// WithTracer returns ctx carrying t, so Start under it records spans.
+func WithTracer(ctx context.Context, t *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// Attribute is one span attribute. Value is a string, int, int64, bool or float64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key string, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Span is one timed operation. Its methods may be called from the goroutine that started it
+// while others start children of it.
+type Span struct {
+	tracer *Tracer
+	id     [8]byte
+	parent [8]byte
+	name   string
+	start  time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []Attribute
+	err   string
+	ended bool
+}
+
+// Start begins a span named name, the child of the span ctx carries if any, and returns a
+// context carrying it. Without a tracer on ctx it returns ctx and a nil span.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	t, _ := ctx.Value(tracerKey{}).(*Tracer)
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, name: name, start: time.Now(), attrs: attrs}
+	_, _ = rand.Read(span.id[:])
+	if parent := FromContext(ctx); parent != nil {
+		span.parent = parent.id
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span ctx carries, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttributes adds attributes, replacing any with the same key.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, attr := range attrs {
+		replaced := false
+		for i := range s.attrs {
+			if s.attrs[i].Key == attr.Key {
+				s.attrs[i] = attr
+				replaced = true
+			}
+		}
+		if !replaced {
+			s.attrs = append(s.attrs, attr)
+		}
+	}
+}
+
+// SetError marks the span failed with err's message. A nil err leaves it as it is.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span and hands it to the tracer. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, payload []byte) otlpRequest {
+	t.Helper()
+	var request otlpRequest
+	require.NoError(t, json.Unmarshal(payload, &request))
+	require.Len(t, request.ResourceSpans, 1)
+	require.Len(t, request.ResourceSpans[0].ScopeSpans, 1)
+	return request
+}
+
+func TestStartWithoutATracerDoesNothing(t *testing.T) {
+	ctx, span := Start(context.Background(), "phase")
+
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+	assert.NotPanics(t, func() {
+		span.SetAttributes(String("site", "default"))
+		span.SetError(errors.New("boom"))
+		span.End()
+	})
+}
+
+func TestSpansNestAndCarryTheirOutcome(t *testing.T) {
+	tracer := New("v1.4.0", nil)
+	ctx := WithTracer(context.Background(), tracer)
+
+	ctx, phase := Start(ctx, "site update")
+	_, site := Start(ctx, "site default", String("site", "default"))
+	site.SetAttributes(Int("exit_code", 1), Int("exit_code", 2), Bool("ok", false))
+	site.SetError(errors.New("drush updatedb failed"))
+	site.End()
+	phase.End()
+	phase.End()
+
+	payload, err := tracer.Encode()
+	require.NoError(t, err)
+	spans := decode(t, payload).ResourceSpans[0].ScopeSpans[0].Spans
+
+	require.Len(t, spans, 2, "a second End does not export the span twice")
+	child, parent := spans[0], spans[1]
+	assert.Equal(t, parent.SpanID, child.ParentSpanID)
+	assert.Empty(t, parent.ParentSpanID)
+	assert.Equal(t, tracer.TraceID(), child.TraceID)
+	assert.Equal(t, otlpStatus{Code: statusError, Message: "drush updatedb failed"}, child.Status)
+	assert.Equal(t, statusOK, parent.Status.Code)
+
+	require.Len(t, child.Attributes, 3, "a repeated key replaces the earlier value")
+	assert.Equal(t, "2", *child.Attributes[1].Value.IntValue, "OTLP/JSON writes integers as strings")
+}
+
+func TestEncodeRedactsEveryString(t *testing.T) {
+	tracer := New("dev", func(s string) string { return strings.ReplaceAll(s, "s3cret", "***") })
+	ctx := WithTracer(context.Background(), tracer)
+
+	_, span := Start(ctx, "composer config s3cret", String("args", "--auth s3cret"))
+	span.SetError(errors.New("401 for s3cret"))
+	span.End()
+
+	payload, err := tracer.Encode()
+	require.NoError(t, err)
+	assert.NotContains(t, string(payload), "s3cret")
+}
+
+func TestConcurrentSpans(t *testing.T) {
+	tracer := New("dev", nil)
+	ctx, parent := Start(WithTracer(context.Background(), tracer), "baseline site install")
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			_, span := Start(ctx, "site")
+			parent.SetAttributes(Int("sites", 20))
+			span.End()
+		})
+	}
+	wg.Wait()
+	parent.End()
+
+	payload, err := tracer.Encode()
+	require.NoError(t, err)
+	assert.Len(t, decode(t, payload).ResourceSpans[0].ScopeSpans[0].Spans, 21)
+}
+
+func TestFileExporterAppendsOneLinePerFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "run.jsonl")
+	tracer := New("dev", nil)
+	_, span := Start(WithTracer(context.Background(), tracer), "run")
+	span.End()
+
+	require.NoError(t, tracer.Flush(context.Background(), FileExporter{Path: path}))
+	require.NoError(t, tracer.Flush(context.Background(), FileExporter{Path: path}))
+
+	written, err := os.ReadFile(path) //nolint:gosec // test-controlled path
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(written), "\n"), "\n")
+	require.Len(t, lines, 2)
+	decode(t, []byte(lines[0]))
+}
+
+func TestHTTPExporterPostsJSON(t *testing.T) {
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	tracer := New("dev", nil)
+	_, span := Start(WithTracer(context.Background(), tracer), "run")
+	span.End()
+
+	exporter := HTTPExporter{Endpoint: server.URL + "/v1/traces", Headers: map[string]string{"Authorization": "Bearer x"}}
+	require.NoError(t, tracer.Flush(context.Background(), exporter))
+
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "Bearer x", header.Get("Authorization"))
+	decode(t, body)
+}
+
+func TestHTTPExporterReportsARejection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+	}))
+	defer server.Close()
+
+	err := New("dev", nil).Flush(context.Background(), HTTPExporter{Endpoint: server.URL})
+	require.ErrorContains(t, err, "unsupported content type")
+}